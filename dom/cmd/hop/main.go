@@ -1,18 +1,24 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/hop/backend/internal/apply"
+	"github.com/hop/backend/internal/backup"
 	"github.com/hop/backend/internal/config"
 	"github.com/hop/backend/internal/database"
 	"github.com/hop/backend/internal/logger"
+	"github.com/hop/backend/internal/nginx"
 	"github.com/hop/backend/internal/server"
 	"github.com/hop/backend/internal/ssl"
 )
@@ -111,14 +117,241 @@ var (
 	systemdInstall bool
 )
 
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "应用声明式配置清单",
+	Long: `根据清单文件（TOML）将站点、SNI 路由、模板参数和证书收敛到期望状态。
+
+示例:
+  hop -C config.toml apply -f hop.toml              # 应用清单
+  hop -C config.toml apply -f hop.toml --dry-run    # 只显示差异
+  hop -C config.toml apply -f hop.toml --prune      # 同时删除清单中未声明的资源
+
+服务器运行时只能使用 --dry-run，请通过 POST /api/apply 提交清单。
+使用 http-01 验证的证书需要由运行中的服务器签发，请通过 POST /api/apply 提交包含这些证书的清单。`,
+	Run: runApplyCmd,
+}
+
+var (
+	applyFile   string
+	applyDryRun bool
+	applyPrune  bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出完整状态归档",
+	Long: `将数据库（DNS 提供商、证书、日志、配置历史）、nginx 目录、ACME 账户和 config.toml
+打包为一个 tar.gz 归档，用于迁移到新主机或灾难恢复。
+
+归档包含私钥和 DNS 凭据，请妥善保管。
+
+示例:
+  hop -C config.toml export -o hop-backup.tar.gz
+  hop -C config.toml export -o hop-backup.tar.gz --users   # 同时导出用户`,
+	Run: runExportCmd,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "从归档恢复状态",
+	Long: `从 'hop export' 生成的归档恢复状态，导入前会校验归档的完整性和版本兼容性。
+导入会替换现有的证书、DNS 提供商、配置历史和 nginx 目录，请在 Hop 服务停止时执行。
+
+示例:
+  hop -C config.toml import -f hop-backup.tar.gz
+  hop -C config.toml import -f hop-backup.tar.gz --users --skip-config`,
+	Run: runImportCmd,
+}
+
+var (
+	exportOutput     string
+	exportUsers      bool
+	importFile       string
+	importUsers      bool
+	importSkipConfig bool
+)
+
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "C", "", "配置文件路径")
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(systemdCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 
 	systemdCmd.Flags().StringVarP(&systemdOutput, "output", "o", "", "输出文件路径")
 	systemdCmd.Flags().BoolVar(&systemdInstall, "install", false, "直接安装到 /etc/systemd/system/")
+
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "清单文件路径")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "只显示差异，不做修改")
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "删除清单中未声明的站点、路由和证书")
+	applyCmd.MarkFlagRequired("file")
+
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "输出文件路径（默认 hop-<时间>.tar.gz）")
+	exportCmd.Flags().BoolVar(&exportUsers, "users", false, "包含用户和账户")
+
+	importCmd.Flags().StringVarP(&importFile, "file", "f", "", "归档文件路径")
+	importCmd.Flags().BoolVar(&importUsers, "users", false, "恢复归档中的用户（会替换现有用户）")
+	importCmd.Flags().BoolVar(&importSkipConfig, "skip-config", false, "不覆盖 config.toml")
+	importCmd.MarkFlagRequired("file")
+
+	backup.AppVersion = version
+}
+
+func runExportCmd(cmd *cobra.Command, args []string) {
+	initCommandState()
+	defer database.Close()
+
+	output := exportOutput
+	if output == "" {
+		output = fmt.Sprintf("hop-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 无法创建文件: %v\n", err)
+		os.Exit(1)
+	}
+
+	manifest, err := backup.Export(f, backup.ExportOptions{IncludeUsers: exportUsers})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+		fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("归档已导出: %s\n", output)
+	fmt.Printf("  文件: %d\n", manifest.Files)
+	for _, table := range sortedKeys(manifest.Tables) {
+		fmt.Printf("  %s: %d 行\n", table, manifest.Tables[table])
+	}
+}
+
+func runImportCmd(cmd *cobra.Command, args []string) {
+	initCommandState()
+	defer database.Close()
+
+	f, err := os.Open(importFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 无法打开文件: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	result, err := backup.Import(f, backup.ImportOptions{
+		IncludeUsers: importUsers,
+		SkipConfig:   importSkipConfig,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "导入失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("归档已导入（来自 Hop %s，创建于 %s）\n", result.Manifest.HopVersion, result.Manifest.CreatedAt)
+	fmt.Printf("  文件: %d\n", result.Files)
+	fmt.Printf("  表: %s\n", strings.Join(result.Tables, ", "))
+	fmt.Printf("  用户: %v\n", result.UsersRestored)
+	fmt.Printf("  配置文件: %v\n", result.ConfigRestored)
+	for _, warning := range result.Warnings {
+		fmt.Printf("警告: %s\n", warning)
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// initCommandState 为需要访问数据的子命令加载配置并初始化数据库
+func initCommandState() {
+	if configFile == "" {
+		fmt.Fprintln(os.Stderr, "错误: 请使用 -C 指定配置文件")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := database.Init(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化数据库失败: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runApplyCmd(cmd *cobra.Command, args []string) {
+	initCommandState()
+	defer database.Close()
+
+	// 证书任务队列和 renewMutex 只在本进程内有效，服务器运行时由服务器应用清单
+	// dry-run 只读取数据，可以在服务器运行时执行
+	unlock, err := database.Lock(config.Get())
+	switch {
+	case errors.Is(err, database.ErrLocked) && applyDryRun:
+	case errors.Is(err, database.ErrLocked):
+		fmt.Fprintln(os.Stderr, "错误: 服务器正在运行，请通过 POST /api/apply 提交清单，或停止服务器后重试")
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	default:
+		defer unlock()
+		if err := nginx.InitNginxConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "初始化 Nginx 配置失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	manifest, err := apply.LoadFile(applyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	author := "cli"
+	if user := os.Getenv("USER"); user != "" {
+		author = "cli:" + user
+	}
+	change := nginx.Change{Author: author, Message: "hop apply -f " + filepath.Base(applyFile)}
+
+	// 签发在本进程内进行，http-01 令牌只保存在本进程中，而验证请求由运行中的服务器应答
+	opts := apply.Options{DryRun: applyDryRun, Prune: applyPrune, NoHTTP01: true}
+	result, err := apply.Apply(manifest, opts, change)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "应用失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, c := range result.Certificates {
+		fmt.Printf("证书 %-8s %s\n", c.Action, strings.Join(c.Domains, ", "))
+	}
+	for _, f := range result.Plan.Files {
+		fmt.Printf("文件 %-8s %s\n", f.Action, f.Path)
+	}
+	if result.Plan.Diff != "" {
+		fmt.Println()
+		fmt.Print(result.Plan.Diff)
+	}
+
+	switch {
+	case !result.HasChanges():
+		fmt.Println("没有需要应用的变更")
+	case applyDryRun:
+		fmt.Println("\n(dry-run) 未做任何修改")
+	default:
+		fmt.Println("\n清单已应用并通过 nginx -t 校验，请重载 Nginx 使配置生效")
+	}
 }
 
 func runSystemdCmd(cmd *cobra.Command, args []string) {
@@ -221,6 +454,14 @@ func runServer() {
 		"config": configFile,
 	})
 
+	// 运行期间锁定数据目录，hop apply 等命令不会与服务器同时修改数据
+	unlock, err := database.Lock(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	defer unlock()
+
 	// 初始化数据库
 	if err := database.Init(cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "初始化数据库失败: %v\n", err)
@@ -228,20 +469,32 @@ func runServer() {
 	}
 	defer database.Close()
 
-	// 启动 SSL 证书自动续期检查 (每天检查一次，提前 30 天续期)
+	ssl.SyncInternalCAFiles()
+	ssl.RecoverJobs()
+	// 站点启用 SSL 但没有可用证书时由 ssl 包自动申请（需开启 acme.auto_issue）
+	nginx.SetCertificateRequester(ssl.RequestSiteCertificate)
+
+	// 启动 SSL 证书自动续期检查 (每小时检查一次续期计划，默认提前 30 天续期)
+	// 续期使用各证书关联的 ACME 账户，CA 支持 ARI 时按 CA 建议的时间续期
 	scheduler := ssl.NewScheduler(
-		"admin@example.com", // 默认邮箱，实际申请时会使用用户指定的邮箱
-		30,                  // 提前 30 天续期
-		24*time.Hour,        // 每天检查一次
+		30,        // 默认提前 30 天续期
+		time.Hour, // 每小时检查一次
 	)
 	go scheduler.Start()
 	defer scheduler.Stop()
 
 	logger.Info("SSL 证书自动续期已启动", map[string]interface{}{
-		"检查间隔": "24 小时",
+		"检查间隔": "1 小时",
 		"续期阈值": "30 天",
 	})
 
+	// 启动自动备份
+	if cfg.Backup.Enabled && cfg.Backup.Interval > 0 {
+		backupScheduler := backup.NewScheduler(time.Duration(cfg.Backup.Interval) * time.Hour)
+		go backupScheduler.Start()
+		defer backupScheduler.Stop()
+	}
+
 	// 创建服务器
 	srv := server.New(cfg)
 
@@ -0,0 +1,88 @@
+package acme
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrRenewalInfoUnsupported CA 不支持 ACME Renewal Information（RFC 9773）
+var ErrRenewalInfoUnsupported = errors.New("CA 不支持 ARI")
+
+// ariDefaultRetry CA 未返回 Retry-After 时再次查询的间隔
+const ariDefaultRetry = 6 * time.Hour
+
+// RenewalInfo CA 建议的续期时间窗口
+type RenewalInfo struct {
+	SuggestedWindow struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+	} `json:"suggestedWindow"`
+	ExplanationURL string `json:"explanationURL"`
+
+	// RetryAfter 下次查询前应等待的时间
+	RetryAfter time.Duration `json:"-"`
+}
+
+// RenewalCertID 计算证书的 ARI 标识：授权密钥标识符和序列号的 base64url 编码，以 "." 连接
+func RenewalCertID(cert *x509.Certificate) (string, error) {
+	if len(cert.AuthorityKeyId) == 0 {
+		return "", fmt.Errorf("证书缺少授权密钥标识符")
+	}
+	// 序列号使用 DER 编码 INTEGER 的内容部分（最高位为 1 时带前导 0）
+	der, err := asn1.Marshal(cert.SerialNumber)
+	if err != nil {
+		return "", err
+	}
+	var serial asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &serial); err != nil {
+		return "", err
+	}
+	return b64(cert.AuthorityKeyId) + "." + b64(serial.Bytes), nil
+}
+
+// GetRenewalInfo 查询证书的建议续期窗口；CA 不支持时返回 ErrRenewalInfoUnsupported
+func (c *Client) GetRenewalInfo(ctx context.Context, cert *x509.Certificate) (*RenewalInfo, error) {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dir.RenewalInfo == "" {
+		return nil, ErrRenewalInfoUnsupported
+	}
+	certID, err := RenewalCertID(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	// renewalInfo 不需要签名，直接 GET
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(dir.RenewalInfo, "/")+"/"+certID, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("查询续期窗口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var info RenewalInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("解析续期窗口失败: %w", err)
+	}
+	if info.SuggestedWindow.Start.IsZero() || info.SuggestedWindow.End.Before(info.SuggestedWindow.Start) {
+		return nil, fmt.Errorf("CA 返回的续期窗口无效")
+	}
+	info.RetryAfter = retryAfter(resp, ariDefaultRetry)
+	return &info, nil
+}
@@ -0,0 +1,588 @@
+// Package acme 实现 RFC 8555 ACME 协议客户端
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LetsEncryptURL Let's Encrypt 生产环境目录
+const LetsEncryptURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+// maxNonceRetries badNonce 错误的最大重试次数
+const maxNonceRetries = 3
+
+// Directory ACME 目录
+type Directory struct {
+	NewNonce    string `json:"newNonce"`
+	NewAccount  string `json:"newAccount"`
+	NewOrder    string `json:"newOrder"`
+	RevokeCert  string `json:"revokeCert"`
+	KeyChange   string `json:"keyChange"`
+	RenewalInfo string `json:"renewalInfo"` // ARI（RFC 9773），CA 不支持时为空
+	Meta        struct {
+		TermsOfService          string   `json:"termsOfService"`
+		Website                 string   `json:"website"`
+		CAAIdentities           []string `json:"caaIdentities"`
+		ExternalAccountRequired bool     `json:"externalAccountRequired"`
+	} `json:"meta"`
+}
+
+// Account ACME 账户
+type Account struct {
+	URL     string   `json:"-"`
+	Status  string   `json:"status"`
+	Contact []string `json:"contact"`
+	Orders  string   `json:"orders"`
+}
+
+// Identifier 订单标识
+type Identifier struct {
+	Type  string `json:"type"` // dns, ip
+	Value string `json:"value"`
+}
+
+// Order ACME 订单
+type Order struct {
+	URL            string       `json:"-"`
+	Status         string       `json:"status"`
+	Expires        string       `json:"expires"`
+	Identifiers    []Identifier `json:"identifiers"`
+	Authorizations []string     `json:"authorizations"`
+	Finalize       string       `json:"finalize"`
+	Certificate    string       `json:"certificate"`
+	Error          *Problem     `json:"error"`
+}
+
+// Authorization 域名授权
+type Authorization struct {
+	URL        string      `json:"-"`
+	Status     string      `json:"status"`
+	Identifier Identifier  `json:"identifier"`
+	Challenges []Challenge `json:"challenges"`
+	Wildcard   bool        `json:"wildcard"`
+}
+
+// Challenge 验证方式
+type Challenge struct {
+	Type   string   `json:"type"`
+	URL    string   `json:"url"`
+	Token  string   `json:"token"`
+	Status string   `json:"status"`
+	Error  *Problem `json:"error"`
+}
+
+// Client ACME 客户端
+type Client struct {
+	DirectoryURL string
+	Key          crypto.Signer // 账户私钥
+	KID          string        // 账户 URL，注册后设置
+	HTTPClient   *http.Client
+	UserAgent    string
+
+	mu     sync.Mutex
+	dir    *Directory
+	nonces []string
+}
+
+// Discover 获取并缓存 ACME 目录
+func (c *Client) Discover(ctx context.Context) (*Directory, error) {
+	c.mu.Lock()
+	if c.dir != nil {
+		dir := c.dir
+		c.mu.Unlock()
+		return dir, nil
+	}
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.DirectoryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取 ACME 目录失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var dir Directory
+	if err := json.NewDecoder(resp.Body).Decode(&dir); err != nil {
+		return nil, fmt.Errorf("解析 ACME 目录失败: %w", err)
+	}
+	if dir.NewNonce == "" || dir.NewAccount == "" || dir.NewOrder == "" {
+		return nil, fmt.Errorf("无效的 ACME 目录: %s", c.DirectoryURL)
+	}
+
+	c.mu.Lock()
+	c.dir = &dir
+	c.mu.Unlock()
+	return &dir, nil
+}
+
+// Register 注册账户（同意服务条款）；账户已存在时返回已有账户
+// eab 为 nil 时不绑定外部账户；CA 要求绑定时返回错误
+func (c *Client) Register(ctx context.Context, email string, eab *ExternalAccountBinding) (*Account, error) {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]interface{}{
+		"termsOfServiceAgreed": true,
+	}
+	if email != "" {
+		req["contact"] = []string{"mailto:" + email}
+	}
+	if eab != nil {
+		binding, err := signEAB(eab, dir.NewAccount, c.Key.Public())
+		if err != nil {
+			return nil, fmt.Errorf("生成 External Account Binding 失败: %w", err)
+		}
+		req["externalAccountBinding"] = binding
+	} else if dir.Meta.ExternalAccountRequired {
+		return nil, fmt.Errorf("该 CA 要求提供 External Account Binding（EAB）")
+	}
+
+	// newAccount 请求必须使用 JWK 签名
+	c.KID = ""
+
+	var account Account
+	resp, err := c.post(ctx, dir.NewAccount, req, &account)
+	if err != nil {
+		return nil, err
+	}
+
+	account.URL = resp.Header.Get("Location")
+	if account.URL == "" {
+		return nil, fmt.Errorf("ACME 服务器未返回账户地址")
+	}
+	c.KID = account.URL
+	return &account, nil
+}
+
+// LookupAccount 按账户私钥查找已注册的账户，不会创建新账户
+// 账户不存在时返回 ErrAccountDoesNotExist
+func (c *Client) LookupAccount(ctx context.Context) (*Account, error) {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.KID = ""
+
+	var account Account
+	resp, err := c.post(ctx, dir.NewAccount, map[string]interface{}{"onlyReturnExisting": true}, &account)
+	if err != nil {
+		return nil, err
+	}
+
+	account.URL = resp.Header.Get("Location")
+	if account.URL == "" {
+		return nil, fmt.Errorf("ACME 服务器未返回账户地址")
+	}
+	c.KID = account.URL
+	return &account, nil
+}
+
+// UpdateContact 更新账户联系邮箱
+func (c *Client) UpdateContact(ctx context.Context, email string) (*Account, error) {
+	req := map[string]interface{}{"contact": []string{}}
+	if email != "" {
+		req["contact"] = []string{"mailto:" + email}
+	}
+
+	var account Account
+	if _, err := c.post(ctx, c.KID, req, &account); err != nil {
+		return nil, err
+	}
+	account.URL = c.KID
+	return &account, nil
+}
+
+// Deactivate 停用账户，停用后无法再使用该账户申请证书
+func (c *Client) Deactivate(ctx context.Context) error {
+	_, err := c.post(ctx, c.KID, map[string]string{"status": "deactivated"}, nil)
+	return err
+}
+
+// NewOrder 为一组域名（或 IP）创建订单
+// replaces 为被替换证书的 ARI 标识，续期时填写，CA 据此不计入速率限制
+func (c *Client) NewOrder(ctx context.Context, domains []string, replaces string) (*Order, error) {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identifiers := make([]Identifier, 0, len(domains))
+	for _, d := range domains {
+		if net.ParseIP(d) != nil {
+			identifiers = append(identifiers, Identifier{Type: "ip", Value: d})
+		} else {
+			identifiers = append(identifiers, Identifier{Type: "dns", Value: d})
+		}
+	}
+
+	req := map[string]interface{}{"identifiers": identifiers}
+	if replaces != "" {
+		req["replaces"] = replaces
+	}
+
+	var order Order
+	resp, err := c.post(ctx, dir.NewOrder, req, &order)
+	if err != nil {
+		return nil, err
+	}
+	order.URL = resp.Header.Get("Location")
+	return &order, nil
+}
+
+// GetOrder 获取订单
+func (c *Client) GetOrder(ctx context.Context, url string) (*Order, error) {
+	var order Order
+	if _, err := c.post(ctx, url, nil, &order); err != nil {
+		return nil, err
+	}
+	order.URL = url
+	return &order, nil
+}
+
+// GetAuthorization 获取授权
+func (c *Client) GetAuthorization(ctx context.Context, url string) (*Authorization, error) {
+	var authz Authorization
+	if _, err := c.post(ctx, url, nil, &authz); err != nil {
+		return nil, err
+	}
+	authz.URL = url
+	return &authz, nil
+}
+
+// Accept 通知 CA 验证已就绪
+func (c *Client) Accept(ctx context.Context, challenge *Challenge) error {
+	_, err := c.post(ctx, challenge.URL, struct{}{}, nil)
+	return err
+}
+
+// WaitAuthorization 轮询授权直到完成
+// 验证失败时返回 *AuthorizationError
+func (c *Client) WaitAuthorization(ctx context.Context, url string) (*Authorization, error) {
+	for {
+		var authz Authorization
+		resp, err := c.post(ctx, url, nil, &authz)
+		if err != nil {
+			return nil, err
+		}
+		authz.URL = url
+
+		switch authz.Status {
+		case "valid":
+			return &authz, nil
+		case "invalid", "deactivated", "expired", "revoked":
+			authErr := &AuthorizationError{Identifier: authz.Identifier.Value}
+			for _, ch := range authz.Challenges {
+				if ch.Error != nil {
+					authErr.Challenge = ch.Type
+					authErr.Problem = ch.Error
+					break
+				}
+			}
+			if authErr.Problem == nil {
+				authErr.Problem = &Problem{Detail: "授权状态为 " + authz.Status}
+			}
+			return nil, authErr
+		}
+
+		if err := sleep(ctx, retryAfter(resp, 2*time.Second)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Finalize 提交 CSR
+func (c *Client) Finalize(ctx context.Context, order *Order, csr []byte) (*Order, error) {
+	var updated Order
+	req := map[string]string{"csr": b64(csr)}
+	if _, err := c.post(ctx, order.Finalize, req, &updated); err != nil {
+		return nil, err
+	}
+	updated.URL = order.URL
+	return &updated, nil
+}
+
+// WaitOrder 轮询订单直到 ready 或 valid
+// 订单失败时返回 *OrderError
+func (c *Client) WaitOrder(ctx context.Context, url string, until string) (*Order, error) {
+	for {
+		var order Order
+		resp, err := c.post(ctx, url, nil, &order)
+		if err != nil {
+			return nil, err
+		}
+		order.URL = url
+
+		switch {
+		case order.Status == until, order.Status == "valid":
+			return &order, nil
+		case order.Status == "invalid":
+			return nil, &OrderError{URL: url, Status: order.Status, Problem: order.Error}
+		}
+
+		if err := sleep(ctx, retryAfter(resp, 2*time.Second)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// FetchCertificate 下载证书链（PEM）
+func (c *Client) FetchCertificate(ctx context.Context, url string) ([]byte, error) {
+	resp, err := c.postRaw(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("CA 返回的证书格式无效")
+	}
+	return data, nil
+}
+
+// 撤销原因（RFC 5280 5.3.1），ACME 只接受以下几种
+const (
+	RevokeUnspecified          = 0
+	RevokeKeyCompromise        = 1
+	RevokeAffiliationChanged   = 3
+	RevokeSuperseded           = 4
+	RevokeCessationOfOperation = 5
+)
+
+// RevokeCertificate 撤销证书，der 为证书的 DER 编码
+// certKey 为 nil 时以账户身份签名，只能撤销该账户签发的证书；
+// 否则使用证书私钥签名（JWS 中嵌入 JWK），不需要证书所属的账户，部分 CA 要求 keyCompromise 原因使用这种方式
+func (c *Client) RevokeCertificate(ctx context.Context, der []byte, reason int, certKey crypto.Signer) error {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return err
+	}
+	if dir.RevokeCert == "" {
+		return fmt.Errorf("CA 不支持撤销证书")
+	}
+
+	signer := c
+	if certKey != nil {
+		signer = &Client{
+			DirectoryURL: c.DirectoryURL,
+			Key:          certKey,
+			HTTPClient:   c.HTTPClient,
+			UserAgent:    c.UserAgent,
+			dir:          dir,
+		}
+	}
+
+	req := map[string]interface{}{
+		"certificate": b64(der),
+		"reason":      reason,
+	}
+	resp, err := signer.postRaw(ctx, dir.RevokeCert, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// KeyAuthorization 计算验证令牌对应的 key authorization
+func (c *Client) KeyAuthorization(token string) (string, error) {
+	thumbprint, err := Thumbprint(c.Key.Public())
+	if err != nil {
+		return "", err
+	}
+	return token + "." + thumbprint, nil
+}
+
+// DNS01Value 计算 dns-01 验证需要写入 TXT 记录的值
+func DNS01Value(keyAuth string) string {
+	sum := sha256.Sum256([]byte(keyAuth))
+	return b64(sum[:])
+}
+
+// post 发送签名请求并解析 JSON 响应
+// payload 为 nil 时发送 POST-as-GET 请求
+func (c *Client) post(ctx context.Context, url string, payload interface{}, out interface{}) (*http.Response, error) {
+	resp, err := c.postRaw(ctx, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("解析 ACME 响应失败: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// postRaw 发送签名请求，成功时调用方负责关闭响应体
+// 遇到 badNonce 时自动使用新的 nonce 重试
+func (c *Client) postRaw(ctx context.Context, url string, payload interface{}) (*http.Response, error) {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if payload != nil {
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		nonce, err := c.nonce(ctx, dir.NewNonce)
+		if err != nil {
+			return nil, err
+		}
+
+		signed, err := signJWS(c.Key, c.KID, nonce, url, body)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(signed))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
+		c.saveNonce(resp)
+
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		respErr := responseError(resp)
+		resp.Body.Close()
+		if IsProblem(respErr, ErrBadNonce) && attempt < maxNonceRetries {
+			continue
+		}
+		return nil, respErr
+	}
+}
+
+// nonce 取出一个缓存的 nonce，没有时向服务器申请
+func (c *Client) nonce(ctx context.Context, newNonceURL string) (string, error) {
+	c.mu.Lock()
+	if n := len(c.nonces); n > 0 {
+		nonce := c.nonces[n-1]
+		c.nonces = c.nonces[:n-1]
+		c.mu.Unlock()
+		return nonce, nil
+	}
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, newNonceURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("获取 nonce 失败: %w", err)
+	}
+	resp.Body.Close()
+
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", fmt.Errorf("ACME 服务器未返回 nonce")
+	}
+	return nonce, nil
+}
+
+func (c *Client) saveNonce(resp *http.Response) {
+	if nonce := resp.Header.Get("Replay-Nonce"); nonce != "" {
+		c.mu.Lock()
+		c.nonces = append(c.nonces, nonce)
+		c.mu.Unlock()
+	}
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ua := "hop-acme"
+	if c.UserAgent != "" {
+		ua = c.UserAgent + " " + ua
+	}
+	req.Header.Set("User-Agent", ua)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// responseError 将错误响应转换为 *Problem
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	var problem Problem
+	if err := json.Unmarshal(data, &problem); err != nil || problem.Type == "" {
+		problem = Problem{Detail: strings.TrimSpace(string(data))}
+	}
+	if problem.Status == 0 {
+		problem.Status = resp.StatusCode
+	}
+	return &problem
+}
+
+// retryAfter 解析 Retry-After 头，缺省时返回 def
+func retryAfter(resp *http.Response, def time.Duration) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return def
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return def
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,429 @@
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCA 最小的 ACME 服务器，校验 nonce、url 和 JWS 签名，验证请求提交后立即通过
+type fakeCA struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu          sync.Mutex
+	nextNonce   int
+	nonces      map[string]bool // 已发放未使用的 nonce
+	accountKey  crypto.PublicKey
+	badNonces   int // 接下来的若干个签名请求返回 badNonce
+	order       Order
+	identifiers []Identifier
+	replaces    string
+	challenge   string // pending, valid
+	cert        []byte
+	requests    []string
+}
+
+func newFakeCA(t *testing.T) *fakeCA {
+	ca := &fakeCA{t: t, nonces: map[string]bool{}, challenge: "pending"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dir", ca.handleDirectory)
+	mux.HandleFunc("/new-nonce", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("newNonce 方法 = %s", r.Method)
+		}
+		ca.issueNonce(w)
+	})
+	mux.HandleFunc("/", ca.handleSigned)
+	ca.srv = httptest.NewServer(mux)
+	t.Cleanup(ca.srv.Close)
+	return ca
+}
+
+func (ca *fakeCA) url(path string) string {
+	return ca.srv.URL + path
+}
+
+func (ca *fakeCA) handleDirectory(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"newNonce":   ca.url("/new-nonce"),
+		"newAccount": ca.url("/new-account"),
+		"newOrder":   ca.url("/new-order"),
+		"revokeCert": ca.url("/revoke-cert"),
+		"meta":       map[string]interface{}{"termsOfService": ca.url("/terms")},
+	})
+}
+
+func (ca *fakeCA) issueNonce(w http.ResponseWriter) {
+	ca.mu.Lock()
+	ca.nextNonce++
+	nonce := fmt.Sprintf("nonce-%d", ca.nextNonce)
+	ca.nonces[nonce] = true
+	ca.mu.Unlock()
+	w.Header().Set("Replay-Nonce", nonce)
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+func (ca *fakeCA) problem(w http.ResponseWriter, status int, problemType, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{Type: problemType, Detail: detail, Status: status})
+}
+
+// handleSigned 处理所有 JWS 签名的 POST 请求
+func (ca *fakeCA) handleSigned(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/jose+json" {
+		ca.problem(w, http.StatusMethodNotAllowed, ErrMalformed, "需要 application/jose+json POST")
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var msg jwsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		ca.problem(w, http.StatusBadRequest, ErrMalformed, err.Error())
+		return
+	}
+	header := decodeProtected(ca.t, msg.Protected)
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	ca.requests = append(ca.requests, r.URL.Path)
+
+	// 无论成功与否，响应都附带新的 nonce
+	ca.nextNonce++
+	fresh := fmt.Sprintf("nonce-%d", ca.nextNonce)
+	ca.nonces[fresh] = true
+	w.Header().Set("Replay-Nonce", fresh)
+
+	if !ca.nonces[header.Nonce] || ca.badNonces > 0 {
+		if ca.badNonces > 0 {
+			ca.badNonces--
+		}
+		ca.problem(w, http.StatusBadRequest, ErrBadNonce, "nonce 无效: "+header.Nonce)
+		return
+	}
+	delete(ca.nonces, header.Nonce)
+
+	if header.URL != ca.url(r.URL.Path) {
+		ca.problem(w, http.StatusUnauthorized, ErrUnauthorized, "url 与请求地址不一致: "+header.URL)
+		return
+	}
+
+	var key crypto.PublicKey
+	if r.URL.Path == "/new-account" {
+		if header.KID != "" || header.JWK == nil {
+			ca.problem(w, http.StatusBadRequest, ErrMalformed, "newAccount 必须使用 jwk")
+			return
+		}
+		key = parseECJWK(ca.t, header.JWK)
+	} else {
+		if header.KID != ca.url("/acct/1") {
+			ca.problem(w, http.StatusBadRequest, ErrAccountDoesNotExist, "未知账户: "+header.KID)
+			return
+		}
+		key = ca.accountKey
+	}
+	if err := verifyJWS(key, msg); err != nil {
+		ca.problem(w, http.StatusUnauthorized, ErrMalformed, err.Error())
+		return
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(msg.Payload)
+	ca.route(w, r.URL.Path, key, payload)
+}
+
+func (ca *fakeCA) route(w http.ResponseWriter, path string, key crypto.PublicKey, payload []byte) {
+	switch path {
+	case "/new-account":
+		var req struct {
+			TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+			Contact              []string `json:"contact"`
+		}
+		json.Unmarshal(payload, &req)
+		if !req.TermsOfServiceAgreed {
+			ca.problem(w, http.StatusBadRequest, ErrMalformed, "未同意服务条款")
+			return
+		}
+		ca.accountKey = key
+		w.Header().Set("Location", ca.url("/acct/1"))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Account{Status: "valid", Contact: req.Contact})
+
+	case "/new-order":
+		var req struct {
+			Identifiers []Identifier `json:"identifiers"`
+			Replaces    string       `json:"replaces"`
+		}
+		json.Unmarshal(payload, &req)
+		ca.identifiers = req.Identifiers
+		ca.replaces = req.Replaces
+		ca.order = Order{
+			Status:         "pending",
+			Identifiers:    req.Identifiers,
+			Authorizations: []string{ca.url("/authz/1")},
+			Finalize:       ca.url("/finalize/1"),
+		}
+		w.Header().Set("Location", ca.url("/order/1"))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ca.order)
+
+	case "/authz/1":
+		if len(payload) != 0 {
+			ca.problem(w, http.StatusBadRequest, ErrMalformed, "POST-as-GET 载荷必须为空")
+			return
+		}
+		w.Header().Set("Retry-After", "0")
+		json.NewEncoder(w).Encode(Authorization{
+			Status:     ca.challenge,
+			Identifier: ca.identifiers[0],
+			Challenges: []Challenge{{Type: "dns-01", URL: ca.url("/chall/1"), Token: "token-1", Status: ca.challenge}},
+		})
+
+	case "/chall/1":
+		if string(payload) != "{}" {
+			ca.problem(w, http.StatusBadRequest, ErrMalformed, "应答载荷必须为 {}")
+			return
+		}
+		ca.challenge = "valid"
+		ca.order.Status = "ready"
+		json.NewEncoder(w).Encode(Challenge{Type: "dns-01", URL: ca.url("/chall/1"), Token: "token-1", Status: "processing"})
+
+	case "/finalize/1":
+		if ca.order.Status != "ready" {
+			ca.problem(w, http.StatusForbidden, "urn:ietf:params:acme:error:orderNotReady", "订单状态为 "+ca.order.Status)
+			return
+		}
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		json.Unmarshal(payload, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil || csr.CheckSignature() != nil {
+			ca.problem(w, http.StatusBadRequest, "urn:ietf:params:acme:error:badCSR", "CSR 无效")
+			return
+		}
+		ca.cert = issueTestCert(ca.t, csr)
+		// 签发异步完成，先返回 processing，轮询时才是 valid
+		ca.order.Status = "processing"
+		json.NewEncoder(w).Encode(ca.order)
+		ca.order.Status = "valid"
+		ca.order.Certificate = ca.url("/cert/1")
+
+	case "/order/1":
+		w.Header().Set("Retry-After", "0")
+		json.NewEncoder(w).Encode(ca.order)
+
+	case "/cert/1":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(ca.cert)
+
+	default:
+		ca.problem(w, http.StatusNotFound, ErrMalformed, "未知地址: "+path)
+	}
+}
+
+func parseECJWK(t *testing.T, raw json.RawMessage) crypto.PublicKey {
+	t.Helper()
+	var jwk struct {
+		Crv string `json:"crv"`
+		Kty string `json:"kty"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil || jwk.Kty != "EC" || jwk.Crv != "P-256" {
+		t.Fatalf("不支持的 jwk: %s", raw)
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+}
+
+func issueTestCert(t *testing.T, csr *x509.CertificateRequest) []byte {
+	t.Helper()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: csr.DNSNames[0]},
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, csr.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTestClient(t *testing.T, ca *fakeCA) *Client {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{DirectoryURL: ca.url("/dir"), Key: key, HTTPClient: ca.srv.Client()}
+}
+
+func TestClientIssue(t *testing.T) {
+	ca := newFakeCA(t)
+	client := newTestClient(t, ca)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dir, err := client.Discover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir.Meta.TermsOfService != ca.url("/terms") {
+		t.Errorf("termsOfService = %s", dir.Meta.TermsOfService)
+	}
+
+	account, err := client.Register(ctx, "admin@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.URL != ca.url("/acct/1") || client.KID != account.URL {
+		t.Errorf("账户地址 = %s, KID = %s", account.URL, client.KID)
+	}
+	if len(account.Contact) != 1 || account.Contact[0] != "mailto:admin@example.com" {
+		t.Errorf("contact = %v", account.Contact)
+	}
+
+	order, err := client.NewOrder(ctx, []string{"example.com", "192.0.2.1"}, "replaced-cert-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.URL != ca.url("/order/1") {
+		t.Errorf("订单地址 = %s", order.URL)
+	}
+	wantIDs := []Identifier{{Type: "dns", Value: "example.com"}, {Type: "ip", Value: "192.0.2.1"}}
+	if fmt.Sprint(ca.identifiers) != fmt.Sprint(wantIDs) || ca.replaces != "replaced-cert-id" {
+		t.Errorf("identifiers = %v, replaces = %s", ca.identifiers, ca.replaces)
+	}
+
+	authz, err := client.GetAuthorization(ctx, order.Authorizations[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authz.Status != "pending" || len(authz.Challenges) != 1 {
+		t.Fatalf("授权 = %+v", authz)
+	}
+	if err := client.Accept(ctx, &authz.Challenges[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.WaitOrder(ctx, order.URL, "ready"); err != nil {
+		t.Fatal(err)
+	}
+
+	certKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{"example.com"}}, certKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finalized, err := client.Finalize(ctx, order, csr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finalized.Status != "processing" || finalized.URL != order.URL {
+		t.Errorf("finalize 返回 = %+v", finalized)
+	}
+
+	valid, err := client.WaitOrder(ctx, order.URL, "valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := client.FetchCertificate(ctx, valid.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(chain), "-----BEGIN CERTIFICATE-----") {
+		t.Errorf("证书链 = %s", chain)
+	}
+
+	// 目录只获取一次，之后的 nonce 都来自上一个响应
+	if ca.nextNonce != len(ca.requests)+1 {
+		t.Errorf("发放了 %d 个 nonce，签名请求 %d 个", ca.nextNonce, len(ca.requests))
+	}
+}
+
+func TestClientBadNonceRetry(t *testing.T) {
+	ca := newFakeCA(t)
+	client := newTestClient(t, ca)
+	ctx := context.Background()
+
+	if _, err := client.Register(ctx, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ca.mu.Lock()
+	ca.badNonces = 1
+	ca.mu.Unlock()
+	if _, err := client.NewOrder(ctx, []string{"example.com"}, ""); err != nil {
+		t.Fatalf("badNonce 后应自动重试: %v", err)
+	}
+	if got := ca.requests; len(got) != 3 || got[1] != "/new-order" || got[2] != "/new-order" {
+		t.Errorf("请求 = %v", got)
+	}
+
+	// 超过重试次数后返回 badNonce 错误
+	ca.mu.Lock()
+	ca.badNonces = maxNonceRetries + 1
+	ca.mu.Unlock()
+	_, err := client.NewOrder(ctx, []string{"example.com"}, "")
+	if !IsProblem(err, ErrBadNonce) {
+		t.Errorf("err = %v, want badNonce", err)
+	}
+	if got := len(ca.requests); got != 3+maxNonceRetries+1 {
+		t.Errorf("请求次数 = %d, want %d", got, 3+maxNonceRetries+1)
+	}
+}
+
+func TestClientProblem(t *testing.T) {
+	ca := newFakeCA(t)
+	client := newTestClient(t, ca)
+	ctx := context.Background()
+
+	// 未注册的账户
+	client.KID = ca.url("/acct/unknown")
+	_, err := client.NewOrder(ctx, []string{"example.com"}, "")
+	if !IsProblem(err, ErrAccountDoesNotExist) {
+		t.Fatalf("err = %v, want accountDoesNotExist", err)
+	}
+	var problem *Problem
+	if !errors.As(err, &problem) || problem.Status != http.StatusBadRequest {
+		t.Errorf("problem = %+v", problem)
+	}
+
+	// 订单未就绪时提交 CSR
+	if _, err := client.Register(ctx, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	order, err := client.NewOrder(ctx, []string{"example.com"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Finalize(ctx, order, []byte("csr")); err == nil || !strings.Contains(err.Error(), "orderNotReady") {
+		t.Errorf("err = %v, want orderNotReady", err)
+	}
+}
@@ -0,0 +1,63 @@
+package acme
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LetsEncryptStagingURL Let's Encrypt 测试环境目录，签发的证书不受信任但限额宽松
+const LetsEncryptStagingURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+// ZeroSSLURL ZeroSSL 目录
+const ZeroSSLURL = "https://acme.zerossl.com/v2/DV90"
+
+// Preset 常用 CA 的目录
+type Preset struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	URL         string `json:"url"`
+	EABRequired bool   `json:"eabRequired"` // 注册账户时是否需要 External Account Binding
+	Staging     bool   `json:"staging"`     // 测试环境，签发的证书不受浏览器信任
+}
+
+// Presets 内置的 CA 目录
+var Presets = []Preset{
+	{Name: "letsencrypt", Label: "Let's Encrypt", URL: LetsEncryptURL},
+	{Name: "letsencrypt-staging", Label: "Let's Encrypt 测试环境", URL: LetsEncryptStagingURL, Staging: true},
+	{Name: "zerossl", Label: "ZeroSSL", URL: ZeroSSLURL, EABRequired: true},
+	{Name: "google", Label: "Google Trust Services", URL: "https://dv.acme-v02.api.pki.goog/directory", EABRequired: true},
+	{Name: "google-staging", Label: "Google Trust Services 测试环境", URL: "https://dv.acme-v02.test-api.pki.goog/directory", EABRequired: true, Staging: true},
+}
+
+// ResolveDirectory 将预设名称或目录地址解析为目录地址
+func ResolveDirectory(nameOrURL string) (string, error) {
+	nameOrURL = strings.TrimSpace(nameOrURL)
+	for _, p := range Presets {
+		if p.Name == nameOrURL {
+			return p.URL, nil
+		}
+	}
+
+	u, err := url.Parse(nameOrURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", fmt.Errorf("无效的 ACME 目录: %s", nameOrURL)
+	}
+	return nameOrURL, nil
+}
+
+// PresetByURL 查找目录地址对应的预设
+func PresetByURL(directory string) (Preset, bool) {
+	for _, p := range Presets {
+		if p.URL == directory {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// ExternalAccountBinding 将 ACME 账户绑定到 CA 的已有账户（RFC 8555 7.3.4）
+type ExternalAccountBinding struct {
+	KID     string // CA 提供的 key identifier
+	HMACKey string // CA 提供的 base64url 编码的 MAC 密钥
+}
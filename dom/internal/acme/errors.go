@@ -0,0 +1,116 @@
+package acme
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ACME 错误类型（RFC 8555 6.7）
+const (
+	ErrBadNonce            = "urn:ietf:params:acme:error:badNonce"
+	ErrRateLimited         = "urn:ietf:params:acme:error:rateLimited"
+	ErrUnauthorized        = "urn:ietf:params:acme:error:unauthorized"
+	ErrDNS                 = "urn:ietf:params:acme:error:dns"
+	ErrCAA                 = "urn:ietf:params:acme:error:caa"
+	ErrConnection          = "urn:ietf:params:acme:error:connection"
+	ErrIncorrectResponse   = "urn:ietf:params:acme:error:incorrectResponse"
+	ErrRejectedIdentifier  = "urn:ietf:params:acme:error:rejectedIdentifier"
+	ErrAccountDoesNotExist = "urn:ietf:params:acme:error:accountDoesNotExist"
+	ErrMalformed           = "urn:ietf:params:acme:error:malformed"
+	ErrServerInternal      = "urn:ietf:params:acme:error:serverInternal"
+	ErrAlreadyRevoked      = "urn:ietf:params:acme:error:alreadyRevoked"
+)
+
+// Problem ACME 服务器返回的错误文档（RFC 7807）
+type Problem struct {
+	Type        string      `json:"type"`
+	Detail      string      `json:"detail"`
+	Status      int         `json:"status"`
+	Identifier  *Identifier `json:"identifier,omitempty"`
+	Subproblems []Problem   `json:"subproblems,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	var b strings.Builder
+	if p.Type != "" {
+		b.WriteString(strings.TrimPrefix(p.Type, "urn:ietf:params:acme:error:"))
+	} else {
+		b.WriteString("ACME 错误")
+	}
+	if p.Status != 0 {
+		fmt.Fprintf(&b, " (HTTP %d)", p.Status)
+	}
+	if p.Detail != "" {
+		fmt.Fprintf(&b, ": %s", p.Detail)
+	}
+	for _, sub := range p.Subproblems {
+		b.WriteString("; ")
+		if sub.Identifier != nil {
+			fmt.Fprintf(&b, "%s: ", sub.Identifier.Value)
+		}
+		b.WriteString(sub.Detail)
+	}
+	return b.String()
+}
+
+// Has 错误本身或其子错误是否为指定类型
+func (p *Problem) Has(problemType string) bool {
+	if p.Type == problemType {
+		return true
+	}
+	for _, sub := range p.Subproblems {
+		if sub.Type == problemType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProblem 判断错误链中是否包含指定类型的 ACME 错误
+func IsProblem(err error, problemType string) bool {
+	var p *Problem
+	return errors.As(err, &p) && p.Has(problemType)
+}
+
+// AuthorizationError 域名授权验证失败
+type AuthorizationError struct {
+	Identifier string   // 域名
+	Challenge  string   // 验证方式，如 dns-01
+	Problem    *Problem // CA 给出的失败原因，可能为空
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Problem == nil {
+		return fmt.Sprintf("域名 %s 验证失败（%s）", e.Identifier, e.Challenge)
+	}
+	return fmt.Sprintf("域名 %s 验证失败（%s）: %s", e.Identifier, e.Challenge, e.Problem.Error())
+}
+
+func (e *AuthorizationError) Unwrap() error {
+	if e.Problem == nil {
+		return nil
+	}
+	return e.Problem
+}
+
+// OrderError 订单进入 invalid 状态
+type OrderError struct {
+	URL     string
+	Status  string
+	Problem *Problem
+}
+
+func (e *OrderError) Error() string {
+	if e.Problem == nil {
+		return fmt.Sprintf("订单状态异常: %s", e.Status)
+	}
+	return fmt.Sprintf("订单状态异常: %s: %s", e.Status, e.Problem.Error())
+}
+
+func (e *OrderError) Unwrap() error {
+	if e.Problem == nil {
+		return nil
+	}
+	return e.Problem
+}
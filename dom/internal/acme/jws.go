@@ -0,0 +1,201 @@
+package acme
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// jwsMessage JWS Flattened JSON 序列化（RFC 7515 7.2.2）
+type jwsMessage struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// signJWS 对请求体签名
+// kid 为空时在头部嵌入 JWK（仅用于 newAccount 和按证书私钥撤销）
+func signJWS(key crypto.Signer, kid, nonce, url string, payload []byte) ([]byte, error) {
+	alg, hash, err := jwsAlgorithm(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	header := map[string]interface{}{
+		"alg": alg,
+		"url": url,
+	}
+	if nonce != "" {
+		header["nonce"] = nonce
+	}
+	if kid != "" {
+		header["kid"] = kid
+	} else {
+		jwk, err := jwkOf(key.Public())
+		if err != nil {
+			return nil, err
+		}
+		header["jwk"] = jwk
+	}
+
+	protected, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := jwsMessage{
+		Protected: b64(protected),
+		Payload:   b64(payload),
+	}
+	sig, err := jwsSign(key, hash, []byte(msg.Protected+"."+msg.Payload))
+	if err != nil {
+		return nil, err
+	}
+	msg.Signature = b64(sig)
+
+	return json.Marshal(msg)
+}
+
+// signEAB 生成 newAccount 请求中的 externalAccountBinding 字段
+// 载荷为账户公钥的 JWK，使用 CA 提供的 MAC 密钥以 HS256 签名
+func signEAB(eab *ExternalAccountBinding, url string, accountKey crypto.PublicKey) (*jwsMessage, error) {
+	macKey, err := decodeEABKey(eab.HMACKey)
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := jwkOf(accountKey)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(jwk)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := json.Marshal(map[string]string{
+		"alg": "HS256",
+		"kid": eab.KID,
+		"url": url,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &jwsMessage{
+		Protected: b64(protected),
+		Payload:   b64(payload),
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write([]byte(msg.Protected + "." + msg.Payload))
+	msg.Signature = b64(mac.Sum(nil))
+	return msg, nil
+}
+
+// decodeEABKey 解码 MAC 密钥，兼容带填充的 base64url 和标准 base64
+func decodeEABKey(key string) ([]byte, error) {
+	key = strings.TrimRight(strings.TrimSpace(key), "=")
+	if data, err := base64.RawURLEncoding.DecodeString(key); err == nil {
+		return data, nil
+	}
+	data, err := base64.RawStdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("EAB HMAC 密钥不是有效的 base64 编码")
+	}
+	return data, nil
+}
+
+// jwsAlgorithm 根据公钥类型选择签名算法
+func jwsAlgorithm(pub crypto.PublicKey) (string, crypto.Hash, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return "ES256", crypto.SHA256, nil
+		case elliptic.P384():
+			return "ES384", crypto.SHA384, nil
+		}
+		return "", 0, fmt.Errorf("不支持的椭圆曲线: %s", k.Curve.Params().Name)
+	case *rsa.PublicKey:
+		return "RS256", crypto.SHA256, nil
+	}
+	return "", 0, fmt.Errorf("不支持的密钥类型: %T", pub)
+}
+
+// jwsSign 计算签名；ECDSA 签名按 JWS 要求编码为定长的 r||s
+func jwsSign(key crypto.Signer, hash crypto.Hash, data []byte) ([]byte, error) {
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	if ecKey, ok := key.(*ecdsa.PrivateKey); ok {
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest)
+		if err != nil {
+			return nil, err
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig, nil
+	}
+
+	return key.Sign(rand.Reader, digest, hash)
+}
+
+// jwkOf 将公钥编码为 JWK
+// 字段按字典序排列，满足 RFC 7638 指纹计算的要求
+func jwkOf(pub crypto.PublicKey) (interface{}, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{
+			Crv: k.Curve.Params().Name,
+			Kty: "EC",
+			X:   b64(k.X.FillBytes(make([]byte, size))),
+			Y:   b64(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case *rsa.PublicKey:
+		return struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{
+			E:   b64(big.NewInt(int64(k.E)).Bytes()),
+			Kty: "RSA",
+			N:   b64(k.N.Bytes()),
+		}, nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型: %T", pub)
+}
+
+// Thumbprint 计算公钥的 JWK 指纹（RFC 7638）
+func Thumbprint(pub crypto.PublicKey) (string, error) {
+	jwk, err := jwkOf(pub)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return b64(sum[:]), nil
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
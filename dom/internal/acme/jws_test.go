@@ -0,0 +1,256 @@
+package acme
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// RFC 7638 3.1 示例中的 RSA 公钥及其指纹
+const (
+	rfc7638N          = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+	rfc7638E          = "AQAB"
+	rfc7638Thumbprint = "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+)
+
+func rfc7638Key(t *testing.T) *rsa.PublicKey {
+	t.Helper()
+	n, err := base64.RawURLEncoding.DecodeString(rfc7638N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(rfc7638E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+}
+
+func TestThumbprintRFC7638(t *testing.T) {
+	got, err := Thumbprint(rfc7638Key(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != rfc7638Thumbprint {
+		t.Errorf("Thumbprint = %s, want %s", got, rfc7638Thumbprint)
+	}
+}
+
+func TestJWKMemberOrder(t *testing.T) {
+	// 指纹输入必须只包含必需成员，按字典序排列且没有空白（RFC 7638 3.2）
+	data, err := json.Marshal(mustJWK(t, rfc7638Key(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"e":"AQAB","kty":"RSA","n":"` + rfc7638N + `"}`
+	if string(data) != want {
+		t.Errorf("RSA JWK = %s, want %s", data, want)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ec map[string]string
+	data, _ = json.Marshal(mustJWK(t, key.Public()))
+	json.Unmarshal(data, &ec)
+	if ec["crv"] != "P-256" || ec["kty"] != "EC" || len(ec) != 4 {
+		t.Errorf("EC JWK = %s", data)
+	}
+	// 坐标按曲线长度补零
+	if x, _ := base64.RawURLEncoding.DecodeString(ec["x"]); len(x) != 32 {
+		t.Errorf("x 长度 = %d, want 32", len(x))
+	}
+}
+
+func TestDNS01Value(t *testing.T) {
+	// RFC 8555 8.4: TXT 值为 key authorization 的 SHA-256 摘要的 base64url 编码
+	keyAuth := "evaGxfADs6pSRb2LAv9IZf17Dt3juxGJ-PCt92wr-oA." + rfc7638Thumbprint
+	sum := sha256.Sum256([]byte(keyAuth))
+	if got, want := DNS01Value(keyAuth), base64.RawURLEncoding.EncodeToString(sum[:]); got != want {
+		t.Errorf("DNS01Value = %s, want %s", got, want)
+	}
+}
+
+func TestSignJWS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		key  crypto.Signer
+		kid  string
+		alg  string
+	}{
+		{"ES384 jwk", ecKey, "", "ES384"},
+		{"RS256 kid", rsaKey, "https://example.com/acme/acct/evOfKhNU60wg", "RS256"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := []byte(`{"termsOfServiceAgreed":true}`)
+			data, err := signJWS(tc.key, tc.kid, "nonce-1", "https://example.com/acme/new-account", payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var msg jwsMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatal(err)
+			}
+			header := decodeProtected(t, msg.Protected)
+			if header.Alg != tc.alg || header.Nonce != "nonce-1" || header.URL != "https://example.com/acme/new-account" {
+				t.Errorf("protected = %+v", header)
+			}
+			// kid 与 jwk 只能出现一个（RFC 8555 6.2）
+			if tc.kid != "" && (header.KID != tc.kid || header.JWK != nil) {
+				t.Errorf("kid 签名的头部 = %+v", header)
+			}
+			if tc.kid == "" && (header.KID != "" || header.JWK == nil) {
+				t.Errorf("jwk 签名的头部 = %+v", header)
+			}
+			if p, _ := base64.RawURLEncoding.DecodeString(msg.Payload); string(p) != string(payload) {
+				t.Errorf("payload = %s", p)
+			}
+			if err := verifyJWS(tc.key.Public(), msg); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSignJWSPostAsGet(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	data, err := signJWS(key, "https://example.com/acme/acct/1", "n", "https://example.com/acme/order/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg jwsMessage
+	json.Unmarshal(data, &msg)
+	// POST-as-GET 的载荷为空字符串（RFC 8555 6.3）
+	if msg.Payload != "" {
+		t.Errorf("payload = %q, want empty", msg.Payload)
+	}
+}
+
+// RFC 8555 7.3.4 示例中的 EAB 参数；RFC 中的签名被截断，这里按规范重新计算 MAC 校验
+func TestSignEAB(t *testing.T) {
+	accountKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	macKey := []byte("0123456789abcdef0123456789abcdef")
+	const url = "https://example.com/acme/new-account"
+
+	for _, encoded := range []string{
+		base64.RawURLEncoding.EncodeToString(macKey),
+		base64.URLEncoding.EncodeToString(macKey),
+		base64.StdEncoding.EncodeToString(macKey),
+	} {
+		msg, err := signEAB(&ExternalAccountBinding{KID: "kid-1", HMACKey: encoded}, url, accountKey.Public())
+		if err != nil {
+			t.Fatalf("%s: %v", encoded, err)
+		}
+
+		var header map[string]string
+		protected, _ := base64.RawURLEncoding.DecodeString(msg.Protected)
+		json.Unmarshal(protected, &header)
+		// 头部只有 alg、kid 和 url，不能包含 nonce
+		if len(header) != 3 || header["alg"] != "HS256" || header["kid"] != "kid-1" || header["url"] != url {
+			t.Errorf("protected = %s", protected)
+		}
+
+		// 载荷为账户公钥的 JWK
+		payload, _ := base64.RawURLEncoding.DecodeString(msg.Payload)
+		jwk, _ := json.Marshal(mustJWK(t, accountKey.Public()))
+		if string(payload) != string(jwk) {
+			t.Errorf("payload = %s, want %s", payload, jwk)
+		}
+
+		mac := hmac.New(sha256.New, macKey)
+		mac.Write([]byte(msg.Protected + "." + msg.Payload))
+		if want := base64.RawURLEncoding.EncodeToString(mac.Sum(nil)); msg.Signature != want {
+			t.Errorf("signature = %s, want %s", msg.Signature, want)
+		}
+	}
+
+	if _, err := signEAB(&ExternalAccountBinding{KID: "kid-1", HMACKey: "not base64!"}, url, accountKey.Public()); err == nil {
+		t.Error("无效的 HMAC 密钥应返回错误")
+	}
+}
+
+type jwsHeader struct {
+	Alg   string          `json:"alg"`
+	Nonce string          `json:"nonce"`
+	URL   string          `json:"url"`
+	KID   string          `json:"kid"`
+	JWK   json.RawMessage `json:"jwk"`
+}
+
+func decodeProtected(t *testing.T, protected string) jwsHeader {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header jwsHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		t.Fatal(err)
+	}
+	return header
+}
+
+func mustJWK(t *testing.T, pub crypto.PublicKey) interface{} {
+	t.Helper()
+	jwk, err := jwkOf(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwk
+}
+
+// verifyJWS 按 JWS 规则校验签名，ECDSA 签名为定长的 r||s
+func verifyJWS(pub crypto.PublicKey, msg jwsMessage) error {
+	sig, err := base64.RawURLEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return err
+	}
+	_, hash, err := jwsAlgorithm(pub)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write([]byte(msg.Protected + "." + msg.Payload))
+	digest := h.Sum(nil)
+
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errSignature
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, hash, digest, sig)
+	}
+	return errSignature
+}
+
+var errSignature = &Problem{Type: ErrMalformed, Detail: "JWS 签名无效"}
@@ -0,0 +1,166 @@
+//go:build pebble
+
+// 使用 Pebble 和 pebble-challtestsrv 的集成测试:
+//
+//	pebble -config test/config/pebble-config.json -dnsserver 127.0.0.1:8053
+//	pebble-challtestsrv -dnsserver :8053 -management :8055
+//	go test -tags pebble ./internal/acme/
+//
+// PEBBLE_DIRECTORY 和 PEBBLE_CHALLTESTSRV 可覆盖默认地址；
+// 设置 PEBBLE_EAB_DIRECTORY、PEBBLE_EAB_KID 和 PEBBLE_EAB_HMAC 时同时测试 EAB 注册
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func pebbleEnv(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func pebbleClient(t *testing.T, directory string) *Client {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pebble 使用自签名的测试证书
+	httpClient := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	return &Client{DirectoryURL: directory, Key: key, HTTPClient: httpClient}
+}
+
+// challtestsrv 调用 pebble-challtestsrv 管理接口
+func challtestsrv(t *testing.T, path string, body interface{}) {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	resp, err := http.Post(pebbleEnv("PEBBLE_CHALLTESTSRV", "http://localhost:8055")+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("challtestsrv %s: HTTP %d", path, resp.StatusCode)
+	}
+}
+
+func TestPebbleIssueDNS01(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	client := pebbleClient(t, pebbleEnv("PEBBLE_DIRECTORY", "https://localhost:14000/dir"))
+	if _, err := client.Register(ctx, "admin@example.com", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	domains := []string{"pebble.example.com", "*.pebble.example.com"}
+	order, err := client.NewOrder(ctx, domains, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, authzURL := range order.Authorizations {
+		authz, err := client.GetAuthorization(ctx, authzURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var challenge *Challenge
+		for i := range authz.Challenges {
+			if authz.Challenges[i].Type == "dns-01" {
+				challenge = &authz.Challenges[i]
+			}
+		}
+		if challenge == nil {
+			t.Fatalf("%s 没有 dns-01 验证", authz.Identifier.Value)
+		}
+
+		keyAuth, err := client.KeyAuthorization(challenge.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		host := "_acme-challenge." + authz.Identifier.Value + "."
+		challtestsrv(t, "/set-txt", map[string]string{"host": host, "value": DNS01Value(keyAuth)})
+		defer challtestsrv(t, "/clear-txt", map[string]string{"host": host})
+
+		if err := client.Accept(ctx, challenge); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.WaitAuthorization(ctx, authzURL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := client.WaitOrder(ctx, order.URL, "ready"); err != nil {
+		t.Fatal(err)
+	}
+	certKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: domains}, certKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Finalize(ctx, order, csr); err != nil {
+		t.Fatal(err)
+	}
+	valid, err := client.WaitOrder(ctx, order.URL, "valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := client.FetchCertificate(ctx, valid.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(chain)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.VerifyHostname("www.pebble.example.com"); err != nil {
+		t.Error(err)
+	}
+
+	if err := client.RevokeCertificate(ctx, cert.Raw, RevokeSuperseded, nil); err != nil {
+		t.Errorf("撤销证书失败: %v", err)
+	}
+}
+
+func TestPebbleExternalAccountBinding(t *testing.T) {
+	directory := os.Getenv("PEBBLE_EAB_DIRECTORY")
+	if directory == "" {
+		t.Skip("未设置 PEBBLE_EAB_DIRECTORY")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client := pebbleClient(t, directory)
+	if _, err := client.Register(ctx, "", nil); err == nil {
+		t.Error("CA 要求 EAB 时未绑定应返回错误")
+	}
+
+	eab := &ExternalAccountBinding{KID: os.Getenv("PEBBLE_EAB_KID"), HMACKey: os.Getenv("PEBBLE_EAB_HMAC")}
+	if _, err := client.Register(ctx, "", eab); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := pebbleClient(t, directory)
+	if _, err := wrong.Register(ctx, "", &ExternalAccountBinding{KID: eab.KID, HMACKey: "d3Jvbmcta2V5"}); err == nil {
+		t.Error("错误的 MAC 密钥应返回错误")
+	}
+}
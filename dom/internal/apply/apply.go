@@ -0,0 +1,456 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hop/backend/internal/acme"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/logger"
+	"github.com/hop/backend/internal/nginx"
+	"github.com/hop/backend/internal/ssl"
+)
+
+var log = logger.WithTag("apply")
+
+// Options 应用选项
+type Options struct {
+	DryRun bool // 只计算差异，不做任何修改
+	Prune  bool // 删除清单中未声明的站点、路由和证书
+
+	// NoHTTP01 拒绝需要 http-01 验证的签发
+	// 命令行进程不监听 80 端口，验证请求由运行中的服务器应答，无法取得本进程内的令牌
+	NoHTTP01 bool
+}
+
+// CertificateAction 证书变更
+type CertificateAction struct {
+	Domain  string   `json:"domain"`
+	Domains []string `json:"domains"`
+	Action  string   `json:"action"` // issue, reissue, update, delete
+	ID      string   `json:"id,omitempty"`
+}
+
+// Result 应用结果
+type Result struct {
+	DryRun       bool                `json:"dryRun"`
+	Certificates []CertificateAction `json:"certificates"`
+	Plan         *nginx.Plan         `json:"plan"`
+}
+
+// HasChanges 是否存在任何变更
+func (r *Result) HasChanges() bool {
+	return len(r.Certificates) > 0 || r.Plan.HasChanges()
+}
+
+// Apply 将当前状态收敛到清单描述的期望状态
+func Apply(m *Manifest, opts Options, change nginx.Change) (*Result, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{DryRun: opts.DryRun, Certificates: []CertificateAction{}}
+
+	// 1. 证书
+	certActions, err := diffCertificates(m, opts.Prune)
+	if err != nil {
+		return nil, err
+	}
+	if certActions != nil {
+		result.Certificates = certActions
+	}
+
+	if !opts.DryRun {
+		if opts.NoHTTP01 {
+			if err := checkNoHTTP01(m, certActions); err != nil {
+				return nil, err
+			}
+		}
+		if err := applyCertificates(m, certActions); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. 站点和路由
+	planReq, err := buildPlanRequest(m, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := nginx.BuildPlan(planReq)
+	if err != nil {
+		return nil, err
+	}
+	result.Plan = plan
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := plan.Apply(change); err != nil {
+		return nil, fmt.Errorf("写入配置失败: %w", err)
+	}
+
+	if m.Template != nil {
+		if err := nginx.SaveTemplateParams(*m.Template); err != nil {
+			return nil, fmt.Errorf("保存模板参数失败: %w", err)
+		}
+	}
+
+	// 3. 站点不再引用后，删除多余的证书
+	for _, action := range certActions {
+		if action.Action != "delete" {
+			continue
+		}
+		if err := ssl.DeleteCertificate(action.ID, false); err != nil {
+			return nil, fmt.Errorf("删除证书 %s 失败: %w", action.Domain, err)
+		}
+		log.Info("证书已删除", map[string]interface{}{"domain": action.Domain})
+	}
+
+	log.Info("清单已应用", map[string]interface{}{
+		"files":        len(plan.Files),
+		"certificates": len(certActions),
+	})
+	return result, nil
+}
+
+// diffCertificates 比较清单与数据库中的证书
+func diffCertificates(m *Manifest, prune bool) ([]CertificateAction, error) {
+	existing, err := database.ListCertificates()
+	if err != nil {
+		return nil, fmt.Errorf("获取证书列表失败: %w", err)
+	}
+
+	// 同一主域名有多个证书时，清单管理最新申请的 ACME 证书
+	byDomain := map[string]database.Certificate{}
+	for _, cert := range existing {
+		if _, ok := byDomain[cert.Domain]; !ok && cert.Source == database.CertSourceACME {
+			byDomain[cert.Domain] = cert
+		}
+	}
+
+	var actions []CertificateAction
+	declared := map[string]bool{}
+	for _, desired := range m.Certificates {
+		mainDomain := desired.Domains[0]
+		declared[mainDomain] = true
+
+		providerID, err := desiredProviderID(desired)
+		if err != nil {
+			return nil, err
+		}
+		directory, err := desiredDirectory(desired)
+		if err != nil {
+			return nil, err
+		}
+
+		current, ok := byDomain[mainDomain]
+		action := CertificateAction{Domain: mainDomain, Domains: desired.Domains}
+		switch {
+		case !ok:
+			action.Action = "issue"
+		case current.Status != "active" || !sameDomains(current.Domains, desired.Domains),
+			directory != "" && current.ACMEDirectory != directory,
+			desired.KeyType != "" && current.KeyType != desired.KeyType,
+			current.Dual != desired.Dual:
+			// 更换 CA 或私钥算法需要重新签发
+			action.Action = "reissue"
+			action.ID = current.ID
+		case current.AutoRenew != autoRenew(desired),
+			current.ChallengeType != challengeType(desired),
+			current.DNSProviderID != providerID,
+			current.EABKID != desired.EABKID || current.EABHMACKey != desired.EABHMACKey,
+			current.ReuseKey != desired.ReuseKey,
+			accountEmail(current.AccountID) != desired.Email:
+			action.Action = "update"
+			action.ID = current.ID
+		default:
+			continue
+		}
+		actions = append(actions, action)
+	}
+
+	if prune {
+		// 仍被清单站点引用的证书不删除
+		referenced := map[string]bool{}
+		for _, site := range m.Sites {
+			if site.CertificateID != "" {
+				referenced[site.CertificateID] = true
+			}
+			if site.UpstreamClientCertID != "" {
+				referenced[site.UpstreamClientCertID] = true
+			}
+			if site.Certificate != "" {
+				declared[site.Certificate] = true
+			}
+		}
+
+		for _, cert := range existing {
+			// 上传和内部 CA 签发的证书不由清单管理
+			if declared[cert.Domain] || referenced[cert.ID] || cert.Source != database.CertSourceACME {
+				continue
+			}
+			var domains []string
+			json.Unmarshal([]byte(cert.Domains), &domains)
+			actions = append(actions, CertificateAction{
+				Domain:  cert.Domain,
+				Domains: domains,
+				Action:  "delete",
+				ID:      cert.ID,
+			})
+		}
+	}
+
+	return actions, nil
+}
+
+// checkNoHTTP01 检查是否有证书需要通过 http-01 签发
+func checkNoHTTP01(m *Manifest, actions []CertificateAction) error {
+	desiredByDomain := map[string]Certificate{}
+	for _, cert := range m.Certificates {
+		desiredByDomain[cert.Domains[0]] = cert
+	}
+
+	for _, action := range actions {
+		if action.Action != "issue" && action.Action != "reissue" {
+			continue
+		}
+		if challengeType(desiredByDomain[action.Domain]) == database.ChallengeHTTP01 {
+			return fmt.Errorf("证书 %s 使用 http-01 验证，验证请求由运行中的 Hop 服务器应答，无法在命令行中签发；请通过 POST /api/apply 提交清单，或改用 dns-01", action.Domain)
+		}
+	}
+	return nil
+}
+
+// applyCertificates 执行证书申请和更新（删除在站点更新之后进行）
+func applyCertificates(m *Manifest, actions []CertificateAction) error {
+	desiredByDomain := map[string]Certificate{}
+	for _, cert := range m.Certificates {
+		desiredByDomain[cert.Domains[0]] = cert
+	}
+
+	for _, action := range actions {
+		desired := desiredByDomain[action.Domain]
+
+		switch action.Action {
+		case "issue", "reissue":
+			providerID, err := desiredProviderID(desired)
+			if err != nil {
+				return err
+			}
+			// 与界面提交的申请一起排队，等待签发完成后再更新站点
+			job, err := ssl.QueueIssue(ssl.IssueOptions{
+				Domains:       desired.Domains,
+				ChallengeType: challengeType(desired),
+				DNSProviderID: providerID,
+				Email:         desired.Email,
+				Directory:     desired.Directory,
+				EABKID:        desired.EABKID,
+				EABHMACKey:    desired.EABHMACKey,
+				KeyType:       desired.KeyType,
+				ReuseKey:      desired.ReuseKey,
+				Dual:          desired.Dual,
+				Replace:       action.ID, // 重新签发成功后站点改用新证书，再删除原证书
+			})
+			if err != nil {
+				return fmt.Errorf("申请证书 %s 失败: %w", action.Domain, err)
+			}
+			cert, err := job.Wait()
+			if err != nil {
+				return fmt.Errorf("申请证书 %s 失败: %w", action.Domain, err)
+			}
+			if cert.AutoRenew != autoRenew(desired) {
+				cert.AutoRenew = autoRenew(desired)
+				if err := database.UpdateCertificate(cert); err != nil {
+					return fmt.Errorf("更新证书 %s 失败: %w", action.Domain, err)
+				}
+			}
+		case "update":
+			cert, err := database.GetCertificate(action.ID)
+			if err != nil {
+				return fmt.Errorf("获取证书 %s 失败: %w", action.Domain, err)
+			}
+			providerID, err := desiredProviderID(desired)
+			if err != nil {
+				return err
+			}
+			cert.AutoRenew = autoRenew(desired)
+			cert.ChallengeType = challengeType(desired)
+			cert.DNSProviderID = providerID
+			cert.EABKID = desired.EABKID
+			cert.EABHMACKey = desired.EABHMACKey
+			cert.ReuseKey = desired.ReuseKey
+			if accountEmail(cert.AccountID) != desired.Email {
+				if err := ssl.LinkAccount(cert, desired.Email); err != nil {
+					return fmt.Errorf("关联证书 %s 的 ACME 账户失败: %w", action.Domain, err)
+				}
+			}
+			if err := database.UpdateCertificate(cert); err != nil {
+				return fmt.Errorf("更新证书 %s 失败: %w", action.Domain, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildPlanRequest 根据清单生成站点和路由的变更计划请求
+func buildPlanRequest(m *Manifest, opts Options) (nginx.PlanRequest, error) {
+	req := nginx.PlanRequest{
+		Sites:          []nginx.ProxySite{},
+		StreamRoutes:   m.StreamRoutes,
+		TemplateParams: m.Template,
+	}
+
+	for _, s := range m.Sites {
+		site := s.ProxySite
+		if s.Certificate != "" {
+			cert, err := database.GetCertificateByDomain(s.Certificate)
+			declared, ok := declaredCertificate(m, s.Certificate)
+			switch {
+			case err == nil && cert.Status == "active":
+				site.CertificateID = cert.ID
+			case opts.DryRun && ok:
+				// 预览时证书尚未申请，使用申请后将生成的路径（相对于 nginx 目录）
+				certPath, keyPath, rsaCertPath, rsaKeyPath := ssl.PlannedCertificatePaths(s.Certificate, declared.Dual)
+				site.SSLCert = strings.TrimPrefix(filepath.ToSlash(certPath), "nginx/")
+				site.SSLKey = strings.TrimPrefix(filepath.ToSlash(keyPath), "nginx/")
+				site.SSLCertRSA = strings.TrimPrefix(filepath.ToSlash(rsaCertPath), "nginx/")
+				site.SSLKeyRSA = strings.TrimPrefix(filepath.ToSlash(rsaKeyPath), "nginx/")
+			default:
+				return req, fmt.Errorf("站点 %s: 找不到可用的证书 %s", site.ID, s.Certificate)
+			}
+		}
+		req.Sites = append(req.Sites, site)
+	}
+
+	if opts.Prune {
+		desiredSites := map[string]bool{}
+		for _, site := range m.Sites {
+			desiredSites[site.ID] = true
+		}
+		sites, err := nginx.ListProxySites()
+		if err != nil {
+			return req, err
+		}
+		for _, site := range sites {
+			if !desiredSites[site.ID] {
+				req.DeleteSites = append(req.DeleteSites, site.ID)
+			}
+		}
+
+		desiredRoutes := map[string]bool{}
+		for _, route := range m.StreamRoutes {
+			desiredRoutes[route.ID] = true
+		}
+		routes, err := nginx.ListStreamRoutes()
+		if err != nil {
+			return req, err
+		}
+		for _, route := range routes {
+			if !desiredRoutes[route.ID] {
+				req.DeleteStreamRoutes = append(req.DeleteStreamRoutes, route.ID)
+			}
+		}
+	}
+
+	return req, nil
+}
+
+// desiredProviderID 返回清单证书使用的 DNS 提供商 ID（http-01 验证时为空）
+func desiredProviderID(c Certificate) (string, error) {
+	if challengeType(c) != database.ChallengeDNS01 {
+		return "", nil
+	}
+	provider, err := resolveDNSProvider(c.DNSProvider)
+	if err != nil {
+		return "", err
+	}
+	return provider.ID, nil
+}
+
+// resolveDNSProvider 根据名称或 ID 查找 DNS 提供商
+func resolveDNSProvider(nameOrID string) (*database.DNSProvider, error) {
+	providers, err := database.ListDNSProviders()
+	if err != nil {
+		return nil, fmt.Errorf("获取 DNS 提供商失败: %w", err)
+	}
+	for _, p := range providers {
+		if p.ID == nameOrID || p.Name == nameOrID {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("DNS 提供商不存在: %s", nameOrID)
+}
+
+// declaredCertificate 清单中声明的该主域名的证书
+func declaredCertificate(m *Manifest, domain string) (Certificate, bool) {
+	for _, cert := range m.Certificates {
+		if cert.Domains[0] == domain {
+			return cert, true
+		}
+	}
+	return Certificate{}, false
+}
+
+// sameDomains 比较数据库中的域名列表（JSON）与期望的域名列表（忽略顺序）
+func sameDomains(domainsJSON string, desired []string) bool {
+	var current []string
+	if err := json.Unmarshal([]byte(domainsJSON), &current); err != nil {
+		return false
+	}
+	if len(current) != len(desired) {
+		return false
+	}
+
+	a := append([]string(nil), current...)
+	b := append([]string(nil), desired...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func autoRenew(c Certificate) bool {
+	return c.AutoRenew == nil || *c.AutoRenew
+}
+
+// accountEmail 证书关联账户的邮箱，未关联时为空
+func accountEmail(accountID string) string {
+	if accountID == "" {
+		return ""
+	}
+	account, err := database.GetACMEAccount(accountID)
+	if err != nil {
+		return ""
+	}
+	return account.Email
+}
+
+// desiredDirectory 清单指定的 ACME 目录地址，未指定时为空
+func desiredDirectory(c Certificate) (string, error) {
+	if c.Directory == "" {
+		return "", nil
+	}
+	directory, err := acme.ResolveDirectory(c.Directory)
+	if err != nil {
+		return "", fmt.Errorf("证书 %s: %w", c.Domains[0], err)
+	}
+	return directory, nil
+}
+
+// challengeType 证书验证方式，默认 dns-01
+func challengeType(c Certificate) string {
+	if c.Challenge == "" {
+		return database.ChallengeDNS01
+	}
+	return c.Challenge
+}
@@ -0,0 +1,281 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+// testEnv 临时数据目录中的数据库，包含一个 DNS 提供商和一个 ACME 账户
+type testEnv struct {
+	provider *database.DNSProvider
+	account  *database.ACMEAccount
+}
+
+func setupTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Data.Dir
+	cfg.Data.Dir = t.TempDir()
+	if err := database.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		cfg.Data.Dir = original
+	})
+	// PATH 中没有 nginx，跳过校验
+	t.Setenv("PATH", t.TempDir())
+	if err := nginx.InitNginxConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	env := &testEnv{
+		provider: &database.DNSProvider{ID: "dns-1", Name: "cloudflare", Type: "cloudflare", Config: "{}"},
+		account:  &database.ACMEAccount{ID: "account-1", Email: "admin@example.com", Directory: "https://acme.example.com/directory"},
+	}
+	if err := database.CreateDNSProvider(env.provider); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.CreateACMEAccount(env.account); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+// createCertificate 写入一张已签发的证书，modify 修改默认字段
+func (env *testEnv) createCertificate(t *testing.T, domains []string, modify func(*database.Certificate)) *database.Certificate {
+	t.Helper()
+	now := time.Now()
+	cert := &database.Certificate{
+		ID:            "cert-" + domains[0],
+		Domain:        domains[0],
+		Domains:       `["` + strings.Join(domains, `","`) + `"]`,
+		Source:        database.CertSourceACME,
+		Usage:         database.CertUsageServer,
+		DNSProviderID: env.provider.ID,
+		ChallengeType: database.ChallengeDNS01,
+		AccountID:     env.account.ID,
+		CertPath:      "nginx/ssl/" + domains[0] + ".crt",
+		KeyPath:       "nginx/ssl/" + domains[0] + ".key",
+		NotBefore:     now,
+		NotAfter:      now.AddDate(0, 0, 90),
+		AutoRenew:     true,
+		Status:        "active",
+	}
+	if modify != nil {
+		modify(cert)
+	}
+	if err := database.CreateCertificate(cert); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// declare 清单中与 createCertificate 默认值一致的证书
+func (env *testEnv) declare(domains ...string) Certificate {
+	return Certificate{Domains: domains, DNSProvider: env.provider.Name, Email: env.account.Email}
+}
+
+func TestDiffCertificates(t *testing.T) {
+	off := false
+
+	for _, tc := range []struct {
+		name     string
+		existing func(*database.Certificate)
+		desired  func(*Certificate)
+		action   string
+	}{
+		{name: "无变化"},
+		{name: "新证书", desired: func(c *Certificate) { c.Domains = []string{"new.example.com"} }, action: "issue"},
+		{name: "域名变化", desired: func(c *Certificate) { c.Domains = append(c.Domains, "api.example.com") }, action: "reissue"},
+		{name: "主域名变化", desired: func(c *Certificate) { c.Domains = []string{"www.example.com", "example.com"} }, action: "issue"},
+		{name: "私钥算法变化", desired: func(c *Certificate) { c.KeyType = "rsa2048" }, action: "reissue"},
+		{name: "开启双证书", desired: func(c *Certificate) { c.Dual = true }, action: "reissue"},
+		{name: "证书状态无效", existing: func(c *database.Certificate) { c.Status = "error" }, action: "reissue"},
+		{name: "关闭自动续期", desired: func(c *Certificate) { c.AutoRenew = &off }, action: "update"},
+		{name: "更换邮箱", desired: func(c *Certificate) { c.Email = "ops@example.com" }, action: "update"},
+		{name: "复用私钥", desired: func(c *Certificate) { c.ReuseKey = true }, action: "update"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := setupTestEnv(t)
+			cert := env.createCertificate(t, []string{"example.com", "www.example.com"}, tc.existing)
+			desired := env.declare("example.com", "www.example.com")
+			if tc.desired != nil {
+				tc.desired(&desired)
+			}
+
+			actions, err := diffCertificates(&Manifest{Certificates: []Certificate{desired}}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.action == "" {
+				if len(actions) != 0 {
+					t.Errorf("actions = %+v, want none", actions)
+				}
+				return
+			}
+			if len(actions) != 1 || actions[0].Action != tc.action {
+				t.Fatalf("actions = %+v, want %s", actions, tc.action)
+			}
+			if tc.action != "issue" && actions[0].ID != cert.ID {
+				t.Errorf("ID = %s, want %s", actions[0].ID, cert.ID)
+			}
+		})
+	}
+}
+
+func TestDiffCertificatesPrune(t *testing.T) {
+	env := setupTestEnv(t)
+	env.createCertificate(t, []string{"kept.example.com"}, nil)
+	stale := env.createCertificate(t, []string{"stale.example.com"}, nil)
+	used := env.createCertificate(t, []string{"used.example.com"}, nil)
+	env.createCertificate(t, []string{"named.example.com"}, nil)
+	env.createCertificate(t, []string{"upload.example.com"}, func(c *database.Certificate) { c.Source = database.CertSourceUpload })
+	env.createCertificate(t, []string{"internal.example.com"}, func(c *database.Certificate) { c.Source = database.CertSourceInternal })
+
+	m := &Manifest{
+		Certificates: []Certificate{env.declare("kept.example.com")},
+		Sites: []Site{
+			{ProxySite: nginx.ProxySite{ID: "a", CertificateID: used.ID}},
+			{ProxySite: nginx.ProxySite{ID: "b"}, Certificate: "named.example.com"},
+		},
+	}
+
+	// 不清理时不删除任何证书
+	actions, err := diffCertificates(m, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 0 {
+		t.Errorf("actions = %+v", actions)
+	}
+
+	// 只删除未声明、未被站点引用的 ACME 证书
+	actions, err = diffCertificates(m, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Action != "delete" || actions[0].ID != stale.ID {
+		t.Errorf("actions = %+v, want delete %s", actions, stale.ID)
+	}
+}
+
+func TestApplyDryRun(t *testing.T) {
+	env := setupTestEnv(t)
+	existing := nginx.ProxySite{ID: "old", ServerName: "old.example.com", UpstreamHost: "127.0.0.1", UpstreamPort: 8080}
+	if err := nginx.SaveProxySite(existing, nginx.SystemChange("测试")); err != nil {
+		t.Fatal(err)
+	}
+	versions, _ := database.CountConfigVersions()
+
+	m := &Manifest{
+		Certificates: []Certificate{env.declare("app.example.com")},
+		Sites: []Site{{
+			ProxySite:   nginx.ProxySite{ID: "app", ServerName: "app.example.com", UpstreamHost: "127.0.0.1", UpstreamPort: 3000, SSL: true},
+			Certificate: "app.example.com",
+		}},
+		StreamRoutes: []nginx.StreamRoute{{ID: "git", Name: "git", Domain: "git.example.com", Backend: "127.0.0.1:2222", Enabled: true}},
+	}
+
+	result, err := Apply(m, Options{DryRun: true, Prune: true}, nginx.SystemChange("测试"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || !result.HasChanges() {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Certificates) != 1 || result.Certificates[0].Action != "issue" {
+		t.Errorf("Certificates = %+v", result.Certificates)
+	}
+
+	var actions []string
+	for _, f := range result.Plan.Files {
+		actions = append(actions, f.Action+" "+f.Path)
+	}
+	sort.Strings(actions)
+	for _, want := range []string{"create conf.d/app.conf", "delete conf.d/old.conf"} {
+		if !containsString(actions, want) {
+			t.Errorf("计划中缺少 %q: %v", want, actions)
+		}
+	}
+	// 证书尚未签发时，预览使用签发后的证书路径
+	if !strings.Contains(result.Plan.Diff, "app.example.com") || !strings.Contains(result.Plan.Diff, "git.example.com") {
+		t.Errorf("Diff =\n%s", result.Plan.Diff)
+	}
+
+	// dry-run 不做任何修改
+	if _, err := os.Stat(filepath.Join(nginx.GetNginxPaths().ConfigsDir, "app.conf")); !os.IsNotExist(err) {
+		t.Errorf("dry-run 写入了站点配置: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(nginx.GetNginxPaths().ConfigsDir, "old.conf")); err != nil {
+		t.Errorf("dry-run 删除了站点配置: %v", err)
+	}
+	if certs, _ := database.ListCertificates(); len(certs) != 0 {
+		t.Errorf("dry-run 创建了证书: %+v", certs)
+	}
+	if jobs, _ := database.ListCertificateJobs(10); len(jobs) != 0 {
+		t.Errorf("dry-run 提交了证书任务: %+v", jobs)
+	}
+	if n, _ := database.CountConfigVersions(); n != versions {
+		t.Errorf("dry-run 记录了配置版本: %d -> %d", versions, n)
+	}
+}
+
+func TestBuildPlanRequestCertificate(t *testing.T) {
+	env := setupTestEnv(t)
+	cert := env.createCertificate(t, []string{"app.example.com"}, nil)
+	env.createCertificate(t, []string{"broken.example.com"}, func(c *database.Certificate) { c.Status = "error" })
+
+	site := func(certificate string) *Manifest {
+		return &Manifest{Sites: []Site{{ProxySite: nginx.ProxySite{ID: "app", ServerName: "app.example.com", SSL: true}, Certificate: certificate}}}
+	}
+
+	// 已签发的证书按主域名解析为证书 ID
+	req, err := buildPlanRequest(site("app.example.com"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Sites[0].CertificateID != cert.ID {
+		t.Errorf("CertificateID = %s, want %s", req.Sites[0].CertificateID, cert.ID)
+	}
+
+	// 证书无效或不存在，且清单中没有声明
+	for _, domain := range []string{"broken.example.com", "missing.example.com"} {
+		for _, dryRun := range []bool{false, true} {
+			if _, err := buildPlanRequest(site(domain), Options{DryRun: dryRun}); err == nil {
+				t.Errorf("%s (dryRun=%v): 应返回错误", domain, dryRun)
+			}
+		}
+	}
+
+	// 清单中声明但尚未签发的证书只能预览
+	m := site("missing.example.com")
+	m.Certificates = []Certificate{env.declare("missing.example.com")}
+	if _, err := buildPlanRequest(m, Options{}); err == nil {
+		t.Error("证书尚未签发时应返回错误")
+	}
+	req, err = buildPlanRequest(m, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Sites[0].CertificateID != "" || !strings.HasPrefix(req.Sites[0].SSLCert, "ssl/missing.example.com") {
+		t.Errorf("site = %+v", req.Sites[0])
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
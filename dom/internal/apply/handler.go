@@ -0,0 +1,77 @@
+package apply
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/hop/backend/internal/nginx"
+)
+
+// Router 创建声明式配置路由
+func Router() chi.Router {
+	r := chi.NewRouter()
+
+	r.Post("/", handleApply)
+
+	return r
+}
+
+// handleApply 应用清单
+// 请求体为 TOML（默认）或 JSON（Content-Type: application/json）
+// 查询参数 dryRun=true 只返回差异，prune=true 删除未声明的资源
+func handleApply(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		jsonError(w, "读取请求体失败", http.StatusBadRequest)
+		return
+	}
+
+	var m *Manifest
+	if strings.Contains(r.Header.Get("Content-Type"), "json") {
+		m, err = ParseJSON(data)
+	} else {
+		m, err = ParseTOML(data)
+	}
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := Options{
+		DryRun: query.Get("dryRun") == "true",
+		Prune:  query.Get("prune") == "true",
+	}
+
+	// 清单中的证书需要排队申请，等待时间可能超过服务器的写超时
+	if len(m.Certificates) > 0 && !opts.DryRun {
+		http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	}
+
+	result, err := Apply(m, opts, nginx.ChangeFromRequest(r, "应用声明式配置"))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, result)
+}
+
+func jsonResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func jsonError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   message,
+		"success": false,
+	})
+}
@@ -0,0 +1,186 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+	"github.com/hop/backend/internal/ssl"
+)
+
+// Manifest 声明式配置清单：描述期望的完整状态
+type Manifest struct {
+	Template     *nginx.TemplateParams `json:"template,omitempty"`     // nginx.conf 模板参数（为空表示不修改）
+	Sites        []Site                `json:"sites"`                  // 代理站点
+	StreamRoutes []nginx.StreamRoute   `json:"streamRoutes"`           // SNI 路由
+	Certificates []Certificate         `json:"certificates,omitempty"` // 证书申请
+}
+
+// Site 代理站点（可通过主域名引用证书）
+type Site struct {
+	nginx.ProxySite
+	Certificate string `json:"certificate,omitempty" toml:"certificate"` // 证书主域名，与 certificate_id 二选一
+}
+
+// Certificate 证书申请
+type Certificate struct {
+	Domains     []string `json:"domains" toml:"domains"`
+	Challenge   string   `json:"challenge,omitempty" toml:"challenge"`      // 验证方式：dns-01（默认）或 http-01
+	DNSProvider string   `json:"dnsProvider,omitempty" toml:"dns_provider"` // DNS 提供商名称或 ID，dns-01 验证时必填
+	Email       string   `json:"email" toml:"email"`
+	AutoRenew   *bool    `json:"autoRenew,omitempty" toml:"auto_renew"` // 默认开启
+	Directory   string   `json:"directory,omitempty" toml:"directory"`  // ACME 目录地址或预设名称，为空使用默认目录
+	EABKID      string   `json:"eabKid,omitempty" toml:"eab_kid"`       // External Account Binding，CA 要求时填写
+	EABHMACKey  string   `json:"eabHmacKey,omitempty" toml:"eab_hmac_key"`
+	KeyType     string   `json:"keyType,omitempty" toml:"key_type"`   // 私钥算法：ec256（默认）、ec384、rsa2048、rsa3072、rsa4096
+	ReuseKey    bool     `json:"reuseKey,omitempty" toml:"reuse_key"` // 续期时复用私钥
+	Dual        bool     `json:"dual,omitempty" toml:"dual"`          // 同时签发 RSA 证书，需使用 ECDSA 私钥
+}
+
+// tomlManifest TOML 文件结构（template 段单独解码，以便保留未填写的参数）
+type tomlManifest struct {
+	Template     toml.Primitive      `toml:"template"`
+	Sites        []Site              `toml:"sites"`
+	StreamRoutes []nginx.StreamRoute `toml:"stream_routes"`
+	Certificates []Certificate       `toml:"certificates"`
+}
+
+// LoadFile 从文件加载清单（TOML 格式）
+func LoadFile(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取清单文件: %w", err)
+	}
+	return ParseTOML(data)
+}
+
+// ParseTOML 解析 TOML 格式的清单
+func ParseTOML(data []byte) (*Manifest, error) {
+	var raw tomlManifest
+	md, err := toml.Decode(string(data), &raw)
+	if err != nil {
+		return nil, fmt.Errorf("解析清单失败: %w", err)
+	}
+
+	m := &Manifest{
+		Sites:        raw.Sites,
+		StreamRoutes: raw.StreamRoutes,
+		Certificates: raw.Certificates,
+	}
+
+	if md.IsDefined("template") {
+		// 以当前参数为基础，只覆盖清单中填写的字段
+		params := nginx.LoadTemplateParams()
+		if err := md.PrimitiveDecode(raw.Template, &params); err != nil {
+			return nil, fmt.Errorf("解析模板参数失败: %w", err)
+		}
+		m.Template = &params
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("清单包含未知字段: %s", undecoded[0].String())
+	}
+
+	return m, nil
+}
+
+// ParseJSON 解析 JSON 格式的清单
+func ParseJSON(data []byte) (*Manifest, error) {
+	var raw struct {
+		Template     json.RawMessage     `json:"template"`
+		Sites        []Site              `json:"sites"`
+		StreamRoutes []nginx.StreamRoute `json:"streamRoutes"`
+		Certificates []Certificate       `json:"certificates"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析清单失败: %w", err)
+	}
+
+	m := &Manifest{
+		Sites:        raw.Sites,
+		StreamRoutes: raw.StreamRoutes,
+		Certificates: raw.Certificates,
+	}
+
+	if len(raw.Template) > 0 && string(raw.Template) != "null" {
+		params := nginx.LoadTemplateParams()
+		if err := json.Unmarshal(raw.Template, &params); err != nil {
+			return nil, fmt.Errorf("解析模板参数失败: %w", err)
+		}
+		m.Template = &params
+	}
+
+	return m, nil
+}
+
+// validate 校验清单内部一致性
+func (m *Manifest) validate() error {
+	siteIDs := map[string]bool{}
+	for _, site := range m.Sites {
+		if site.ID == "" {
+			return fmt.Errorf("站点ID不能为空")
+		}
+		if siteIDs[site.ID] {
+			return fmt.Errorf("站点ID重复: %s", site.ID)
+		}
+		siteIDs[site.ID] = true
+		if site.Certificate != "" && site.CertificateID != "" {
+			return fmt.Errorf("站点 %s: certificate 与 certificate_id 不能同时指定", site.ID)
+		}
+	}
+
+	routeIDs := map[string]bool{}
+	for _, route := range m.StreamRoutes {
+		if route.ID == "" {
+			return fmt.Errorf("路由ID不能为空")
+		}
+		if routeIDs[route.ID] {
+			return fmt.Errorf("路由ID重复: %s", route.ID)
+		}
+		routeIDs[route.ID] = true
+	}
+
+	certDomains := map[string]bool{}
+	for _, cert := range m.Certificates {
+		if len(cert.Domains) == 0 {
+			return fmt.Errorf("证书至少需要一个域名")
+		}
+		if certDomains[cert.Domains[0]] {
+			return fmt.Errorf("证书主域名重复: %s", cert.Domains[0])
+		}
+		certDomains[cert.Domains[0]] = true
+		switch challengeType(cert) {
+		case database.ChallengeDNS01:
+			if cert.DNSProvider == "" {
+				return fmt.Errorf("证书 %s: DNS 提供商不能为空", cert.Domains[0])
+			}
+		case database.ChallengeHTTP01:
+			for _, d := range cert.Domains {
+				if strings.HasPrefix(d, "*.") {
+					return fmt.Errorf("证书 %s: 通配符域名只能使用 DNS 验证", cert.Domains[0])
+				}
+			}
+		default:
+			return fmt.Errorf("证书 %s: 不支持的验证方式 %s", cert.Domains[0], cert.Challenge)
+		}
+		if cert.Email == "" {
+			return fmt.Errorf("证书 %s: 邮箱不能为空", cert.Domains[0])
+		}
+		if _, err := desiredDirectory(cert); err != nil {
+			return err
+		}
+		if (cert.EABKID == "") != (cert.EABHMACKey == "") {
+			return fmt.Errorf("证书 %s: eab_kid 和 eab_hmac_key 需要同时填写", cert.Domains[0])
+		}
+		if err := ssl.ValidateKeyOptions(cert.KeyType, cert.Dual); err != nil {
+			return fmt.Errorf("证书 %s: %w", cert.Domains[0], err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,565 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/logger"
+	"github.com/hop/backend/internal/nginx"
+)
+
+var log = logger.WithTag("backup")
+
+// FormatVersion 归档格式版本，格式不兼容时递增
+const FormatVersion = 1
+
+// AppVersion 当前 Hop 版本（由 main 在启动时设置）
+var AppVersion = "dev"
+
+// 归档内的固定路径
+const (
+	manifestName = "manifest.json"
+	configName   = "config.toml"
+	databaseDir  = "database"
+	dataDir      = "data"
+)
+
+// userTables 用户相关的表（可选导出）
+var userTables = []string{"user", "account"}
+
+// dataTables 始终导出的表，按写入顺序排列
+var dataTables = []string{
+	"dns_provider",
+	"acme_account",
+	"internal_ca",
+	"client_ca",
+	"certificate",
+	"certificate_hook",
+	"certificate_renewal",
+	"certificate_log",
+	"config_version",
+	"config_version_file",
+	"notification_channel",
+}
+
+// dataDirs 数据目录下需要归档的子目录
+// lego 是旧版本使用外部 lego 时的工作目录，保留以兼容旧归档
+var dataDirs = []string{"nginx", "acme", "lego"}
+
+// Manifest 归档清单
+type Manifest struct {
+	FormatVersion int            `json:"formatVersion"`
+	HopVersion    string         `json:"hopVersion"`
+	CreatedAt     string         `json:"createdAt"`
+	IncludesUsers bool           `json:"includesUsers"`
+	Tables        map[string]int `json:"tables"` // 表名 -> 行数
+	Files         int            `json:"files"`  // 数据目录中的文件数
+}
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	IncludeUsers bool // 是否包含用户和账户（含密码哈希）
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	IncludeUsers bool // 是否恢复用户（归档中包含用户时）
+	SkipConfig   bool // 不覆盖 config.toml
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Manifest       *Manifest `json:"manifest"`
+	Tables         []string  `json:"tables"`         // 已恢复的表
+	Files          int       `json:"files"`          // 已恢复的文件数
+	UsersRestored  bool      `json:"usersRestored"`  // 是否恢复了用户
+	ConfigRestored bool      `json:"configRestored"` // 是否恢复了 config.toml
+	Warnings       []string  `json:"warnings"`
+}
+
+// archiveFile 待写入归档的数据文件
+type archiveFile struct {
+	name    string // 归档内路径
+	absPath string
+	info    os.FileInfo
+}
+
+// Export 将当前状态导出为 tar.gz 归档写入 w
+// 归档包含私钥和 DNS 凭据，应妥善保管
+func Export(w io.Writer, opts ExportOptions) (*Manifest, error) {
+	if database.GetDB() == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	cfg := config.Get()
+
+	// 先收集所有内容，出错时不会写出不完整的归档
+	tables := dataTables
+	if opts.IncludeUsers {
+		tables = append(append([]string{}, userTables...), dataTables...)
+	}
+
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		HopVersion:    AppVersion,
+		CreatedAt:     time.Now().Format(time.RFC3339),
+		IncludesUsers: opts.IncludeUsers,
+		Tables:        map[string]int{},
+	}
+
+	dumps := map[string][]byte{}
+	for _, table := range tables {
+		rows, err := database.DumpTable(table)
+		if err != nil {
+			return nil, fmt.Errorf("导出表 %s 失败: %w", table, err)
+		}
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		dumps[table] = data
+		manifest.Tables[table] = len(rows)
+	}
+
+	configData, err := readConfigFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := collectDataFiles(cfg.Data.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.info.Mode().IsRegular() {
+			manifest.Files++
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	if err := writeEntry(tw, manifestName, manifestData, 0644); err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, configName, configData, 0600); err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if err := writeEntry(tw, path.Join(databaseDir, table+".json"), dumps[table], 0600); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range files {
+		if err := writeDataFile(tw, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	log.Info("状态已导出", map[string]interface{}{
+		"files":  manifest.Files,
+		"tables": len(manifest.Tables),
+		"users":  opts.IncludeUsers,
+	})
+	return manifest, nil
+}
+
+// readConfigFile 读取当前配置文件内容（未从文件加载时编码内存中的配置）
+func readConfigFile(cfg *config.Config) ([]byte, error) {
+	if p := config.GetPath(); p != "" {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// collectDataFiles 收集数据目录下需要归档的文件和目录
+func collectDataFiles(base string) ([]archiveFile, error) {
+	var files []archiveFile
+	for _, dir := range dataDirs {
+		root := filepath.Join(base, dir)
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				// 跳过符号链接、套接字等特殊文件
+				return nil
+			}
+			rel, err := filepath.Rel(base, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, archiveFile{
+				name:    path.Join(dataDir, filepath.ToSlash(rel)),
+				absPath: p,
+				info:    info,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("读取目录 %s 失败: %w", dir, err)
+		}
+	}
+	return files, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, mode int64) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func writeDataFile(tw *tar.Writer, f archiveFile) error {
+	hdr, err := tar.FileInfoHeader(f.info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = f.name
+	if f.info.IsDir() {
+		hdr.Name += "/"
+	}
+	hdr.Uname, hdr.Gname = "", ""
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if f.info.IsDir() {
+		return nil
+	}
+
+	file, err := os.Open(f.absPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// Import 从 tar.gz 归档恢复状态
+// 先完整读取并校验归档，校验通过后才会修改数据库和数据目录
+func Import(r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	if database.GetDB() == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	cfg := config.Get()
+	staging, err := os.MkdirTemp(cfg.Data.Dir, ".import-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	archive, err := readArchive(r, staging)
+	if err != nil {
+		return nil, err
+	}
+	if err := archive.validate(); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Manifest: archive.manifest, Files: archive.files, Warnings: []string{}}
+
+	// 1. 数据库
+	order := append([]string{}, dataTables...)
+	restoreUsers := opts.IncludeUsers && archive.manifest.IncludesUsers
+	if restoreUsers {
+		// 旧会话对应的用户已被替换，一并清空
+		archive.tables["session"] = []database.Row{}
+		order = append(append([]string{}, userTables...), append([]string{"session"}, dataTables...)...)
+	} else if opts.IncludeUsers {
+		result.Warnings = append(result.Warnings, "归档中不包含用户，已保留现有用户")
+	}
+	for _, table := range userTables {
+		if !restoreUsers {
+			delete(archive.tables, table)
+		}
+	}
+
+	if err := database.RestoreTables(order, archive.tables); err != nil {
+		return nil, fmt.Errorf("恢复数据库失败: %w", err)
+	}
+	for _, table := range order {
+		if _, ok := archive.tables[table]; ok && table != "session" {
+			result.Tables = append(result.Tables, table)
+		}
+	}
+	result.UsersRestored = restoreUsers
+
+	// 2. 数据目录
+	if err := swapDataDirs(cfg.Data.Dir, staging); err != nil {
+		return nil, fmt.Errorf("恢复数据目录失败: %w", err)
+	}
+
+	// 3. 配置文件（保留本机的数据目录）
+	if !opts.SkipConfig && archive.config != nil {
+		imported := config.DefaultConfig()
+		if _, err := toml.Decode(string(archive.config), imported); err != nil {
+			return nil, fmt.Errorf("解析归档中的配置文件失败: %w", err)
+		}
+		err := config.Update(func(c *config.Config) {
+			dir := c.Data.Dir
+			*c = *imported
+			c.Data.Dir = dir
+		})
+		if err != nil {
+			result.Warnings = append(result.Warnings, "保存配置文件失败: "+err.Error())
+		} else {
+			result.ConfigRestored = true
+			result.Warnings = append(result.Warnings, "配置文件已恢复，服务器和认证设置需重启 Hop 后生效")
+		}
+	}
+
+	if output, err := nginx.TestConfig(); err != nil {
+		result.Warnings = append(result.Warnings, "nginx 配置检查未通过: "+strings.TrimSpace(output))
+	}
+
+	log.Info("状态已导入", map[string]interface{}{
+		"hopVersion": archive.manifest.HopVersion,
+		"files":      result.Files,
+		"users":      restoreUsers,
+	})
+	return result, nil
+}
+
+// archiveContent 读取到内存的归档内容（数据文件解压到临时目录）
+type archiveContent struct {
+	manifest *Manifest
+	config   []byte
+	tables   map[string][]database.Row
+	dirs     map[string]bool // 归档中出现的数据子目录
+	files    int
+}
+
+// readArchive 读取归档，数据文件解压到 staging 目录
+func readArchive(r io.Reader, staging string) (*archiveContent, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("无效的归档文件: %w", err)
+	}
+	defer gr.Close()
+
+	content := &archiveContent{
+		tables: map[string][]database.Row{},
+		dirs:   map[string]bool{},
+	}
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取归档失败: %w", err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("归档包含非法路径: %s", hdr.Name)
+		}
+
+		switch {
+		case name == manifestName:
+			var m Manifest
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return nil, fmt.Errorf("解析归档清单失败: %w", err)
+			}
+			content.manifest = &m
+
+		case name == configName:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			content.config = data
+
+		case path.Dir(name) == databaseDir && strings.HasSuffix(name, ".json"):
+			table := strings.TrimSuffix(path.Base(name), ".json")
+			decoder := json.NewDecoder(tr)
+			decoder.UseNumber()
+			var rows []database.Row
+			if err := decoder.Decode(&rows); err != nil {
+				return nil, fmt.Errorf("解析表 %s 失败: %w", table, err)
+			}
+			content.tables[table] = rows
+
+		case strings.HasPrefix(name, dataDir+"/"):
+			rel := strings.TrimPrefix(name, dataDir+"/")
+			top := strings.SplitN(rel, "/", 2)[0]
+			if !contains(dataDirs, top) {
+				return nil, fmt.Errorf("归档包含未知的数据目录: %s", top)
+			}
+			content.dirs[top] = true
+
+			target := filepath.Join(staging, filepath.FromSlash(rel))
+			switch hdr.Typeflag {
+			case tar.TypeDir:
+				if err := os.MkdirAll(target, 0755); err != nil {
+					return nil, err
+				}
+			case tar.TypeReg:
+				if err := extractFile(tr, target, os.FileMode(hdr.Mode).Perm()); err != nil {
+					return nil, err
+				}
+				content.files++
+			default:
+				return nil, fmt.Errorf("归档包含不支持的文件类型: %s", hdr.Name)
+			}
+
+		default:
+			return nil, fmt.Errorf("归档包含未知条目: %s", hdr.Name)
+		}
+	}
+
+	return content, nil
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// validate 检查归档与当前版本的兼容性
+func (a *archiveContent) validate() error {
+	if a.manifest == nil {
+		return fmt.Errorf("归档缺少 %s，不是有效的 Hop 归档", manifestName)
+	}
+	if a.manifest.FormatVersion < 1 || a.manifest.FormatVersion > FormatVersion {
+		return fmt.Errorf("不支持的归档格式版本 %d（当前支持 %d），归档来自 Hop %s",
+			a.manifest.FormatVersion, FormatVersion, a.manifest.HopVersion)
+	}
+
+	known := append(append([]string{}, userTables...), dataTables...)
+	for table, rows := range a.tables {
+		if !contains(known, table) {
+			return fmt.Errorf("归档包含未知的表: %s", table)
+		}
+		if expected, ok := a.manifest.Tables[table]; !ok || expected != len(rows) {
+			return fmt.Errorf("表 %s 的行数与清单不一致，归档可能已损坏", table)
+		}
+
+		columns, err := database.TableColumns(table)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			for col := range row {
+				if !contains(columns, col) {
+					return fmt.Errorf("表 %s 包含当前版本不支持的列 %s，请升级 Hop 后再导入", table, col)
+				}
+			}
+		}
+	}
+	for table := range a.manifest.Tables {
+		if _, ok := a.tables[table]; !ok {
+			return fmt.Errorf("归档缺少表 %s，归档可能已损坏", table)
+		}
+	}
+
+	if a.manifest.Files != a.files {
+		return fmt.Errorf("数据文件数与清单不一致（%d/%d），归档可能已损坏", a.files, a.manifest.Files)
+	}
+	if !a.dirs["nginx"] {
+		return fmt.Errorf("归档缺少 nginx 目录")
+	}
+
+	return nil
+}
+
+// swapDataDirs 用临时目录中的内容替换数据目录下的子目录
+func swapDataDirs(base, staging string) error {
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := filepath.Join(base, name)
+		old := target + ".old"
+		os.RemoveAll(old)
+
+		if _, err := os.Stat(target); err == nil {
+			if err := os.Rename(target, old); err != nil {
+				return err
+			}
+		}
+		if err := os.Rename(filepath.Join(staging, name), target); err != nil {
+			// 还原旧目录
+			os.Rename(old, target)
+			return err
+		}
+		os.RemoveAll(old)
+	}
+	return nil
+}
+
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
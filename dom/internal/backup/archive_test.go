@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// localTables 只在本机有意义、不导出的表
+var localTables = []string{
+	"session",         // 登录会话，恢复用户时清空
+	"verification",    // 一次性验证码
+	"certificate_job", // 申请和续期任务，只用于查看进度，服务重启时会被标记为中断
+}
+
+// useDataDir 将数据目录指向 dir 并在其中初始化数据库
+func useDataDir(t *testing.T, dir string) {
+	t.Helper()
+	database.Close()
+	config.Get().Data.Dir = dir
+	if err := database.Init(config.Get()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// schemaTables 当前数据库中的所有表
+func schemaTables(t *testing.T) []string {
+	t.Helper()
+	rows, err := database.GetDB().Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		tables = append(tables, name)
+	}
+	return tables
+}
+
+// fillTable 按列类型向表中写入一行，新增的表和列无需修改测试
+func fillTable(t *testing.T, table string) {
+	t.Helper()
+	rows, err := database.GetDB().Query(fmt.Sprintf("PRAGMA table_info(%q)", table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var columns, placeholders []string
+	var args []interface{}
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			dflt             interface{}
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			t.Fatal(err)
+		}
+		columns = append(columns, fmt.Sprintf("%q", name))
+		placeholders = append(placeholders, "?")
+		if strings.Contains(strings.ToUpper(colType), "INT") {
+			args = append(args, 1)
+		} else {
+			args = append(args, table+"."+name)
+		}
+	}
+	rows.Close()
+
+	query := fmt.Sprintf("INSERT INTO %q (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	if _, err := database.GetDB().Exec(query, args...); err != nil {
+		t.Fatalf("写入 %s 失败: %v", table, err)
+	}
+}
+
+func dumpJSON(t *testing.T, table string) string {
+	t.Helper()
+	rows, err := database.DumpTable(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(rows)
+	return string(data)
+}
+
+func TestArchiveCoversAllTables(t *testing.T) {
+	original := config.Get().Data.Dir
+	t.Cleanup(func() {
+		database.Close()
+		config.Get().Data.Dir = original
+	})
+	useDataDir(t, t.TempDir())
+
+	// 新增的表需要加入 dataTables，或明确列为不导出的本机表
+	exported := append(append(append([]string{}, userTables...), dataTables...), localTables...)
+	for _, table := range schemaTables(t) {
+		if !contains(exported, table) {
+			t.Errorf("表 %s 既不会导出，也不在 localTables 中", table)
+		}
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	original := config.Get().Data.Dir
+	t.Cleanup(func() {
+		database.Close()
+		config.Get().Data.Dir = original
+	})
+
+	// 源环境：每个表一行数据，加上 nginx 和 acme 目录中的文件
+	source := t.TempDir()
+	useDataDir(t, source)
+	tables := append(append([]string{}, userTables...), dataTables...)
+	want := map[string]string{}
+	for _, table := range tables {
+		fillTable(t, table)
+		want[table] = dumpJSON(t, table)
+	}
+	files := map[string]string{
+		"nginx/nginx.conf":              "worker_processes 2;\n",
+		"nginx/conf.d/app.conf":         "server {}\n",
+		"nginx/ssl/example.com.key":     "private key\n",
+		"acme/accounts/example/key.pem": "account key\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(source, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var archive bytes.Buffer
+	manifest, err := Export(&archive, ExportOptions{IncludeUsers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Files != len(files) {
+		t.Errorf("manifest.Files = %d, want %d", manifest.Files, len(files))
+	}
+	for _, table := range tables {
+		if manifest.Tables[table] != 1 {
+			t.Errorf("清单中 %s 的行数 = %d, want 1", table, manifest.Tables[table])
+		}
+	}
+
+	// 目标环境：空数据库
+	target := t.TempDir()
+	useDataDir(t, target)
+	result, err := Import(bytes.NewReader(archive.Bytes()), ImportOptions{IncludeUsers: true, SkipConfig: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.UsersRestored || result.Files != len(files) {
+		t.Errorf("result = %+v", result)
+	}
+
+	restored := append([]string{}, result.Tables...)
+	sort.Strings(restored)
+	sorted := append([]string{}, tables...)
+	sort.Strings(sorted)
+	if strings.Join(restored, ",") != strings.Join(sorted, ",") {
+		t.Errorf("已恢复的表 = %v, want %v", restored, sorted)
+	}
+	for _, table := range tables {
+		if got := dumpJSON(t, table); got != want[table] {
+			t.Errorf("表 %s 往返后不一致:\n got %s\nwant %s", table, got, want[table])
+		}
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(target, filepath.FromSlash(name)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q (%v)", name, data, err)
+		}
+	}
+}
+
+func TestImportWithoutUsersKeepsExistingUsers(t *testing.T) {
+	original := config.Get().Data.Dir
+	t.Cleanup(func() {
+		database.Close()
+		config.Get().Data.Dir = original
+	})
+
+	source := t.TempDir()
+	useDataDir(t, source)
+	os.MkdirAll(filepath.Join(source, "nginx"), 0755)
+	fillTable(t, "certificate")
+	var archive bytes.Buffer
+	if _, err := Export(&archive, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	useDataDir(t, t.TempDir())
+	fillTable(t, "user")
+	before := dumpJSON(t, "user")
+	if _, err := Import(&archive, ImportOptions{IncludeUsers: true, SkipConfig: true}); err != nil {
+		t.Fatal(err)
+	}
+	if dumpJSON(t, "user") != before {
+		t.Error("归档不含用户时不应修改现有用户")
+	}
+	if dumpJSON(t, "certificate") == "[]" {
+		t.Error("证书未恢复")
+	}
+}
@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/hop/backend/internal/config"
+)
+
+// Router 创建备份路由
+func Router() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/export", handleExport)
+	r.Post("/import", handleImport)
+
+	// 本地快照
+	r.Get("/snapshots", handleListSnapshots)
+	r.Post("/snapshots", handleCreateSnapshot)
+	r.Post("/snapshots/{id}/verify", handleVerifySnapshot)
+	r.Post("/snapshots/{id}/restore", handleRestoreSnapshot)
+	r.Delete("/snapshots/{id}", handleDeleteSnapshot)
+
+	return r
+}
+
+// handleExport 下载状态归档
+// 查询参数 users=true 时包含用户
+func handleExport(w http.ResponseWriter, r *http.Request) {
+	opts := ExportOptions{IncludeUsers: r.URL.Query().Get("users") == "true"}
+
+	// 先写入内存，导出失败时可以返回错误信息
+	var buf bytes.Buffer
+	if _, err := Export(&buf, opts); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("hop-%s.tar.gz", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", buf.Len()))
+	buf.WriteTo(w)
+}
+
+// handleImport 上传归档并恢复状态
+// 请求体为归档文件内容，查询参数 users=true 恢复用户，skipConfig=true 不覆盖配置文件
+func handleImport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := ImportOptions{
+		IncludeUsers: query.Get("users") == "true",
+		SkipConfig:   query.Get("skipConfig") == "true",
+	}
+
+	result, err := Import(r.Body, opts)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, result)
+}
+
+// === 快照 API ===
+
+func handleListSnapshots(w http.ResponseWriter, r *http.Request) {
+	snapshots, err := ListSnapshots()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"snapshots": snapshots,
+		"dir":       config.Get().BackupDir(),
+	})
+}
+
+func handleCreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := CreateSnapshot("manual")
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, snapshot)
+}
+
+func handleVerifySnapshot(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := VerifySnapshot(id); err != nil {
+		jsonResponse(w, map[string]interface{}{
+			"valid": false,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{"valid": true})
+}
+
+// handleRestoreSnapshot 从快照恢复，响应中返回恢复前自动创建的快照
+func handleRestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	safety, err := RestoreSnapshot(id)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success":    true,
+		"preRestore": safety,
+	})
+}
+
+func handleDeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := DeleteSnapshot(id); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{"success": true})
+}
+
+func jsonResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func jsonError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   message,
+		"success": false,
+	})
+}
@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/hop/backend/internal/config"
+)
+
+// Scheduler 自动备份调度器
+type Scheduler struct {
+	interval time.Duration
+	stop     chan struct{}
+}
+
+// NewScheduler 创建调度器
+func NewScheduler(interval time.Duration) *Scheduler {
+	return &Scheduler{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动定时备份
+func (s *Scheduler) Start() {
+	log.Info("启动自动备份", map[string]interface{}{
+		"interval": s.interval.String(),
+		"dir":      config.Get().BackupDir(),
+	})
+
+	// 距离上次备份已超过间隔时立即备份一次
+	if s.due() {
+		go s.run()
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			go s.run()
+		case <-s.stop:
+			log.Info("停止自动备份")
+			return
+		}
+	}
+}
+
+// Stop 停止定时备份
+func (s *Scheduler) Stop() {
+	close(s.stop)
+}
+
+// due 是否需要立即备份（避免每次重启都产生一个快照）
+func (s *Scheduler) due() bool {
+	snapshots, err := ListSnapshots()
+	if err != nil || len(snapshots) == 0 {
+		return true
+	}
+	last, err := time.Parse(time.RFC3339, snapshots[0].CreatedAt)
+	if err != nil {
+		return true
+	}
+	return time.Since(last) >= s.interval
+}
+
+// run 执行一次备份和清理
+func (s *Scheduler) run() {
+	if _, err := CreateSnapshot("scheduled"); err != nil {
+		log.Error("自动备份失败", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	cfg := config.Get()
+	if _, err := PruneSnapshots(cfg.Backup.Keep, cfg.Backup.MaxAge); err != nil {
+		log.Warn("清理旧快照失败", map[string]interface{}{"error": err.Error()})
+	}
+}
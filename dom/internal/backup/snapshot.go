@@ -0,0 +1,538 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+	"github.com/hop/backend/internal/ssl"
+)
+
+// 快照归档内的数据库文件名
+const snapshotDBName = "hop.db"
+
+// snapshotIDPattern 快照 ID 格式，防止路径穿越
+var snapshotIDPattern = regexp.MustCompile(`^hop-\d{8}-\d{6}(-\d+)?$`)
+
+// snapshotMu 快照的创建、恢复和清理互斥执行
+var snapshotMu sync.Mutex
+
+// Snapshot 本地备份快照
+// 每个快照由 <id>.tar.gz 和描述文件 <id>.json 组成
+type Snapshot struct {
+	ID         string `json:"id"`
+	CreatedAt  string `json:"createdAt"`
+	HopVersion string `json:"hopVersion"`
+	Trigger    string `json:"trigger"` // scheduled, manual, pre-restore
+	Size       int64  `json:"size"`    // 归档字节数
+	SHA256     string `json:"sha256"`  // 归档校验和
+	Files      int    `json:"files"`   // nginx、acme 和 lego 目录中的文件数
+}
+
+// CreateSnapshot 创建快照：在线备份数据库并打包 nginx、acme 和 lego 目录
+// 创建完成后会立即校验，校验失败的快照会被删除
+func CreateSnapshot(trigger string) (*Snapshot, error) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	return createSnapshot(trigger)
+}
+
+func createSnapshot(trigger string) (*Snapshot, error) {
+	if database.GetDB() == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	cfg := config.Get()
+	dir := cfg.BackupDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建备份目录失败: %w", err)
+	}
+
+	now := time.Now()
+	id := "hop-" + now.Format("20060102-150405")
+	for i := 2; fileExists(filepath.Join(dir, id+".tar.gz")); i++ {
+		id = fmt.Sprintf("hop-%s-%d", now.Format("20060102-150405"), i)
+	}
+
+	// 1. 在线备份数据库
+	dbFile := filepath.Join(dir, "."+id+".db")
+	defer os.Remove(dbFile)
+	if err := database.BackupTo(dbFile); err != nil {
+		return nil, fmt.Errorf("备份数据库失败: %w", err)
+	}
+	if err := database.CheckIntegrity(dbFile); err != nil {
+		return nil, err
+	}
+
+	// 2. 打包
+	files, err := collectDataFiles(cfg.Data.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	archivePath := filepath.Join(dir, id+".tar.gz")
+	partPath := filepath.Join(dir, "."+id+".tar.gz.part")
+	defer os.Remove(partPath)
+
+	size, sum, err := writeSnapshotArchive(partPath, dbFile, files)
+	if err != nil {
+		return nil, fmt.Errorf("写入快照失败: %w", err)
+	}
+	if err := os.Rename(partPath, archivePath); err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		ID:         id,
+		CreatedAt:  now.Format(time.RFC3339),
+		HopVersion: AppVersion,
+		Trigger:    trigger,
+		Size:       size,
+		SHA256:     sum,
+	}
+	for _, f := range files {
+		if f.info.Mode().IsRegular() {
+			snapshot.Files++
+		}
+	}
+
+	if err := writeSnapshotMeta(dir, snapshot); err != nil {
+		os.Remove(archivePath)
+		return nil, err
+	}
+
+	// 3. 校验
+	if err := verifySnapshot(snapshot); err != nil {
+		removeSnapshot(dir, id)
+		return nil, fmt.Errorf("快照校验失败: %w", err)
+	}
+
+	log.Info("快照已创建", map[string]interface{}{
+		"id":      id,
+		"trigger": trigger,
+		"size":    size,
+		"files":   snapshot.Files,
+	})
+	return snapshot, nil
+}
+
+// writeSnapshotArchive 写入快照归档，返回归档大小和 sha256
+func writeSnapshotArchive(target, dbFile string, files []archiveFile) (int64, string, error) {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	gw := gzip.NewWriter(io.MultiWriter(f, hash, counter))
+	tw := tar.NewWriter(gw)
+
+	dbInfo, err := os.Stat(dbFile)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := writeDataFile(tw, archiveFile{name: snapshotDBName, absPath: dbFile, info: dbInfo}); err != nil {
+		return 0, "", err
+	}
+	for _, file := range files {
+		if err := writeDataFile(tw, file); err != nil {
+			return 0, "", err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := gw.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, "", err
+	}
+
+	return counter.n, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func writeSnapshotMeta(dir string, snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, snapshot.ID+".json"), data, 0600)
+}
+
+// ListSnapshots 列出所有快照（按创建时间倒序）
+func ListSnapshots() ([]Snapshot, error) {
+	dir := config.Get().BackupDir()
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || id == entry.Name() || !snapshotIDPattern.MatchString(id) {
+			continue
+		}
+		snapshot, err := GetSnapshot(id)
+		if err != nil {
+			log.Warn("跳过无效的快照", map[string]interface{}{"id": id, "error": err.Error()})
+			continue
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].ID > snapshots[j].ID
+	})
+	return snapshots, nil
+}
+
+// GetSnapshot 获取快照信息
+func GetSnapshot(id string) (*Snapshot, error) {
+	if !snapshotIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("无效的快照 ID: %s", id)
+	}
+
+	dir := config.Get().BackupDir()
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("快照不存在: %s", id)
+		}
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("解析快照描述文件失败: %w", err)
+	}
+	if !fileExists(filepath.Join(dir, id+".tar.gz")) {
+		return nil, fmt.Errorf("快照归档缺失: %s", id)
+	}
+	return &snapshot, nil
+}
+
+// VerifySnapshot 校验快照：归档校验和、文件数和数据库完整性
+func VerifySnapshot(id string) error {
+	snapshot, err := GetSnapshot(id)
+	if err != nil {
+		return err
+	}
+	return verifySnapshot(snapshot)
+}
+
+func verifySnapshot(snapshot *Snapshot) error {
+	dir := config.Get().BackupDir()
+	archivePath := filepath.Join(dir, snapshot.ID+".tar.gz")
+
+	sum, err := fileSHA256(archivePath)
+	if err != nil {
+		return err
+	}
+	if sum != snapshot.SHA256 {
+		return fmt.Errorf("归档校验和不匹配")
+	}
+
+	staging, err := os.MkdirTemp(dir, ".verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	files, err := extractSnapshot(archivePath, staging, true)
+	if err != nil {
+		return err
+	}
+	if files != snapshot.Files {
+		return fmt.Errorf("文件数不匹配（%d/%d）", files, snapshot.Files)
+	}
+	return database.CheckIntegrity(filepath.Join(staging, snapshotDBName))
+}
+
+// extractSnapshot 解压快照到 staging，返回数据文件数
+// dbOnly 为 true 时只解压数据库，其余文件只计数
+func extractSnapshot(archivePath, staging string, dbOnly bool) (int, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("无效的快照归档: %w", err)
+	}
+	defer gr.Close()
+
+	hasDB := false
+	files := 0
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("读取快照失败: %w", err)
+		}
+
+		name := path.Clean(hdr.Name)
+		switch {
+		case name == snapshotDBName:
+			if err := extractFile(tr, filepath.Join(staging, snapshotDBName), 0600); err != nil {
+				return 0, err
+			}
+			hasDB = true
+
+		case strings.HasPrefix(name, dataDir+"/"):
+			rel := strings.TrimPrefix(name, dataDir+"/")
+			if !contains(dataDirs, strings.SplitN(rel, "/", 2)[0]) || strings.Contains(rel, "..") {
+				return 0, fmt.Errorf("快照包含非法路径: %s", hdr.Name)
+			}
+			if hdr.Typeflag == tar.TypeReg {
+				files++
+			}
+			if dbOnly {
+				continue
+			}
+
+			target := filepath.Join(staging, dataDir, filepath.FromSlash(rel))
+			switch hdr.Typeflag {
+			case tar.TypeDir:
+				if err := os.MkdirAll(target, 0755); err != nil {
+					return 0, err
+				}
+			case tar.TypeReg:
+				if err := extractFile(tr, target, os.FileMode(hdr.Mode).Perm()); err != nil {
+					return 0, err
+				}
+			}
+
+		default:
+			return 0, fmt.Errorf("快照包含未知条目: %s", hdr.Name)
+		}
+	}
+
+	if !hasDB {
+		return 0, fmt.Errorf("快照缺少数据库文件")
+	}
+	return files, nil
+}
+
+// RestoreSnapshot 从快照恢复数据库、nginx、acme 和 lego 目录
+// 恢复前会校验快照，并自动创建一个 pre-restore 快照以便撤销
+// 恢复期间暂停证书任务，恢复后重新生成 nginx.conf，校验通过才重新加载，否则撤销恢复
+func RestoreSnapshot(id string) (*Snapshot, error) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	snapshot, err := GetSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySnapshot(snapshot); err != nil {
+		return nil, fmt.Errorf("快照校验失败: %w", err)
+	}
+
+	resume := ssl.PauseJobs()
+	defer resume()
+
+	safety, err := createSnapshot("pre-restore")
+	if err != nil {
+		return nil, fmt.Errorf("创建恢复前快照失败: %w", err)
+	}
+
+	if err := restoreSnapshotFiles(id); err != nil {
+		return nil, err
+	}
+
+	if err := applyRestoredConfig(); err != nil {
+		if rbErr := restoreSnapshotFiles(safety.ID); rbErr != nil {
+			log.Error("撤销快照恢复失败", map[string]interface{}{
+				"id":    safety.ID,
+				"error": rbErr.Error(),
+			})
+			return nil, fmt.Errorf("%w，撤销恢复失败，请手动恢复快照 %s", err, safety.ID)
+		}
+		return nil, fmt.Errorf("%w，已撤销恢复", err)
+	}
+
+	output, err := nginx.Reload()
+	if errors.Is(err, exec.ErrNotFound) {
+		log.Warn("未找到 nginx，跳过重新加载", nil)
+	} else if err != nil {
+		log.Error("恢复快照后重新加载 nginx 失败", map[string]interface{}{
+			"id":    id,
+			"error": strings.TrimSpace(output),
+		})
+	}
+
+	log.Info("已从快照恢复", map[string]interface{}{
+		"id":         id,
+		"preRestore": safety.ID,
+	})
+	return safety, nil
+}
+
+// restoreSnapshotFiles 用快照替换数据库和数据目录
+func restoreSnapshotFiles(id string) error {
+	cfg := config.Get()
+	staging, err := os.MkdirTemp(cfg.Data.Dir, ".restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if _, err := extractSnapshot(filepath.Join(cfg.BackupDir(), id+".tar.gz"), staging, false); err != nil {
+		return err
+	}
+
+	if err := database.RestoreFrom(filepath.Join(staging, snapshotDBName)); err != nil {
+		return fmt.Errorf("恢复数据库失败: %w", err)
+	}
+
+	if fileExists(filepath.Join(staging, dataDir)) {
+		if err := swapDataDirs(cfg.Data.Dir, filepath.Join(staging, dataDir)); err != nil {
+			return fmt.Errorf("恢复数据目录失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyRestoredConfig 按恢复后的模板参数重新生成 nginx.conf 并执行 nginx -t，未安装 nginx 时跳过校验
+func applyRestoredConfig() error {
+	if err := nginx.EnsureStreamDir(); err != nil {
+		return err
+	}
+	if err := nginx.RegenerateNginxConf(nginx.SystemChange("从快照恢复")); err != nil {
+		return fmt.Errorf("重新生成 nginx.conf 失败: %w", err)
+	}
+	if output, err := nginx.TestConfig(); err != nil && !errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("nginx 配置校验失败: %s", strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// DeleteSnapshot 删除快照
+func DeleteSnapshot(id string) error {
+	if !snapshotIDPattern.MatchString(id) {
+		return fmt.Errorf("无效的快照 ID: %s", id)
+	}
+
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	dir := config.Get().BackupDir()
+	if !fileExists(filepath.Join(dir, id+".json")) && !fileExists(filepath.Join(dir, id+".tar.gz")) {
+		return fmt.Errorf("快照不存在: %s", id)
+	}
+	return removeSnapshot(dir, id)
+}
+
+func removeSnapshot(dir, id string) error {
+	if err := os.Remove(filepath.Join(dir, id+".tar.gz")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, id+".json")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// PruneSnapshots 按数量和时间清理旧快照，最新的快照总是保留
+// keep 为最多保留的数量，maxAge 为最长保留天数，0 表示不限制
+func PruneSnapshots(keep, maxAge int) ([]string, error) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	snapshots, err := ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := config.Get().BackupDir()
+	cutoff := time.Now().AddDate(0, 0, -maxAge)
+
+	var removed []string
+	for i, snapshot := range snapshots {
+		if i == 0 {
+			continue
+		}
+
+		expired := false
+		if keep > 0 && i >= keep {
+			expired = true
+		}
+		if maxAge > 0 {
+			if createdAt, err := time.Parse(time.RFC3339, snapshot.CreatedAt); err == nil && createdAt.Before(cutoff) {
+				expired = true
+			}
+		}
+		if !expired {
+			continue
+		}
+
+		if err := removeSnapshot(dir, snapshot.ID); err != nil {
+			log.Warn("删除快照失败", map[string]interface{}{"id": snapshot.ID, "error": err.Error()})
+			continue
+		}
+		removed = append(removed, snapshot.ID)
+	}
+
+	if len(removed) > 0 {
+		log.Info("已清理旧快照", map[string]interface{}{"count": len(removed)})
+	}
+	return removed, nil
+}
+
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,118 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+// useSnapshotDir 在临时数据目录中初始化数据库和 nginx 目录，bin 中的程序替换 PATH
+func useSnapshotDir(t *testing.T, nginxScript string) string {
+	t.Helper()
+	original := config.Get().Data.Dir
+	t.Cleanup(func() {
+		database.Close()
+		config.Get().Data.Dir = original
+	})
+	dir := t.TempDir()
+	useDataDir(t, dir)
+	if err := nginx.EnsureNginxDirs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := nginx.EnsureStreamDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	bin := t.TempDir()
+	if nginxScript != "" {
+		if err := os.WriteFile(filepath.Join(bin, "nginx"), []byte("#!/bin/sh\n"+nginxScript+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+	return dir
+}
+
+func tableExists(t *testing.T, table string) bool {
+	t.Helper()
+	var n int
+	database.GetDB().QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+	return n > 0
+}
+
+func countCertificates(t *testing.T) int {
+	t.Helper()
+	var n int
+	if err := database.GetDB().QueryRow(`SELECT COUNT(*) FROM certificate`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	dir := useSnapshotDir(t, "")
+
+	// 模拟旧版本的快照：缺少后来新增的表
+	if _, err := database.GetDB().Exec(`DROP TABLE certificate_renewal`); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := createSnapshot("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useDataDir(t, dir)
+
+	// 快照之后的修改
+	fillTable(t, "certificate")
+	confPath := nginx.GetNginxPaths().ConfigPath
+	os.Remove(confPath)
+
+	safety, err := RestoreSnapshot(snapshot.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if safety.Trigger != "pre-restore" {
+		t.Errorf("Trigger = %s", safety.Trigger)
+	}
+	if n := countCertificates(t); n != 0 {
+		t.Errorf("恢复后证书数量 = %d, want 0", n)
+	}
+	if !tableExists(t, "certificate_renewal") {
+		t.Error("恢复旧快照后未运行迁移")
+	}
+	if _, err := os.Stat(confPath); err != nil {
+		t.Errorf("未重新生成 nginx.conf: %v", err)
+	}
+}
+
+func TestRestoreSnapshotRollsBackWhenNginxTestFails(t *testing.T) {
+	useSnapshotDir(t, `[ "$1" = "-t" ] && { echo "emerg: bad config"; exit 1; }
+exit 0`)
+
+	snapshot, err := createSnapshot("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fillTable(t, "certificate")
+	site := filepath.Join(nginx.GetNginxPaths().ConfigsDir, "new.conf")
+	if err := os.WriteFile(site, []byte("server {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = RestoreSnapshot(snapshot.ID)
+	if err == nil || !strings.Contains(err.Error(), "已撤销恢复") || !strings.Contains(err.Error(), "emerg: bad config") {
+		t.Fatalf("err = %v", err)
+	}
+	// 撤销后保持恢复前的状态
+	if n := countCertificates(t); n != 1 {
+		t.Errorf("证书数量 = %d, want 1", n)
+	}
+	if _, err := os.Stat(site); err != nil {
+		t.Errorf("恢复前的站点配置丢失: %v", err)
+	}
+}
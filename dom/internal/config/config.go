@@ -14,6 +14,8 @@ type Config struct {
 	Auth   AuthConfig   `toml:"auth"`
 	Nginx  NginxConfig  `toml:"nginx"`
 	Data   DataConfig   `toml:"data"`
+	Backup BackupConfig `toml:"backup"`
+	ACME   ACMEConfig   `toml:"acme"`
 }
 
 // ServerConfig 服务器配置
@@ -38,6 +40,11 @@ type NginxConfig struct {
 	ClientMaxBodySize string `toml:"client_max_body_size"` // 客户端最大请求体
 	Gzip              bool   `toml:"gzip"`                 // 是否启用 gzip
 	ServerTokens      bool   `toml:"server_tokens"`        // 是否显示 nginx 版本
+	HTTPChallenge     bool   `toml:"http_challenge"`       // 是否监听 80 端口提供 ACME HTTP-01 验证
+	TLSProfile        string `toml:"tls_profile"`          // TLS 配置档：modern、intermediate 或 old
+	OCSPStapling      bool   `toml:"ocsp_stapling"`        // 是否启用 OCSP Stapling
+	Resolver          string `toml:"resolver"`             // OCSP Stapling 使用的 DNS 解析服务器
+	DHParamBits       int    `toml:"dh_param_bits"`        // DH 参数长度，0 表示不配置
 }
 
 // DataConfig 数据目录配置
@@ -45,6 +52,29 @@ type DataConfig struct {
 	Dir string `toml:"dir"`
 }
 
+// BackupConfig 自动备份配置
+type BackupConfig struct {
+	Enabled  bool   `toml:"enabled"`  // 是否启用自动备份
+	Dir      string `toml:"dir"`      // 备份目录（相对路径基于数据目录）
+	Interval int    `toml:"interval"` // 备份间隔小时数
+	Keep     int    `toml:"keep"`     // 最多保留的备份数量（0 表示不限制）
+	MaxAge   int    `toml:"max_age"`  // 备份最长保留天数（0 表示不限制）
+}
+
+// ACMEConfig ACME 客户端配置
+type ACMEConfig struct {
+	Directory          string   `toml:"directory"`           // 默认 ACME 目录地址或预设名称
+	Staging            bool     `toml:"staging"`             // 测试模式：未指定 CA 的证书改用 Let's Encrypt 测试环境
+	CABundle           string   `toml:"ca_bundle"`           // 额外信任的 CA 证书（PEM），用于私有 ACME 服务器
+	DNSResolvers       []string `toml:"dns_resolvers"`       // DNS 传播检查使用的解析服务器（host:port），为空使用系统解析
+	PropagationTimeout int      `toml:"propagation_timeout"` // DNS 传播检查超时秒数（0 表示不检查）
+
+	// 站点启用 SSL 且没有覆盖其域名的证书时自动申请
+	AutoIssue   bool   `toml:"auto_issue"`
+	DNSProvider string `toml:"dns_provider"` // 自动申请使用的 DNS 提供商（ID 或名称），为空时使用 HTTP-01 验证
+	Email       string `toml:"email"`        // 自动申请使用的 ACME 账户邮箱
+}
+
 var cfg *Config
 var cfgPath string // 配置文件路径
 
@@ -67,10 +97,22 @@ func DefaultConfig() *Config {
 			ClientMaxBodySize: "100m",
 			Gzip:              true,
 			ServerTokens:      false,
+			TLSProfile:        "intermediate",
 		},
 		Data: DataConfig{
 			Dir: "./data",
 		},
+		Backup: BackupConfig{
+			Enabled:  true,
+			Dir:      "backups",
+			Interval: 24,
+			Keep:     7,
+			MaxAge:   30,
+		},
+		ACME: ACMEConfig{
+			Directory:          "https://acme-v02.api.letsencrypt.org/directory",
+			PropagationTimeout: 120,
+		},
 	}
 }
 
@@ -97,6 +139,9 @@ func Load(configPath string) (*Config, error) {
 		configDir := filepath.Dir(configPath)
 		cfg.Data.Dir = filepath.Join(configDir, cfg.Data.Dir)
 	}
+	if cfg.ACME.CABundle != "" && !filepath.IsAbs(cfg.ACME.CABundle) {
+		cfg.ACME.CABundle = filepath.Join(filepath.Dir(configPath), cfg.ACME.CABundle)
+	}
 
 	// 确保数据目录存在
 	if err := os.MkdirAll(cfg.Data.Dir, 0755); err != nil {
@@ -119,6 +164,19 @@ func (c *Config) DBPath() string {
 	return filepath.Join(c.Data.Dir, "hop.db")
 }
 
+// BackupDir 获取备份目录
+func (c *Config) BackupDir() string {
+	if filepath.IsAbs(c.Backup.Dir) {
+		return c.Backup.Dir
+	}
+	return filepath.Join(c.Data.Dir, c.Backup.Dir)
+}
+
+// ACMEDir 获取 ACME 账户目录
+func (c *Config) ACMEDir() string {
+	return filepath.Join(c.Data.Dir, "acme")
+}
+
 // Address 获取服务器监听地址
 func (c *Config) Address() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
@@ -193,16 +251,56 @@ client_max_body_size = "100m"
 gzip = true
 # 是否显示 nginx 版本号（建议关闭以提高安全性）
 server_tokens = false
+# 是否监听 80 端口，将 ACME HTTP-01 验证请求转发给 Hop，其余请求跳转到 HTTPS
+# 使用 HTTP-01 方式申请证书时会自动开启
+http_challenge = false
+# TLS 配置档（参考 Mozilla SSL Configuration Generator）
+# modern：仅 TLS 1.3；intermediate：TLS 1.2 和 1.3（推荐）；old：兼容 TLS 1.0/1.1 的旧客户端
+# 站点可单独设置配置档或只允许 TLS 1.3
+tls_profile = "intermediate"
+# 是否启用 OCSP Stapling，启用时需要配置 DNS 解析服务器
+ocsp_stapling = false
+# OCSP Stapling 使用的 DNS 解析服务器，空格分隔，例如 "1.1.1.1 8.8.8.8 ipv6=off"
+resolver = ""
+# DH 参数长度（2048、3072 或 4096），使用 RFC 7919 预定义的 ffdhe 组生成 ssl/dhparam.pem
+# 只影响 DHE 加密套件，0 表示不配置
+dh_param_bits = 0
 
 [data]
 # 数据目录（相对路径基于配置文件位置）
-# 存储数据库、nginx 配置、lego 工作目录等
+# 存储数据库、nginx 配置、ACME 账户等
 dir = "./data"
 
+[backup]
+# 是否启用自动备份（数据库、nginx 目录和 ACME 账户）
+enabled = true
+# 备份目录（相对路径基于数据目录）
+dir = "backups"
+# 备份间隔（小时）
+interval = 24
+# 最多保留的备份数量（0 表示不限制）
+keep = 7
+# 备份最长保留天数（0 表示不限制）
+max_age = 30
+
+[acme]
+# 默认 ACME 目录地址（默认 Let's Encrypt 生产环境），申请证书时可单独指定
+# 也可以使用预设名称：letsencrypt、letsencrypt-staging、zerossl、google、google-staging
+directory = "https://acme-v02.api.letsencrypt.org/directory"
+# 测试模式：未单独指定 CA 的证书使用 Let's Encrypt 测试环境，避免调试时触发速率限制
+staging = false
+# 额外信任的 CA 证书文件（PEM，相对路径基于配置文件位置），连接私有 ACME 服务器（如 Pebble、step-ca）时使用
+ca_bundle = ""
+# DNS 传播检查使用的解析服务器，例如 ["223.5.5.5:53", "1.1.1.1:53"]，留空使用系统解析
+dns_resolvers = []
+# DNS 传播检查超时秒数（0 表示不检查）
+propagation_timeout = 120
+
 # 说明：
 # - Nginx 配置文件会自动生成到 data/nginx/ 目录
 # - 站点配置存储在 data/nginx/conf.d/
 # - SSL 证书存储在 data/nginx/ssl/
+# - ACME 账户存储在数据库中，续期时使用证书关联的账户
 # - 系统会每 24 小时检查一次证书过期时间
 # - 如果证书在 30 天内过期且开启了自动续期，系统会自动续期
 `
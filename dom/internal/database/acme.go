@@ -0,0 +1,109 @@
+package database
+
+import (
+	"time"
+)
+
+// ACMEAccount ACME 账户
+type ACMEAccount struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`     // 联系邮箱
+	Directory string    `json:"directory"` // ACME 目录地址
+	KeyPEM    string    `json:"-"`         // 账户私钥（PEM）
+	URL       string    `json:"url"`       // CA 返回的账户地址（registration URI）
+	Status    string    `json:"status"`    // valid, deactivated
+	EABKID    string    `json:"eabKid"`    // 注册时使用的 External Account Binding key ID
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// acmeAccountColumns ACME 账户表查询列，顺序与 scanACMEAccount 一致
+const acmeAccountColumns = `id, email, directory, keyPem, url, status, eabKid, createdAt, updatedAt`
+
+// scanACMEAccount 扫描一行账户记录
+func scanACMEAccount(row rowScanner) (*ACMEAccount, error) {
+	var account ACMEAccount
+	var createdAt, updatedAt string
+
+	if err := row.Scan(&account.ID, &account.Email, &account.Directory, &account.KeyPEM, &account.URL,
+		&account.Status, &account.EABKID, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	account.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	account.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &account, nil
+}
+
+// CreateACMEAccount 创建 ACME 账户
+func CreateACMEAccount(account *ACMEAccount) error {
+	now := time.Now()
+	account.CreatedAt = now
+	account.UpdatedAt = now
+	if account.Status == "" {
+		account.Status = "valid"
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO acme_account (`+acmeAccountColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, account.ID, account.Email, account.Directory, account.KeyPEM, account.URL, account.Status, account.EABKID,
+		now.Format(time.RFC3339), now.Format(time.RFC3339))
+	return err
+}
+
+// UpdateACMEAccount 更新 ACME 账户
+func UpdateACMEAccount(account *ACMEAccount) error {
+	now := time.Now()
+	account.UpdatedAt = now
+
+	_, err := db.Exec(`
+		UPDATE acme_account SET email = ?, directory = ?, keyPem = ?, url = ?, status = ?, eabKid = ?, updatedAt = ?
+		WHERE id = ?
+	`, account.Email, account.Directory, account.KeyPEM, account.URL, account.Status, account.EABKID,
+		now.Format(time.RFC3339), account.ID)
+	return err
+}
+
+// GetACMEAccount 获取 ACME 账户
+func GetACMEAccount(id string) (*ACMEAccount, error) {
+	return scanACMEAccount(db.QueryRow(`SELECT `+acmeAccountColumns+` FROM acme_account WHERE id = ?`, id))
+}
+
+// FindACMEAccount 根据目录和邮箱查找账户
+func FindACMEAccount(directory, email string) (*ACMEAccount, error) {
+	return scanACMEAccount(db.QueryRow(`SELECT `+acmeAccountColumns+` FROM acme_account WHERE directory = ? AND email = ?`, directory, email))
+}
+
+// ListACMEAccounts 获取所有 ACME 账户
+func ListACMEAccounts() ([]ACMEAccount, error) {
+	rows, err := db.Query(`SELECT ` + acmeAccountColumns + ` FROM acme_account ORDER BY createdAt DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []ACMEAccount
+	for rows.Next() {
+		account, err := scanACMEAccount(rows)
+		if err != nil {
+			continue
+		}
+		accounts = append(accounts, *account)
+	}
+
+	return accounts, nil
+}
+
+// DeleteACMEAccount 删除 ACME 账户
+func DeleteACMEAccount(id string) error {
+	_, err := db.Exec(`DELETE FROM acme_account WHERE id = ?`, id)
+	return err
+}
+
+// CountCertificatesByAccount 统计使用该账户的证书数量
+func CountCertificatesByAccount(accountID string) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM certificate WHERE accountId = ?`, accountID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// BackupTo 使用 SQLite 在线备份 API 将当前数据库复制到 destPath
+// 备份期间数据库仍可正常读写
+func BackupTo(destPath string) error {
+	destDB, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	return copyDatabase(destDB, db)
+}
+
+// RestoreFrom 使用 SQLite 在线备份 API 将 srcPath 中的数据库复制回当前数据库
+// 复制后运行迁移，旧版本的备份会升级到当前表结构
+func RestoreFrom(srcPath string) error {
+	srcDB, err := sql.Open("sqlite3", "file:"+srcPath+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer srcDB.Close()
+
+	if err := copyDatabase(db, srcDB); err != nil {
+		return err
+	}
+	if err := runMigrations(); err != nil {
+		return fmt.Errorf("升级数据库结构失败: %w", err)
+	}
+	return nil
+}
+
+// CheckIntegrity 对指定的数据库文件执行 PRAGMA integrity_check
+func CheckIntegrity(path string) error {
+	checkDB, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer checkDB.Close()
+
+	var result string
+	if err := checkDB.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("数据库完整性检查失败: %s", result)
+	}
+	return nil
+}
+
+// copyDatabase 将 src 的 main 数据库完整复制到 dest
+func copyDatabase(dest, src *sql.DB) error {
+	ctx := context.Background()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			destSQLite, ok := destDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("不支持的数据库驱动")
+			}
+			srcSQLite, ok := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("不支持的数据库驱动")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+
+			// 一次复制所有页
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
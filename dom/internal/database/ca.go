@@ -0,0 +1,175 @@
+package database
+
+import (
+	"time"
+)
+
+// 内部 CA 证书文件路径（相对 data 目录）
+const (
+	InternalCACertPath  = "nginx/ssl/internal-ca.crt"       // 根证书，供客户端和上游校验
+	InternalCAChainPath = "nginx/ssl/internal-ca-chain.crt" // 根证书 + 中间证书，供 nginx 校验客户端证书
+)
+
+// InternalCA 内部证书颁发机构（根证书 + 用于签发的中间证书）
+type InternalCA struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	RootCertPEM string    `json:"rootCertPem"` // 根证书（PEM），分发到客户端信任
+	RootKeyPEM  string    `json:"-"`           // 根证书私钥，导入时可不提供
+	CertPEM     string    `json:"certPem"`     // 签发证书使用的中间证书（PEM）
+	KeyPEM      string    `json:"-"`           // 中间证书私钥
+	LeafDays    int       `json:"leafDays"`    // 签发证书的有效天数
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// internalCAColumns 内部 CA 表查询列，顺序与 scanInternalCA 一致
+const internalCAColumns = `id, name, rootCertPem, rootKeyPem, certPem, keyPem, leafDays, createdAt, updatedAt`
+
+// scanInternalCA 扫描一行内部 CA 记录
+func scanInternalCA(row rowScanner) (*InternalCA, error) {
+	var ca InternalCA
+	var createdAt, updatedAt string
+
+	if err := row.Scan(&ca.ID, &ca.Name, &ca.RootCertPEM, &ca.RootKeyPEM, &ca.CertPEM, &ca.KeyPEM, &ca.LeafDays,
+		&createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	ca.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	ca.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &ca, nil
+}
+
+// GetInternalCA 获取内部 CA，未配置时返回 sql.ErrNoRows
+func GetInternalCA() (*InternalCA, error) {
+	return scanInternalCA(db.QueryRow(`SELECT ` + internalCAColumns + ` FROM internal_ca ORDER BY createdAt LIMIT 1`))
+}
+
+// SaveInternalCA 保存内部 CA，替换已有的 CA
+func SaveInternalCA(ca *InternalCA) error {
+	now := time.Now()
+	ca.CreatedAt = now
+	ca.UpdatedAt = now
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM internal_ca`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO internal_ca (`+internalCAColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, ca.ID, ca.Name, ca.RootCertPEM, ca.RootKeyPEM, ca.CertPEM, ca.KeyPEM, ca.LeafDays,
+		now.Format(time.RFC3339), now.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateInternalCALeafDays 修改签发证书的有效天数
+func UpdateInternalCALeafDays(id string, days int) error {
+	_, err := db.Exec(`UPDATE internal_ca SET leafDays = ?, updatedAt = ? WHERE id = ?`,
+		days, time.Now().Format(time.RFC3339), id)
+	return err
+}
+
+// DeleteInternalCA 删除内部 CA
+func DeleteInternalCA() error {
+	_, err := db.Exec(`DELETE FROM internal_ca`)
+	return err
+}
+
+// CountCertificatesBySource 统计指定来源的证书数量
+func CountCertificatesBySource(source string) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM certificate WHERE source = ?`, source).Scan(&count)
+	return count, err
+}
+
+// ClientCA 用于校验客户端证书（mTLS）的 CA 证书包
+type ClientCA struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CertPEM   string    `json:"certPem"`  // 一个或多个 CA 证书（PEM）
+	CertPath  string    `json:"certPath"` // 证书包文件路径（相对 data 目录）
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// clientCAColumns 客户端 CA 表查询列，顺序与 scanClientCA 一致
+const clientCAColumns = `id, name, certPem, certPath, createdAt, updatedAt`
+
+// scanClientCA 扫描一行客户端 CA 记录
+func scanClientCA(row rowScanner) (*ClientCA, error) {
+	var ca ClientCA
+	var createdAt, updatedAt string
+
+	if err := row.Scan(&ca.ID, &ca.Name, &ca.CertPEM, &ca.CertPath, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	ca.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	ca.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &ca, nil
+}
+
+// CreateClientCA 创建客户端 CA
+func CreateClientCA(ca *ClientCA) error {
+	now := time.Now()
+	ca.CreatedAt = now
+	ca.UpdatedAt = now
+
+	_, err := db.Exec(`
+		INSERT INTO client_ca (`+clientCAColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, ca.ID, ca.Name, ca.CertPEM, ca.CertPath, now.Format(time.RFC3339), now.Format(time.RFC3339))
+	return err
+}
+
+// UpdateClientCA 更新客户端 CA 的名称和证书
+func UpdateClientCA(ca *ClientCA) error {
+	ca.UpdatedAt = time.Now()
+	_, err := db.Exec(`UPDATE client_ca SET name = ?, certPem = ?, updatedAt = ? WHERE id = ?`,
+		ca.Name, ca.CertPEM, ca.UpdatedAt.Format(time.RFC3339), ca.ID)
+	return err
+}
+
+// GetClientCA 根据 ID 获取客户端 CA
+func GetClientCA(id string) (*ClientCA, error) {
+	return scanClientCA(db.QueryRow(`SELECT `+clientCAColumns+` FROM client_ca WHERE id = ?`, id))
+}
+
+// GetClientCAByName 根据名称获取客户端 CA
+func GetClientCAByName(name string) (*ClientCA, error) {
+	return scanClientCA(db.QueryRow(`SELECT `+clientCAColumns+` FROM client_ca WHERE name = ?`, name))
+}
+
+// ListClientCAs 获取所有客户端 CA
+func ListClientCAs() ([]ClientCA, error) {
+	rows, err := db.Query(`SELECT ` + clientCAColumns + ` FROM client_ca ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cas []ClientCA
+	for rows.Next() {
+		ca, err := scanClientCA(rows)
+		if err != nil {
+			continue
+		}
+		cas = append(cas, *ca)
+	}
+	return cas, nil
+}
+
+// DeleteClientCA 删除客户端 CA
+func DeleteClientCA(id string) error {
+	_, err := db.Exec(`DELETE FROM client_ca WHERE id = ?`, id)
+	return err
+}
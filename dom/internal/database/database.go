@@ -2,6 +2,8 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -89,14 +91,49 @@ func GetDB() *sql.DB {
 	return db
 }
 
-// Close 关闭数据库
+// Close 关闭数据库，之后 GetDB 返回 nil
 func Close() error {
-	if db != nil {
-		return db.Close()
+	if db == nil {
+		return nil
 	}
-	return nil
+	err := db.Close()
+	db = nil
+	return err
 }
 
+// certificateTableSchema certificate 表的列定义，同一主域名可以有多个证书
+const certificateTableSchema = `(
+		id TEXT PRIMARY KEY,
+		domain TEXT NOT NULL,
+		domains TEXT NOT NULL,
+		source TEXT NOT NULL DEFAULT 'acme',
+		usage TEXT NOT NULL DEFAULT 'server',
+		dnsProviderId TEXT NOT NULL,
+		challengeType TEXT NOT NULL DEFAULT 'dns-01',
+		acmeDirectory TEXT NOT NULL DEFAULT '',
+		eabKid TEXT NOT NULL DEFAULT '',
+		eabHmacKey TEXT NOT NULL DEFAULT '',
+		accountId TEXT NOT NULL DEFAULT '',
+		keyType TEXT NOT NULL DEFAULT '',
+		reuseKey INTEGER DEFAULT 0,
+		dual INTEGER DEFAULT 0,
+		certPath TEXT NOT NULL,
+		keyPath TEXT NOT NULL,
+		rsaCertPath TEXT NOT NULL DEFAULT '',
+		rsaKeyPath TEXT NOT NULL DEFAULT '',
+		issuer TEXT,
+		notBefore TEXT NOT NULL,
+		notAfter TEXT NOT NULL,
+		autoRenew INTEGER DEFAULT 1,
+		renewBeforeDays INTEGER NOT NULL DEFAULT 0,
+		lastRenewAt TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		error TEXT,
+		createdAt TEXT NOT NULL,
+		updatedAt TEXT NOT NULL,
+		FOREIGN KEY (dnsProviderId) REFERENCES dns_provider(id)
+	)`
+
 // runMigrations 运行数据库迁移（兼容 Better Auth schema）
 func runMigrations() error {
 	migrations := []string{
@@ -169,40 +206,149 @@ func runMigrations() error {
 		)`,
 
 		// Certificate 表
-		`CREATE TABLE IF NOT EXISTS certificate (
-			id TEXT PRIMARY KEY,
-			domain TEXT UNIQUE NOT NULL,
-			domains TEXT NOT NULL,
-			dnsProviderId TEXT NOT NULL,
-			certPath TEXT NOT NULL,
-			keyPath TEXT NOT NULL,
-			issuer TEXT,
-			notBefore TEXT NOT NULL,
-			notAfter TEXT NOT NULL,
-			autoRenew INTEGER DEFAULT 1,
-			lastRenewAt TEXT,
-			status TEXT NOT NULL DEFAULT 'pending',
-			error TEXT,
-			createdAt TEXT NOT NULL,
-			updatedAt TEXT NOT NULL,
-			FOREIGN KEY (dnsProviderId) REFERENCES dns_provider(id)
-		)`,
+		`CREATE TABLE IF NOT EXISTS certificate ` + certificateTableSchema,
 
 		// Certificate Log 表
 		`CREATE TABLE IF NOT EXISTS certificate_log (
 			id TEXT PRIMARY KEY,
 			certificateId TEXT NOT NULL,
+			jobId TEXT NOT NULL DEFAULT '',
 			action TEXT NOT NULL,
 			message TEXT NOT NULL,
 			createdAt TEXT NOT NULL,
 			FOREIGN KEY (certificateId) REFERENCES certificate(id) ON DELETE CASCADE
 		)`,
 
+		// 证书申请和续期任务表
+		`CREATE TABLE IF NOT EXISTS certificate_job (
+			id TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			certificateId TEXT NOT NULL DEFAULT '',
+			domain TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			createdAt TEXT NOT NULL,
+			startedAt TEXT,
+			finishedAt TEXT
+		)`,
+
+		// ACME 账户表
+		`CREATE TABLE IF NOT EXISTS acme_account (
+			id TEXT PRIMARY KEY,
+			email TEXT NOT NULL,
+			directory TEXT NOT NULL,
+			keyPem TEXT NOT NULL,
+			url TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'valid',
+			eabKid TEXT NOT NULL DEFAULT '',
+			createdAt TEXT NOT NULL,
+			updatedAt TEXT NOT NULL,
+			UNIQUE (directory, email)
+		)`,
+
+		// 内部 CA 表
+		`CREATE TABLE IF NOT EXISTS internal_ca (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			rootCertPem TEXT NOT NULL,
+			rootKeyPem TEXT NOT NULL DEFAULT '',
+			certPem TEXT NOT NULL,
+			keyPem TEXT NOT NULL,
+			leafDays INTEGER NOT NULL DEFAULT 90,
+			createdAt TEXT NOT NULL,
+			updatedAt TEXT NOT NULL
+		)`,
+
+		// 客户端 CA 表（mTLS 校验客户端证书）
+		`CREATE TABLE IF NOT EXISTS client_ca (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			certPem TEXT NOT NULL,
+			certPath TEXT NOT NULL,
+			createdAt TEXT NOT NULL,
+			updatedAt TEXT NOT NULL
+		)`,
+
+		// 证书部署钩子表
+		`CREATE TABLE IF NOT EXISTS certificate_hook (
+			id TEXT PRIMARY KEY,
+			certificateId TEXT NOT NULL,
+			type TEXT NOT NULL,
+			enabled INTEGER DEFAULT 1,
+			certPath TEXT NOT NULL DEFAULT '',
+			keyPath TEXT NOT NULL DEFAULT '',
+			owner TEXT NOT NULL DEFAULT '',
+			mode TEXT NOT NULL DEFAULT '',
+			command TEXT NOT NULL DEFAULT '',
+			timeout INTEGER NOT NULL DEFAULT 0,
+			password TEXT NOT NULL DEFAULT '',
+			legacy INTEGER DEFAULT 0,
+			lastRunAt TEXT,
+			lastError TEXT NOT NULL DEFAULT '',
+			createdAt TEXT NOT NULL,
+			updatedAt TEXT NOT NULL,
+			FOREIGN KEY (certificateId) REFERENCES certificate(id) ON DELETE CASCADE
+		)`,
+
+		// 通知渠道表
+		`CREATE TABLE IF NOT EXISTS notification_channel (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			type TEXT NOT NULL,
+			config TEXT NOT NULL,
+			events TEXT NOT NULL DEFAULT '[]',
+			enabled INTEGER DEFAULT 1,
+			lastSentAt TEXT,
+			lastError TEXT NOT NULL DEFAULT '',
+			createdAt TEXT NOT NULL,
+			updatedAt TEXT NOT NULL
+		)`,
+
+		// 证书续期计划表（每个证书一行，由调度器维护）
+		`CREATE TABLE IF NOT EXISTS certificate_renewal (
+			certificateId TEXT PRIMARY KEY,
+			renewAt TEXT,
+			basis TEXT NOT NULL DEFAULT '',
+			ariCheckAt TEXT,
+			explanationUrl TEXT NOT NULL DEFAULT '',
+			failures INTEGER NOT NULL DEFAULT 0,
+			retryAt TEXT,
+			lastAttemptAt TEXT,
+			lastError TEXT NOT NULL DEFAULT '',
+			updatedAt TEXT NOT NULL,
+			FOREIGN KEY (certificateId) REFERENCES certificate(id) ON DELETE CASCADE
+		)`,
+
 		// SSL 相关索引
 		`CREATE INDEX IF NOT EXISTS idx_certificate_domain ON certificate(domain)`,
 		`CREATE INDEX IF NOT EXISTS idx_certificate_status ON certificate(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_certificate_notAfter ON certificate(notAfter)`,
 		`CREATE INDEX IF NOT EXISTS idx_certificate_log_certId ON certificate_log(certificateId)`,
+		`CREATE INDEX IF NOT EXISTS idx_certificate_job_status ON certificate_job(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_certificate_hook_certId ON certificate_hook(certificateId)`,
+
+		// Config Version 表（配置历史版本）
+		`CREATE TABLE IF NOT EXISTS config_version (
+			id TEXT PRIMARY KEY,
+			number INTEGER UNIQUE NOT NULL,
+			author TEXT NOT NULL,
+			message TEXT NOT NULL,
+			createdAt TEXT NOT NULL
+		)`,
+
+		// Config Version File 表（版本中的文件快照）
+		`CREATE TABLE IF NOT EXISTS config_version_file (
+			id TEXT PRIMARY KEY,
+			versionId TEXT NOT NULL,
+			path TEXT NOT NULL,
+			content TEXT NOT NULL,
+			deleted INTEGER DEFAULT 0,
+			FOREIGN KEY (versionId) REFERENCES config_version(id) ON DELETE CASCADE
+		)`,
+
+		// 配置历史相关索引
+		`CREATE INDEX IF NOT EXISTS idx_config_version_file_versionId ON config_version_file(versionId)`,
+		`CREATE INDEX IF NOT EXISTS idx_config_version_file_path ON config_version_file(path)`,
 	}
 
 	for _, migration := range migrations {
@@ -211,6 +357,108 @@ func runMigrations() error {
 		}
 	}
 
+	// 为已有表补充新增的列
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"certificate", "challengeType", "TEXT NOT NULL DEFAULT 'dns-01'"},
+		{"certificate", "source", "TEXT NOT NULL DEFAULT 'acme'"},
+		{"certificate", "usage", "TEXT NOT NULL DEFAULT 'server'"},
+		{"certificate", "acmeDirectory", "TEXT NOT NULL DEFAULT ''"},
+		{"certificate", "eabKid", "TEXT NOT NULL DEFAULT ''"},
+		{"certificate", "eabHmacKey", "TEXT NOT NULL DEFAULT ''"},
+		{"certificate", "accountId", "TEXT NOT NULL DEFAULT ''"},
+		{"certificate", "keyType", "TEXT NOT NULL DEFAULT ''"},
+		{"certificate", "reuseKey", "INTEGER DEFAULT 0"},
+		{"certificate", "dual", "INTEGER DEFAULT 0"},
+		{"certificate", "rsaCertPath", "TEXT NOT NULL DEFAULT ''"},
+		{"certificate", "rsaKeyPath", "TEXT NOT NULL DEFAULT ''"},
+		{"certificate", "renewBeforeDays", "INTEGER NOT NULL DEFAULT 0"},
+		{"certificate_log", "jobId", "TEXT NOT NULL DEFAULT ''"},
+	}
+
+	for _, c := range columns {
+		if err := addColumnIfMissing(c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	if err := dropCertificateDomainUnique(); err != nil {
+		return fmt.Errorf("迁移证书表失败: %w", err)
+	}
+
 	log.Info("数据库迁移完成")
 	return nil
 }
+
+// dropCertificateDomainUnique 去掉旧版本 certificate.domain 的唯一约束
+// SQLite 不支持删除约束，按新定义重建表后复制数据和索引
+func dropCertificateDomainUnique() error {
+	var schema string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'certificate'`).Scan(&schema); err != nil {
+		return err
+	}
+	if !strings.Contains(schema, "domain TEXT UNIQUE") {
+		return nil
+	}
+
+	columns, err := TableColumns("certificate")
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query(`SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = 'certificate' AND sql IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	var indexes []string
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err == nil {
+			indexes = append(indexes, index)
+		}
+	}
+	rows.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	list := strings.Join(columns, ", ")
+	statements := []string{
+		`CREATE TABLE certificate_new ` + certificateTableSchema,
+		fmt.Sprintf(`INSERT INTO certificate_new (%s) SELECT %s FROM certificate`, list, list),
+		`DROP TABLE certificate`,
+		`ALTER TABLE certificate_new RENAME TO certificate`,
+	}
+	for _, stmt := range append(statements, indexes...) {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Info("已移除证书主域名的唯一约束", nil)
+	return nil
+}
+
+// addColumnIfMissing 列不存在时添加列
+func addColumnIfMissing(table, column, definition string) error {
+	existing, err := TableColumns(table)
+	if err != nil {
+		return err
+	}
+	for _, name := range existing {
+		if name == column {
+			return nil
+		}
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("添加列 %s.%s 失败: %w", table, column, err)
+	}
+	return nil
+}
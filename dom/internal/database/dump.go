@@ -0,0 +1,131 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Row 表中的一行数据（列名 -> 值）
+type Row map[string]interface{}
+
+// TableColumns 获取表的列名
+func TableColumns(table string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%q)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue interface{}
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("表不存在: %s", table)
+	}
+	return columns, rows.Err()
+}
+
+// DumpTable 导出表中的所有行
+func DumpTable(table string) ([]Row, error) {
+	columns, err := TableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %q ORDER BY rowid", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Row{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := Row{}
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// RestoreTables 在一个事务中用给定数据替换各表内容
+// order 指定写入顺序，未出现在 tables 中的表保持不变
+func RestoreTables(order []string, tables map[string][]Row) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range order {
+		rows, ok := tables[table]
+		if !ok {
+			continue
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %q", table)); err != nil {
+			return fmt.Errorf("清空表 %s 失败: %w", table, err)
+		}
+
+		for _, row := range rows {
+			columns := make([]string, 0, len(row))
+			placeholders := make([]string, 0, len(row))
+			args := make([]interface{}, 0, len(row))
+			for col, value := range row {
+				columns = append(columns, fmt.Sprintf("%q", col))
+				placeholders = append(placeholders, "?")
+				args = append(args, sqlValue(value))
+			}
+
+			query := fmt.Sprintf("INSERT INTO %q (%s) VALUES (%s)",
+				table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+			if _, err := tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("写入表 %s 失败: %w", table, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// sqlValue 将 JSON 解码得到的值转换为数据库驱动可接受的类型
+func sqlValue(value interface{}) interface{} {
+	n, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return n.String()
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 部署钩子类型
+const (
+	HookCopy    = "copy"    // 复制证书和私钥到其他路径
+	HookCommand = "command" // 执行本地命令
+	HookPKCS12  = "pkcs12"  // 导出 PKCS#12 文件（Java 9 及以上可直接作为密钥库使用）
+)
+
+// CertificateHook 证书申请或续期成功后执行的部署钩子
+type CertificateHook struct {
+	ID            string     `json:"id"`
+	CertificateID string     `json:"certificateId"`
+	Type          string     `json:"type"` // copy, command, pkcs12
+	Enabled       bool       `json:"enabled"`
+	CertPath      string     `json:"certPath"` // copy: 证书（含证书链）目标路径；pkcs12: 输出文件路径
+	KeyPath       string     `json:"keyPath"`  // copy: 私钥目标路径
+	Owner         string     `json:"owner"`    // 文件所有者，user 或 user:group
+	Mode          string     `json:"mode"`     // 文件权限（八进制），如 0640
+	Command       string     `json:"command"`  // command: 通过 sh -c 执行
+	Timeout       int        `json:"timeout"`  // command: 超时秒数
+	Password      string     `json:"-"`        // pkcs12: 文件密码
+	Legacy        bool       `json:"legacy"`   // pkcs12: 使用 3DES 加密，兼容旧版 Java 和 Windows
+	LastRunAt     *time.Time `json:"lastRunAt"`
+	LastError     string     `json:"lastError"` // 最近一次执行的错误，成功时为空
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// certificateHookColumns 部署钩子表查询列，顺序与 scanCertificateHook 一致
+const certificateHookColumns = `id, certificateId, type, enabled, certPath, keyPath, owner, mode, command, timeout, password, legacy, lastRunAt, lastError, createdAt, updatedAt`
+
+// scanCertificateHook 扫描一行部署钩子记录
+func scanCertificateHook(row rowScanner) (*CertificateHook, error) {
+	var hook CertificateHook
+	var createdAt, updatedAt string
+	var lastRunAt sql.NullString
+
+	if err := row.Scan(&hook.ID, &hook.CertificateID, &hook.Type, &hook.Enabled, &hook.CertPath, &hook.KeyPath,
+		&hook.Owner, &hook.Mode, &hook.Command, &hook.Timeout, &hook.Password, &hook.Legacy,
+		&lastRunAt, &hook.LastError, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	hook.LastRunAt = parseNullTime(lastRunAt)
+	hook.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	hook.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &hook, nil
+}
+
+// CreateCertificateHook 创建部署钩子
+func CreateCertificateHook(hook *CertificateHook) error {
+	now := time.Now()
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+
+	_, err := db.Exec(`
+		INSERT INTO certificate_hook (`+certificateHookColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, hook.ID, hook.CertificateID, hook.Type, hook.Enabled, hook.CertPath, hook.KeyPath,
+		hook.Owner, hook.Mode, hook.Command, hook.Timeout, hook.Password, hook.Legacy,
+		formatNullTime(hook.LastRunAt), hook.LastError, now.Format(time.RFC3339), now.Format(time.RFC3339))
+	return err
+}
+
+// UpdateCertificateHook 修改部署钩子设置
+func UpdateCertificateHook(hook *CertificateHook) error {
+	hook.UpdatedAt = time.Now()
+
+	_, err := db.Exec(`
+		UPDATE certificate_hook
+		SET type = ?, enabled = ?, certPath = ?, keyPath = ?, owner = ?, mode = ?, command = ?, timeout = ?,
+			password = ?, legacy = ?, updatedAt = ?
+		WHERE id = ?
+	`, hook.Type, hook.Enabled, hook.CertPath, hook.KeyPath, hook.Owner, hook.Mode, hook.Command,
+		hook.Timeout, hook.Password, hook.Legacy, hook.UpdatedAt.Format(time.RFC3339), hook.ID)
+	return err
+}
+
+// UpdateCertificateHookResult 记录部署钩子的执行结果
+func UpdateCertificateHookResult(id string, runAt time.Time, lastError string) error {
+	_, err := db.Exec(`UPDATE certificate_hook SET lastRunAt = ?, lastError = ? WHERE id = ?`,
+		runAt.Format(time.RFC3339), lastError, id)
+	return err
+}
+
+// GetCertificateHook 获取部署钩子
+func GetCertificateHook(id string) (*CertificateHook, error) {
+	return scanCertificateHook(db.QueryRow(`SELECT `+certificateHookColumns+` FROM certificate_hook WHERE id = ?`, id))
+}
+
+// ListCertificateHooks 按创建顺序列出证书的部署钩子
+func ListCertificateHooks(certificateID string) ([]CertificateHook, error) {
+	rows, err := db.Query(`
+		SELECT `+certificateHookColumns+`
+		FROM certificate_hook
+		WHERE certificateId = ?
+		ORDER BY createdAt, rowid
+	`, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []CertificateHook
+	for rows.Next() {
+		hook, err := scanCertificateHook(rows)
+		if err != nil {
+			continue
+		}
+		hooks = append(hooks, *hook)
+	}
+	return hooks, nil
+}
+
+// MoveCertificateHooks 将部署钩子转移到新证书，用于重新申请时替换旧证书记录
+func MoveCertificateHooks(fromID, toID string) error {
+	_, err := db.Exec(`UPDATE certificate_hook SET certificateId = ? WHERE certificateId = ?`, toID, fromID)
+	return err
+}
+
+// DeleteCertificateHook 删除部署钩子
+func DeleteCertificateHook(id string) error {
+	_, err := db.Exec(`DELETE FROM certificate_hook WHERE id = ?`, id)
+	return err
+}
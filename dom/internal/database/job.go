@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CertificateJob 证书申请或续期任务
+type CertificateJob struct {
+	ID            string     `json:"id"`
+	Type          string     `json:"type"`          // issue, renew
+	CertificateID string     `json:"certificateId"` // 申请任务开始执行后才确定
+	Domain        string     `json:"domain"`        // 主域名
+	Status        string     `json:"status"`        // queued, running, succeeded, failed
+	Error         string     `json:"error"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	StartedAt     *time.Time `json:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt"`
+}
+
+// 任务状态
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// certificateJobColumns 任务表查询列，顺序与 scanCertificateJob 一致
+const certificateJobColumns = `id, type, certificateId, domain, status, error, createdAt, startedAt, finishedAt`
+
+// scanCertificateJob 扫描一行任务记录
+func scanCertificateJob(row rowScanner) (*CertificateJob, error) {
+	var job CertificateJob
+	var createdAt string
+	var startedAt, finishedAt sql.NullString
+
+	if err := row.Scan(&job.ID, &job.Type, &job.CertificateID, &job.Domain, &job.Status, &job.Error,
+		&createdAt, &startedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+
+	job.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	job.StartedAt = parseNullTime(startedAt)
+	job.FinishedAt = parseNullTime(finishedAt)
+	return &job, nil
+}
+
+func parseNullTime(s sql.NullString) *time.Time {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func formatNullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+// CreateCertificateJob 创建任务
+func CreateCertificateJob(job *CertificateJob) error {
+	job.CreatedAt = time.Now()
+
+	_, err := db.Exec(`
+		INSERT INTO certificate_job (`+certificateJobColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, job.ID, job.Type, job.CertificateID, job.Domain, job.Status, job.Error,
+		job.CreatedAt.Format(time.RFC3339), formatNullTime(job.StartedAt), formatNullTime(job.FinishedAt))
+	return err
+}
+
+// UpdateCertificateJob 更新任务状态
+func UpdateCertificateJob(job *CertificateJob) error {
+	_, err := db.Exec(`
+		UPDATE certificate_job
+		SET certificateId = ?, status = ?, error = ?, startedAt = ?, finishedAt = ?
+		WHERE id = ?
+	`, job.CertificateID, job.Status, job.Error, formatNullTime(job.StartedAt), formatNullTime(job.FinishedAt), job.ID)
+	return err
+}
+
+// GetCertificateJob 获取任务
+func GetCertificateJob(id string) (*CertificateJob, error) {
+	row := db.QueryRow(`SELECT `+certificateJobColumns+` FROM certificate_job WHERE id = ?`, id)
+	return scanCertificateJob(row)
+}
+
+// ListCertificateJobs 按创建时间倒序列出最近的任务
+func ListCertificateJobs(limit int) ([]CertificateJob, error) {
+	rows, err := db.Query(`
+		SELECT `+certificateJobColumns+`
+		FROM certificate_job
+		ORDER BY createdAt DESC, rowid DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []CertificateJob
+	for rows.Next() {
+		job, err := scanCertificateJob(rows)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// FailUnfinishedCertificateJobs 将未完成的任务标记为失败，用于服务重启后清理，返回影响的任务数
+func FailUnfinishedCertificateJobs(message string) (int64, error) {
+	result, err := db.Exec(`
+		UPDATE certificate_job
+		SET status = ?, error = ?, finishedAt = ?
+		WHERE status IN (?, ?)
+	`, JobFailed, message, time.Now().Format(time.RFC3339), JobQueued, JobRunning)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetJobLogs 按时间顺序获取任务产生的证书日志
+func GetJobLogs(jobID string) ([]CertificateLog, error) {
+	rows, err := db.Query(`
+		SELECT id, certificateId, jobId, action, message, createdAt
+		FROM certificate_log
+		WHERE jobId = ?
+		ORDER BY rowid
+	`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []CertificateLog
+	for rows.Next() {
+		var logEntry CertificateLog
+		var createdAt string
+
+		if err := rows.Scan(&logEntry.ID, &logEntry.CertificateID, &logEntry.JobID, &logEntry.Action, &logEntry.Message, &createdAt); err != nil {
+			continue
+		}
+
+		logEntry.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		logs = append(logs, logEntry)
+	}
+
+	return logs, nil
+}
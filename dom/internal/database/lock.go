@@ -0,0 +1,34 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/hop/backend/internal/config"
+)
+
+// ErrLocked 数据目录已被其他 hop 进程锁定
+var ErrLocked = errors.New("数据目录正在被其他 hop 进程使用")
+
+// Lock 锁定数据目录，服务器运行期间持有，防止命令行同时修改数据
+// 进程退出时锁自动释放，返回的函数用于提前释放
+func Lock(cfg *config.Config) (unlock func(), err error) {
+	if err := os.MkdirAll(cfg.Data.Dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(cfg.Data.Dir, "hop.lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("锁定数据目录失败: %w", err)
+	}
+	return func() { f.Close() }, nil
+}
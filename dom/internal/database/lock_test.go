@@ -0,0 +1,29 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hop/backend/internal/config"
+)
+
+func TestLock(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Data.Dir = t.TempDir()
+
+	unlock, err := Lock(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 服务器持有锁时，命令行无法再次锁定
+	if _, err := Lock(cfg); !errors.Is(err, ErrLocked) {
+		t.Fatalf("err = %v, want ErrLocked", err)
+	}
+
+	unlock()
+	unlock, err = Lock(cfg)
+	if err != nil {
+		t.Fatalf("释放后重新锁定失败: %v", err)
+	}
+	unlock()
+}
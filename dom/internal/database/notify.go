@@ -0,0 +1,103 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// NotificationChannel 通知渠道
+type NotificationChannel struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`   // webhook, email, slack, feishu, dingtalk
+	Config     string     `json:"config"` // JSON 配置
+	Events     string     `json:"events"` // 订阅的事件（JSON 数组）
+	Enabled    bool       `json:"enabled"`
+	LastSentAt *time.Time `json:"lastSentAt"`
+	LastError  string     `json:"lastError"` // 最近一次发送的错误，成功时为空
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// notificationChannelColumns 通知渠道表查询列，顺序与 scanNotificationChannel 一致
+const notificationChannelColumns = `id, name, type, config, events, enabled, lastSentAt, lastError, createdAt, updatedAt`
+
+// scanNotificationChannel 扫描一行通知渠道记录
+func scanNotificationChannel(row rowScanner) (*NotificationChannel, error) {
+	var ch NotificationChannel
+	var createdAt, updatedAt string
+	var lastSentAt sql.NullString
+
+	if err := row.Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Config, &ch.Events, &ch.Enabled,
+		&lastSentAt, &ch.LastError, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	ch.LastSentAt = parseNullTime(lastSentAt)
+	ch.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	ch.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &ch, nil
+}
+
+// CreateNotificationChannel 创建通知渠道
+func CreateNotificationChannel(ch *NotificationChannel) error {
+	now := time.Now()
+	ch.CreatedAt = now
+	ch.UpdatedAt = now
+
+	_, err := db.Exec(`
+		INSERT INTO notification_channel (`+notificationChannelColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, ch.ID, ch.Name, ch.Type, ch.Config, ch.Events, ch.Enabled,
+		formatNullTime(ch.LastSentAt), ch.LastError, now.Format(time.RFC3339), now.Format(time.RFC3339))
+	return err
+}
+
+// UpdateNotificationChannel 修改通知渠道设置
+func UpdateNotificationChannel(ch *NotificationChannel) error {
+	ch.UpdatedAt = time.Now()
+
+	_, err := db.Exec(`
+		UPDATE notification_channel
+		SET name = ?, type = ?, config = ?, events = ?, enabled = ?, updatedAt = ?
+		WHERE id = ?
+	`, ch.Name, ch.Type, ch.Config, ch.Events, ch.Enabled, ch.UpdatedAt.Format(time.RFC3339), ch.ID)
+	return err
+}
+
+// UpdateNotificationChannelResult 记录最近一次发送的结果
+func UpdateNotificationChannelResult(id string, sentAt time.Time, lastError string) error {
+	_, err := db.Exec(`UPDATE notification_channel SET lastSentAt = ?, lastError = ? WHERE id = ?`,
+		sentAt.Format(time.RFC3339), lastError, id)
+	return err
+}
+
+// GetNotificationChannel 获取通知渠道
+func GetNotificationChannel(id string) (*NotificationChannel, error) {
+	return scanNotificationChannel(db.QueryRow(`SELECT `+notificationChannelColumns+` FROM notification_channel WHERE id = ?`, id))
+}
+
+// ListNotificationChannels 按名称列出通知渠道
+func ListNotificationChannels() ([]NotificationChannel, error) {
+	rows, err := db.Query(`SELECT ` + notificationChannelColumns + ` FROM notification_channel ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []NotificationChannel
+	for rows.Next() {
+		ch, err := scanNotificationChannel(rows)
+		if err != nil {
+			continue
+		}
+		channels = append(channels, *ch)
+	}
+	return channels, nil
+}
+
+// DeleteNotificationChannel 删除通知渠道
+func DeleteNotificationChannel(id string) error {
+	_, err := db.Exec(`DELETE FROM notification_channel WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CertificateRenewal 证书的续期计划，由续期调度器维护
+type CertificateRenewal struct {
+	CertificateID  string     `json:"certificateId"`
+	RenewAt        *time.Time `json:"renewAt"`        // 计划续期时间
+	Basis          string     `json:"basis"`          // 续期时间的依据：ari, custom, lifetime, default
+	ARICheckAt     *time.Time `json:"ariCheckAt"`     // 下次向 CA 查询续期窗口（ARI）的时间
+	ExplanationURL string     `json:"explanationUrl"` // CA 对续期窗口的说明（例如因吊销提前续期）
+	Failures       int        `json:"failures"`       // 连续失败次数
+	RetryAt        *time.Time `json:"retryAt"`        // 失败后下次重试的时间
+	LastAttemptAt  *time.Time `json:"lastAttemptAt"`  // 最近一次续期时间
+	LastError      string     `json:"lastError"`      // 最近一次续期的错误，成功时为空
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// certificateRenewalColumns 续期计划表查询列，顺序与 scanCertificateRenewal 一致
+const certificateRenewalColumns = `certificateId, renewAt, basis, ariCheckAt, explanationUrl, failures, retryAt, lastAttemptAt, lastError, updatedAt`
+
+// scanCertificateRenewal 扫描一行续期计划
+func scanCertificateRenewal(row rowScanner) (*CertificateRenewal, error) {
+	var r CertificateRenewal
+	var renewAt, ariCheckAt, retryAt, lastAttemptAt sql.NullString
+	var updatedAt string
+
+	if err := row.Scan(&r.CertificateID, &renewAt, &r.Basis, &ariCheckAt, &r.ExplanationURL, &r.Failures,
+		&retryAt, &lastAttemptAt, &r.LastError, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	r.RenewAt = parseNullTime(renewAt)
+	r.ARICheckAt = parseNullTime(ariCheckAt)
+	r.RetryAt = parseNullTime(retryAt)
+	r.LastAttemptAt = parseNullTime(lastAttemptAt)
+	r.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &r, nil
+}
+
+// GetCertificateRenewal 获取证书的续期计划，没有记录时返回空计划
+func GetCertificateRenewal(certificateID string) (*CertificateRenewal, error) {
+	r, err := scanCertificateRenewal(db.QueryRow(`SELECT `+certificateRenewalColumns+` FROM certificate_renewal WHERE certificateId = ?`, certificateID))
+	if err == sql.ErrNoRows {
+		return &CertificateRenewal{CertificateID: certificateID}, nil
+	}
+	return r, err
+}
+
+// ListCertificateRenewals 获取所有证书的续期计划，按证书 ID 索引
+func ListCertificateRenewals() (map[string]*CertificateRenewal, error) {
+	rows, err := db.Query(`SELECT ` + certificateRenewalColumns + ` FROM certificate_renewal`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	renewals := make(map[string]*CertificateRenewal)
+	for rows.Next() {
+		r, err := scanCertificateRenewal(rows)
+		if err != nil {
+			continue
+		}
+		renewals[r.CertificateID] = r
+	}
+	return renewals, nil
+}
+
+// SaveCertificateRenewal 保存证书的续期计划
+func SaveCertificateRenewal(r *CertificateRenewal) error {
+	r.UpdatedAt = time.Now()
+
+	_, err := db.Exec(`
+		INSERT INTO certificate_renewal (`+certificateRenewalColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(certificateId) DO UPDATE SET
+			renewAt = excluded.renewAt, basis = excluded.basis, ariCheckAt = excluded.ariCheckAt,
+			explanationUrl = excluded.explanationUrl, failures = excluded.failures, retryAt = excluded.retryAt,
+			lastAttemptAt = excluded.lastAttemptAt, lastError = excluded.lastError, updatedAt = excluded.updatedAt
+	`, r.CertificateID, formatNullTime(r.RenewAt), r.Basis, formatNullTime(r.ARICheckAt), r.ExplanationURL, r.Failures,
+		formatNullTime(r.RetryAt), formatNullTime(r.LastAttemptAt), r.LastError, r.UpdatedAt.Format(time.RFC3339))
+	return err
+}
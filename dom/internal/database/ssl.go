@@ -1,6 +1,9 @@
 package database
 
 import (
+	"database/sql"
+	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -19,25 +22,67 @@ type Certificate struct {
 	ID            string     `json:"id"`
 	Domain        string     `json:"domain"`        // 主域名
 	Domains       string     `json:"domains"`       // 所有域名 (JSON 数组)
-	DNSProviderID string     `json:"dnsProviderId"` // DNS 提供商 ID
+	Source        string     `json:"source"`        // 来源：acme（ACME 签发）, upload（手动上传）, internal（内部 CA 签发）
+	Usage         string     `json:"usage"`         // 用途：server（服务器证书）, client（客户端证书，用于上游 mTLS）
+	DNSProviderID string     `json:"dnsProviderId"` // DNS 提供商 ID（http-01 验证时为空）
+	ChallengeType string     `json:"challengeType"` // 验证方式：dns-01, http-01
+	ACMEDirectory string     `json:"acmeDirectory"` // 签发证书的 ACME 目录地址，为空使用默认目录
+	EABKID        string     `json:"eabKid"`        // External Account Binding key ID
+	EABHMACKey    string     `json:"eabHmacKey"`    // External Account Binding MAC 密钥
+	AccountID     string     `json:"accountId"`     // 申请和续期使用的 ACME 账户
+	KeyType       string     `json:"keyType"`       // 私钥算法：ec256, ec384, rsa2048, rsa3072, rsa4096，为空表示旧版本签发、未记录
+	ReuseKey      bool       `json:"reuseKey"`      // 续期时复用已有私钥
+	Dual          bool       `json:"dual"`          // 同时签发一张 RSA 证书，与 ECDSA 证书一起提供
 	CertPath      string     `json:"certPath"`      // 证书文件路径
 	KeyPath       string     `json:"keyPath"`       // 私钥文件路径
+	RSACertPath   string     `json:"rsaCertPath"`   // 双证书模式下 RSA 证书文件路径
+	RSAKeyPath    string     `json:"rsaKeyPath"`    // 双证书模式下 RSA 私钥文件路径
 	Issuer        string     `json:"issuer"`        // 颁发者
 	NotBefore     time.Time  `json:"notBefore"`     // 生效时间
 	NotAfter      time.Time  `json:"notAfter"`      // 过期时间
 	AutoRenew     bool       `json:"autoRenew"`     // 是否自动续期
+	RenewBefore   int        `json:"renewBefore"`   // 提前续期天数，0 表示使用 CA 建议（ARI）或默认值
 	LastRenewAt   *time.Time `json:"lastRenewAt"`   // 最后续期时间
-	Status        string     `json:"status"`        // pending, active, expired, error
+	Status        string     `json:"status"`        // pending, active, expired, error, revoked
 	Error         *string    `json:"error"`         // 错误信息
 	CreatedAt     time.Time  `json:"createdAt"`
 	UpdatedAt     time.Time  `json:"updatedAt"`
 }
 
+// 证书验证方式
+const (
+	ChallengeDNS01  = "dns-01"
+	ChallengeHTTP01 = "http-01"
+)
+
+// 证书来源
+const (
+	CertSourceACME     = "acme"
+	CertSourceUpload   = "upload"
+	CertSourceInternal = "internal"
+)
+
+// 证书用途
+const (
+	CertUsageServer = "server"
+	CertUsageClient = "client"
+)
+
+// 证书私钥算法
+const (
+	KeyTypeEC256   = "ec256"
+	KeyTypeEC384   = "ec384"
+	KeyTypeRSA2048 = "rsa2048"
+	KeyTypeRSA3072 = "rsa3072"
+	KeyTypeRSA4096 = "rsa4096"
+)
+
 // CertificateLog 证书操作日志
 type CertificateLog struct {
 	ID            string    `json:"id"`
 	CertificateID string    `json:"certificateId"`
-	Action        string    `json:"action"` // create, renew, error
+	JobID         string    `json:"jobId"`  // 申请或续期任务产生的日志
+	Action        string    `json:"action"` // create, renew, error，任务执行中为所处阶段
 	Message       string    `json:"message"`
 	CreatedAt     time.Time `json:"createdAt"`
 }
@@ -119,11 +164,51 @@ func DeleteDNSProvider(id string) error {
 	return err
 }
 
+// certificateColumns 证书表查询列，顺序与 scanCertificate 一致
+const certificateColumns = `id, domain, domains, source, usage, dnsProviderId, challengeType, acmeDirectory, eabKid, eabHmacKey, accountId, keyType, reuseKey, dual, certPath, keyPath, rsaCertPath, rsaKeyPath, issuer, notBefore, notAfter, autoRenew, renewBeforeDays, lastRenewAt, status, error, createdAt, updatedAt`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCertificate 扫描一行证书记录
+func scanCertificate(row rowScanner) (*Certificate, error) {
+	var cert Certificate
+	var notBefore, notAfter, createdAt, updatedAt string
+	var lastRenewAt *string
+
+	if err := row.Scan(&cert.ID, &cert.Domain, &cert.Domains, &cert.Source, &cert.Usage, &cert.DNSProviderID, &cert.ChallengeType, &cert.ACMEDirectory, &cert.EABKID, &cert.EABHMACKey, &cert.AccountID, &cert.KeyType, &cert.ReuseKey, &cert.Dual, &cert.CertPath, &cert.KeyPath, &cert.RSACertPath, &cert.RSAKeyPath, &cert.Issuer,
+		&notBefore, &notAfter, &cert.AutoRenew, &cert.RenewBefore, &lastRenewAt, &cert.Status, &cert.Error, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	cert.NotBefore, _ = time.Parse(time.RFC3339, notBefore)
+	cert.NotAfter, _ = time.Parse(time.RFC3339, notAfter)
+	cert.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	cert.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	if lastRenewAt != nil {
+		t, _ := time.Parse(time.RFC3339, *lastRenewAt)
+		cert.LastRenewAt = &t
+	}
+
+	return &cert, nil
+}
+
 // CreateCertificate 创建证书记录
 func CreateCertificate(cert *Certificate) error {
 	now := time.Now()
 	cert.CreatedAt = now
 	cert.UpdatedAt = now
+	if cert.Source == "" {
+		cert.Source = CertSourceACME
+	}
+	if cert.Usage == "" {
+		cert.Usage = CertUsageServer
+	}
+	if cert.ChallengeType == "" && cert.Source == CertSourceACME {
+		cert.ChallengeType = ChallengeDNS01
+	}
 
 	var lastRenewAt *string
 	if cert.LastRenewAt != nil {
@@ -132,11 +217,11 @@ func CreateCertificate(cert *Certificate) error {
 	}
 
 	_, err := db.Exec(`
-		INSERT INTO certificate (id, domain, domains, dnsProviderId, certPath, keyPath, issuer, notBefore, notAfter, autoRenew, lastRenewAt, status, error, createdAt, updatedAt)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, cert.ID, cert.Domain, cert.Domains, cert.DNSProviderID, cert.CertPath, cert.KeyPath, cert.Issuer,
+		INSERT INTO certificate (`+certificateColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, cert.ID, cert.Domain, cert.Domains, cert.Source, cert.Usage, cert.DNSProviderID, cert.ChallengeType, cert.ACMEDirectory, cert.EABKID, cert.EABHMACKey, cert.AccountID, cert.KeyType, cert.ReuseKey, cert.Dual, cert.CertPath, cert.KeyPath, cert.RSACertPath, cert.RSAKeyPath, cert.Issuer,
 		cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339),
-		cert.AutoRenew, lastRenewAt, cert.Status, cert.Error,
+		cert.AutoRenew, cert.RenewBefore, lastRenewAt, cert.Status, cert.Error,
 		now.Format(time.RFC3339), now.Format(time.RFC3339))
 	return err
 }
@@ -153,116 +238,148 @@ func UpdateCertificate(cert *Certificate) error {
 	}
 
 	_, err := db.Exec(`
-		UPDATE certificate SET domain = ?, domains = ?, dnsProviderId = ?, certPath = ?, keyPath = ?, issuer = ?, notBefore = ?, notAfter = ?, autoRenew = ?, lastRenewAt = ?, status = ?, error = ?, updatedAt = ?
+		UPDATE certificate SET domain = ?, domains = ?, source = ?, usage = ?, dnsProviderId = ?, challengeType = ?, acmeDirectory = ?, eabKid = ?, eabHmacKey = ?, accountId = ?, keyType = ?, reuseKey = ?, dual = ?, certPath = ?, keyPath = ?, rsaCertPath = ?, rsaKeyPath = ?, issuer = ?, notBefore = ?, notAfter = ?, autoRenew = ?, renewBeforeDays = ?, lastRenewAt = ?, status = ?, error = ?, updatedAt = ?
 		WHERE id = ?
-	`, cert.Domain, cert.Domains, cert.DNSProviderID, cert.CertPath, cert.KeyPath, cert.Issuer,
+	`, cert.Domain, cert.Domains, cert.Source, cert.Usage, cert.DNSProviderID, cert.ChallengeType, cert.ACMEDirectory, cert.EABKID, cert.EABHMACKey, cert.AccountID, cert.KeyType, cert.ReuseKey, cert.Dual, cert.CertPath, cert.KeyPath, cert.RSACertPath, cert.RSAKeyPath, cert.Issuer,
 		cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339),
-		cert.AutoRenew, lastRenewAt, cert.Status, cert.Error,
+		cert.AutoRenew, cert.RenewBefore, lastRenewAt, cert.Status, cert.Error,
 		now.Format(time.RFC3339), cert.ID)
 	return err
 }
 
 // GetCertificate 获取证书
 func GetCertificate(id string) (*Certificate, error) {
-	var cert Certificate
-	var notBefore, notAfter, createdAt, updatedAt string
-	var lastRenewAt *string
-
-	err := db.QueryRow(`
-		SELECT id, domain, domains, dnsProviderId, certPath, keyPath, issuer, notBefore, notAfter, autoRenew, lastRenewAt, status, error, createdAt, updatedAt
-		FROM certificate WHERE id = ?
-	`, id).Scan(&cert.ID, &cert.Domain, &cert.Domains, &cert.DNSProviderID, &cert.CertPath, &cert.KeyPath, &cert.Issuer,
-		&notBefore, &notAfter, &cert.AutoRenew, &lastRenewAt, &cert.Status, &cert.Error, &createdAt, &updatedAt)
-	if err != nil {
-		return nil, err
-	}
-
-	cert.NotBefore, _ = time.Parse(time.RFC3339, notBefore)
-	cert.NotAfter, _ = time.Parse(time.RFC3339, notAfter)
-	cert.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-	cert.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-	if lastRenewAt != nil {
-		t, _ := time.Parse(time.RFC3339, *lastRenewAt)
-		cert.LastRenewAt = &t
-	}
-
-	return &cert, nil
+	return scanCertificate(db.QueryRow(`SELECT `+certificateColumns+` FROM certificate WHERE id = ?`, id))
 }
 
-// GetCertificateByDomain 根据域名获取证书
+// GetCertificateByDomain 根据主域名获取证书，有多个时优先返回有效期最长的有效证书
 func GetCertificateByDomain(domain string) (*Certificate, error) {
-	var cert Certificate
-	var notBefore, notAfter, createdAt, updatedAt string
-	var lastRenewAt *string
+	return scanCertificate(db.QueryRow(`
+		SELECT `+certificateColumns+`
+		FROM certificate
+		WHERE domain = ?
+		ORDER BY status = 'active' DESC, notAfter DESC
+		LIMIT 1
+	`, domain))
+}
 
-	err := db.QueryRow(`
-		SELECT id, domain, domains, dnsProviderId, certPath, keyPath, issuer, notBefore, notAfter, autoRenew, lastRenewAt, status, error, createdAt, updatedAt
-		FROM certificate WHERE domain = ?
-	`, domain).Scan(&cert.ID, &cert.Domain, &cert.Domains, &cert.DNSProviderID, &cert.CertPath, &cert.KeyPath, &cert.Issuer,
-		&notBefore, &notAfter, &cert.AutoRenew, &lastRenewAt, &cert.Status, &cert.Error, &createdAt, &updatedAt)
+// ListCertificatesByDomain 获取主域名相同的所有证书
+func ListCertificatesByDomain(domain string) ([]Certificate, error) {
+	return queryCertificates(`SELECT `+certificateColumns+` FROM certificate WHERE domain = ? ORDER BY createdAt DESC`, domain)
+}
+
+// FindCertificateForName 查找覆盖站点域名的有效服务器证书，没有时返回 sql.ErrNoRows
+// serverName 可以包含多个以空格分隔的域名，需要全部覆盖；精确匹配的证书优先于通配符证书，其次选择过期时间最晚的
+func FindCertificateForName(serverName string) (*Certificate, error) {
+	certs, err := queryCertificates(`
+		SELECT `+certificateColumns+`
+		FROM certificate
+		WHERE status = 'active' AND usage = ?
+		ORDER BY notAfter DESC
+	`, CertUsageServer)
 	if err != nil {
 		return nil, err
 	}
 
-	cert.NotBefore, _ = time.Parse(time.RFC3339, notBefore)
-	cert.NotAfter, _ = time.Parse(time.RFC3339, notAfter)
-	cert.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-	cert.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-	if lastRenewAt != nil {
-		t, _ := time.Parse(time.RFC3339, *lastRenewAt)
-		cert.LastRenewAt = &t
+	var wildcard *Certificate
+	for i := range certs {
+		exact, ok := certificateMatch(&certs[i], serverName)
+		if !ok {
+			continue
+		}
+		if exact {
+			return &certs[i], nil
+		}
+		if wildcard == nil {
+			wildcard = &certs[i]
+		}
+	}
+	if wildcard == nil {
+		return nil, sql.ErrNoRows
 	}
+	return wildcard, nil
+}
 
-	return &cert, nil
+// CertificateCovers 证书的域名是否覆盖站点的全部域名
+func CertificateCovers(cert *Certificate, serverName string) bool {
+	_, ok := certificateMatch(cert, serverName)
+	return ok
 }
 
-// ListCertificates 获取所有证书
-func ListCertificates() ([]Certificate, error) {
-	rows, err := db.Query(`
-		SELECT id, domain, domains, dnsProviderId, certPath, keyPath, issuer, notBefore, notAfter, autoRenew, lastRenewAt, status, error, createdAt, updatedAt
-		FROM certificate ORDER BY createdAt DESC
-	`)
-	if err != nil {
-		return nil, err
+// certificateMatch 检查证书是否覆盖 serverName 中的每个域名，exact 表示全部为精确匹配
+// 通配符只匹配一级子域名，例如 *.example.com 覆盖 a.example.com，不覆盖 example.com 和 a.b.example.com
+func certificateMatch(cert *Certificate, serverName string) (exact, ok bool) {
+	var domains []string
+	if err := json.Unmarshal([]byte(cert.Domains), &domains); err != nil || len(domains) == 0 {
+		domains = []string{cert.Domain}
+	}
+	names := strings.Fields(strings.ToLower(serverName))
+	if len(names) == 0 {
+		return false, false
 	}
-	defer rows.Close()
-
-	var certs []Certificate
-	for rows.Next() {
-		var cert Certificate
-		var notBefore, notAfter, createdAt, updatedAt string
-		var lastRenewAt *string
 
-		if err := rows.Scan(&cert.ID, &cert.Domain, &cert.Domains, &cert.DNSProviderID, &cert.CertPath, &cert.KeyPath, &cert.Issuer,
-			&notBefore, &notAfter, &cert.AutoRenew, &lastRenewAt, &cert.Status, &cert.Error, &createdAt, &updatedAt); err != nil {
-			continue
+	exact = true
+	for _, name := range names {
+		matched, wildcardOnly := false, true
+		for _, d := range domains {
+			d = strings.ToLower(d)
+			if d == name {
+				matched, wildcardOnly = true, false
+				break
+			}
+			if strings.HasPrefix(d, "*.") && !strings.HasPrefix(name, "*.") {
+				if i := strings.Index(name, "."); i > 0 && name[i+1:] == d[2:] {
+					matched = true
+				}
+			}
 		}
-
-		cert.NotBefore, _ = time.Parse(time.RFC3339, notBefore)
-		cert.NotAfter, _ = time.Parse(time.RFC3339, notAfter)
-		cert.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		cert.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-		if lastRenewAt != nil {
-			t, _ := time.Parse(time.RFC3339, *lastRenewAt)
-			cert.LastRenewAt = &t
+		if !matched {
+			return false, false
+		}
+		if wildcardOnly {
+			exact = false
 		}
-
-		certs = append(certs, cert)
 	}
+	return exact, true
+}
 
-	return certs, nil
+// CertificatePathInUse 证书文件路径是否已被其他证书使用
+func CertificatePathInUse(certPath string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM certificate WHERE certPath = ? OR rsaCertPath = ?`, certPath, certPath).Scan(&count)
+	return count > 0, err
+}
+
+// ListCertificates 获取所有证书
+func ListCertificates() ([]Certificate, error) {
+	return queryCertificates(`SELECT ` + certificateColumns + ` FROM certificate ORDER BY createdAt DESC`)
 }
 
-// ListCertificatesExpiringSoon 获取即将过期的证书
-func ListCertificatesExpiringSoon(days int) ([]Certificate, error) {
+// ListAutoRenewCertificates 获取需要自动续期的证书（包括上次续期失败的证书）
+func ListAutoRenewCertificates() ([]Certificate, error) {
+	return queryCertificates(`
+		SELECT `+certificateColumns+`
+		FROM certificate
+		WHERE source != ? AND status IN ('active', 'error') AND autoRenew = 1
+		ORDER BY notAfter ASC
+	`, CertSourceUpload)
+}
+
+// ListManualCertificatesExpiringSoon 获取即将过期且不会自动续期的证书（上传的证书和关闭了自动续期的证书，只做提醒）
+func ListManualCertificatesExpiringSoon(days int) ([]Certificate, error) {
 	threshold := time.Now().AddDate(0, 0, days)
 
-	rows, err := db.Query(`
-		SELECT id, domain, domains, dnsProviderId, certPath, keyPath, issuer, notBefore, notAfter, autoRenew, lastRenewAt, status, error, createdAt, updatedAt
-		FROM certificate 
-		WHERE status = 'active' AND autoRenew = 1 AND notAfter <= ?
+	return queryCertificates(`
+		SELECT `+certificateColumns+`
+		FROM certificate
+		WHERE (source = ? OR autoRenew = 0) AND status IN ('active', 'expired') AND notAfter <= ?
 		ORDER BY notAfter ASC
-	`, threshold.Format(time.RFC3339))
+	`, CertSourceUpload, threshold.Format(time.RFC3339))
+}
+
+// queryCertificates 查询证书列表
+func queryCertificates(query string, args ...interface{}) ([]Certificate, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -270,34 +387,42 @@ func ListCertificatesExpiringSoon(days int) ([]Certificate, error) {
 
 	var certs []Certificate
 	for rows.Next() {
-		var cert Certificate
-		var notBefore, notAfter, createdAt, updatedAt string
-		var lastRenewAt *string
-
-		if err := rows.Scan(&cert.ID, &cert.Domain, &cert.Domains, &cert.DNSProviderID, &cert.CertPath, &cert.KeyPath, &cert.Issuer,
-			&notBefore, &notAfter, &cert.AutoRenew, &lastRenewAt, &cert.Status, &cert.Error, &createdAt, &updatedAt); err != nil {
+		cert, err := scanCertificate(rows)
+		if err != nil {
 			continue
 		}
-
-		cert.NotBefore, _ = time.Parse(time.RFC3339, notBefore)
-		cert.NotAfter, _ = time.Parse(time.RFC3339, notAfter)
-		cert.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		cert.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-		if lastRenewAt != nil {
-			t, _ := time.Parse(time.RFC3339, *lastRenewAt)
-			cert.LastRenewAt = &t
-		}
-
-		certs = append(certs, cert)
+		certs = append(certs, *cert)
 	}
 
 	return certs, nil
 }
 
-// DeleteCertificate 删除证书
+// certificateDependentTables 以 certificateId 引用证书的表
+// 连接未开启 foreign_keys，ON DELETE CASCADE 不会生效，删除证书时需要逐表删除
+var certificateDependentTables = []string{
+	"certificate_hook",
+	"certificate_renewal",
+	"certificate_log",
+	"certificate_job",
+}
+
+// DeleteCertificate 删除证书及其部署钩子、续期计划、日志和任务记录
 func DeleteCertificate(id string) error {
-	_, err := db.Exec(`DELETE FROM certificate WHERE id = ?`, id)
-	return err
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range certificateDependentTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE certificateId = ?`, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM certificate WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // CreateCertificateLog 创建证书日志
@@ -306,19 +431,29 @@ func CreateCertificateLog(logEntry *CertificateLog) error {
 	logEntry.CreatedAt = now
 
 	_, err := db.Exec(`
-		INSERT INTO certificate_log (id, certificateId, action, message, createdAt)
-		VALUES (?, ?, ?, ?, ?)
-	`, logEntry.ID, logEntry.CertificateID, logEntry.Action, logEntry.Message, now.Format(time.RFC3339))
+		INSERT INTO certificate_log (id, certificateId, jobId, action, message, createdAt)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, logEntry.ID, logEntry.CertificateID, logEntry.JobID, logEntry.Action, logEntry.Message, now.Format(time.RFC3339))
 	return err
 }
 
+// HasCertificateLogSince 证书在指定时间之后是否有该操作的日志
+func HasCertificateLogSince(certificateID, action string, since time.Time) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM certificate_log
+		WHERE certificateId = ? AND action = ? AND createdAt >= ?
+	`, certificateID, action, since.Format(time.RFC3339)).Scan(&count)
+	return count > 0, err
+}
+
 // GetCertificateLogs 获取证书日志
 func GetCertificateLogs(certificateID string, limit int) ([]CertificateLog, error) {
 	rows, err := db.Query(`
-		SELECT id, certificateId, action, message, createdAt
+		SELECT id, certificateId, jobId, action, message, createdAt
 		FROM certificate_log 
 		WHERE certificateId = ?
-		ORDER BY createdAt DESC
+		ORDER BY createdAt DESC, rowid DESC
 		LIMIT ?
 	`, certificateID, limit)
 	if err != nil {
@@ -331,7 +466,7 @@ func GetCertificateLogs(certificateID string, limit int) ([]CertificateLog, erro
 		var logEntry CertificateLog
 		var createdAt string
 
-		if err := rows.Scan(&logEntry.ID, &logEntry.CertificateID, &logEntry.Action, &logEntry.Message, &createdAt); err != nil {
+		if err := rows.Scan(&logEntry.ID, &logEntry.CertificateID, &logEntry.JobID, &logEntry.Action, &logEntry.Message, &createdAt); err != nil {
 			continue
 		}
 
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hop/backend/internal/config"
+)
+
+// useTestDB 在临时数据目录中初始化数据库，测试结束后关闭
+func useTestDB(t *testing.T) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Data.Dir
+	cfg.Data.Dir = t.TempDir()
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		Close()
+		cfg.Data.Dir = original
+	})
+}
+
+// createTestCertificate 写入证书及其钩子、续期计划、日志和任务记录
+func createTestCertificate(t *testing.T, id string) {
+	t.Helper()
+	now := time.Now()
+	steps := []func() error{
+		func() error {
+			return CreateCertificate(&Certificate{
+				ID: id, Domain: id + ".example.com", Domains: `["` + id + `.example.com"]`,
+				CertPath: "nginx/ssl/" + id + ".crt", KeyPath: "nginx/ssl/" + id + ".key",
+				NotBefore: now, NotAfter: now.AddDate(0, 0, 90), Status: "active",
+			})
+		},
+		func() error {
+			return CreateCertificateHook(&CertificateHook{ID: id + "-hook", CertificateID: id, Type: "command", Command: "true"})
+		},
+		func() error {
+			return SaveCertificateRenewal(&CertificateRenewal{CertificateID: id, RenewAt: &now})
+		},
+		func() error {
+			return CreateCertificateJob(&CertificateJob{ID: id + "-job", Type: "renew", CertificateID: id, Domain: id, Status: JobSucceeded})
+		},
+		func() error {
+			return CreateCertificateLog(&CertificateLog{ID: id + "-log", CertificateID: id, JobID: id + "-job", Action: "renew", Message: "ok"})
+		},
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func countRows(t *testing.T, table, certID string) int {
+	t.Helper()
+	column := "certificateId"
+	if table == "certificate" {
+		column = "id"
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE `+column+` = ?`, certID).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestDeleteCertificateRemovesDependents(t *testing.T) {
+	useTestDB(t)
+	createTestCertificate(t, "old")
+	createTestCertificate(t, "kept")
+
+	if err := DeleteCertificate("old"); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := append([]string{"certificate"}, certificateDependentTables...)
+	for _, table := range tables {
+		if n := countRows(t, table, "old"); n != 0 {
+			t.Errorf("%s 中残留 %d 行", table, n)
+		}
+		// 其他证书的记录不受影响
+		if n := countRows(t, table, "kept"); n != 1 {
+			t.Errorf("%s 中 kept 的记录 = %d, want 1", table, n)
+		}
+	}
+}
+
+func TestCertificateDependentTablesComplete(t *testing.T) {
+	useTestDB(t)
+
+	// 新增引用证书的表时需要加入 certificateDependentTables
+	rows, err := db.Query(`SELECT m.name FROM sqlite_master m, pragma_table_info(m.name) p
+		WHERE m.type = 'table' AND p.name = 'certificateId'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	known := map[string]bool{}
+	for _, table := range certificateDependentTables {
+		known[table] = true
+	}
+	for rows.Next() {
+		var table string
+		rows.Scan(&table)
+		if !known[table] {
+			t.Errorf("%s 引用证书，但删除证书时不会清理", table)
+		}
+	}
+}
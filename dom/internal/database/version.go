@@ -0,0 +1,202 @@
+package database
+
+import (
+	"time"
+)
+
+// ConfigVersion 配置历史版本（一次变更对应一个版本）
+type ConfigVersion struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"number"`  // 递增版本号
+	Author    string    `json:"author"`  // 变更人
+	Message   string    `json:"message"` // 变更说明
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ConfigVersionFile 版本中的单个文件快照
+type ConfigVersionFile struct {
+	ID        string `json:"id"`
+	VersionID string `json:"versionId"`
+	Path      string `json:"path"`    // 相对于 nginx 目录的路径
+	Content   string `json:"content"` // 文件内容（删除时为空）
+	Deleted   bool   `json:"deleted"` // 是否为删除操作
+}
+
+// CreateConfigVersion 创建配置版本及其文件快照
+func CreateConfigVersion(version *ConfigVersion, files []ConfigVersionFile) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(number), 0) + 1 FROM config_version`).Scan(&version.Number); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	version.CreatedAt = now
+
+	if _, err := tx.Exec(`
+		INSERT INTO config_version (id, number, author, message, createdAt)
+		VALUES (?, ?, ?, ?, ?)
+	`, version.ID, version.Number, version.Author, version.Message, now.Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	for i := range files {
+		files[i].VersionID = version.ID
+		if _, err := tx.Exec(`
+			INSERT INTO config_version_file (id, versionId, path, content, deleted)
+			VALUES (?, ?, ?, ?, ?)
+		`, files[i].ID, files[i].VersionID, files[i].Path, files[i].Content, files[i].Deleted); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetConfigVersion 获取配置版本
+func GetConfigVersion(id string) (*ConfigVersion, error) {
+	var version ConfigVersion
+	var createdAt string
+
+	err := db.QueryRow(`
+		SELECT id, number, author, message, createdAt
+		FROM config_version WHERE id = ?
+	`, id).Scan(&version.ID, &version.Number, &version.Author, &version.Message, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	version.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &version, nil
+}
+
+// GetConfigVersionByNumber 根据版本号获取配置版本
+func GetConfigVersionByNumber(number int) (*ConfigVersion, error) {
+	var version ConfigVersion
+	var createdAt string
+
+	err := db.QueryRow(`
+		SELECT id, number, author, message, createdAt
+		FROM config_version WHERE number = ?
+	`, number).Scan(&version.ID, &version.Number, &version.Author, &version.Message, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	version.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &version, nil
+}
+
+// ListConfigVersions 获取配置版本列表（按版本号倒序）
+func ListConfigVersions(limit, offset int) ([]ConfigVersion, error) {
+	rows, err := db.Query(`
+		SELECT id, number, author, message, createdAt
+		FROM config_version ORDER BY number DESC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []ConfigVersion
+	for rows.Next() {
+		var version ConfigVersion
+		var createdAt string
+
+		if err := rows.Scan(&version.ID, &version.Number, &version.Author, &version.Message, &createdAt); err != nil {
+			continue
+		}
+
+		version.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// CountConfigVersions 获取配置版本总数
+func CountConfigVersions() (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM config_version`).Scan(&count)
+	return count, err
+}
+
+// ListConfigVersionFiles 获取某个版本变更的文件
+func ListConfigVersionFiles(versionID string) ([]ConfigVersionFile, error) {
+	rows, err := db.Query(`
+		SELECT id, versionId, path, content, deleted
+		FROM config_version_file WHERE versionId = ?
+		ORDER BY path ASC
+	`, versionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []ConfigVersionFile
+	for rows.Next() {
+		var file ConfigVersionFile
+		if err := rows.Scan(&file.ID, &file.VersionID, &file.Path, &file.Content, &file.Deleted); err != nil {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// GetConfigTreeAt 获取指定版本号时所有文件的状态
+// 每个路径返回不晚于该版本的最后一次快照（包括删除标记）
+func GetConfigTreeAt(number int) ([]ConfigVersionFile, error) {
+	rows, err := db.Query(`
+		SELECT f.id, f.versionId, f.path, f.content, f.deleted
+		FROM config_version_file f
+		JOIN config_version v ON f.versionId = v.id
+		WHERE v.number = (
+			SELECT MAX(v2.number)
+			FROM config_version_file f2
+			JOIN config_version v2 ON f2.versionId = v2.id
+			WHERE f2.path = f.path AND v2.number <= ?
+		)
+		ORDER BY f.path ASC
+	`, number)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []ConfigVersionFile
+	for rows.Next() {
+		var file ConfigVersionFile
+		if err := rows.Scan(&file.ID, &file.VersionID, &file.Path, &file.Content, &file.Deleted); err != nil {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// GetLatestConfigFile 获取某个路径最后一次记录的快照
+func GetLatestConfigFile(path string) (*ConfigVersionFile, error) {
+	var file ConfigVersionFile
+
+	err := db.QueryRow(`
+		SELECT f.id, f.versionId, f.path, f.content, f.deleted
+		FROM config_version_file f
+		JOIN config_version v ON f.versionId = v.id
+		WHERE f.path = ?
+		ORDER BY v.number DESC
+		LIMIT 1
+	`, path).Scan(&file.ID, &file.VersionID, &file.Path, &file.Content, &file.Deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
@@ -0,0 +1,212 @@
+package nginx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines unified diff 中每个变更块前后保留的上下文行数
+const diffContextLines = 3
+
+// diffOp 行级编辑操作
+type diffOp struct {
+	kind byte // ' ' 相同, '-' 删除, '+' 新增
+	line string
+}
+
+// UnifiedDiff 生成两个文本之间的 unified diff
+// 内容相同时返回空字符串
+func UnifiedDiff(oldName, newName, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldContent), splitLines(newContent))
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n", oldName)
+	fmt.Fprintf(&buf, "+++ %s\n", newName)
+
+	// 按上下文行数切分变更块
+	i := 0
+	for i < len(ops) {
+		// 找到下一个变更
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i >= len(ops) {
+			break
+		}
+
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// 向后扩展，直到连续相同行超过两倍上下文
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContextLines {
+				end += min(run-end, diffContextLines)
+				break
+			}
+			end = run
+		}
+
+		writeHunk(&buf, ops, start, end)
+		i = end
+	}
+
+	return buf.String()
+}
+
+// writeHunk 输出一个变更块
+func writeHunk(buf *strings.Builder, ops []diffOp, start, end int) {
+	// 计算变更块在新旧文件中的起始行号
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	// 与 GNU diff 保持一致：长度为 0 时起始行号为前一行
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+	for _, op := range ops[start:end] {
+		buf.WriteByte(op.kind)
+		buf.WriteString(op.line)
+		buf.WriteByte('\n')
+	}
+}
+
+func hunkRange(line, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines 按行切分文本（末尾换行不产生空行）
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines 使用 Myers 算法计算行级最短编辑脚本
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	total := n + m
+	if total == 0 {
+		return nil
+	}
+
+	offset := total
+	v := make([]int, 2*total+2)
+	var trace [][]int
+
+	for d := 0; d <= total; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset, d)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrack 根据 Myers 搜索轨迹回溯出编辑操作
+func backtrack(trace [][]int, a, b []string, offset, depth int) []diffOp {
+	x, y := len(a), len(b)
+	var ops []diffOp
+
+	for d := depth; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{kind: ' ', line: a[x]})
+		}
+
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{kind: '+', line: b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{kind: '-', line: a[x]})
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{kind: ' ', line: a[x]})
+	}
+
+	// 反转为正序
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,534 @@
+package nginx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/auth"
+	"github.com/hop/backend/internal/database"
+)
+
+// changeMessageHeader 请求头中携带的变更说明
+const changeMessageHeader = "X-Hop-Change-Message"
+
+// Change 一次配置变更的来源信息，每次变更会记录为一个历史版本
+type Change struct {
+	Author  string // 变更人
+	Message string // 变更说明
+}
+
+// SystemChange 由系统自动触发的变更
+func SystemChange(message string) Change {
+	return Change{Author: "system", Message: message}
+}
+
+// fileChange 单个文件的写入或删除
+type fileChange struct {
+	Path    string // 绝对路径
+	Content []byte
+	Deleted bool
+}
+
+// VersionResponse 历史版本响应
+type VersionResponse struct {
+	ID        string   `json:"id"`
+	Number    int      `json:"number"`
+	Author    string   `json:"author"`
+	Message   string   `json:"message"`
+	Files     []string `json:"files"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// ChangeFromRequest 从请求中提取变更人和变更说明
+// 变更说明优先使用请求头 X-Hop-Change-Message，否则使用默认说明
+func ChangeFromRequest(r *http.Request, message string) Change {
+	author := "anonymous"
+	if user, err := auth.GetCurrentUser(r); err == nil && user != nil {
+		author = user.Email
+	}
+	if custom := strings.TrimSpace(r.Header.Get(changeMessageHeader)); custom != "" {
+		message = custom
+	}
+	return Change{Author: author, Message: message}
+}
+
+// applyFileChanges 写入/删除文件，并将变更记录为一个历史版本
+func applyFileChanges(change Change, files []fileChange) error {
+	if err := writeFileChanges(files); err != nil {
+		return err
+	}
+	if err := recordVersion(change, files); err != nil {
+		return fmt.Errorf("文件已写入，但%w", err)
+	}
+	return nil
+}
+
+// writeFileChanges 仅落盘，不记录历史
+func writeFileChanges(files []fileChange) error {
+	for _, f := range files {
+		if f.Deleted {
+			if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.Path, f.Content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyPath 将绝对路径转换为历史记录中的相对路径
+// 不在 nginx 目录内或位于 SSL 目录（证书私钥由 SSL 模块管理）的文件不记录历史
+func historyPath(absPath string) (string, bool) {
+	paths := GetNginxPaths()
+	cleaned := filepath.Clean(absPath)
+
+	if cleaned == paths.SSLDir || strings.HasPrefix(cleaned, paths.SSLDir+string(filepath.Separator)) {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(paths.BaseDir, cleaned)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// recordVersion 记录历史版本（内容未变化的文件会被忽略）
+func recordVersion(change Change, files []fileChange) error {
+	if database.GetDB() == nil {
+		return nil
+	}
+
+	var snapshots []database.ConfigVersionFile
+	for _, f := range files {
+		rel, ok := historyPath(f.Path)
+		if !ok {
+			continue
+		}
+
+		// 与上一次记录比较，避免产生空版本
+		last, err := database.GetLatestConfigFile(rel)
+		if err == nil && last.Deleted == f.Deleted && last.Content == string(f.Content) {
+			continue
+		}
+		if err != nil && f.Deleted {
+			// 从未记录过的文件被删除，无需记录
+			continue
+		}
+
+		content := string(f.Content)
+		if f.Deleted {
+			content = ""
+		}
+		snapshots = append(snapshots, database.ConfigVersionFile{
+			ID:      uuid.New().String(),
+			Path:    rel,
+			Content: content,
+			Deleted: f.Deleted,
+		})
+	}
+
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	if change.Author == "" {
+		change.Author = "system"
+	}
+	if change.Message == "" {
+		change.Message = "更新配置"
+	}
+
+	version := &database.ConfigVersion{
+		ID:      uuid.New().String(),
+		Author:  change.Author,
+		Message: change.Message,
+	}
+	if err := database.CreateConfigVersion(version, snapshots); err != nil {
+		return fmt.Errorf("记录配置历史失败: %w", err)
+	}
+
+	log.Info("配置历史已记录", map[string]interface{}{
+		"version": version.Number,
+		"author":  version.Author,
+		"files":   len(snapshots),
+	})
+	return nil
+}
+
+// ensureHistoryBaseline 首次启用历史记录时，为已有文件创建初始快照
+func ensureHistoryBaseline() error {
+	if database.GetDB() == nil {
+		return nil
+	}
+
+	count, err := database.CountConfigVersions()
+	if err != nil {
+		return fmt.Errorf("读取配置历史失败: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	files, err := collectTrackedFiles()
+	if err != nil {
+		return err
+	}
+
+	return recordVersion(SystemChange("初始快照"), files)
+}
+
+// collectTrackedFiles 读取 nginx 目录下所有需要记录历史的文件
+func collectTrackedFiles() ([]fileChange, error) {
+	paths := GetNginxPaths()
+	var files []fileChange
+
+	err := filepath.WalkDir(paths.BaseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == paths.SSLDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := historyPath(path); !ok {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, fileChange{Path: path, Content: content})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取配置目录失败: %w", err)
+	}
+
+	return files, nil
+}
+
+// recordUntrackedFiles 将 nginx 目录中历史没有记录的文件记录为一个版本
+func recordUntrackedFiles() error {
+	files, err := collectTrackedFiles()
+	if err != nil {
+		return err
+	}
+
+	var untracked []fileChange
+	var names []string
+	for _, f := range files {
+		rel, _ := historyPath(f.Path)
+		if _, err := database.GetLatestConfigFile(rel); err == nil {
+			continue
+		}
+		untracked = append(untracked, f)
+		names = append(names, rel)
+	}
+	if len(untracked) == 0 {
+		return nil
+	}
+
+	log.Warn("发现历史中未记录的文件", map[string]interface{}{"files": names})
+	return recordVersion(SystemChange("记录未跟踪的文件"), untracked)
+}
+
+// versionTree 获取指定版本时的文件树（相对路径 -> 内容，不包含已删除文件）
+// number 为 0 表示第一个版本之前的空树
+func versionTree(number int) (map[string]string, error) {
+	tree := map[string]string{}
+	if number <= 0 {
+		return tree, nil
+	}
+
+	files, err := database.GetConfigTreeAt(number)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if !f.Deleted {
+			tree[f.Path] = f.Content
+		}
+	}
+	return tree, nil
+}
+
+// DiffVersions 生成两个版本之间的 unified diff
+// fromNumber 为 0 表示与空树比较；pathFilter 非空时只比较该文件
+func DiffVersions(fromNumber, toNumber int, pathFilter string) (string, error) {
+	from, err := versionTree(fromNumber)
+	if err != nil {
+		return "", fmt.Errorf("读取版本 #%d 失败: %w", fromNumber, err)
+	}
+	to, err := versionTree(toNumber)
+	if err != nil {
+		return "", fmt.Errorf("读取版本 #%d 失败: %w", toNumber, err)
+	}
+
+	return diffTrees(from, to, pathFilter), nil
+}
+
+// diffTrees 比较两棵文件树，按路径顺序输出 unified diff
+func diffTrees(from, to map[string]string, pathFilter string) string {
+	pathSet := map[string]bool{}
+	for p := range from {
+		pathSet[p] = true
+	}
+	for p := range to {
+		pathSet[p] = true
+	}
+
+	var allPaths []string
+	for p := range pathSet {
+		if pathFilter == "" || p == pathFilter {
+			allPaths = append(allPaths, p)
+		}
+	}
+	sort.Strings(allPaths)
+
+	var buf strings.Builder
+	for _, p := range allPaths {
+		oldContent, oldOK := from[p]
+		newContent, newOK := to[p]
+
+		oldName, newName := "a/"+p, "b/"+p
+		if !oldOK {
+			oldName = "/dev/null"
+		}
+		if !newOK {
+			newName = "/dev/null"
+		}
+
+		buf.WriteString(UnifiedDiff(oldName, newName, oldContent, newContent))
+	}
+	return buf.String()
+}
+
+// RestoreVersion 将 nginx 目录恢复到指定版本
+// 恢复后执行 nginx -t 校验，校验失败则回滚到恢复前的状态
+func RestoreVersion(versionID string, change Change) (*database.ConfigVersion, error) {
+	version, err := database.GetConfigVersion(versionID)
+	if err != nil {
+		return nil, fmt.Errorf("版本不存在")
+	}
+
+	target, err := versionTree(version.Number)
+	if err != nil {
+		return nil, fmt.Errorf("读取版本 #%d 失败: %w", version.Number, err)
+	}
+
+	// 在 hop 之外创建、历史中没有记录的文件不属于目标版本，先记录当前内容以便找回，恢复时一并删除
+	if err := recordUntrackedFiles(); err != nil {
+		return nil, err
+	}
+
+	// 历史中出现过的所有路径都需要处理（目标版本中不存在的文件将被删除）
+	latest, err := database.GetConfigTreeAt(math.MaxInt)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置历史失败: %w", err)
+	}
+
+	paths := GetNginxPaths()
+	var changes, rollback []fileChange
+	for _, f := range latest {
+		absPath := filepath.Join(paths.BaseDir, filepath.FromSlash(f.Path))
+		current, readErr := os.ReadFile(absPath)
+		exists := readErr == nil
+
+		content, want := target[f.Path]
+		switch {
+		case want && (!exists || string(current) != content):
+			changes = append(changes, fileChange{Path: absPath, Content: []byte(content)})
+		case !want && exists:
+			changes = append(changes, fileChange{Path: absPath, Deleted: true})
+		default:
+			continue
+		}
+
+		rollback = append(rollback, fileChange{Path: absPath, Content: current, Deleted: !exists})
+	}
+
+	if len(changes) == 0 {
+		return version, nil
+	}
+
+	if err := writeFileChanges(changes); err != nil {
+		writeFileChanges(rollback)
+		return nil, err
+	}
+
+	// 未安装 nginx 时无法校验，直接保留恢复结果
+	if output, err := TestConfig(); err != nil && !errors.Is(err, exec.ErrNotFound) {
+		if rbErr := writeFileChanges(rollback); rbErr != nil {
+			log.Error("回滚配置失败", map[string]interface{}{"error": rbErr.Error()})
+		}
+		return nil, fmt.Errorf("nginx 配置校验失败，已回滚: %s", strings.TrimSpace(output))
+	}
+
+	if change.Message == "" {
+		change.Message = fmt.Sprintf("恢复到版本 #%d", version.Number)
+	}
+	if err := recordVersion(change, changes); err != nil {
+		return nil, fmt.Errorf("配置已恢复，但%w", err)
+	}
+
+	log.Info("配置已恢复", map[string]interface{}{"version": version.Number, "files": len(changes)})
+	return version, nil
+}
+
+// ===== HTTP Handlers =====
+
+// handleListVersions 列出历史版本
+func handleListVersions(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	versions, err := database.ListConfigVersions(limit, offset)
+	if err != nil {
+		jsonError(w, "获取历史版本失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, _ := database.CountConfigVersions()
+
+	response := make([]VersionResponse, 0, len(versions))
+	for _, v := range versions {
+		response = append(response, versionToResponse(&v))
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"versions": response,
+		"total":    total,
+	})
+}
+
+// handleGetVersion 获取单个版本（包含变更文件内容）
+func handleGetVersion(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		jsonError(w, "缺少版本ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := database.GetConfigVersion(id)
+	if err != nil {
+		jsonError(w, "版本不存在", http.StatusNotFound)
+		return
+	}
+
+	files, err := database.ListConfigVersionFiles(version.ID)
+	if err != nil {
+		jsonError(w, "读取版本文件失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"version": versionToResponse(version),
+		"files":   files,
+	})
+}
+
+// handleDiffVersions 比较两个版本
+// from 为空时与 to 的上一个版本比较
+func handleDiffVersions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	to, err := database.GetConfigVersion(query.Get("to"))
+	if err != nil {
+		jsonError(w, "目标版本不存在", http.StatusNotFound)
+		return
+	}
+
+	fromNumber := to.Number - 1
+	if fromID := query.Get("from"); fromID != "" {
+		from, err := database.GetConfigVersion(fromID)
+		if err != nil {
+			jsonError(w, "起始版本不存在", http.StatusNotFound)
+			return
+		}
+		fromNumber = from.Number
+	}
+
+	diff, err := DiffVersions(fromNumber, to.Number, query.Get("path"))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"from": fromNumber,
+		"to":   to.Number,
+		"diff": diff,
+	})
+}
+
+// handleRestoreVersion 恢复到指定版本
+func handleRestoreVersion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	if req.ID == "" {
+		jsonError(w, "缺少版本ID", http.StatusBadRequest)
+		return
+	}
+
+	change := ChangeFromRequest(r, req.Message)
+	version, err := RestoreVersion(req.ID, change)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"number":  version.Number,
+	})
+}
+
+func versionToResponse(v *database.ConfigVersion) VersionResponse {
+	files := []string{}
+	if versionFiles, err := database.ListConfigVersionFiles(v.ID); err == nil {
+		for _, f := range versionFiles {
+			files = append(files, f.Path)
+		}
+	}
+
+	return VersionResponse{
+		ID:        v.ID,
+		Number:    v.Number,
+		Author:    v.Author,
+		Message:   v.Message,
+		Files:     files,
+		CreatedAt: v.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
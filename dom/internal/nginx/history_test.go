@@ -0,0 +1,269 @@
+package nginx
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hop/backend/internal/database"
+)
+
+// useHistory 初始化数据库和 nginx.conf，nginxScript 非空时作为 PATH 中的 nginx，否则 PATH 中没有 nginx
+func useHistory(t *testing.T, nginxScript string) NginxPaths {
+	t.Helper()
+	paths := useTestDB(t)
+	bin := t.TempDir()
+	if nginxScript != "" {
+		if err := os.WriteFile(filepath.Join(bin, "nginx"), []byte("#!/bin/sh\n"+nginxScript+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+	if err := InitNginxConfig(); err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+// latestVersion 最新的版本及其文件（按路径排序）
+func latestVersion(t *testing.T) (database.ConfigVersion, []string) {
+	t.Helper()
+	versions, err := database.ListConfigVersions(1, 0)
+	if err != nil || len(versions) == 0 {
+		t.Fatalf("读取版本失败: %v", err)
+	}
+	files, err := database.ListConfigVersionFiles(versions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		name := f.Path
+		if f.Deleted {
+			name = "-" + name
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return versions[0], names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "<missing>"
+	}
+	return string(data)
+}
+
+func TestStreamRouteChangesRecordOneVersion(t *testing.T) {
+	useHistory(t, "")
+	route := StreamRoute{ID: "git", Name: "git", Domain: "git.example.com", Backend: "127.0.0.1:2222", Enabled: true}
+
+	for _, tc := range []struct {
+		name  string
+		run   func(Change) error
+		files []string
+	}{
+		{
+			name:  "保存",
+			run:   func(c Change) error { return SaveStreamRoute(route, c) },
+			files: []string{"nginx.conf", "stream/.git.json"},
+		},
+		{
+			name: "切换",
+			run: func(c Change) error {
+				_, err := ToggleStreamRoute(route.ID, c)
+				return err
+			},
+			files: []string{"nginx.conf", "stream/.git.json"},
+		},
+		{
+			name:  "删除",
+			run:   func(c Change) error { return DeleteStreamRoute(route.ID, c) },
+			files: []string{"-stream/.git.json"},
+		},
+	} {
+		before, _ := database.CountConfigVersions()
+		if err := tc.run(Change{Author: "tester", Message: tc.name}); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		after, _ := database.CountConfigVersions()
+		if after != before+1 {
+			t.Errorf("%s: 记录了 %d 个版本, want 1", tc.name, after-before)
+		}
+		version, files := latestVersion(t)
+		if version.Message != tc.name || version.Author != "tester" {
+			t.Errorf("%s: version = %+v", tc.name, version)
+		}
+		if strings.Join(files, ",") != strings.Join(tc.files, ",") {
+			t.Errorf("%s: files = %v, want %v", tc.name, files, tc.files)
+		}
+	}
+}
+
+func TestDiffVersions(t *testing.T) {
+	useHistory(t, "")
+	base, _ := latestVersion(t)
+	if err := SaveStreamRoute(StreamRoute{ID: "git", Domain: "git.example.com", Backend: "127.0.0.1:2222", Enabled: true}, SystemChange("保存")); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProxySite(testSite("app", "app.example.com"), SystemChange("保存")); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := latestVersion(t)
+
+	for _, tc := range []struct {
+		name     string
+		from, to int
+		filter   string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "新增的文件",
+			from:     base.Number,
+			to:       head.Number,
+			contains: []string{"--- /dev/null\n+++ b/stream/.git.json", "+++ b/conf.d/app.conf", "--- a/nginx.conf", "git.example.com"},
+		},
+		{
+			name:     "只比较指定文件",
+			from:     base.Number,
+			to:       head.Number,
+			filter:   "conf.d/app.conf",
+			contains: []string{"app.example.com"},
+			excludes: []string{"nginx.conf", "stream/.git.json"},
+		},
+		{
+			name:     "反向比较为删除",
+			from:     head.Number,
+			to:       base.Number,
+			contains: []string{"--- a/conf.d/app.conf\n+++ /dev/null"},
+		},
+		{
+			name:     "与空树比较",
+			to:       base.Number,
+			contains: []string{"--- /dev/null\n+++ b/nginx.conf"},
+		},
+		{
+			name: "相同版本",
+			from: head.Number,
+			to:   head.Number,
+		},
+	} {
+		diff, err := DiffVersions(tc.from, tc.to, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(tc.contains) == 0 && diff != "" {
+			t.Errorf("%s: diff =\n%s", tc.name, diff)
+		}
+		for _, s := range tc.contains {
+			if !strings.Contains(diff, s) {
+				t.Errorf("%s: diff 中缺少 %q:\n%s", tc.name, s, diff)
+			}
+		}
+		for _, s := range tc.excludes {
+			if strings.Contains(diff, s) {
+				t.Errorf("%s: diff 中不应包含 %q:\n%s", tc.name, s, diff)
+			}
+		}
+	}
+}
+
+func TestRestoreVersionRoundTrip(t *testing.T) {
+	paths := useHistory(t, "")
+	confPath := paths.ConfigPath
+	sitePath := filepath.Join(paths.ConfigsDir, "app.conf")
+	routePath := filepath.Join(GetStreamDir(), ".git.json")
+	manualPath := filepath.Join(paths.ConfigsDir, "manual.conf")
+
+	base, _ := latestVersion(t)
+	baseConf := readFile(t, confPath)
+
+	if err := SaveStreamRoute(StreamRoute{ID: "git", Domain: "git.example.com", Backend: "127.0.0.1:2222", Enabled: true}, SystemChange("保存路由")); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProxySite(testSite("app", "app.example.com"), SystemChange("保存站点")); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := latestVersion(t)
+	headConf, headSite := readFile(t, confPath), readFile(t, sitePath)
+
+	// 在 hop 之外创建的文件
+	if err := os.WriteFile(manualPath, []byte("server { listen 8081; }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 恢复到初始版本：之后创建的文件全部删除
+	if _, err := RestoreVersion(base.ID, Change{Author: "tester"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, confPath); got != baseConf {
+		t.Errorf("nginx.conf 未恢复:\n%s", got)
+	}
+	for _, p := range []string{sitePath, routePath, manualPath} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s 未删除: %v", p, err)
+		}
+	}
+	version, files := latestVersion(t)
+	if version.Message != "恢复到版本 #1" {
+		t.Errorf("Message = %s", version.Message)
+	}
+	want := []string{"-conf.d/.app.json", "-conf.d/app.conf", "-conf.d/manual.conf", "-stream/.git.json", "nginx.conf"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+
+	// 未跟踪的文件在删除前已记录，可以找回
+	untracked, err := database.GetConfigVersionByNumber(version.Number - 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, _ := versionTree(untracked.Number)
+	if tree["conf.d/manual.conf"] != "server { listen 8081; }\n" {
+		t.Errorf("未记录未跟踪的文件: %q", tree["conf.d/manual.conf"])
+	}
+
+	// 再恢复到最新版本
+	if _, err := RestoreVersion(head.ID, Change{Author: "tester"}); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, confPath) != headConf || readFile(t, sitePath) != headSite {
+		t.Error("恢复到最新版本后文件不一致")
+	}
+	if route, err := GetStreamRoute("git"); err != nil || route.Domain != "git.example.com" {
+		t.Errorf("route = %+v, err = %v", route, err)
+	}
+	if _, err := os.Stat(manualPath); !os.IsNotExist(err) {
+		t.Errorf("head 版本中不存在 manual.conf: %v", err)
+	}
+}
+
+func TestRestoreVersionRollsBackWhenNginxTestFails(t *testing.T) {
+	paths := useHistory(t, `[ "$1" = "-t" ] && { echo "emerg: bad config"; exit 1; }
+exit 0`)
+	sitePath := filepath.Join(paths.ConfigsDir, "app.conf")
+
+	base, _ := latestVersion(t)
+	if err := SaveProxySite(testSite("app", "app.example.com"), SystemChange("保存站点")); err != nil {
+		t.Fatal(err)
+	}
+	site := readFile(t, sitePath)
+	versions, _ := database.CountConfigVersions()
+
+	_, err := RestoreVersion(base.ID, Change{Author: "tester"})
+	if err == nil || !strings.Contains(err.Error(), "已回滚") || !strings.Contains(err.Error(), "emerg: bad config") {
+		t.Fatalf("err = %v", err)
+	}
+	if readFile(t, sitePath) != site {
+		t.Error("校验失败后未回滚站点配置")
+	}
+	if n, _ := database.CountConfigVersions(); n != versions {
+		t.Errorf("校验失败后记录了版本: %d -> %d", versions, n)
+	}
+}
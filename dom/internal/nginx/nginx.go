@@ -13,7 +13,6 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
-	"github.com/hop/backend/internal/config"
 	"github.com/hop/backend/internal/logger"
 )
 
@@ -50,6 +49,7 @@ func Router() chi.Router {
 	r.Post("/reload", handleReload)
 	r.Post("/regenerate", handleRegenerate)
 	r.Post("/template-params", handleSaveTemplateParams)
+	r.Post("/plan", handlePlan)
 	r.Delete("/file", handleDeleteFile)
 
 	// 代理站点管理 API
@@ -66,6 +66,12 @@ func Router() chi.Router {
 	r.Post("/stream/toggle", handleToggleStreamRoute)
 	r.Delete("/stream/delete", handleDeleteStreamRoute)
 
+	// 配置历史版本 API
+	r.Get("/history/list", handleListVersions)
+	r.Get("/history/get", handleGetVersion)
+	r.Get("/history/diff", handleDiffVersions)
+	r.Post("/history/restore", handleRestoreVersion)
+
 	return r
 }
 
@@ -211,7 +217,8 @@ func handleSaveFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := os.WriteFile(req.Path, []byte(req.Content), 0644); err != nil {
+	change := ChangeFromRequest(r, "编辑文件 "+filepath.Base(req.Path))
+	if err := applyFileChanges(change, []fileChange{{Path: req.Path, Content: []byte(req.Content)}}); err != nil {
 		jsonError(w, "Failed to save file: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -270,7 +277,8 @@ func handleCreateFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 创建文件
-	if err := os.WriteFile(filePath, []byte(req.Content), 0644); err != nil {
+	change := ChangeFromRequest(r, "创建文件 "+fileName)
+	if err := applyFileChanges(change, []fileChange{{Path: filePath, Content: []byte(req.Content)}}); err != nil {
 		jsonError(w, "Failed to create file: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -324,7 +332,8 @@ func handleDeleteFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 删除文件
-	if err := os.Remove(filePath); err != nil {
+	change := ChangeFromRequest(r, "删除文件 "+filepath.Base(filePath))
+	if err := applyFileChanges(change, []fileChange{{Path: filePath, Deleted: true}}); err != nil {
 		jsonError(w, "Failed to delete file: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -353,6 +362,20 @@ func extractServerNames(content string) []string {
 	return serverNames
 }
 
+// TestConfig 执行 nginx -t 校验配置，返回命令输出
+func TestConfig() (string, error) {
+	cmd := exec.Command("nginx", "-t")
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// Reload 执行 nginx -s reload，返回命令输出
+func Reload() (string, error) {
+	cmd := exec.Command("nginx", "-s", "reload")
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
 // execCommand 执行命令
 func execCommand(command string, args []string) map[string]interface{} {
 	cmd := exec.Command(command, args...)
@@ -464,15 +487,7 @@ func drainBody(r *http.Request) {
 
 // handleGetTemplateParams 获取当前模板参数
 func handleGetTemplateParams(w http.ResponseWriter, r *http.Request) {
-	cfg := config.Get()
-	jsonResponse(w, TemplateParams{
-		WorkerProcesses:   cfg.Nginx.WorkerProcesses,
-		WorkerConnections: cfg.Nginx.WorkerConnections,
-		Keepalive:         cfg.Nginx.Keepalive,
-		ClientMaxBodySize: cfg.Nginx.ClientMaxBodySize,
-		Gzip:              cfg.Nginx.Gzip,
-		ServerTokens:      cfg.Nginx.ServerTokens,
-	})
+	jsonResponse(w, LoadTemplateParams())
 }
 
 // handleSaveTemplateParams 保存模板参数并重新生成配置
@@ -497,10 +512,14 @@ func handleSaveTemplateParams(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 生成并保存新的 nginx.conf
-	if err := GenerateAndSaveNginxConf(fullParams); err != nil {
+	if err := GenerateAndSaveNginxConf(fullParams, ChangeFromRequest(r, "更新模板参数")); err != nil {
 		jsonError(w, "Failed to generate config: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := SaveTemplateParams(params); err != nil {
+		jsonError(w, "保存模板参数失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	log.Info("模板参数已更新，nginx.conf 已重新生成", nil)
 	jsonResponse(w, map[string]bool{"success": true})
@@ -508,7 +527,7 @@ func handleSaveTemplateParams(w http.ResponseWriter, r *http.Request) {
 
 // handleRegenerate 使用当前参数重新生成 nginx.conf
 func handleRegenerate(w http.ResponseWriter, r *http.Request) {
-	if err := RegenerateNginxConf(); err != nil {
+	if err := RegenerateNginxConf(ChangeFromRequest(r, "重新生成 nginx.conf")); err != nil {
 		jsonError(w, "Failed to regenerate config: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
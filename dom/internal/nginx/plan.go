@@ -0,0 +1,236 @@
+package nginx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PlanRequest 变更计划请求：描述一组待应用的变更
+type PlanRequest struct {
+	Sites              []ProxySite     `json:"sites"`              // 新增或更新的代理站点
+	DeleteSites        []string        `json:"deleteSites"`        // 删除的代理站点 ID
+	StreamRoutes       []StreamRoute   `json:"streamRoutes"`       // 新增或更新的 SNI 路由
+	DeleteStreamRoutes []string        `json:"deleteStreamRoutes"` // 删除的 SNI 路由 ID
+	TemplateParams     *TemplateParams `json:"templateParams"`     // 新的模板参数（为空则使用当前参数）
+}
+
+// PlannedFile 计划中单个文件的变更
+type PlannedFile struct {
+	Path   string `json:"path"`   // 相对于 nginx 目录的路径
+	Action string `json:"action"` // create, update, delete
+	Diff   string `json:"diff"`   // 该文件的 unified diff
+}
+
+// Plan 变更计划，包含所有将被写入的文件及其 diff
+type Plan struct {
+	Files []PlannedFile `json:"files"`
+	Diff  string        `json:"diff"` // 所有文件合并后的 unified diff
+
+	changes     []fileChange
+	dhParamBits int // 渲染 nginx.conf 使用的 DH 参数长度
+}
+
+// HasChanges 计划是否包含实际变更
+func (p *Plan) HasChanges() bool {
+	return len(p.changes) > 0
+}
+
+// Apply 将计划中的文件变更写入磁盘（记录为一个历史版本）
+// 写入后执行 nginx -t 校验，校验失败则回滚到写入前的状态
+func (p *Plan) Apply(change Change) error {
+	if !p.HasChanges() {
+		return nil
+	}
+	if err := EnsureNginxDirs(); err != nil {
+		return err
+	}
+	// nginx.conf 引用的 DH 参数文件需要先存在
+	if err := ensureDHParam(p.dhParamBits); err != nil {
+		return err
+	}
+
+	var rollback []fileChange
+	for _, c := range p.changes {
+		current, err := os.ReadFile(c.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		rollback = append(rollback, fileChange{Path: c.Path, Content: current, Deleted: err != nil})
+	}
+
+	if err := writeFileChanges(p.changes); err != nil {
+		writeFileChanges(rollback)
+		return err
+	}
+
+	// 未安装 nginx 时无法校验，直接保留写入结果
+	if output, err := TestConfig(); err != nil && !errors.Is(err, exec.ErrNotFound) {
+		if rbErr := writeFileChanges(rollback); rbErr != nil {
+			log.Error("回滚配置失败", map[string]interface{}{"error": rbErr.Error()})
+		}
+		return fmt.Errorf("nginx 配置校验失败，已回滚: %s", strings.TrimSpace(output))
+	}
+
+	if err := recordVersion(change, p.changes); err != nil {
+		return fmt.Errorf("文件已写入，但%w", err)
+	}
+	return nil
+}
+
+// BuildPlan 计算变更计划，不写入磁盘
+// 计划总是包含重新生成的 nginx.conf（内容无变化时不会出现在结果中）
+func BuildPlan(req PlanRequest) (*Plan, error) {
+	var changes []fileChange
+
+	// 代理站点
+	for _, site := range req.Sites {
+		files, err := buildProxySiteFiles(site)
+		if err != nil {
+			return nil, fmt.Errorf("站点 %s: %w", site.ID, err)
+		}
+		changes = append(changes, files...)
+	}
+	for _, id := range req.DeleteSites {
+		changes = append(changes, proxySiteDeletions(id)...)
+	}
+
+	// SNI 路由：在当前路由基础上叠加变更，用于渲染 nginx.conf
+	current, err := ListStreamRoutes()
+	if err != nil {
+		return nil, err
+	}
+	routes := map[string]StreamRoute{}
+	for _, route := range current {
+		routes[route.ID] = route
+	}
+	for _, route := range req.StreamRoutes {
+		file, err := buildStreamRouteFile(route)
+		if err != nil {
+			return nil, fmt.Errorf("路由 %s: %w", route.ID, err)
+		}
+		changes = append(changes, file)
+		routes[route.ID] = route
+	}
+	for _, id := range req.DeleteStreamRoutes {
+		changes = append(changes, fileChange{Path: filepath.Join(GetStreamDir(), "."+id+".json"), Deleted: true})
+		delete(routes, id)
+	}
+
+	// nginx.conf
+	params := LoadTemplateParams()
+	if req.TemplateParams != nil {
+		params = *req.TemplateParams
+	}
+	confFile, err := buildNginxConfFile(FullTemplateParams{
+		TemplateParams: params,
+		StreamRoutes:   sortedStreamRoutes(routes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, confFile)
+
+	plan, err := planFromChanges(changes)
+	if err != nil {
+		return nil, err
+	}
+	plan.dhParamBits = params.DHParamBits
+	return plan, nil
+}
+
+// planFromChanges 将文件变更与磁盘现状比较，生成计划
+func planFromChanges(changes []fileChange) (*Plan, error) {
+	paths := GetNginxPaths()
+
+	// 同一路径以最后一次变更为准
+	byPath := map[string]fileChange{}
+	for _, c := range changes {
+		byPath[filepath.Clean(c.Path)] = c
+	}
+
+	var keys []string
+	for p := range byPath {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	plan := &Plan{Files: []PlannedFile{}}
+	var diff strings.Builder
+	for _, absPath := range keys {
+		c := byPath[absPath]
+
+		rel, err := filepath.Rel(paths.BaseDir, absPath)
+		if err != nil {
+			rel = absPath
+		}
+		rel = filepath.ToSlash(rel)
+
+		current, readErr := os.ReadFile(absPath)
+		if readErr != nil && !os.IsNotExist(readErr) {
+			return nil, fmt.Errorf("读取 %s 失败: %w", rel, readErr)
+		}
+		exists := readErr == nil
+
+		var action, fileDiff string
+		switch {
+		case c.Deleted && !exists:
+			continue
+		case c.Deleted:
+			action = "delete"
+			fileDiff = UnifiedDiff("a/"+rel, "/dev/null", string(current), "")
+		case !exists:
+			action = "create"
+			fileDiff = UnifiedDiff("/dev/null", "b/"+rel, "", string(c.Content))
+		case string(current) == string(c.Content):
+			continue
+		default:
+			action = "update"
+			fileDiff = UnifiedDiff("a/"+rel, "b/"+rel, string(current), string(c.Content))
+		}
+
+		plan.Files = append(plan.Files, PlannedFile{Path: rel, Action: action, Diff: fileDiff})
+		plan.changes = append(plan.changes, c)
+		diff.WriteString(fileDiff)
+	}
+	plan.Diff = diff.String()
+
+	return plan, nil
+}
+
+// sortedStreamRoutes 按元数据文件名排序，与 ListStreamRoutes 的顺序保持一致
+func sortedStreamRoutes(routes map[string]StreamRoute) []StreamRoute {
+	result := make([]StreamRoute, 0, len(routes))
+	for _, route := range routes {
+		result = append(result, route)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return "."+result[i].ID+".json" < "."+result[j].ID+".json"
+	})
+	return result
+}
+
+// ===== HTTP Handlers =====
+
+// handlePlan 预览一组变更将产生的文件 diff（不写入磁盘）
+func handlePlan(w http.ResponseWriter, r *http.Request) {
+	var req PlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+
+	plan, err := BuildPlan(req)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, plan)
+}
@@ -0,0 +1,246 @@
+package nginx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hop/backend/internal/database"
+)
+
+// plannedActions 计划中的文件变更，格式为 "action path"
+func plannedActions(plan *Plan) []string {
+	var actions []string
+	for _, f := range plan.Files {
+		actions = append(actions, f.Action+" "+f.Path)
+	}
+	return actions
+}
+
+func TestPlanFromChanges(t *testing.T) {
+	paths := useTempDataDir(t)
+	file := func(name string) string { return filepath.Join(paths.ConfigsDir, name) }
+	for name, content := range map[string]string{
+		"same.conf":   "same\n",
+		"update.conf": "old\n",
+		"delete.conf": "delete\n",
+	} {
+		if err := os.WriteFile(file(name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		changes []fileChange
+		actions []string
+		diff    []string
+	}{
+		{
+			name:    "新建",
+			changes: []fileChange{{Path: file("new.conf"), Content: []byte("new\n")}},
+			actions: []string{"create conf.d/new.conf"},
+			diff:    []string{"--- /dev/null\n+++ b/conf.d/new.conf", "+new"},
+		},
+		{
+			name:    "修改",
+			changes: []fileChange{{Path: file("update.conf"), Content: []byte("new\n")}},
+			actions: []string{"update conf.d/update.conf"},
+			diff:    []string{"--- a/conf.d/update.conf\n+++ b/conf.d/update.conf", "-old", "+new"},
+		},
+		{
+			name:    "内容相同",
+			changes: []fileChange{{Path: file("same.conf"), Content: []byte("same\n")}},
+		},
+		{
+			name:    "删除",
+			changes: []fileChange{{Path: file("delete.conf"), Deleted: true}},
+			actions: []string{"delete conf.d/delete.conf"},
+			diff:    []string{"--- a/conf.d/delete.conf\n+++ /dev/null", "-delete"},
+		},
+		{
+			name:    "删除不存在的文件",
+			changes: []fileChange{{Path: file("missing.conf"), Deleted: true}},
+		},
+		{
+			name: "同一文件以最后一次变更为准",
+			changes: []fileChange{
+				{Path: file("update.conf"), Content: []byte("first\n")},
+				{Path: file("update.conf"), Deleted: true},
+			},
+			actions: []string{"delete conf.d/update.conf"},
+		},
+		{
+			name: "按路径排序",
+			changes: []fileChange{
+				{Path: file("b.conf"), Content: []byte("b\n")},
+				{Path: file("a.conf"), Content: []byte("a\n")},
+			},
+			actions: []string{"create conf.d/a.conf", "create conf.d/b.conf"},
+		},
+	} {
+		plan, err := planFromChanges(tc.changes)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := strings.Join(plannedActions(plan), ","); got != strings.Join(tc.actions, ",") {
+			t.Errorf("%s: actions = %s, want %v", tc.name, got, tc.actions)
+		}
+		if plan.HasChanges() != (len(tc.actions) > 0) {
+			t.Errorf("%s: HasChanges = %v", tc.name, plan.HasChanges())
+		}
+		for _, s := range tc.diff {
+			if !strings.Contains(plan.Diff, s) {
+				t.Errorf("%s: diff 中缺少 %q:\n%s", tc.name, s, plan.Diff)
+			}
+		}
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	useTestDB(t)
+	if err := InitNginxConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProxySite(testSite("old", "old.example.com"), SystemChange("测试")); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveStreamRoute(StreamRoute{ID: "ssh", Domain: "ssh.example.com", Backend: "127.0.0.1:22", Enabled: true}, SystemChange("测试")); err != nil {
+		t.Fatal(err)
+	}
+	params := LoadTemplateParams()
+	params.WorkerConnections = params.WorkerConnections + 1
+
+	for _, tc := range []struct {
+		name    string
+		req     PlanRequest
+		actions []string
+		diff    []string
+	}{
+		{
+			name: "无变更",
+		},
+		{
+			name:    "新增站点",
+			req:     PlanRequest{Sites: []ProxySite{testSite("app", "app.example.com")}},
+			actions: []string{"create conf.d/.app.json", "create conf.d/app.conf"},
+			diff:    []string{"server_name app.example.com;"},
+		},
+		{
+			name:    "保存未修改的站点",
+			req:     PlanRequest{Sites: []ProxySite{testSite("old", "old.example.com")}},
+			actions: nil,
+		},
+		{
+			name:    "删除站点",
+			req:     PlanRequest{DeleteSites: []string{"old"}},
+			actions: []string{"delete conf.d/.old.json", "delete conf.d/old.conf"},
+		},
+		{
+			name:    "新增路由时重新生成 nginx.conf",
+			req:     PlanRequest{StreamRoutes: []StreamRoute{{ID: "git", Domain: "git.example.com", Backend: "127.0.0.1:2222", Enabled: true}}},
+			actions: []string{"update nginx.conf", "create stream/.git.json"},
+			diff:    []string{"+    upstream backend_git {"},
+		},
+		{
+			name:    "删除路由",
+			req:     PlanRequest{DeleteStreamRoutes: []string{"ssh"}},
+			actions: []string{"update nginx.conf", "delete stream/.ssh.json"},
+			diff:    []string{"-    upstream backend_ssh {"},
+		},
+		{
+			name:    "修改模板参数",
+			req:     PlanRequest{TemplateParams: &params},
+			actions: []string{"update nginx.conf"},
+		},
+	} {
+		plan, err := BuildPlan(tc.req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := strings.Join(plannedActions(plan), ","); got != strings.Join(tc.actions, ",") {
+			t.Errorf("%s: actions = %s, want %v", tc.name, got, tc.actions)
+		}
+		for _, s := range tc.diff {
+			if !strings.Contains(plan.Diff, s) {
+				t.Errorf("%s: diff 中缺少 %q:\n%s", tc.name, s, plan.Diff)
+			}
+		}
+	}
+
+	// 无效的请求
+	for name, req := range map[string]PlanRequest{
+		"站点缺少域名": {Sites: []ProxySite{testSite("bad", "")}},
+		"路由缺少后端": {StreamRoutes: []StreamRoute{{ID: "bad", Domain: "bad.example.com"}}},
+	} {
+		if _, err := BuildPlan(req); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestPlanApplyNginxTest(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		nginx    string // PATH 中的 nginx 脚本，为空表示未安装
+		wantErr  bool
+		versions int
+	}{
+		{name: "未安装 nginx", versions: 1},
+		{name: "校验通过", nginx: "exit 0", versions: 1},
+		{name: "校验失败", nginx: `[ "$1" = "-t" ] && { echo "emerg: unknown directive"; exit 1; }; exit 0`, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			paths := useHistory(t, tc.nginx)
+			if err := SaveProxySite(testSite("old", "old.example.com"), SystemChange("测试")); err != nil {
+				t.Fatal(err)
+			}
+			confBefore := readFile(t, paths.ConfigPath)
+			oldSite := readFile(t, filepath.Join(paths.ConfigsDir, "old.conf"))
+			versions, _ := database.CountConfigVersions()
+
+			plan, err := BuildPlan(PlanRequest{
+				Sites:        []ProxySite{testSite("app", "app.example.com")},
+				DeleteSites:  []string{"old"},
+				StreamRoutes: []StreamRoute{{ID: "git", Domain: "git.example.com", Backend: "127.0.0.1:2222", Enabled: true}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = plan.Apply(SystemChange("测试"))
+
+			if n, _ := database.CountConfigVersions(); n-versions != tc.versions {
+				t.Errorf("记录了 %d 个版本, want %d", n-versions, tc.versions)
+			}
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if readFile(t, filepath.Join(paths.ConfigsDir, "app.conf")) == "<missing>" {
+					t.Error("站点配置未写入")
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), "已回滚") || !strings.Contains(err.Error(), "emerg: unknown directive") {
+				t.Fatalf("err = %v", err)
+			}
+			// 新建的文件被删除，修改和删除的文件恢复原内容
+			for _, name := range []string{"app.conf", ".app.json"} {
+				if _, err := os.Stat(filepath.Join(paths.ConfigsDir, name)); !os.IsNotExist(err) {
+					t.Errorf("%s 未删除: %v", name, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(GetStreamDir(), ".git.json")); !os.IsNotExist(err) {
+				t.Errorf("路由元数据未删除: %v", err)
+			}
+			if readFile(t, filepath.Join(paths.ConfigsDir, "old.conf")) != oldSite {
+				t.Error("删除的站点配置未恢复")
+			}
+			if readFile(t, paths.ConfigPath) != confBefore {
+				t.Error("nginx.conf 未恢复")
+			}
+		})
+	}
+}
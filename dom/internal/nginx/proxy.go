@@ -16,32 +16,85 @@ import (
 
 // ProxySite 代理站点配置
 type ProxySite struct {
-	ID         string `json:"id"`                // 唯一标识（文件名，不含扩展名）
-	ServerName string `json:"serverName"`        // 域名
-	SSL        bool   `json:"ssl"`               // 是否启用 SSL
-	SSLCert    string `json:"sslCert,omitempty"` // SSL 证书路径
-	SSLKey     string `json:"sslKey,omitempty"`  // SSL 私钥路径
+	ID         string `json:"id" toml:"id"`                                // 唯一标识（文件名，不含扩展名）
+	ServerName string `json:"serverName" toml:"server_name"`               // 域名
+	SSL        bool   `json:"ssl" toml:"ssl"`                              // 是否启用 SSL
+	SSLCert    string `json:"sslCert,omitempty" toml:"ssl_cert,omitempty"` // SSL 证书路径
+	SSLKey     string `json:"sslKey,omitempty" toml:"ssl_key,omitempty"`   // SSL 私钥路径
+
+	// 双证书：与 ECDSA 证书一起提供的 RSA 证书，由关联证书自动填写
+	SSLCertRSA string `json:"sslCertRsa,omitempty" toml:"ssl_cert_rsa,omitempty"`
+	SSLKeyRSA  string `json:"sslKeyRsa,omitempty" toml:"ssl_key_rsa,omitempty"`
+
+	// TLS 设置，为空时使用 nginx.conf 中的全局配置档
+	TLSProfile string `json:"tlsProfile,omitempty" toml:"tls_profile,omitempty"` // modern、intermediate 或 old
+	TLS13Only  bool   `json:"tls13Only,omitempty" toml:"tls13_only,omitempty"`   // 只允许 TLS 1.3，优先于 TLSProfile
 
 	// 证书选择（新增）
-	CertificateID string `json:"certificateId,omitempty"` // 关联的证书 ID
+	CertificateID string `json:"certificateId,omitempty" toml:"certificate_id,omitempty"` // 关联的证书 ID，为空时自动选择覆盖站点域名的证书
+
+	// 等待自动申请的证书时为申请任务 ID，证书签发后自动关联并启用 HTTPS
+	CertificateJobID string `json:"certificateJobId,omitempty" toml:"certificate_job_id,omitempty"`
 
 	// 上游配置
-	UpstreamScheme string `json:"upstreamScheme"` // http 或 https
-	UpstreamHost   string `json:"upstreamHost"`   // 上游主机名/IP
-	UpstreamPort   int    `json:"upstreamPort"`   // 上游端口
+	UpstreamScheme string `json:"upstreamScheme" toml:"upstream_scheme"` // http 或 https
+	UpstreamHost   string `json:"upstreamHost" toml:"upstream_host"`     // 上游主机名/IP
+	UpstreamPort   int    `json:"upstreamPort" toml:"upstream_port"`     // 上游端口
+
+	// HTTPS 上游的 TLS 设置
+	UpstreamSSLName   string `json:"upstreamSslName,omitempty" toml:"upstream_ssl_name,omitempty"`     // 发送的 SNI 并用于校验证书，默认使用上游主机名
+	UpstreamSSLVerify bool   `json:"upstreamSslVerify,omitempty" toml:"upstream_ssl_verify,omitempty"` // 校验上游证书
+	UpstreamCA        string `json:"upstreamCa,omitempty" toml:"upstream_ca,omitempty"`                // 信任的 CA：system（默认）、internal 或 CA 证书包的 ID/名称
+	UpstreamCAPath    string `json:"upstreamCaPath,omitempty" toml:"upstream_ca_path,omitempty"`       // CA 证书文件路径，由 UpstreamCA 自动填写
+
+	// 访问要求 mTLS 的上游时出示的客户端证书
+	UpstreamClientCertID string `json:"upstreamClientCertId,omitempty" toml:"upstream_client_cert_id,omitempty"` // 证书 ID
+	UpstreamClientCert   string `json:"upstreamClientCert,omitempty" toml:"upstream_client_cert,omitempty"`      // 证书路径，由证书 ID 自动填写
+	UpstreamClientKey    string `json:"upstreamClientKey,omitempty" toml:"upstream_client_key,omitempty"`
 
 	// 功能选项
-	WebSocket bool `json:"websocket"` // 是否支持 WebSocket
+	WebSocket bool `json:"websocket" toml:"websocket"` // 是否支持 WebSocket
 
 	// 认证配置（登录 URL 和 Cookie 域名从全局配置读取）
-	AuthEnabled bool `json:"authEnabled"` // 是否启用访问认证
+	AuthEnabled bool `json:"authEnabled" toml:"auth_enabled"` // 是否启用访问认证
+
+	// 客户端证书认证（mTLS），需要启用 SSL
+	ClientAuth        string `json:"clientAuth,omitempty" toml:"client_auth,omitempty"`                // optional 或 required，为空不校验
+	ClientCA          string `json:"clientCa,omitempty" toml:"client_ca,omitempty"`                    // 客户端 CA 的 ID 或名称，internal 表示内部 CA
+	ClientCAPath      string `json:"clientCaPath,omitempty" toml:"client_ca_path,omitempty"`           // CA 证书包路径，由 ClientCA 自动填写
+	ClientVerifyDepth int    `json:"clientVerifyDepth,omitempty" toml:"client_verify_depth,omitempty"` // 证书链校验深度，默认 2
+	ClientCertHeaders bool   `json:"clientCertHeaders,omitempty" toml:"client_cert_headers,omitempty"` // 向上游转发客户端证书信息
+}
+
+// 客户端证书认证模式
+const (
+	ClientAuthOptional = "optional" // 客户端可以不提供证书，由上游根据 X-SSL-Client-Verify 判断
+	ClientAuthRequired = "required" // 必须提供有效证书，否则 nginx 返回 400
+)
+
+// ClientCAInternal 使用内部 CA 校验客户端证书
+const ClientCAInternal = "internal"
+
+// defaultClientVerifyDepth 客户端证书链校验深度，覆盖 客户端证书 -> 中间证书 -> 根证书
+const defaultClientVerifyDepth = 2
+
+// UpstreamCASystem 使用系统 CA 证书校验上游
+const UpstreamCASystem = "system"
+
+// systemCABundles 常见发行版的系统 CA 证书包路径
+var systemCABundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt", // Debian/Ubuntu/Alpine
+	"/etc/pki/tls/certs/ca-bundle.crt",   // RHEL/CentOS/Fedora
+	"/etc/ssl/ca-bundle.pem",             // openSUSE
+	"/etc/ssl/cert.pem",                  // macOS/BSD
 }
 
 // proxyTemplateData 用于模板渲染的数据结构
 type proxyTemplateData struct {
 	ProxySite
-	AuthLoginURL     string // 从全局配置读取
-	AuthCookieDomain string // 从全局配置读取，如果为空则自动从站点域名提取
+	AuthLoginURL     string      // 从全局配置读取
+	AuthCookieDomain string      // 从全局配置读取，如果为空则自动从站点域名提取
+	TLS              *tlsProfile // 站点单独设置的 TLS 配置档，为空使用全局配置
 }
 
 // proxyTemplate 代理站点配置模板
@@ -72,6 +125,26 @@ server {
 {{if .SSL}}
     ssl_certificate {{.SSLCert}};
     ssl_certificate_key {{.SSLKey}};
+{{- if .SSLCertRSA}}
+    ssl_certificate {{.SSLCertRSA}};
+    ssl_certificate_key {{.SSLKeyRSA}};
+{{- end}}
+{{- with .TLS}}
+
+    # TLS 配置档: {{.Name}}
+    ssl_protocols {{.Protocols}};
+{{- if .Ciphers}}
+    ssl_ciphers {{.Ciphers}};
+{{- end}}
+    ssl_prefer_server_ciphers {{if .PreferServerCiphers}}on{{else}}off{{end}};
+{{- end}}
+{{- if .ClientCAPath}}
+
+    # 客户端证书认证
+    ssl_client_certificate {{.ClientCAPath}};
+    ssl_verify_client {{if eq .ClientAuth "optional"}}optional{{else}}on{{end}};
+    ssl_verify_depth {{.ClientVerifyDepth}};
+{{- end}}
 {{end}}
     location / {
 {{if .AuthEnabled}}
@@ -82,6 +155,29 @@ server {
         proxy_set_header X-Real-IP $remote_addr;
         proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
         proxy_set_header X-Forwarded-Proto $scheme;
+{{- if and .ClientCAPath .ClientCertHeaders}}
+        proxy_set_header X-SSL-Client-Verify $ssl_client_verify;
+        proxy_set_header X-SSL-Client-S-DN $ssl_client_s_dn;
+        proxy_set_header X-SSL-Client-Serial $ssl_client_serial;
+        proxy_set_header X-SSL-Client-Fingerprint $ssl_client_fingerprint;
+{{- end}}
+{{- if eq .UpstreamScheme "https"}}
+
+        # 上游 TLS
+        proxy_ssl_server_name on;
+{{- if .UpstreamSSLName}}
+        proxy_ssl_name {{.UpstreamSSLName}};
+{{- end}}
+{{- if .UpstreamCAPath}}
+        proxy_ssl_verify on;
+        proxy_ssl_verify_depth 3;
+        proxy_ssl_trusted_certificate {{.UpstreamCAPath}};
+{{- end}}
+{{- if .UpstreamClientCert}}
+        proxy_ssl_certificate {{.UpstreamClientCert}};
+        proxy_ssl_certificate_key {{.UpstreamClientKey}};
+{{- end}}
+{{- end}}
 {{if .WebSocket}}
         # WebSocket 支持
         proxy_http_version 1.1;
@@ -126,11 +222,17 @@ func renderProxySiteConfigWithAuth(site ProxySite, authLoginURL, authCookieDomai
 		return "", fmt.Errorf("解析模板失败: %w", err)
 	}
 
+	tls, err := siteTLSProfile(site)
+	if err != nil {
+		return "", err
+	}
+
 	// 构建模板数据
 	data := proxyTemplateData{
 		ProxySite:        site,
 		AuthLoginURL:     authLoginURL,
 		AuthCookieDomain: authCookieDomain,
+		TLS:              tls,
 	}
 
 	var buf strings.Builder
@@ -141,20 +243,63 @@ func renderProxySiteConfigWithAuth(site ProxySite, authLoginURL, authCookieDomai
 	return buf.String(), nil
 }
 
+// CertificateRequester 为没有可用证书的站点申请证书，返回申请任务 ID；未开启自动申请时返回空
+type CertificateRequester func(serverName string) (string, error)
+
+var certificateRequester CertificateRequester
+
+// SetCertificateRequester 注册自动申请证书的方法，由 ssl 包提供
+func SetCertificateRequester(fn CertificateRequester) {
+	certificateRequester = fn
+}
+
+// requestSiteCertificate 站点启用 SSL 但没有覆盖其域名的证书时自动申请，签发前站点以 HTTP 提供服务
+func requestSiteCertificate(site *ProxySite) error {
+	if !site.SSL || site.CertificateID != "" || site.SSLCert != "" || certificateRequester == nil {
+		return nil
+	}
+	if _, err := database.FindCertificateForName(site.ServerName); err == nil {
+		return nil
+	}
+
+	jobID, err := certificateRequester(site.ServerName)
+	if err != nil {
+		return fmt.Errorf("自动申请证书失败: %w", err)
+	}
+	site.CertificateJobID = jobID
+	return nil
+}
+
 // SaveProxySite 保存代理站点配置
-func SaveProxySite(site ProxySite) error {
+func SaveProxySite(site ProxySite, change Change) error {
+	files, err := buildProxySiteFiles(site)
+	if err != nil {
+		return err
+	}
+
+	// 写入配置文件和元数据文件（作为同一个历史版本）
+	if err := applyFileChanges(change, files); err != nil {
+		return fmt.Errorf("保存配置文件失败: %w", err)
+	}
+
+	log.Info("代理站点已保存", map[string]interface{}{"id": site.ID, "serverName": site.ServerName})
+	return nil
+}
+
+// buildProxySiteFiles 校验站点并生成需要写入的配置文件和元数据文件（不落盘）
+func buildProxySiteFiles(site ProxySite) ([]fileChange, error) {
 	// 验证必填字段
 	if site.ID == "" {
-		return fmt.Errorf("站点ID不能为空")
+		return nil, fmt.Errorf("站点ID不能为空")
 	}
 	if site.ServerName == "" {
-		return fmt.Errorf("域名不能为空")
+		return nil, fmt.Errorf("域名不能为空")
 	}
 	if site.UpstreamHost == "" {
-		return fmt.Errorf("上游主机不能为空")
+		return nil, fmt.Errorf("上游主机不能为空")
 	}
 	if site.UpstreamPort == 0 {
-		return fmt.Errorf("上游端口不能为空")
+		return nil, fmt.Errorf("上游端口不能为空")
 	}
 	if site.UpstreamScheme == "" {
 		site.UpstreamScheme = "http"
@@ -169,7 +314,7 @@ func SaveProxySite(site ProxySite) error {
 
 		// 验证登录 URL 必填
 		if authLoginURL == "" {
-			return fmt.Errorf("启用认证时必须在系统设置中配置登录页 URL")
+			return nil, fmt.Errorf("启用认证时必须在系统设置中配置登录页 URL")
 		}
 		// 自动提取 Cookie 域名（如果全局配置未指定）
 		if authCookieDomain == "" {
@@ -177,30 +322,68 @@ func SaveProxySite(site ProxySite) error {
 		}
 	}
 
+	// 启用 SSL 但未指定证书时，自动选择覆盖站点域名的有效证书
+	if site.SSL && site.CertificateID == "" && site.SSLCert == "" {
+		if cert, err := database.FindCertificateForName(site.ServerName); err == nil {
+			site.CertificateID = cert.ID
+		} else if site.CertificateJobID == "" {
+			return nil, fmt.Errorf("没有覆盖 %s 的有效证书，请先申请证书", site.ServerName)
+		}
+	}
+	if site.CertificateID != "" {
+		site.CertificateJobID = ""
+	}
+
 	// 如果启用 SSL 且指定了证书 ID，从数据库获取证书路径
 	if site.SSL && site.CertificateID != "" {
 		cert, err := database.GetCertificate(site.CertificateID)
 		if err != nil {
-			return fmt.Errorf("获取证书失败: %w", err)
+			return nil, fmt.Errorf("获取证书失败: %w", err)
 		}
-		// 验证证书状态
+		// 验证证书状态，站点原有的证书之后被撤销或续期出错时仍允许保存
 		if cert.Status != "active" {
-			return fmt.Errorf("证书状态无效: %s，请选择有效的证书", cert.Status)
+			if !referencedBefore(site.ID, cert.ID) {
+				return nil, fmt.Errorf("证书状态无效: %s，请选择有效的证书", cert.Status)
+			}
+			log.Warn("站点使用的证书状态无效，请尽快更换", map[string]interface{}{
+				"site":   site.ID,
+				"domain": cert.Domain,
+				"status": cert.Status,
+			})
+		}
+		if cert.Usage == database.CertUsageClient {
+			return nil, fmt.Errorf("%s 是客户端证书，不能用于站点", cert.Domain)
 		}
 		// 数据库中存储的路径是相对于 data 目录的，例如: nginx/ssl/example.com.crt
 		// Nginx 配置中需要相对于 nginx 目录的路径，例如: ssl/example.com.crt
 		// 所以需要去掉 "nginx/" 前缀
 		site.SSLCert = strings.TrimPrefix(cert.CertPath, "nginx/")
 		site.SSLKey = strings.TrimPrefix(cert.KeyPath, "nginx/")
+		site.SSLCertRSA = strings.TrimPrefix(cert.RSACertPath, "nginx/")
+		site.SSLKeyRSA = strings.TrimPrefix(cert.RSAKeyPath, "nginx/")
+	}
+
+	if err := resolveClientCA(&site); err != nil {
+		return nil, err
+	}
+	if err := resolveUpstreamTLS(&site); err != nil {
+		return nil, err
+	}
+
+	// 等待自动申请的证书时先以 HTTP 提供服务，证书签发后重新生成配置
+	rendered := site
+	if site.SSL && site.CertificateID == "" && site.CertificateJobID != "" {
+		rendered.SSL = false
+		rendered.ClientAuth = ""
 	}
 
 	// 渲染配置（使用认证信息）
-	content, err := renderProxySiteConfigWithAuth(site, authLoginURL, authCookieDomain)
+	content, err := renderProxySiteConfigWithAuth(rendered, authLoginURL, authCookieDomain)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 保存配置文件
+	// 配置文件
 	paths := GetNginxPaths()
 	filePath := filepath.Join(paths.ConfigsDir, site.ID+".conf")
 
@@ -208,28 +391,126 @@ func SaveProxySite(site ProxySite) error {
 	metaPath := filepath.Join(paths.ConfigsDir, "."+site.ID+".json")
 	metaData, err := json.MarshalIndent(site, "", "  ")
 	if err != nil {
-		return fmt.Errorf("序列化元数据失败: %w", err)
+		return nil, fmt.Errorf("序列化元数据失败: %w", err)
+	}
+
+	return []fileChange{
+		{Path: filePath, Content: []byte(content)},
+		{Path: metaPath, Content: metaData},
+	}, nil
+}
+
+// resolveClientCA 校验客户端证书认证设置，并填写 CA 证书包路径
+func resolveClientCA(site *ProxySite) error {
+	site.ClientCAPath = ""
+	switch site.ClientAuth {
+	case "":
+		return nil
+	case ClientAuthOptional, ClientAuthRequired:
+	default:
+		return fmt.Errorf("不支持的客户端证书认证模式: %s", site.ClientAuth)
+	}
+	if !site.SSL {
+		return fmt.Errorf("客户端证书认证需要启用 SSL")
+	}
+	if site.ClientVerifyDepth == 0 {
+		site.ClientVerifyDepth = defaultClientVerifyDepth
+	}
+	if site.ClientVerifyDepth < 1 || site.ClientVerifyDepth > 10 {
+		return fmt.Errorf("证书链校验深度需要在 1 到 10 之间")
 	}
 
-	// 确保目录存在
-	if err := os.MkdirAll(paths.ConfigsDir, 0755); err != nil {
-		return fmt.Errorf("创建目录失败: %w", err)
+	if site.ClientCA == "" {
+		return fmt.Errorf("请选择校验客户端证书的 CA")
+	}
+	// 校验客户端证书需要中间证书，内部 CA 使用包含中间证书的证书链
+	path, err := caBundlePath(site.ClientCA, database.InternalCAChainPath)
+	if err != nil {
+		return err
 	}
+	site.ClientCAPath = path
+	return nil
+}
 
-	// 写入配置文件
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("保存配置文件失败: %w", err)
+// caBundlePath 返回 CA 证书包相对于 nginx 目录的路径（与站点证书一致）
+// ref 为 internal（使用 internalPath）或 CA 证书包的 ID/名称
+func caBundlePath(ref, internalPath string) (string, error) {
+	if ref == ClientCAInternal {
+		if _, err := database.GetInternalCA(); err != nil {
+			return "", fmt.Errorf("内部 CA 未配置")
+		}
+		return strings.TrimPrefix(internalPath, "nginx/"), nil
+	}
+	ca, err := database.GetClientCA(ref)
+	if err != nil {
+		if ca, err = database.GetClientCAByName(ref); err != nil {
+			return "", fmt.Errorf("CA 证书包不存在: %s", ref)
+		}
 	}
+	return strings.TrimPrefix(ca.CertPath, "nginx/"), nil
+}
 
-	// 写入元数据文件
-	if err := os.WriteFile(metaPath, metaData, 0644); err != nil {
-		return fmt.Errorf("保存元数据失败: %w", err)
+// resolveUpstreamTLS 校验 HTTPS 上游的 TLS 设置，并填写 CA 和客户端证书路径
+func resolveUpstreamTLS(site *ProxySite) error {
+	site.UpstreamCAPath = ""
+	site.UpstreamClientCert = ""
+	site.UpstreamClientKey = ""
+	if site.UpstreamScheme != "https" {
+		return nil
+	}
+
+	if site.UpstreamSSLVerify {
+		switch site.UpstreamCA {
+		case "", UpstreamCASystem:
+			for _, bundle := range systemCABundles {
+				if _, err := os.Stat(bundle); err == nil {
+					site.UpstreamCAPath = bundle
+					break
+				}
+			}
+			if site.UpstreamCAPath == "" {
+				return fmt.Errorf("未找到系统 CA 证书，请选择内部 CA 或 CA 证书包")
+			}
+		default:
+			path, err := caBundlePath(site.UpstreamCA, database.InternalCACertPath)
+			if err != nil {
+				return err
+			}
+			site.UpstreamCAPath = path
+		}
 	}
 
-	log.Info("代理站点已保存", map[string]interface{}{"id": site.ID, "serverName": site.ServerName})
+	if site.UpstreamClientCertID != "" {
+		cert, err := database.GetCertificate(site.UpstreamClientCertID)
+		if err != nil {
+			return fmt.Errorf("获取上游客户端证书失败: %w", err)
+		}
+		if cert.Status != "active" {
+			if !referencedBefore(site.ID, cert.ID) {
+				return fmt.Errorf("上游客户端证书状态无效: %s", cert.Status)
+			}
+			log.Warn("站点使用的上游客户端证书状态无效，请尽快更换", map[string]interface{}{
+				"site":   site.ID,
+				"domain": cert.Domain,
+				"status": cert.Status,
+			})
+		}
+		site.UpstreamClientCert = strings.TrimPrefix(cert.CertPath, "nginx/")
+		site.UpstreamClientKey = strings.TrimPrefix(cert.KeyPath, "nginx/")
+	}
 	return nil
 }
 
+// referencedBefore 已保存的站点是否已引用该证书
+// 只有新选择的证书需要是有效状态，已引用的证书被撤销或续期出错后站点仍可修改其他设置或重新生成
+func referencedBefore(siteID, certID string) bool {
+	existing, err := GetProxySite(siteID)
+	if err != nil {
+		return false
+	}
+	return existing.CertificateID == certID || existing.UpstreamClientCertID == certID
+}
+
 // GetProxySite 获取代理站点配置
 func GetProxySite(id string) (*ProxySite, error) {
 	paths := GetNginxPaths()
@@ -282,26 +563,54 @@ func ListProxySites() ([]ProxySite, error) {
 	return sites, nil
 }
 
-// DeleteProxySite 删除代理站点
-func DeleteProxySite(id string) error {
-	paths := GetNginxPaths()
-	confPath := filepath.Join(paths.ConfigsDir, id+".conf")
-	metaPath := filepath.Join(paths.ConfigsDir, "."+id+".json")
+// CertificateRefs 站点引用的证书 ID，包括站点证书和上游客户端证书
+func (s ProxySite) CertificateRefs() []string {
+	var refs []string
+	if s.SSL && s.CertificateID != "" {
+		refs = append(refs, s.CertificateID)
+	}
+	if s.UpstreamClientCertID != "" && s.UpstreamClientCertID != s.CertificateID {
+		refs = append(refs, s.UpstreamClientCertID)
+	}
+	return refs
+}
 
-	// 删除配置文件
-	if err := os.Remove(confPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("删除配置文件失败: %w", err)
+// CertificateSites 证书与站点的依赖索引，键为证书 ID，值为引用该证书的站点
+// 站点配置生成时已写入证书路径，证书路径变化或删除证书前需要据此处理相关站点
+func CertificateSites() (map[string][]ProxySite, error) {
+	sites, err := ListProxySites()
+	if err != nil {
+		return nil, err
 	}
+	index := make(map[string][]ProxySite)
+	for _, site := range sites {
+		for _, id := range site.CertificateRefs() {
+			index[id] = append(index[id], site)
+		}
+	}
+	return index, nil
+}
 
-	// 删除元数据文件
-	if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("删除元数据失败: %w", err)
+// DeleteProxySite 删除代理站点
+func DeleteProxySite(id string, change Change) error {
+	// 删除配置文件和元数据文件
+	if err := applyFileChanges(change, proxySiteDeletions(id)); err != nil {
+		return fmt.Errorf("删除配置文件失败: %w", err)
 	}
 
 	log.Info("代理站点已删除", map[string]interface{}{"id": id})
 	return nil
 }
 
+// proxySiteDeletions 删除站点需要移除的文件
+func proxySiteDeletions(id string) []fileChange {
+	paths := GetNginxPaths()
+	return []fileChange{
+		{Path: filepath.Join(paths.ConfigsDir, id+".conf"), Deleted: true},
+		{Path: filepath.Join(paths.ConfigsDir, "."+id+".json"), Deleted: true},
+	}
+}
+
 // ===== HTTP Handlers =====
 
 // handleListProxySites 列出所有代理站点
@@ -342,14 +651,20 @@ func handleSaveProxySite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := SaveProxySite(site); err != nil {
+	if err := requestSiteCertificate(&site); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := SaveProxySite(site, ChangeFromRequest(r, "保存代理站点 "+site.ID)); err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	jsonResponse(w, map[string]interface{}{
-		"success": true,
-		"id":      site.ID,
+		"success":          true,
+		"id":               site.ID,
+		"certificateJobId": site.CertificateJobID,
 	})
 }
 
@@ -361,7 +676,7 @@ func handleDeleteProxySite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := DeleteProxySite(id); err != nil {
+	if err := DeleteProxySite(id, ChangeFromRequest(r, "删除代理站点 "+id)); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
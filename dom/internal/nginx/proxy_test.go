@@ -0,0 +1,123 @@
+package nginx
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// useTestDB 在临时数据目录中初始化数据库，测试结束后关闭
+func useTestDB(t *testing.T) NginxPaths {
+	t.Helper()
+	paths := useTempDataDir(t)
+	if err := database.Init(config.Get()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return paths
+}
+
+// createTestCertificate 写入一条证书记录
+func createTestCertificate(t *testing.T, domain, usage, status string) *database.Certificate {
+	t.Helper()
+	now := time.Now()
+	cert := &database.Certificate{
+		ID:        uuid.New().String(),
+		Domain:    domain,
+		Domains:   `["` + domain + `"]`,
+		Usage:     usage,
+		CertPath:  "nginx/ssl/" + domain + ".crt",
+		KeyPath:   "nginx/ssl/" + domain + ".key",
+		NotBefore: now,
+		NotAfter:  now.AddDate(0, 0, 90),
+		Status:    status,
+	}
+	if err := database.CreateCertificate(cert); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func setCertificateStatus(t *testing.T, cert *database.Certificate, status string) {
+	t.Helper()
+	cert.Status = status
+	if err := database.UpdateCertificate(cert); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testSite(id, serverName string) ProxySite {
+	return ProxySite{
+		ID:           id,
+		ServerName:   serverName,
+		UpstreamHost: "127.0.0.1",
+		UpstreamPort: 8080,
+	}
+}
+
+func TestProxySiteInactiveCertificate(t *testing.T) {
+	useTestDB(t)
+	cert := createTestCertificate(t, "app.example.com", database.CertUsageServer, "active")
+	revoked := createTestCertificate(t, "other.example.com", database.CertUsageServer, "revoked")
+
+	site := testSite("app", "app.example.com")
+	site.SSL = true
+	site.CertificateID = cert.ID
+	if err := SaveProxySite(site, SystemChange("测试")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 站点已引用的证书被撤销后，仍可以修改站点的其他设置
+	for _, status := range []string{"revoked", "error", "expired"} {
+		setCertificateStatus(t, cert, status)
+		site.UpstreamPort = 9090
+		files, err := buildProxySiteFiles(site)
+		if err != nil {
+			t.Fatalf("%s: %v", status, err)
+		}
+		if !strings.Contains(string(files[0].Content), "ssl/app.example.com.crt") {
+			t.Errorf("%s: 配置中缺少原证书:\n%s", status, files[0].Content)
+		}
+	}
+
+	// 新选择的证书必须有效
+	site.CertificateID = revoked.ID
+	if _, err := buildProxySiteFiles(site); err == nil || !strings.Contains(err.Error(), "证书状态无效") {
+		t.Errorf("err = %v", err)
+	}
+
+	// 新站点不能使用无效的证书
+	other := testSite("other", "other.example.com")
+	other.SSL = true
+	other.CertificateID = revoked.ID
+	if _, err := buildProxySiteFiles(other); err == nil {
+		t.Error("新站点使用已撤销的证书应返回错误")
+	}
+}
+
+func TestProxySiteInactiveUpstreamClientCertificate(t *testing.T) {
+	useTestDB(t)
+	client := createTestCertificate(t, "client", database.CertUsageClient, "active")
+
+	site := testSite("mtls", "mtls.example.com")
+	site.UpstreamScheme = "https"
+	site.UpstreamClientCertID = client.ID
+	if err := SaveProxySite(site, SystemChange("测试")); err != nil {
+		t.Fatal(err)
+	}
+
+	setCertificateStatus(t, client, "revoked")
+	if _, err := buildProxySiteFiles(site); err != nil {
+		t.Errorf("已引用的客户端证书被撤销后保存失败: %v", err)
+	}
+
+	site.ID = "mtls-new"
+	if _, err := buildProxySiteFiles(site); err == nil {
+		t.Error("新站点使用已撤销的客户端证书应返回错误")
+	}
+}
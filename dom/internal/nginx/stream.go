@@ -11,11 +11,11 @@ import (
 
 // StreamRoute SNI 路由规则
 type StreamRoute struct {
-	ID      string `json:"id"`      // 唯一标识
-	Name    string `json:"name"`    // 名称/备注
-	Domain  string `json:"domain"`  // 域名（SNI 匹配）
-	Backend string `json:"backend"` // 后端地址 (host:port)
-	Enabled bool   `json:"enabled"` // 是否启用
+	ID      string `json:"id" toml:"id"`           // 唯一标识
+	Name    string `json:"name" toml:"name"`       // 名称/备注
+	Domain  string `json:"domain" toml:"domain"`   // 域名（SNI 匹配）
+	Backend string `json:"backend" toml:"backend"` // 后端地址 (host:port)
+	Enabled bool   `json:"enabled" toml:"enabled"` // 是否启用
 }
 
 // GetStreamDir 获取 stream 配置目录路径
@@ -33,38 +33,46 @@ func EnsureStreamDir() error {
 	return nil
 }
 
-// SaveStreamRoute 保存 SNI 路由规则
-func SaveStreamRoute(route StreamRoute) error {
+// SaveStreamRoute 保存 SNI 路由规则，元数据和重新生成的 nginx.conf 记录为一个历史版本
+func SaveStreamRoute(route StreamRoute, change Change) error {
+	plan, err := BuildPlan(PlanRequest{StreamRoutes: []StreamRoute{route}})
+	if err != nil {
+		return err
+	}
+
+	// 确保目录存在
+	if err := EnsureStreamDir(); err != nil {
+		return err
+	}
+
+	if err := plan.Apply(change); err != nil {
+		return fmt.Errorf("保存路由失败: %w", err)
+	}
+
+	log.Info("SNI 路由已保存", map[string]interface{}{"id": route.ID, "domain": route.Domain})
+	return nil
+}
+
+// buildStreamRouteFile 校验路由并生成元数据文件（不落盘）
+func buildStreamRouteFile(route StreamRoute) (fileChange, error) {
 	// 验证必填字段
 	if route.ID == "" {
-		return fmt.Errorf("路由ID不能为空")
+		return fileChange{}, fmt.Errorf("路由ID不能为空")
 	}
 	if route.Domain == "" {
-		return fmt.Errorf("域名不能为空")
+		return fileChange{}, fmt.Errorf("域名不能为空")
 	}
 	if route.Backend == "" {
-		return fmt.Errorf("后端地址不能为空")
-	}
-
-	// 确保目录存在
-	if err := EnsureStreamDir(); err != nil {
-		return err
+		return fileChange{}, fmt.Errorf("后端地址不能为空")
 	}
 
-	// 保存元数据文件
-	streamDir := GetStreamDir()
-	metaPath := filepath.Join(streamDir, "."+route.ID+".json")
+	metaPath := filepath.Join(GetStreamDir(), "."+route.ID+".json")
 	metaData, err := json.MarshalIndent(route, "", "  ")
 	if err != nil {
-		return fmt.Errorf("序列化元数据失败: %w", err)
-	}
-
-	if err := os.WriteFile(metaPath, metaData, 0644); err != nil {
-		return fmt.Errorf("保存元数据失败: %w", err)
+		return fileChange{}, fmt.Errorf("序列化元数据失败: %w", err)
 	}
 
-	log.Info("SNI 路由已保存", map[string]interface{}{"id": route.ID, "domain": route.Domain})
-	return nil
+	return fileChange{Path: metaPath, Content: metaData}, nil
 }
 
 // GetStreamRoute 获取单个 SNI 路由规则
@@ -119,13 +127,15 @@ func ListStreamRoutes() ([]StreamRoute, error) {
 	return routes, nil
 }
 
-// DeleteStreamRoute 删除 SNI 路由规则
-func DeleteStreamRoute(id string) error {
-	streamDir := GetStreamDir()
-	metaPath := filepath.Join(streamDir, "."+id+".json")
+// DeleteStreamRoute 删除 SNI 路由规则，元数据和重新生成的 nginx.conf 记录为一个历史版本
+func DeleteStreamRoute(id string, change Change) error {
+	plan, err := BuildPlan(PlanRequest{DeleteStreamRoutes: []string{id}})
+	if err != nil {
+		return err
+	}
 
-	if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("删除元数据失败: %w", err)
+	if err := plan.Apply(change); err != nil {
+		return fmt.Errorf("删除路由失败: %w", err)
 	}
 
 	log.Info("SNI 路由已删除", map[string]interface{}{"id": id})
@@ -133,7 +143,7 @@ func DeleteStreamRoute(id string) error {
 }
 
 // ToggleStreamRoute 切换 SNI 路由启用状态
-func ToggleStreamRoute(id string) (*StreamRoute, error) {
+func ToggleStreamRoute(id string, change Change) (*StreamRoute, error) {
 	route, err := GetStreamRoute(id)
 	if err != nil {
 		return nil, err
@@ -141,7 +151,7 @@ func ToggleStreamRoute(id string) (*StreamRoute, error) {
 
 	route.Enabled = !route.Enabled
 
-	if err := SaveStreamRoute(*route); err != nil {
+	if err := SaveStreamRoute(*route, change); err != nil {
 		return nil, err
 	}
 
@@ -188,16 +198,12 @@ func handleSaveStreamRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := SaveStreamRoute(route); err != nil {
+	change := ChangeFromRequest(r, "保存 SNI 路由 "+route.ID)
+	if err := SaveStreamRoute(route, change); err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// 保存后重新生成 nginx.conf
-	if err := RegenerateNginxConf(); err != nil {
-		log.Warn("重新生成 nginx.conf 失败", map[string]interface{}{"error": err.Error()})
-	}
-
 	jsonResponse(w, map[string]interface{}{
 		"success": true,
 		"id":      route.ID,
@@ -212,16 +218,12 @@ func handleDeleteStreamRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := DeleteStreamRoute(id); err != nil {
+	change := ChangeFromRequest(r, "删除 SNI 路由 "+id)
+	if err := DeleteStreamRoute(id, change); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// 删除后重新生成 nginx.conf
-	if err := RegenerateNginxConf(); err != nil {
-		log.Warn("重新生成 nginx.conf 失败", map[string]interface{}{"error": err.Error()})
-	}
-
 	jsonResponse(w, map[string]bool{"success": true})
 }
 
@@ -233,17 +235,13 @@ func handleToggleStreamRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	route, err := ToggleStreamRoute(id)
+	change := ChangeFromRequest(r, "切换 SNI 路由 "+id)
+	route, err := ToggleStreamRoute(id, change)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// 切换后重新生成 nginx.conf
-	if err := RegenerateNginxConf(); err != nil {
-		log.Warn("重新生成 nginx.conf 失败", map[string]interface{}{"error": err.Error()})
-	}
-
 	jsonResponse(w, map[string]interface{}{
 		"success": true,
 		"enabled": route.Enabled,
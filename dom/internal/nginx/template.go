@@ -18,6 +18,11 @@ type TemplateParams struct {
 	ClientMaxBodySize string `json:"clientMaxBodySize" toml:"client_max_body_size"` // 客户端最大请求体
 	Gzip              bool   `json:"gzip" toml:"gzip"`                              // 是否启用 gzip
 	ServerTokens      bool   `json:"serverTokens" toml:"server_tokens"`             // 是否显示 nginx 版本
+	HTTPChallenge     bool   `json:"httpChallenge" toml:"http_challenge"`           // 是否监听 80 端口提供 ACME HTTP-01 验证
+	TLSProfile        string `json:"tlsProfile" toml:"tls_profile"`                 // TLS 配置档：modern、intermediate（默认）或 old
+	OCSPStapling      bool   `json:"ocspStapling" toml:"ocsp_stapling"`             // 是否启用 OCSP Stapling
+	Resolver          string `json:"resolver" toml:"resolver"`                      // OCSP Stapling 使用的 DNS 解析服务器，空格分隔
+	DHParamBits       int    `json:"dhParamBits" toml:"dh_param_bits"`              // DH 参数长度：2048、3072 或 4096，0 表示不配置
 }
 
 // FullTemplateParams 完整模板参数（包含 stream 路由）
@@ -35,6 +40,7 @@ func DefaultTemplateParams() TemplateParams {
 		ClientMaxBodySize: "100m",
 		Gzip:              true,
 		ServerTokens:      false,
+		TLSProfile:        TLSProfileIntermediate,
 	}
 }
 
@@ -48,9 +54,31 @@ func LoadTemplateParams() TemplateParams {
 		ClientMaxBodySize: cfg.Nginx.ClientMaxBodySize,
 		Gzip:              cfg.Nginx.Gzip,
 		ServerTokens:      cfg.Nginx.ServerTokens,
+		HTTPChallenge:     cfg.Nginx.HTTPChallenge,
+		TLSProfile:        cfg.Nginx.TLSProfile,
+		OCSPStapling:      cfg.Nginx.OCSPStapling,
+		Resolver:          cfg.Nginx.Resolver,
+		DHParamBits:       cfg.Nginx.DHParamBits,
 	}
 }
 
+// SaveTemplateParams 将模板参数保存到配置文件
+func SaveTemplateParams(params TemplateParams) error {
+	return config.Update(func(cfg *config.Config) {
+		cfg.Nginx.WorkerProcesses = params.WorkerProcesses
+		cfg.Nginx.WorkerConnections = params.WorkerConnections
+		cfg.Nginx.Keepalive = params.Keepalive
+		cfg.Nginx.ClientMaxBodySize = params.ClientMaxBodySize
+		cfg.Nginx.Gzip = params.Gzip
+		cfg.Nginx.ServerTokens = params.ServerTokens
+		cfg.Nginx.HTTPChallenge = params.HTTPChallenge
+		cfg.Nginx.TLSProfile = params.TLSProfile
+		cfg.Nginx.OCSPStapling = params.OCSPStapling
+		cfg.Nginx.Resolver = params.Resolver
+		cfg.Nginx.DHParamBits = params.DHParamBits
+	})
+}
+
 // NginxPaths 返回 nginx 相关的路径配置
 type NginxPaths struct {
 	// 根目录，所有其他路径都基于此
@@ -176,13 +204,48 @@ http {
     gzip_min_length 1000;
     {{end}}
 
-    # SSL 通用配置
-    ssl_protocols TLSv1.2 TLSv1.3;
-    ssl_prefer_server_ciphers on;
-    ssl_ciphers ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384;
+    # SSL 通用配置（TLS 配置档: {{.TLS.Name}}）
+    ssl_protocols {{.TLS.Protocols}};
+{{- if .TLS.Ciphers}}
+    ssl_ciphers {{.TLS.Ciphers}};
+{{- end}}
+    ssl_prefer_server_ciphers {{if .TLS.PreferServerCiphers}}on{{else}}off{{end}};
+    ssl_ecdh_curve X25519:prime256v1:secp384r1;
     ssl_session_cache shared:SSL:10m;
     ssl_session_timeout 1d;
     ssl_session_tickets off;
+{{- if .DHParamBits}}
+    ssl_dhparam {{.DHParamFile}};
+{{- end}}
+{{- if .OCSPStapling}}
+
+    # OCSP Stapling：证书没有 OCSP 地址时 nginx 只记录警告
+    ssl_stapling on;
+    ssl_stapling_verify on;
+{{- if .TrustedCertificate}}
+    ssl_trusted_certificate {{.TrustedCertificate}};
+{{- end}}
+    resolver {{.Resolver}} valid=300s;
+    resolver_timeout 5s;
+{{- end}}
+
+    {{- if .HTTPChallenge}}
+
+    # 80 端口：ACME HTTP-01 验证由 Hop 响应，其余请求跳转到 HTTPS
+    server {
+        listen 80 default_server;
+        server_name _;
+
+        location ^~ /.well-known/acme-challenge/ {
+            proxy_pass http://127.0.0.1:{{.HopPort}};
+            proxy_set_header Host $host;
+        }
+
+        location / {
+            return 301 https://$host$request_uri;
+        }
+    }
+    {{- end}}
 
     # 包含站点配置
     include conf.d/*.conf;
@@ -196,30 +259,69 @@ func RenderNginxConf(params FullTemplateParams) (string, error) {
 		return "", fmt.Errorf("解析模板失败: %w", err)
 	}
 
+	profile, err := resolveTLSProfile(params.TLSProfile)
+	if err != nil {
+		return "", err
+	}
+	if err := validateDHParamBits(params.DHParamBits); err != nil {
+		return "", err
+	}
+	var trusted string
+	if params.OCSPStapling {
+		if err := validateResolver(params.Resolver); err != nil {
+			return "", err
+		}
+		trusted = systemTrustedCertificate()
+	}
+
+	// HopPort 用于将 HTTP-01 验证请求转发给 Hop
+	// TrustedCertificate 为校验 OCSP 响应使用的系统 CA 证书包
+	data := struct {
+		FullTemplateParams
+		HopPort            int
+		TLS                tlsProfile
+		DHParamFile        string
+		TrustedCertificate string
+	}{params, config.Get().Server.Port, profile, dhParamFile, trusted}
+
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, params); err != nil {
+	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("渲染模板失败: %w", err)
 	}
 
 	return buf.String(), nil
 }
 
+// buildNginxConfFile 渲染 nginx.conf 文件（不落盘）
+func buildNginxConfFile(params FullTemplateParams) (fileChange, error) {
+	content, err := RenderNginxConf(params)
+	if err != nil {
+		return fileChange{}, err
+	}
+	return fileChange{Path: GetNginxPaths().ConfigPath, Content: []byte(content)}, nil
+}
+
 // GenerateAndSaveNginxConf 生成并保存 nginx.conf
-func GenerateAndSaveNginxConf(params FullTemplateParams) error {
+func GenerateAndSaveNginxConf(params FullTemplateParams, change Change) error {
 	// 确保目录存在
 	if err := EnsureNginxDirs(); err != nil {
 		return err
 	}
 
 	// 渲染配置
-	content, err := RenderNginxConf(params)
+	file, err := buildNginxConfFile(params)
 	if err != nil {
 		return err
 	}
 
+	// nginx.conf 引用的 DH 参数文件需要先存在
+	if err := ensureDHParam(params.DHParamBits); err != nil {
+		return err
+	}
+
 	// 保存文件
 	paths := GetNginxPaths()
-	if err := os.WriteFile(paths.ConfigPath, []byte(content), 0644); err != nil {
+	if err := applyFileChanges(change, []fileChange{file}); err != nil {
 		return fmt.Errorf("保存 nginx.conf 失败: %w", err)
 	}
 
@@ -228,7 +330,7 @@ func GenerateAndSaveNginxConf(params FullTemplateParams) error {
 }
 
 // RegenerateNginxConf 使用当前参数重新生成 nginx.conf
-func RegenerateNginxConf() error {
+func RegenerateNginxConf(change Change) error {
 	// 读取当前模板参数
 	templateParams := LoadTemplateParams()
 
@@ -245,7 +347,7 @@ func RegenerateNginxConf() error {
 		StreamRoutes:   streamRoutes,
 	}
 
-	return GenerateAndSaveNginxConf(fullParams)
+	return GenerateAndSaveNginxConf(fullParams, change)
 }
 
 // InitNginxConfig 初始化 nginx 配置（如果不存在则创建）
@@ -259,6 +361,11 @@ func InitNginxConfig() error {
 		return err
 	}
 
+	// 为已有配置创建历史基线
+	if err := ensureHistoryBaseline(); err != nil {
+		log.Warn("创建配置历史基线失败", map[string]interface{}{"error": err.Error()})
+	}
+
 	paths := GetNginxPaths()
 
 	// 如果配置文件不存在，使用默认参数生成
@@ -267,7 +374,7 @@ func InitNginxConfig() error {
 			TemplateParams: DefaultTemplateParams(),
 			StreamRoutes:   []StreamRoute{},
 		}
-		return GenerateAndSaveNginxConf(fullParams)
+		return GenerateAndSaveNginxConf(fullParams, SystemChange("初始化 nginx.conf"))
 	}
 
 	return nil
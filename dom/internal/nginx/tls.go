@@ -0,0 +1,219 @@
+package nginx
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TLS 配置档，参考 Mozilla SSL Configuration Generator
+const (
+	TLSProfileModern       = "modern"       // 仅 TLS 1.3，适合只需支持新客户端的站点
+	TLSProfileIntermediate = "intermediate" // TLS 1.2 和 1.3，默认
+	TLSProfileOld          = "old"          // 兼容 TLS 1.0/1.1 的旧客户端
+)
+
+// tlsProfile 一组 SSL 协议和加密套件设置
+type tlsProfile struct {
+	Name                string
+	Protocols           string
+	Ciphers             string // 为空不设置 ssl_ciphers（TLS 1.3 的加密套件不受其影响）
+	PreferServerCiphers bool
+}
+
+// intermediateCiphers Mozilla intermediate 配置档的 TLS 1.2 加密套件
+const intermediateCiphers = "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:" +
+	"ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:" +
+	"ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:" +
+	"DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:DHE-RSA-CHACHA20-POLY1305"
+
+var tlsProfiles = map[string]tlsProfile{
+	TLSProfileModern: {
+		Name:      TLSProfileModern,
+		Protocols: "TLSv1.3",
+	},
+	TLSProfileIntermediate: {
+		Name:      TLSProfileIntermediate,
+		Protocols: "TLSv1.2 TLSv1.3",
+		Ciphers:   intermediateCiphers,
+	},
+	TLSProfileOld: {
+		Name:      TLSProfileOld,
+		Protocols: "TLSv1 TLSv1.1 TLSv1.2 TLSv1.3",
+		// OpenSSL 3 默认安全级别不允许 TLS 1.0/1.1 和 SHA1 套件，需要降到 0
+		Ciphers: intermediateCiphers + ":" +
+			"ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES128-SHA:" +
+			"ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:" +
+			"DHE-RSA-AES128-SHA256:DHE-RSA-AES256-SHA256:AES128-GCM-SHA256:AES256-GCM-SHA384:" +
+			"AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:@SECLEVEL=0",
+		PreferServerCiphers: true,
+	},
+}
+
+// resolveTLSProfile 按名称获取 TLS 配置档，为空时使用 intermediate
+func resolveTLSProfile(name string) (tlsProfile, error) {
+	if name == "" {
+		name = TLSProfileIntermediate
+	}
+	profile, ok := tlsProfiles[name]
+	if !ok {
+		return tlsProfile{}, fmt.Errorf("不支持的 TLS 配置档: %s，可选 modern、intermediate、old", name)
+	}
+	return profile, nil
+}
+
+// siteTLSProfile 站点单独设置的 TLS 配置档，未设置时返回 nil（使用 nginx.conf 中的全局设置）
+func siteTLSProfile(site ProxySite) (*tlsProfile, error) {
+	if site.TLS13Only {
+		profile := tlsProfiles[TLSProfileModern]
+		profile.Name = "TLS 1.3 only"
+		return &profile, nil
+	}
+	if site.TLSProfile == "" {
+		return nil, nil
+	}
+	profile, err := resolveTLSProfile(site.TLSProfile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// validateResolver 校验 OCSP Stapling 使用的 DNS 解析服务器，例如 "1.1.1.1 8.8.8.8:53 ipv6=off"
+func validateResolver(resolver string) error {
+	fields := strings.Fields(resolver)
+	if len(fields) == 0 {
+		return fmt.Errorf("启用 OCSP Stapling 需要配置 DNS 解析服务器")
+	}
+	for _, field := range fields {
+		if field == "ipv4=off" || field == "ipv6=off" || field == "ipv4=on" || field == "ipv6=on" {
+			continue
+		}
+		host := field
+		if h, _, err := net.SplitHostPort(field); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if net.ParseIP(host) == nil && !isHostname(host) {
+			return fmt.Errorf("无效的 DNS 解析服务器: %s", field)
+		}
+	}
+	return nil
+}
+
+// isHostname 是否为合法的主机名
+func isHostname(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// systemTrustedCertificate 系统 CA 证书包，用于校验 OCSP 响应
+func systemTrustedCertificate() string {
+	for _, bundle := range systemCABundles {
+		if _, err := os.Stat(bundle); err == nil {
+			return bundle
+		}
+	}
+	return ""
+}
+
+// dhParamFile nginx.conf 中引用的 DH 参数文件，相对于 nginx 目录
+const dhParamFile = "ssl/dhparam.pem"
+
+// ffdheGroups RFC 7919 预定义的 ffdhe 组，p = 2^b - 2^(b-64) + (floor(2^(b-130) * e) + c) * 2^64 - 1
+var ffdheGroups = map[int]int64{
+	2048: 560316,
+	3072: 2625351,
+	4096: 5736041,
+}
+
+// validateDHParamBits 校验 DH 参数长度，0 表示不配置
+func validateDHParamBits(bits int) error {
+	if bits == 0 {
+		return nil
+	}
+	if _, ok := ffdheGroups[bits]; !ok {
+		return fmt.Errorf("不支持的 DH 参数长度: %d，可选 2048、3072、4096", bits)
+	}
+	return nil
+}
+
+// ensureDHParam 生成 DH 参数文件，已有相同长度的文件时跳过
+// 使用 RFC 7919 的 ffdhe 组（与 Mozilla 推荐的 dhparam 一致），不需要 openssl 且可立即完成
+func ensureDHParam(bits int) error {
+	if bits == 0 {
+		return nil
+	}
+	path := filepath.Join(GetNginxPaths().BaseDir, dhParamFile)
+	if current, err := dhParamBits(path); err == nil && current == bits {
+		return nil
+	}
+
+	der, err := asn1.Marshal(struct{ P, G *big.Int }{ffdhePrime(bits), big.NewInt(2)})
+	if err != nil {
+		return fmt.Errorf("生成 DH 参数失败: %w", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "DH PARAMETERS", Bytes: der})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存 DH 参数失败: %w", err)
+	}
+	log.Info("DH 参数已生成", map[string]interface{}{"bits": bits, "path": path})
+	return nil
+}
+
+// dhParamBits 读取已有 DH 参数文件的长度
+func dhParamBits(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "DH PARAMETERS" {
+		return 0, fmt.Errorf("不是 DH 参数文件")
+	}
+	var params struct{ P, G *big.Int }
+	if _, err := asn1.Unmarshal(block.Bytes, &params); err != nil {
+		return 0, err
+	}
+	return params.P.BitLen(), nil
+}
+
+// ffdhePrime 计算 RFC 7919 ffdhe 组的素数
+func ffdhePrime(bits int) *big.Int {
+	n := uint(bits)
+	p := new(big.Int).Lsh(big.NewInt(1), n)
+	p.Sub(p, new(big.Int).Lsh(big.NewInt(1), n-64))
+	x := floorScaledE(n - 130)
+	x.Add(x, big.NewInt(ffdheGroups[bits]))
+	p.Add(p, x.Lsh(x, 64))
+	return p.Sub(p, big.NewInt(1))
+}
+
+// floorScaledE 计算 floor(2^n * e)，按 e = Σ 1/k! 用整数运算，多保留 64 位抵消截断误差
+func floorScaledE(n uint) *big.Int {
+	const guard = 64
+	sum := new(big.Int)
+	term := new(big.Int).Lsh(big.NewInt(1), n+guard)
+	for k := int64(1); term.Sign() > 0; k++ {
+		sum.Add(sum, term)
+		term.Div(term, big.NewInt(k))
+	}
+	return sum.Rsh(sum, guard)
+}
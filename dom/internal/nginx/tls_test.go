@@ -0,0 +1,80 @@
+package nginx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hop/backend/internal/config"
+)
+
+// useTempDataDir 将数据目录指向临时目录，测试结束后恢复
+// PATH 中没有 nginx，写入配置时跳过校验
+func useTempDataDir(t *testing.T) NginxPaths {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Data.Dir
+	cfg.Data.Dir = t.TempDir()
+	t.Cleanup(func() { cfg.Data.Dir = original })
+	t.Setenv("PATH", t.TempDir())
+
+	if err := EnsureNginxDirs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureStreamDir(); err != nil {
+		t.Fatal(err)
+	}
+	return GetNginxPaths()
+}
+
+func TestPlanApplyGeneratesDHParam(t *testing.T) {
+	paths := useTempDataDir(t)
+
+	params := DefaultTemplateParams()
+	params.DHParamBits = 2048
+	plan, err := BuildPlan(PlanRequest{TemplateParams: &params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plan.Diff, "ssl_dhparam "+dhParamFile) {
+		t.Fatalf("nginx.conf 未引用 DH 参数文件:\n%s", plan.Diff)
+	}
+
+	dhPath := filepath.Join(paths.BaseDir, dhParamFile)
+	if _, err := os.Stat(dhPath); !os.IsNotExist(err) {
+		t.Fatalf("预览时不应生成 DH 参数文件: %v", err)
+	}
+
+	if err := plan.Apply(SystemChange("测试")); err != nil {
+		t.Fatal(err)
+	}
+	if bits, err := dhParamBits(dhPath); err != nil || bits != 2048 {
+		t.Errorf("DH 参数文件 = %d (%v), want 2048", bits, err)
+	}
+	if _, err := os.Stat(paths.ConfigPath); err != nil {
+		t.Errorf("nginx.conf 未写入: %v", err)
+	}
+}
+
+func TestEnsureDHParam(t *testing.T) {
+	paths := useTempDataDir(t)
+	dhPath := filepath.Join(paths.BaseDir, dhParamFile)
+
+	// 0 表示不配置，不生成文件
+	if err := ensureDHParam(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dhPath); !os.IsNotExist(err) {
+		t.Errorf("不应生成 DH 参数文件: %v", err)
+	}
+
+	for _, bits := range []int{2048, 3072, 4096} {
+		if err := ensureDHParam(bits); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := dhParamBits(dhPath); err != nil || got != bits {
+			t.Errorf("DH 参数长度 = %d (%v), want %d", got, err, bits)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConfig SMTP 邮件
+type EmailConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`     // 默认 tls 465、starttls 587、none 25
+	Security string   `json:"security"` // starttls（默认）、tls 或 none
+	Username string   `json:"username"` // 为空时不认证
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+func (c *EmailConfig) validate() error {
+	c.Host = strings.TrimSpace(c.Host)
+	if c.Host == "" {
+		return fmt.Errorf("SMTP 服务器不能为空")
+	}
+	switch c.Security {
+	case "":
+		c.Security = "starttls"
+	case "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("不支持的加密方式: %s", c.Security)
+	}
+	if c.Port == 0 {
+		c.Port = map[string]int{"tls": 465, "starttls": 587, "none": 25}[c.Security]
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("无效的端口: %d", c.Port)
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("无效的发件人: %s", c.From)
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("至少需要一个收件人")
+	}
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("无效的收件人: %s", to)
+		}
+	}
+	return nil
+}
+
+func (c *EmailConfig) secret() *string { return &c.Password }
+
+func (c *EmailConfig) Send(ctx context.Context, e *Event) error {
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	tlsConfig := &tls.Config{ServerName: c.Host}
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if c.Security == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if c.Security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP 服务器不支持 STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(c.From)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("发件人被拒绝: %w", err)
+	}
+	for _, to := range c.To {
+		addr, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("收件人 %s 被拒绝: %w", addr.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if _, err := w.Write(c.message(e)); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// message 生成邮件内容，正文使用 base64 编码
+func (c *EmailConfig) message(e *Event) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(c.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("utf-8", "[Hop] "+e.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(e.Message + "\n"))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,220 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP 最小的 SMTP 服务器，记录收到的信封和邮件内容
+type fakeSMTP struct {
+	addr string
+
+	mu   sync.Mutex
+	auth string // AUTH PLAIN 的凭据（已解码）
+	from string
+	rcpt []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTP{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+
+		s.mu.Lock()
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			// 不声明 STARTTLS
+			tp.PrintfLine("250-fake\r\n250 AUTH PLAIN")
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(initial)
+			if mechanism != "PLAIN" || string(decoded) != "\x00user\x00pass" {
+				tp.PrintfLine("535 5.7.8 authentication failed")
+				break
+			}
+			s.auth = string(decoded)
+			tp.PrintfLine("235 2.7.0 ok")
+		case "MAIL":
+			s.from = arg
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			if strings.Contains(arg, "reject") {
+				tp.PrintfLine("550 5.1.1 no such user")
+				break
+			}
+			s.rcpt = append(s.rcpt, arg)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			s.mu.Unlock()
+			data, err := io.ReadAll(tp.DotReader())
+			s.mu.Lock()
+			if err == nil {
+				s.data = string(data)
+			}
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			s.mu.Unlock()
+			return
+		default:
+			tp.PrintfLine("502 unsupported")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *fakeSMTP) config() EmailConfig {
+	host, port, _ := net.SplitHostPort(s.addr)
+	p, _ := strconv.Atoi(port)
+	return EmailConfig{
+		Host:     host,
+		Port:     p,
+		Security: "none",
+		From:     "Hop <hop@example.com>",
+		To:       []string{"ops@example.com", "Admin <admin@example.com>"},
+	}
+}
+
+func TestEmailSend(t *testing.T) {
+	server := newFakeSMTP(t)
+	cfg := server.config()
+	// net/smtp 只允许在 TLS 或本机连接上使用 PLAIN 认证
+	cfg.Username = "user"
+	cfg.Password = "pass"
+	channel := mustParse(t, "email", cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := channel.Send(ctx, sampleEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.auth == "" {
+		t.Error("未进行认证")
+	}
+	if server.from != "FROM:<hop@example.com>" {
+		t.Errorf("MAIL %s", server.from)
+	}
+	if strings.Join(server.rcpt, ",") != "TO:<ops@example.com>,TO:<admin@example.com>" {
+		t.Errorf("RCPT %v", server.rcpt)
+	}
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(server.data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "[Hop] 证书续期失败: example.com" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if msg.Header.Get("Content-Transfer-Encoding") != "base64" || !strings.HasPrefix(msg.Header.Get("Content-Type"), "text/plain; charset=utf-8") {
+		t.Errorf("header = %v", msg.Header)
+	}
+	if date, err := msg.Header.Date(); err != nil || !date.Equal(sampleEvent().Time) {
+		t.Errorf("Date = %s", msg.Header.Get("Date"))
+	}
+
+	raw, _ := io.ReadAll(msg.Body)
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 行超过 76 个字符: %d", len(line))
+		}
+	}
+	body, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(raw), "\r\n", ""))
+	if err != nil || string(body) != sampleEvent().Message+"\n" {
+		t.Errorf("body = %q (%v)", body, err)
+	}
+}
+
+func TestEmailErrors(t *testing.T) {
+	server := newFakeSMTP(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg := server.config()
+	cfg.Username = "user"
+	cfg.Password = "wrong"
+	if err := mustParse(t, "email", cfg).Send(ctx, sampleEvent()); err == nil || !strings.Contains(err.Error(), "SMTP 认证失败") {
+		t.Errorf("err = %v", err)
+	}
+
+	cfg = server.config()
+	cfg.To = []string{"reject@example.com"}
+	if err := mustParse(t, "email", cfg).Send(ctx, sampleEvent()); err == nil || !strings.Contains(err.Error(), "reject@example.com 被拒绝") {
+		t.Errorf("err = %v", err)
+	}
+
+	// 要求 STARTTLS 但服务器不支持时不能以明文发送
+	cfg = server.config()
+	cfg.Security = "starttls"
+	if err := mustParse(t, "email", cfg).Send(ctx, sampleEvent()); err == nil || !strings.Contains(err.Error(), "不支持 STARTTLS") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestEmailValidate(t *testing.T) {
+	cfg := mustParse(t, "email", EmailConfig{Host: " smtp.example.com ", From: "hop@example.com", To: []string{"ops@example.com"}}).(*EmailConfig)
+	if cfg.Host != "smtp.example.com" || cfg.Security != "starttls" || cfg.Port != 587 {
+		t.Errorf("默认值 = %+v", cfg)
+	}
+	cfg = mustParse(t, "email", EmailConfig{Host: "smtp.example.com", Security: "tls", From: "hop@example.com", To: []string{"ops@example.com"}}).(*EmailConfig)
+	if cfg.Port != 465 {
+		t.Errorf("tls 默认端口 = %d", cfg.Port)
+	}
+
+	for _, c := range []EmailConfig{
+		{From: "hop@example.com", To: []string{"ops@example.com"}},
+		{Host: "smtp.example.com", Security: "ssl", From: "hop@example.com", To: []string{"ops@example.com"}},
+		{Host: "smtp.example.com", Port: 70000, From: "hop@example.com", To: []string{"ops@example.com"}},
+		{Host: "smtp.example.com", From: "not an address", To: []string{"ops@example.com"}},
+		{Host: "smtp.example.com", From: "hop@example.com"},
+		{Host: "smtp.example.com", From: "hop@example.com", To: []string{"ops"}},
+	} {
+		if err := c.validate(); err == nil {
+			t.Errorf("%+v: 应返回错误", c)
+		}
+	}
+}
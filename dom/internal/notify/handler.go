@@ -0,0 +1,282 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/database"
+)
+
+// Router 创建通知路由
+func Router() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/events", handleListEvents)
+	r.Get("/channels", handleListChannels)
+	r.Post("/channels", handleCreateChannel)
+	r.Put("/channels/{id}", handleUpdateChannel)
+	r.Delete("/channels/{id}", handleDeleteChannel)
+	r.Post("/channels/{id}/test", handleTestChannel)
+
+	return r
+}
+
+// ChannelRequest 创建或修改通知渠道请求
+type ChannelRequest struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Config  json.RawMessage `json:"config"` // 修改时密钥留空沿用原值
+	Events  []string        `json:"events"`
+	Enabled *bool           `json:"enabled"` // 不填时创建为启用，修改时保持不变
+}
+
+// ChannelResponse 通知渠道信息，配置中不包含密钥
+type ChannelResponse struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Config     ChannelConfig `json:"config"`
+	HasSecret  bool          `json:"hasSecret"`
+	Events     []string      `json:"events"`
+	Enabled    bool          `json:"enabled"`
+	LastSentAt *string       `json:"lastSentAt"`
+	LastError  string        `json:"lastError"`
+	CreatedAt  string        `json:"createdAt"`
+	UpdatedAt  string        `json:"updatedAt"`
+}
+
+func channelToResponse(ch *database.NotificationChannel) ChannelResponse {
+	resp := ChannelResponse{
+		ID:        ch.ID,
+		Name:      ch.Name,
+		Type:      ch.Type,
+		Events:    channelEvents(ch),
+		Enabled:   ch.Enabled,
+		LastError: ch.LastError,
+		CreatedAt: ch.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: ch.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if resp.Events == nil {
+		resp.Events = []string{}
+	}
+	if cfg, err := ParseChannelConfig(ch.Type, ch.Config); err == nil {
+		if s := cfg.secret(); s != nil {
+			resp.HasSecret = *s != ""
+			*s = ""
+		}
+		resp.Config = cfg
+	}
+	if ch.LastSentAt != nil {
+		s := ch.LastSentAt.Format("2006-01-02T15:04:05Z07:00")
+		resp.LastSentAt = &s
+	}
+	return resp
+}
+
+// validateEvents 校验订阅的事件并去重
+func validateEvents(events []string) ([]string, bool) {
+	var result []string
+	for _, e := range events {
+		valid := false
+		for _, known := range Events {
+			if e == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, false
+		}
+		dup := false
+		for _, r := range result {
+			if r == e {
+				dup = true
+			}
+		}
+		if !dup {
+			result = append(result, e)
+		}
+	}
+	return result, len(result) > 0
+}
+
+func handleListEvents(w http.ResponseWriter, r *http.Request) {
+	events := make([]map[string]string, 0, len(Events))
+	for _, e := range Events {
+		events = append(events, map[string]string{"type": e, "label": eventTitles[e]})
+	}
+	jsonResponse(w, map[string]interface{}{
+		"events": events,
+	})
+}
+
+func handleListChannels(w http.ResponseWriter, r *http.Request) {
+	channels, err := database.ListNotificationChannels()
+	if err != nil {
+		jsonError(w, "获取通知渠道失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ChannelResponse, 0, len(channels))
+	for i := range channels {
+		resp = append(resp, channelToResponse(&channels[i]))
+	}
+	jsonResponse(w, map[string]interface{}{
+		"channels": resp,
+	})
+}
+
+func handleCreateChannel(w http.ResponseWriter, r *http.Request) {
+	var req ChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		jsonError(w, "名称不能为空", http.StatusBadRequest)
+		return
+	}
+	events, ok := validateEvents(req.Events)
+	if !ok {
+		jsonError(w, "请选择要通知的事件", http.StatusBadRequest)
+		return
+	}
+	cfg, err := ParseChannelConfig(req.Type, string(req.Config))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	configJSON, _ := json.Marshal(cfg)
+	eventsJSON, _ := json.Marshal(events)
+	ch := &database.NotificationChannel{
+		ID:      uuid.New().String(),
+		Name:    req.Name,
+		Type:    req.Type,
+		Config:  string(configJSON),
+		Events:  string(eventsJSON),
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if err := database.CreateNotificationChannel(ch); err != nil {
+		jsonError(w, "创建通知渠道失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"channel": channelToResponse(ch),
+	})
+}
+
+func handleUpdateChannel(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req ChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	ch, err := database.GetNotificationChannel(id)
+	if err != nil {
+		jsonError(w, "通知渠道不存在", http.StatusNotFound)
+		return
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		ch.Name = name
+	}
+	if req.Events != nil {
+		events, ok := validateEvents(req.Events)
+		if !ok {
+			jsonError(w, "请选择要通知的事件", http.StatusBadRequest)
+			return
+		}
+		eventsJSON, _ := json.Marshal(events)
+		ch.Events = string(eventsJSON)
+	}
+	if req.Enabled != nil {
+		ch.Enabled = *req.Enabled
+	}
+	if len(req.Config) > 0 {
+		channelType := ch.Type
+		if req.Type != "" {
+			channelType = req.Type
+		}
+		cfg, err := ParseChannelConfig(channelType, string(req.Config))
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// 未填写密钥时沿用原值
+		if s := cfg.secret(); s != nil && *s == "" && channelType == ch.Type {
+			if old, err := ParseChannelConfig(ch.Type, ch.Config); err == nil {
+				*s = *old.secret()
+			}
+		}
+		configJSON, _ := json.Marshal(cfg)
+		ch.Type = channelType
+		ch.Config = string(configJSON)
+	}
+
+	if err := database.UpdateNotificationChannel(ch); err != nil {
+		jsonError(w, "更新通知渠道失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"channel": channelToResponse(ch),
+	})
+}
+
+func handleDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := database.DeleteNotificationChannel(id); err != nil {
+		jsonError(w, "删除通知渠道失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]bool{"success": true})
+}
+
+// handleTestChannel 发送测试通知，不受事件订阅和启用状态限制
+func handleTestChannel(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	ch, err := database.GetNotificationChannel(id)
+	if err != nil {
+		jsonError(w, "通知渠道不存在", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), sendTimeout)
+	defer cancel()
+	if err := Send(ctx, ch, testEvent()); err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	jsonResponse(w, map[string]bool{"success": true})
+}
+
+func jsonResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func jsonError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   message,
+		"success": false,
+	})
+}
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/logger"
+)
+
+var log = logger.WithTag("notify")
+
+// sendTimeout 单个渠道发送的超时时间
+const sendTimeout = 30 * time.Second
+
+// 事件类型
+const (
+	EventRenewFailed    = "renew_failed"    // 续期失败
+	EventRenewSucceeded = "renew_succeeded" // 续期成功
+	EventExpiring       = "expiring"        // 不会自动续期的证书即将过期或已过期
+	EventTest           = "test"            // 发送测试通知
+)
+
+// Events 可订阅的事件，顺序用于界面展示
+var Events = []string{EventRenewFailed, EventExpiring, EventRenewSucceeded}
+
+// eventTitles 事件标题
+var eventTitles = map[string]string{
+	EventRenewFailed:    "证书续期失败",
+	EventRenewSucceeded: "证书已续期",
+	EventExpiring:       "证书即将过期",
+	EventTest:           "测试通知",
+}
+
+// Event 通知事件，也是 webhook 模板的数据
+type Event struct {
+	Type          string    `json:"type"`
+	Title         string    `json:"title"`
+	Message       string    `json:"message"`
+	CertificateID string    `json:"certificateId"`
+	Domain        string    `json:"domain"`
+	Domains       []string  `json:"domains"`
+	NotAfter      time.Time `json:"notAfter"`
+	DaysRemaining int       `json:"daysRemaining"`
+	Error         string    `json:"error"`
+	Time          time.Time `json:"time"`
+}
+
+// Text 纯文本内容，用于邮件和聊天工具
+func (e *Event) Text() string {
+	return e.Title + "\n\n" + e.Message
+}
+
+// ChannelConfig 通知渠道配置
+type ChannelConfig interface {
+	// Send 发送通知
+	Send(ctx context.Context, e *Event) error
+	// validate 校验配置并补充默认值
+	validate() error
+	// secret 密钥字段，没有密钥时返回 nil；接口不返回密钥，修改时留空沿用原值
+	secret() *string
+}
+
+// ParseChannelConfig 解析渠道配置
+func ParseChannelConfig(channelType, configJSON string) (ChannelConfig, error) {
+	var cfg ChannelConfig
+	switch channelType {
+	case "webhook":
+		cfg = &WebhookConfig{}
+	case "email":
+		cfg = &EmailConfig{}
+	case "slack":
+		cfg = &SlackConfig{}
+	case "feishu":
+		cfg = &FeishuConfig{}
+	case "dingtalk":
+		cfg = &DingTalkConfig{}
+	default:
+		return nil, fmt.Errorf("不支持的通知渠道类型: %s", channelType)
+	}
+	if err := json.Unmarshal([]byte(configJSON), cfg); err != nil {
+		return nil, fmt.Errorf("解析渠道配置失败: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// channelEvents 解析渠道订阅的事件
+func channelEvents(ch *database.NotificationChannel) []string {
+	var events []string
+	json.Unmarshal([]byte(ch.Events), &events)
+	return events
+}
+
+func subscribed(ch *database.NotificationChannel, eventType string) bool {
+	for _, e := range channelEvents(ch) {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify 向订阅该事件的渠道发送通知，在后台执行，不阻塞调用方
+func Notify(e Event) {
+	if e.Title == "" {
+		e.Title = eventTitles[e.Type]
+		if e.Domain != "" {
+			e.Title += ": " + e.Domain
+		}
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	channels, err := database.ListNotificationChannels()
+	if err != nil {
+		log.Error("获取通知渠道失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	for i := range channels {
+		ch := channels[i]
+		if !ch.Enabled || !subscribed(&ch, e.Type) {
+			continue
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			Send(ctx, &ch, &e)
+		}()
+	}
+}
+
+// Send 通过渠道发送通知，结果记录到渠道
+func Send(ctx context.Context, ch *database.NotificationChannel, e *Event) error {
+	cfg, err := ParseChannelConfig(ch.Type, ch.Config)
+	if err == nil {
+		err = cfg.Send(ctx, e)
+	}
+
+	now := time.Now()
+	ch.LastSentAt = &now
+	ch.LastError = ""
+	if err != nil {
+		ch.LastError = err.Error()
+	}
+	database.UpdateNotificationChannelResult(ch.ID, now, ch.LastError)
+
+	if err != nil {
+		log.Error("发送通知失败", map[string]interface{}{
+			"channel": ch.Name,
+			"event":   e.Type,
+			"error":   err.Error(),
+		})
+		return err
+	}
+	log.Info("通知已发送", map[string]interface{}{
+		"channel": ch.Name,
+		"event":   e.Type,
+		"domain":  e.Domain,
+	})
+	return nil
+}
+
+// testEvent 测试通知使用示例数据，便于检查 webhook 模板
+func testEvent() *Event {
+	now := time.Now()
+	return &Event{
+		Type:          EventTest,
+		Title:         eventTitles[EventTest],
+		Message:       "这是一条来自 Hop 的测试通知，收到说明通知渠道配置正确。",
+		Domain:        "example.com",
+		Domains:       []string{"example.com", "www.example.com"},
+		NotAfter:      now.AddDate(0, 0, 30),
+		DaysRemaining: 30,
+		Time:          now,
+	}
+}
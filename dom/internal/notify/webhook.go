@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// maxResponseBody 错误信息中保留的响应内容长度
+const maxResponseBody = 300
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// WebhookConfig 通用 webhook，请求体为模板渲染的结果
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`  // 默认 POST
+	Headers map[string]string `json:"headers"` // 额外的请求头
+	Body    string            `json:"body"`    // Go 模板，数据为事件；为空时发送事件的 JSON
+
+	tmpl *template.Template
+}
+
+// templateFuncs webhook 模板函数，json 把值编码为 JSON（字符串会带引号并转义）
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func (c *WebhookConfig) validate() error {
+	if err := validateURL(c.URL); err != nil {
+		return err
+	}
+	c.Method = strings.ToUpper(strings.TrimSpace(c.Method))
+	if c.Method == "" {
+		c.Method = http.MethodPost
+	}
+	if c.Method != http.MethodPost && c.Method != http.MethodPut {
+		return fmt.Errorf("请求方法只支持 POST 或 PUT")
+	}
+	if c.Body != "" {
+		tmpl, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(c.Body)
+		if err != nil {
+			return fmt.Errorf("请求体模板错误: %w", err)
+		}
+		c.tmpl = tmpl
+	}
+	return nil
+}
+
+func (c *WebhookConfig) secret() *string { return nil }
+
+func (c *WebhookConfig) Send(ctx context.Context, e *Event) error {
+	var body []byte
+	if c.tmpl == nil {
+		body, _ = json.Marshal(e)
+	} else {
+		var buf bytes.Buffer
+		if err := c.tmpl.Execute(&buf, e); err != nil {
+			return fmt.Errorf("渲染请求体失败: %w", err)
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, c.Method, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Hop")
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	_, err = doRequest(req)
+	return err
+}
+
+// SlackConfig Slack incoming webhook，也适用于 Mattermost、Rocket.Chat 等兼容接口
+type SlackConfig struct {
+	URL string `json:"url"`
+}
+
+func (c *SlackConfig) validate() error { return validateURL(c.URL) }
+func (c *SlackConfig) secret() *string { return nil }
+
+func (c *SlackConfig) Send(ctx context.Context, e *Event) error {
+	_, err := postJSON(ctx, c.URL, map[string]interface{}{
+		"text": e.Text(),
+	})
+	return err
+}
+
+// FeishuConfig 飞书（Lark）自定义机器人
+type FeishuConfig struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"` // 签名校验密钥，机器人未开启签名时留空
+}
+
+func (c *FeishuConfig) validate() error { return validateURL(c.URL) }
+func (c *FeishuConfig) secret() *string { return &c.Secret }
+
+func (c *FeishuConfig) Send(ctx context.Context, e *Event) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": e.Text()},
+	}
+	if c.Secret != "" {
+		// 签名：以 timestamp + "\n" + secret 为密钥对空字符串做 HmacSHA256
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+c.Secret))
+		payload["timestamp"] = timestamp
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	respBody, err := postJSON(ctx, c.URL, payload)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(respBody, &resp) == nil && resp.Code != 0 {
+		return fmt.Errorf("飞书返回错误 %d: %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// DingTalkConfig 钉钉自定义机器人
+type DingTalkConfig struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"` // 加签密钥（SEC 开头），未开启加签时留空
+}
+
+func (c *DingTalkConfig) validate() error { return validateURL(c.URL) }
+func (c *DingTalkConfig) secret() *string { return &c.Secret }
+
+func (c *DingTalkConfig) Send(ctx context.Context, e *Event) error {
+	target := c.URL
+	if c.Secret != "" {
+		// 加签：以 secret 为密钥对 timestamp + "\n" + secret 做 HmacSHA256，结果放在查询参数中
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(c.Secret))
+		mac.Write([]byte(timestamp + "\n" + c.Secret))
+		sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		q.Set("timestamp", timestamp)
+		q.Set("sign", sign)
+		u.RawQuery = q.Encode()
+		target = u.String()
+	}
+
+	respBody, err := postJSON(ctx, target, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": e.Text()},
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if json.Unmarshal(respBody, &resp) == nil && resp.ErrCode != 0 {
+		return fmt.Errorf("钉钉返回错误 %d: %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
+func validateURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("URL 不能为空")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的 URL: %s", raw)
+	}
+	return nil
+}
+
+// postJSON 以 JSON 格式 POST 请求，返回响应内容
+func postJSON(ctx context.Context, target string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Hop")
+	return doRequest(req)
+}
+
+// doRequest 发送请求，非 2xx 状态码视为失败
+func doRequest(req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text := strings.TrimSpace(string(body))
+		if len(text) > maxResponseBody {
+			text = text[:maxResponseBody]
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, text)
+	}
+	return body, nil
+}
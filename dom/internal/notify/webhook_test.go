@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// capturedRequest 测试服务器收到的请求
+type capturedRequest struct {
+	method string
+	query  map[string]string
+	header http.Header
+	body   []byte
+}
+
+// newCaptureServer 记录收到的请求并返回固定的响应
+func newCaptureServer(t *testing.T, status int, response string) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+	var requests []capturedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query := map[string]string{}
+		for k := range r.URL.Query() {
+			query[k] = r.URL.Query().Get(k)
+		}
+		requests = append(requests, capturedRequest{method: r.Method, query: query, header: r.Header.Clone(), body: body})
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func sampleEvent() *Event {
+	return &Event{
+		Type:          EventRenewFailed,
+		Title:         "证书续期失败: example.com",
+		Message:       "example.com 续期失败: \"dns\" 超时",
+		CertificateID: "c1",
+		Domain:        "example.com",
+		Domains:       []string{"example.com", "*.example.com"},
+		NotAfter:      time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		DaysRemaining: 14,
+		Error:         "dns 超时",
+		Time:          time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC),
+	}
+}
+
+func mustParse(t *testing.T, channelType string, cfg interface{}) ChannelConfig {
+	t.Helper()
+	data, _ := json.Marshal(cfg)
+	parsed, err := ParseChannelConfig(channelType, string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	srv, requests := newCaptureServer(t, http.StatusOK, "ok")
+	cfg := mustParse(t, "webhook", WebhookConfig{
+		URL:     srv.URL + "/hook",
+		Method:  "put",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		// json 函数负责引号和转义，消息中的引号不会破坏请求体
+		Body: `{"title": {{json .Title}}, "message": {{json .Message}}, "domains": {{json .Domains}}, "days": {{.DaysRemaining}}}`,
+	})
+
+	if err := cfg.Send(context.Background(), sampleEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("收到 %d 个请求", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.method != http.MethodPut {
+		t.Errorf("method = %s, want PUT", req.method)
+	}
+	if req.header.Get("Authorization") != "Bearer token" || req.header.Get("Content-Type") != "application/json" {
+		t.Errorf("header = %v", req.header)
+	}
+
+	var body struct {
+		Title   string   `json:"title"`
+		Message string   `json:"message"`
+		Domains []string `json:"domains"`
+		Days    int      `json:"days"`
+	}
+	if err := json.Unmarshal(req.body, &body); err != nil {
+		t.Fatalf("模板渲染结果不是有效的 JSON: %s", req.body)
+	}
+	e := sampleEvent()
+	if body.Title != e.Title || body.Message != e.Message || len(body.Domains) != 2 || body.Days != 14 {
+		t.Errorf("body = %+v", body)
+	}
+}
+
+func TestWebhookDefaultBody(t *testing.T) {
+	srv, requests := newCaptureServer(t, http.StatusNoContent, "")
+	cfg := mustParse(t, "webhook", WebhookConfig{URL: srv.URL})
+
+	if err := cfg.Send(context.Background(), sampleEvent()); err != nil {
+		t.Fatal(err)
+	}
+	req := (*requests)[0]
+	if req.method != http.MethodPost {
+		t.Errorf("method = %s, want POST", req.method)
+	}
+	// 未设置模板时发送事件的 JSON
+	var e Event
+	if err := json.Unmarshal(req.body, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != EventRenewFailed || e.CertificateID != "c1" || !e.NotAfter.Equal(sampleEvent().NotAfter) {
+		t.Errorf("event = %+v", e)
+	}
+}
+
+func TestWebhookErrors(t *testing.T) {
+	for _, cfg := range []WebhookConfig{
+		{URL: ""},
+		{URL: "ftp://example.com"},
+		{URL: "https://example.com", Method: "GET"},
+		{URL: "https://example.com", Body: "{{.Title"},
+	} {
+		data, _ := json.Marshal(cfg)
+		if _, err := ParseChannelConfig("webhook", string(data)); err == nil {
+			t.Errorf("%+v: 应返回错误", cfg)
+		}
+	}
+
+	// 事件没有的字段在渲染时报错
+	cfg := mustParse(t, "webhook", WebhookConfig{URL: "http://127.0.0.1:1", Body: `{{.Missing}}`})
+	if err := cfg.Send(context.Background(), sampleEvent()); err == nil || !strings.Contains(err.Error(), "渲染请求体失败") {
+		t.Errorf("err = %v", err)
+	}
+
+	// 非 2xx 响应视为失败，错误中保留截断后的响应内容
+	srv, _ := newCaptureServer(t, http.StatusBadGateway, strings.Repeat("x", 1000))
+	cfg = mustParse(t, "webhook", WebhookConfig{URL: srv.URL})
+	err := cfg.Send(context.Background(), sampleEvent())
+	if err == nil || !strings.HasPrefix(err.Error(), "HTTP 502: ") || len(err.Error()) != len("HTTP 502: ")+maxResponseBody {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSlack(t *testing.T) {
+	srv, requests := newCaptureServer(t, http.StatusOK, "ok")
+	cfg := mustParse(t, "slack", SlackConfig{URL: srv.URL})
+
+	if err := cfg.Send(context.Background(), sampleEvent()); err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]string
+	json.Unmarshal((*requests)[0].body, &body)
+	if len(body) != 1 || body["text"] != sampleEvent().Text() {
+		t.Errorf("body = %s", (*requests)[0].body)
+	}
+}
+
+func TestFeishu(t *testing.T) {
+	srv, requests := newCaptureServer(t, http.StatusOK, `{"code":0,"msg":"success"}`)
+	cfg := mustParse(t, "feishu", FeishuConfig{URL: srv.URL, Secret: "feishu-secret"})
+
+	before := time.Now().Unix()
+	if err := cfg.Send(context.Background(), sampleEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		MsgType   string            `json:"msg_type"`
+		Content   map[string]string `json:"content"`
+		Timestamp string            `json:"timestamp"`
+		Sign      string            `json:"sign"`
+	}
+	json.Unmarshal((*requests)[0].body, &body)
+	if body.MsgType != "text" || body.Content["text"] != sampleEvent().Text() {
+		t.Errorf("body = %s", (*requests)[0].body)
+	}
+
+	// 飞书校验方式：以 timestamp + "\n" + secret 为密钥对空字符串做 HmacSHA256，时间戳为秒
+	ts, _ := strconv.ParseInt(body.Timestamp, 10, 64)
+	if ts < before || ts > time.Now().Unix() {
+		t.Errorf("timestamp = %s", body.Timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte(body.Timestamp+"\nfeishu-secret"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); body.Sign != want {
+		t.Errorf("sign = %s, want %s", body.Sign, want)
+	}
+
+	// 未设置密钥时不签名
+	cfg = mustParse(t, "feishu", FeishuConfig{URL: srv.URL})
+	cfg.Send(context.Background(), sampleEvent())
+	if strings.Contains(string((*requests)[1].body), "sign") {
+		t.Errorf("body = %s", (*requests)[1].body)
+	}
+
+	// 飞书以 HTTP 200 返回业务错误
+	srv, _ = newCaptureServer(t, http.StatusOK, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`)
+	cfg = mustParse(t, "feishu", FeishuConfig{URL: srv.URL, Secret: "wrong"})
+	if err := cfg.Send(context.Background(), sampleEvent()); err == nil || !strings.Contains(err.Error(), "19021") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestDingTalk(t *testing.T) {
+	srv, requests := newCaptureServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	cfg := mustParse(t, "dingtalk", DingTalkConfig{URL: srv.URL + "/robot/send?access_token=abc", Secret: "SECdingtalk"})
+
+	before := time.Now().UnixMilli()
+	if err := cfg.Send(context.Background(), sampleEvent()); err != nil {
+		t.Fatal(err)
+	}
+	req := (*requests)[0]
+
+	var body struct {
+		MsgType string            `json:"msgtype"`
+		Text    map[string]string `json:"text"`
+	}
+	json.Unmarshal(req.body, &body)
+	if body.MsgType != "text" || body.Text["content"] != sampleEvent().Text() {
+		t.Errorf("body = %s", req.body)
+	}
+
+	// 钉钉加签：以 secret 为密钥对 timestamp + "\n" + secret 做 HmacSHA256，时间戳为毫秒，放在查询参数中
+	if req.query["access_token"] != "abc" {
+		t.Errorf("access_token 丢失: %v", req.query)
+	}
+	ts, _ := strconv.ParseInt(req.query["timestamp"], 10, 64)
+	if ts < before || ts > time.Now().UnixMilli() {
+		t.Errorf("timestamp = %s", req.query["timestamp"])
+	}
+	mac := hmac.New(sha256.New, []byte("SECdingtalk"))
+	mac.Write([]byte(req.query["timestamp"] + "\nSECdingtalk"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); req.query["sign"] != want {
+		t.Errorf("sign = %s, want %s", req.query["sign"], want)
+	}
+
+	srv, _ = newCaptureServer(t, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`)
+	cfg = mustParse(t, "dingtalk", DingTalkConfig{URL: srv.URL, Secret: "SECwrong"})
+	if err := cfg.Send(context.Background(), sampleEvent()); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParseChannelConfigUnknownType(t *testing.T) {
+	if _, err := ParseChannelConfig("sms", "{}"); err == nil {
+		t.Error("未知类型应返回错误")
+	}
+}
@@ -36,6 +36,7 @@ type NginxConfig struct {
 	ClientMaxBodySize string `json:"clientMaxBodySize"`
 	Gzip              bool   `json:"gzip"`
 	ServerTokens      bool   `json:"serverTokens"`
+	HTTPChallenge     bool   `json:"httpChallenge"`
 }
 
 // UpdateAuthConfigRequest 更新认证配置请求
@@ -74,6 +75,7 @@ func handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			ClientMaxBodySize: cfg.Nginx.ClientMaxBodySize,
 			Gzip:              cfg.Nginx.Gzip,
 			ServerTokens:      cfg.Nginx.ServerTokens,
+			HTTPChallenge:     cfg.Nginx.HTTPChallenge,
 		},
 	}
 
@@ -11,11 +11,14 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"github.com/hop/backend/internal/apply"
 	"github.com/hop/backend/internal/assets"
 	"github.com/hop/backend/internal/auth"
+	"github.com/hop/backend/internal/backup"
 	"github.com/hop/backend/internal/config"
 	"github.com/hop/backend/internal/logger"
 	"github.com/hop/backend/internal/nginx"
+	"github.com/hop/backend/internal/notify"
 	"github.com/hop/backend/internal/ssl"
 )
 
@@ -51,7 +54,7 @@ func New(cfg *config.Config) *Server {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173", "http://localhost:3000"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Requested-With"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Requested-With", "X-Hop-Change-Message"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -81,8 +84,20 @@ func (s *Server) setupRoutes() {
 
 		// 配置管理路由
 		r.Mount("/config", configRouter())
+
+		// 声明式配置路由
+		r.Mount("/apply", apply.Router())
+
+		// 备份路由
+		r.Mount("/backup", backup.Router())
+
+		// 通知路由
+		r.Mount("/notify", notify.Router())
 	})
 
+	// ACME HTTP-01 验证（由 nginx 80 端口转发，无需认证）
+	s.router.Get("/.well-known/acme-challenge/{token}", ssl.HTTPChallengeHandler)
+
 	// 静态文件服务 (SPA)
 	distDir := filepath.Join(s.cfg.Data.Dir, "../dist")
 	assetsHandler := assets.NewHandler(distDir)
@@ -108,8 +123,8 @@ func (s *Server) Run() error {
 	server := &http.Server{
 		Addr:         addr,
 		Handler:      s.router,
-		ReadTimeout:  5 * time.Minute, // 证书申请可能需要较长时间
-		WriteTimeout: 5 * time.Minute, // 证书申请可能需要较长时间
+		ReadTimeout:  time.Minute,
+		WriteTimeout: time.Minute, // 证书申请在后台任务中执行，任务日志流和声明式配置会单独取消写超时
 		IdleTimeout:  60 * time.Second,
 	}
 
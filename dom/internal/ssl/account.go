@@ -0,0 +1,456 @@
+package ssl
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/acme"
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// accountTimeout 账户操作的最长时间
+const accountTimeout = time.Minute
+
+// legacyAccountFile 旧版本保存在 data/acme 下的账户文件
+type legacyAccountFile struct {
+	Email string `json:"email"`
+	URL   string `json:"url"`
+	Key   string `json:"key"` // PEM 编码的账户私钥
+}
+
+// legacyAccountPath 旧账户文件路径：data/acme/<CA 主机名>/<email>.json
+func legacyAccountPath(directory, email string) string {
+	host := "default"
+	if u, err := url.Parse(directory); err == nil && u.Host != "" {
+		host = strings.ReplaceAll(u.Host, ":", "_")
+	}
+	if email == "" {
+		email = "default"
+	}
+	return filepath.Join(config.Get().ACMEDir(), host, email+".json")
+}
+
+// loadLegacyAccountKey 读取旧账户文件中的私钥，不存在时返回 nil
+func loadLegacyAccountKey(directory, email string) (crypto.Signer, error) {
+	data, err := os.ReadFile(legacyAccountPath(directory, email))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取账户文件失败: %w", err)
+	}
+
+	var stored legacyAccountFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("解析账户文件失败: %w", err)
+	}
+	key, err := parsePrivateKey([]byte(stored.Key))
+	if err != nil {
+		return nil, fmt.Errorf("解析账户私钥失败: %w", err)
+	}
+	return key, nil
+}
+
+// accountClient 创建以该账户身份签名的 ACME 客户端
+func accountClient(account *database.ACMEAccount) (*acme.Client, error) {
+	if account.Status != "valid" {
+		return nil, fmt.Errorf("ACME 账户 %s 已停用", account.Email)
+	}
+
+	client, err := newACMEClient(account.Directory)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey([]byte(account.KeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("解析账户私钥失败: %w", err)
+	}
+	client.Key = key
+	client.KID = account.URL
+	return client, nil
+}
+
+// RegisterAccount 在 CA 注册账户并保存
+// 旧版本留下的同一邮箱账户文件会被导入，继续使用原账户
+func RegisterAccount(ctx context.Context, directory, email string, eab *acme.ExternalAccountBinding) (*database.ACMEAccount, error) {
+	if _, err := database.FindACMEAccount(directory, email); err == nil {
+		return nil, fmt.Errorf("该 CA 下已存在邮箱为 %s 的账户", email)
+	}
+
+	client, err := newACMEClient(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := loadLegacyAccountKey(directory, email)
+	if err != nil {
+		return nil, err
+	}
+
+	var registered *acme.Account
+	if key != nil {
+		client.Key = key
+		registered, err = client.LookupAccount(ctx)
+		if err != nil && !acme.IsProblem(err, acme.ErrAccountDoesNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			log.Info("已导入旧版本的 ACME 账户", map[string]interface{}{
+				"email":     email,
+				"directory": directory,
+			})
+		}
+	} else {
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return nil, fmt.Errorf("生成账户私钥失败: %w", err)
+		}
+		client.Key = key
+	}
+
+	if registered == nil {
+		// ZeroSSL 可以通过邮箱自动获取 EAB 凭据
+		if eab == nil && directory == acme.ZeroSSLURL {
+			if eab, err = zeroSSLEAB(ctx, client.HTTPClient, email); err != nil {
+				return nil, err
+			}
+		}
+		if registered, err = client.Register(ctx, email, eab); err != nil {
+			return nil, err
+		}
+		log.Info("已注册 ACME 账户", map[string]interface{}{
+			"email":     email,
+			"directory": directory,
+		})
+	}
+
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	account := &database.ACMEAccount{
+		ID:        uuid.New().String(),
+		Email:     email,
+		Directory: directory,
+		KeyPEM:    string(keyPEM),
+		URL:       registered.URL,
+	}
+	if eab != nil {
+		account.EABKID = eab.KID
+	}
+	if err := database.CreateACMEAccount(account); err != nil {
+		return nil, fmt.Errorf("保存 ACME 账户失败: %w", err)
+	}
+	return account, nil
+}
+
+// ensureAccount 获取该 CA 下指定邮箱的账户，不存在时注册
+func ensureAccount(ctx context.Context, directory, email string, eab *acme.ExternalAccountBinding) (*database.ACMEAccount, error) {
+	if account, err := database.FindACMEAccount(directory, email); err == nil {
+		return account, nil
+	}
+	if email == "" {
+		return nil, fmt.Errorf("邮箱不能为空")
+	}
+	return RegisterAccount(ctx, directory, email, eab)
+}
+
+// certificateAccount 确定续期证书使用的账户
+// 指定邮箱时使用（必要时注册）该邮箱的账户；没有关联账户的旧证书使用同一 CA 下唯一的账户
+func certificateAccount(ctx context.Context, cert *database.Certificate, email string) (*database.ACMEAccount, error) {
+	directory, err := resolveDirectory(cert.ACMEDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	if email != "" {
+		return ensureAccount(ctx, directory, email, eabOf(cert.EABKID, cert.EABHMACKey))
+	}
+
+	if cert.AccountID != "" {
+		account, err := database.GetACMEAccount(cert.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("证书关联的 ACME 账户不存在，请手动续期并填写邮箱")
+		}
+		return account, nil
+	}
+
+	accounts, err := database.ListACMEAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("获取 ACME 账户失败: %w", err)
+	}
+	var candidates []database.ACMEAccount
+	for _, account := range accounts {
+		if account.Directory == directory && account.Status == "valid" {
+			candidates = append(candidates, account)
+		}
+	}
+	if len(candidates) != 1 {
+		return nil, fmt.Errorf("证书未关联 ACME 账户，请手动续期并填写邮箱")
+	}
+	return &candidates[0], nil
+}
+
+// LinkAccount 将证书关联到指定邮箱的账户，账户不存在时注册（调用方负责保存证书）
+func LinkAccount(cert *database.Certificate, email string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), accountTimeout)
+	defer cancel()
+
+	account, err := certificateAccount(ctx, cert, email)
+	if err != nil {
+		return err
+	}
+	cert.AccountID = account.ID
+	cert.ACMEDirectory = account.Directory
+	return nil
+}
+
+// zeroSSLEAB 使用邮箱向 ZeroSSL 申请 EAB 凭据
+func zeroSSLEAB(ctx context.Context, httpClient *http.Client, email string) (*acme.ExternalAccountBinding, error) {
+	if email == "" {
+		return nil, fmt.Errorf("ZeroSSL 需要邮箱或 EAB 凭据")
+	}
+
+	form := url.Values{"email": {email}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.zerossl.com/acme/eab-credentials-email", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取 ZeroSSL EAB 凭据失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool   `json:"success"`
+		KID     string `json:"eab_kid"`
+		HMACKey string `json:"eab_hmac_key"`
+		Error   struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析 ZeroSSL 响应失败: %w", err)
+	}
+	if !result.Success || result.KID == "" {
+		return nil, fmt.Errorf("获取 ZeroSSL EAB 凭据失败: %s", result.Error.Type)
+	}
+
+	log.Info("已获取 ZeroSSL EAB 凭据", map[string]interface{}{"email": email})
+	return &acme.ExternalAccountBinding{KID: result.KID, HMACKey: result.HMACKey}, nil
+}
+
+// === ACME 账户 API ===
+
+// AccountResponse 账户响应（不包含私钥）
+type AccountResponse struct {
+	ID               string `json:"id"`
+	Email            string `json:"email"`
+	Directory        string `json:"directory"`
+	CAName           string `json:"caName"`
+	URL              string `json:"url"`
+	Status           string `json:"status"`
+	EABKID           string `json:"eabKid"`
+	CertificateCount int    `json:"certificateCount"`
+	CreatedAt        string `json:"createdAt"`
+	UpdatedAt        string `json:"updatedAt"`
+}
+
+// CreateAccountRequest 注册账户请求
+type CreateAccountRequest struct {
+	Email         string `json:"email"`
+	ACMEDirectory string `json:"acmeDirectory"` // 预设名称或目录地址，为空使用默认目录
+	EABKID        string `json:"eabKid"`
+	EABHMACKey    string `json:"eabHmacKey"`
+}
+
+// UpdateAccountRequest 更新账户请求
+type UpdateAccountRequest struct {
+	Email string `json:"email"`
+}
+
+func handleListAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := database.ListACMEAccounts()
+	if err != nil {
+		jsonError(w, "获取 ACME 账户列表失败", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]AccountResponse, 0, len(accounts))
+	for i := range accounts {
+		response = append(response, accountToResponse(&accounts[i]))
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"accounts": response,
+	})
+}
+
+func handleCreateAccount(w http.ResponseWriter, r *http.Request) {
+	var req CreateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	if req.Email == "" {
+		jsonError(w, "邮箱不能为空", http.StatusBadRequest)
+		return
+	}
+	if (req.EABKID == "") != (req.EABHMACKey == "") {
+		jsonError(w, "EAB key ID 和 HMAC 密钥需要同时填写", http.StatusBadRequest)
+		return
+	}
+
+	directory, err := resolveDirectory(req.ACMEDirectory)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), accountTimeout)
+	defer cancel()
+
+	account, err := RegisterAccount(ctx, directory, req.Email, eabOf(req.EABKID, req.EABHMACKey))
+	if err != nil {
+		jsonError(w, "注册 ACME 账户失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"account": accountToResponse(account),
+	})
+}
+
+func handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req UpdateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if req.Email == "" {
+		jsonError(w, "邮箱不能为空", http.StatusBadRequest)
+		return
+	}
+
+	account, err := database.GetACMEAccount(id)
+	if err != nil {
+		jsonError(w, "ACME 账户不存在", http.StatusNotFound)
+		return
+	}
+	if other, err := database.FindACMEAccount(account.Directory, req.Email); err == nil && other.ID != account.ID {
+		jsonError(w, "该 CA 下已存在邮箱为 "+req.Email+" 的账户", http.StatusConflict)
+		return
+	}
+
+	client, err := accountClient(account)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), accountTimeout)
+	defer cancel()
+
+	if _, err := client.UpdateContact(ctx, req.Email); err != nil {
+		jsonError(w, "更新 CA 账户联系方式失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	account.Email = req.Email
+	if err := database.UpdateACMEAccount(account); err != nil {
+		jsonError(w, "更新 ACME 账户失败", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"account": accountToResponse(account),
+	})
+}
+
+// handleDeleteAccount 停用并删除账户，仍有证书使用时拒绝删除
+func handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	account, err := database.GetACMEAccount(id)
+	if err != nil {
+		jsonError(w, "ACME 账户不存在", http.StatusNotFound)
+		return
+	}
+
+	count, err := database.CountCertificatesByAccount(id)
+	if err != nil {
+		jsonError(w, "查询证书失败", http.StatusInternalServerError)
+		return
+	}
+	if count > 0 {
+		jsonError(w, fmt.Sprintf("仍有 %d 个证书使用该账户，无法删除", count), http.StatusConflict)
+		return
+	}
+
+	// 本地私钥删除后账户无法再使用，先在 CA 停用
+	if account.Status == "valid" {
+		ctx, cancel := context.WithTimeout(r.Context(), accountTimeout)
+		defer cancel()
+
+		client, err := accountClient(account)
+		if err == nil {
+			err = client.Deactivate(ctx)
+		}
+		if err != nil {
+			log.Warn("停用 ACME 账户失败", map[string]interface{}{
+				"email": account.Email,
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if err := database.DeleteACMEAccount(id); err != nil {
+		jsonError(w, "删除 ACME 账户失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]bool{"success": true})
+}
+
+func accountToResponse(a *database.ACMEAccount) AccountResponse {
+	var caName string
+	if preset, ok := acme.PresetByURL(a.Directory); ok {
+		caName = preset.Label
+	}
+	count, _ := database.CountCertificatesByAccount(a.ID)
+
+	return AccountResponse{
+		ID:               a.ID,
+		Email:            a.Email,
+		Directory:        a.Directory,
+		CAName:           caName,
+		URL:              a.URL,
+		Status:           a.Status,
+		EABKID:           a.EABKID,
+		CertificateCount: count,
+		CreatedAt:        a.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:        a.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,316 @@
+package ssl
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hop/backend/internal/acme"
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// issueTimeout 单次申请或续期的最长时间
+const issueTimeout = 10 * time.Minute
+
+// issuedCertificate CA 签发的证书和对应私钥（PEM）
+type issuedCertificate struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// defaultDirectory 未单独指定 CA 时使用的目录
+func defaultDirectory() (string, error) {
+	cfg := config.Get().ACME
+	if cfg.Staging {
+		return acme.LetsEncryptStagingURL, nil
+	}
+	if cfg.Directory == "" {
+		return acme.LetsEncryptURL, nil
+	}
+	return acme.ResolveDirectory(cfg.Directory)
+}
+
+// resolveDirectory 解析证书指定的 CA，为空时使用默认目录
+func resolveDirectory(nameOrURL string) (string, error) {
+	if strings.TrimSpace(nameOrURL) == "" {
+		return defaultDirectory()
+	}
+	return acme.ResolveDirectory(nameOrURL)
+}
+
+// newACMEClient 创建 ACME 客户端，信任系统根证书和配置的 CA 证书
+func newACMEClient(directory string) (*acme.Client, error) {
+	cfg := config.Get().ACME
+
+	if directory == "" {
+		var err error
+		if directory, err = defaultDirectory(); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if cfg.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA 证书文件中没有有效的证书: %s", cfg.CABundle)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		httpClient.Transport = transport
+	}
+
+	return &acme.Client{
+		DirectoryURL: directory,
+		HTTPClient:   httpClient,
+		UserAgent:    "hop",
+	}, nil
+}
+
+// challengeSolver 完成一种 ACME 验证方式
+type challengeSolver interface {
+	challengeType() string
+	present(ctx context.Context, identifier, token, keyAuth string) error
+	cleanUp(ctx context.Context, identifier, token, keyAuth string) error
+}
+
+// dnsChallenge 通过 DNS 提供商 API 完成 dns-01 验证
+type dnsChallenge struct {
+	solver DNSSolver
+}
+
+func (dnsChallenge) challengeType() string {
+	return database.ChallengeDNS01
+}
+
+func (c dnsChallenge) present(ctx context.Context, identifier, token, keyAuth string) error {
+	fqdn := challengeFQDN(identifier)
+	value := acme.DNS01Value(keyAuth)
+
+	log.Info("添加 DNS 验证记录", map[string]interface{}{"fqdn": fqdn})
+	if err := c.solver.Present(ctx, fqdn, value); err != nil {
+		return err
+	}
+
+	if config.Get().ACME.PropagationTimeout > 0 {
+		reportProgress(ctx, StageChallenge, "等待 DNS 记录 %s 生效", fqdn)
+	}
+	waitForPropagation(ctx, fqdn, value)
+	return nil
+}
+
+func (c dnsChallenge) cleanUp(ctx context.Context, identifier, token, keyAuth string) error {
+	return c.solver.CleanUp(ctx, challengeFQDN(identifier), acme.DNS01Value(keyAuth))
+}
+
+// obtainCertificate 使用指定私钥申请证书
+// replaces 为被替换证书的 ARI 标识，为空表示不是续期或 CA 不支持 ARI
+func obtainCertificate(ctx context.Context, domains []string, solver challengeSolver, account *database.ACMEAccount, certKey crypto.Signer, replaces string) (*issuedCertificate, error) {
+	mainDomain := domains[0]
+	fail := func(stage IssueStage, err error) (*issuedCertificate, error) {
+		return nil, &IssueError{Stage: stage, Domain: mainDomain, Err: err}
+	}
+
+	client, err := accountClient(account)
+	if err != nil {
+		return fail(StageAccount, err)
+	}
+
+	// 只有支持 ARI 的 CA 才接受 replaces 字段
+	if dir, err := client.Discover(ctx); err != nil || dir.RenewalInfo == "" {
+		replaces = ""
+	}
+
+	reportProgress(ctx, StageOrder, "创建订单: %s", strings.Join(domains, ", "))
+	order, err := client.NewOrder(ctx, domains, replaces)
+	if err != nil && replaces != "" {
+		// 原证书已被替换过或不属于当前账户时 CA 会拒绝，改为普通订单
+		log.Warn("CA 拒绝替换原证书，改为创建普通订单", map[string]interface{}{
+			"domain": mainDomain,
+			"error":  err.Error(),
+		})
+		order, err = client.NewOrder(ctx, domains, "")
+	}
+	if err != nil {
+		return fail(StageOrder, err)
+	}
+
+	for _, authzURL := range order.Authorizations {
+		if err := authorize(ctx, client, authzURL, solver); err != nil {
+			stage := StageValidation
+			var setupErr *challengeSetupError
+			if errors.As(err, &setupErr) {
+				stage = StageChallenge
+			}
+			return fail(stage, err)
+		}
+	}
+
+	order, err = client.WaitOrder(ctx, order.URL, "ready")
+	if err != nil {
+		return fail(StageFinalize, err)
+	}
+
+	reportProgress(ctx, StageFinalize, "提交 CSR，等待 CA 签发")
+	csr, err := createCSR(certKey, domains)
+	if err != nil {
+		return fail(StageFinalize, err)
+	}
+
+	if order.Status == "ready" {
+		if order, err = client.Finalize(ctx, order, csr); err != nil {
+			return fail(StageFinalize, err)
+		}
+	}
+	order, err = client.WaitOrder(ctx, order.URL, "valid")
+	if err != nil {
+		return fail(StageFinalize, err)
+	}
+
+	reportProgress(ctx, StageDownload, "下载证书")
+	certPEM, err := client.FetchCertificate(ctx, order.Certificate)
+	if err != nil {
+		return fail(StageDownload, err)
+	}
+
+	keyPEM, err := encodePrivateKey(certKey)
+	if err != nil {
+		return fail(StageSave, err)
+	}
+	return &issuedCertificate{CertPEM: certPEM, KeyPEM: keyPEM}, nil
+}
+
+// challengeSetupError 设置验证记录失败（区别于 CA 验证失败）
+type challengeSetupError struct {
+	err error
+}
+
+func (e *challengeSetupError) Error() string { return e.err.Error() }
+func (e *challengeSetupError) Unwrap() error { return e.err }
+
+// authorize 完成单个域名授权；无论成功与否都会清理验证数据
+func authorize(ctx context.Context, client *acme.Client, authzURL string, solver challengeSolver) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return err
+	}
+	if authz.Status == "valid" {
+		reportProgress(ctx, StageValidation, "%s 的授权仍然有效，跳过验证", authz.Identifier.Value)
+		return nil
+	}
+
+	var challenge *acme.Challenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == solver.challengeType() {
+			challenge = &authz.Challenges[i]
+			break
+		}
+	}
+	if challenge == nil {
+		return &challengeSetupError{fmt.Errorf("CA 未提供 %s 的 %s 验证", authz.Identifier.Value, solver.challengeType())}
+	}
+
+	keyAuth, err := client.KeyAuthorization(challenge.Token)
+	if err != nil {
+		return &challengeSetupError{err}
+	}
+	identifier := authz.Identifier.Value
+
+	reportProgress(ctx, StageChallenge, "设置 %s 的 %s 验证", identifier, solver.challengeType())
+	if err := solver.present(ctx, identifier, challenge.Token, keyAuth); err != nil {
+		return &challengeSetupError{err}
+	}
+	defer func() {
+		// 使用独立的 context，确保申请超时后仍能清理
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := solver.cleanUp(cleanupCtx, identifier, challenge.Token, keyAuth); err != nil {
+			log.Warn("清理验证数据失败", map[string]interface{}{
+				"identifier": identifier,
+				"challenge":  solver.challengeType(),
+				"error":      err.Error(),
+			})
+		}
+	}()
+
+	reportProgress(ctx, StageValidation, "等待 CA 验证 %s", identifier)
+	if err := client.Accept(ctx, challenge); err != nil {
+		return err
+	}
+	_, err = client.WaitAuthorization(ctx, authz.URL)
+	return err
+}
+
+// createCSR 生成证书签名请求
+func createCSR(key crypto.Signer, domains []string) ([]byte, error) {
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: domains[0]},
+	}
+	for _, d := range domains {
+		if ip := net.ParseIP(d); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, d)
+		}
+	}
+	// CN 最长 64 字节
+	if len(template.Subject.CommonName) > 64 {
+		template.Subject.CommonName = ""
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return nil, fmt.Errorf("生成 CSR 失败: %w", err)
+	}
+	return csr, nil
+}
+
+// encodePrivateKey 以 PKCS#8 PEM 编码私钥
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("编码私钥失败: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// parsePrivateKey 解析 PEM 私钥（PKCS#8、PKCS#1 或 SEC 1）
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("无法解析 PEM 格式")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("不支持的私钥类型")
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("不支持的私钥格式")
+}
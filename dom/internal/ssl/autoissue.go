@@ -0,0 +1,126 @@
+package ssl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+// RequestSiteCertificate 为启用 SSL 但没有可用证书的站点申请证书，返回申请任务 ID
+// 未开启 acme.auto_issue 时返回空；同一域名已有排队或执行中的申请任务时返回该任务
+// 证书签发后由 linkPendingSites 关联到等待的站点
+func RequestSiteCertificate(serverName string) (string, error) {
+	cfg := config.Get().ACME
+	if !cfg.AutoIssue {
+		return "", nil
+	}
+
+	domains := strings.Fields(serverName)
+	if len(domains) == 0 {
+		return "", fmt.Errorf("站点域名不能为空")
+	}
+
+	opts := IssueOptions{Domains: domains, Email: cfg.Email}
+	if cfg.DNSProvider != "" {
+		provider, err := findDNSProvider(cfg.DNSProvider)
+		if err != nil {
+			return "", err
+		}
+		opts.ChallengeType = database.ChallengeDNS01
+		opts.DNSProviderID = provider.ID
+	} else {
+		if err := validateHTTPChallengeDomains(domains); err != nil {
+			return "", err
+		}
+		opts.ChallengeType = database.ChallengeHTTP01
+	}
+	if opts.Email == "" {
+		account, err := defaultAccount()
+		if err != nil {
+			return "", err
+		}
+		opts.AccountID = account.ID
+	}
+
+	job, err := queueIssue(opts, true)
+	if err != nil {
+		return "", err
+	}
+	log.Info("已为站点自动申请证书", map[string]interface{}{
+		"domains": domains,
+		"job":     job.Record().ID,
+	})
+	return job.Record().ID, nil
+}
+
+// findDNSProvider 按 ID 或名称查找 DNS 提供商
+func findDNSProvider(ref string) (*database.DNSProvider, error) {
+	if provider, err := database.GetDNSProvider(ref); err == nil {
+		return provider, nil
+	}
+	providers, err := database.ListDNSProviders()
+	if err != nil {
+		return nil, fmt.Errorf("获取 DNS 提供商失败: %w", err)
+	}
+	for i := range providers {
+		if providers[i].Name == ref {
+			return &providers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("自动申请使用的 DNS 提供商 %s 不存在", ref)
+}
+
+// defaultAccount 未配置自动申请邮箱时，使用默认 CA 下唯一的有效账户
+func defaultAccount() (*database.ACMEAccount, error) {
+	directory, err := defaultDirectory()
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := database.ListACMEAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("获取 ACME 账户失败: %w", err)
+	}
+	var found *database.ACMEAccount
+	for i := range accounts {
+		if accounts[i].Directory != directory || accounts[i].Status != "valid" {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("默认 CA 下有多个 ACME 账户，请在配置中设置自动申请使用的邮箱")
+		}
+		found = &accounts[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("默认 CA 下没有 ACME 账户，请在配置中设置自动申请使用的邮箱")
+	}
+	return found, nil
+}
+
+// linkPendingSites 新证书签发后关联等待证书的站点，站点改为使用该证书启用 HTTPS
+func linkPendingSites(ctx context.Context, cert *database.Certificate) {
+	if cert.Usage == database.CertUsageClient {
+		return
+	}
+	sites, err := nginx.ListProxySites()
+	if err != nil {
+		return
+	}
+
+	for _, site := range sites {
+		if site.CertificateJobID == "" || site.CertificateID != "" || !database.CertificateCovers(cert, site.ServerName) {
+			continue
+		}
+		site.CertificateID = cert.ID
+		site.CertificateJobID = ""
+		change := nginx.SystemChange(fmt.Sprintf("证书 %s 已签发，站点 %s 启用 HTTPS", cert.Domain, site.ServerName))
+		if err := nginx.SaveProxySite(site, change); err != nil {
+			addCertificateLog(ctx, cert.ID, "link", fmt.Sprintf("站点 %s 关联证书失败: %s", site.ServerName, err.Error()))
+			continue
+		}
+		addCertificateLog(ctx, cert.ID, "link", fmt.Sprintf("站点 %s 已关联该证书并启用 HTTPS", site.ServerName))
+	}
+}
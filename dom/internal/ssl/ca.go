@@ -0,0 +1,711 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+const (
+	defaultLeafDays   = 90
+	maxLeafDays       = 825 // 与公共 CA 的上限保持一致，避免客户端拒绝
+	rootCAValidity    = 10 * 365 * 24 * time.Hour
+	issuingCAValidity = 5 * 365 * 24 * time.Hour
+)
+
+// GenerateInternalCA 生成新的内部 CA：ECDSA P-384 根证书和 P-256 中间证书，替换已有的 CA
+func GenerateInternalCA(name string, leafDays int) (*database.InternalCA, error) {
+	if name == "" {
+		name = "Hop Internal CA"
+	}
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成根证书私钥失败: %w", err)
+	}
+	rootTemplate, err := caTemplate(name+" Root", rootCAValidity, 1)
+	if err != nil {
+		return nil, err
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("生成根证书失败: %w", err)
+	}
+	root, _ := x509.ParseCertificate(rootDER)
+
+	ca := &database.InternalCA{
+		ID:          uuid.New().String(),
+		Name:        name,
+		RootCertPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER})),
+		LeafDays:    leafDays,
+	}
+	if ca.RootKeyPEM, err = encodeKeyString(rootKey); err != nil {
+		return nil, err
+	}
+	if err := createIssuingCA(ca, root, rootKey); err != nil {
+		return nil, err
+	}
+	return ca, saveInternalCA(ca)
+}
+
+// ImportCAOptions 导入已有 CA 的参数
+// 只提供根证书和私钥时自动生成中间证书；提供中间证书和私钥时根证书私钥可以省略
+type ImportCAOptions struct {
+	Name           string
+	RootCertPEM    string
+	RootKeyPEM     string
+	IssuingCertPEM string
+	IssuingKeyPEM  string
+	LeafDays       int
+}
+
+// ImportInternalCA 导入已有的根证书和中间证书，替换已有的 CA
+func ImportInternalCA(opts ImportCAOptions) (*database.InternalCA, error) {
+	rootCerts, err := parseCertificateChain([]byte(opts.RootCertPEM))
+	if err != nil {
+		return nil, fmt.Errorf("根证书: %w", err)
+	}
+	root := rootCerts[0]
+	if !root.IsCA {
+		return nil, fmt.Errorf("根证书不是 CA 证书")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = root.Subject.CommonName
+	}
+	ca := &database.InternalCA{
+		ID:          uuid.New().String(),
+		Name:        name,
+		RootCertPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})),
+		LeafDays:    opts.LeafDays,
+	}
+
+	var rootKey crypto.Signer
+	if strings.TrimSpace(opts.RootKeyPEM) != "" {
+		if rootKey, err = parseUploadedKey([]byte(opts.RootKeyPEM)); err != nil {
+			return nil, fmt.Errorf("根证书私钥: %w", err)
+		}
+		if !publicKeysEqual(root.PublicKey, rootKey.Public()) {
+			return nil, fmt.Errorf("根证书私钥与根证书不匹配")
+		}
+		if ca.RootKeyPEM, err = encodeKeyString(rootKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(opts.IssuingCertPEM) == "" {
+		if rootKey == nil {
+			return nil, fmt.Errorf("未提供中间证书时需要根证书私钥")
+		}
+		if err := createIssuingCA(ca, root, rootKey); err != nil {
+			return nil, err
+		}
+		return ca, saveInternalCA(ca)
+	}
+
+	issuingCerts, err := parseCertificateChain([]byte(opts.IssuingCertPEM))
+	if err != nil {
+		return nil, fmt.Errorf("中间证书: %w", err)
+	}
+	issuing := issuingCerts[0]
+	if !issuing.IsCA {
+		return nil, fmt.Errorf("中间证书不是 CA 证书")
+	}
+	if err := issuing.CheckSignatureFrom(root); err != nil {
+		return nil, fmt.Errorf("中间证书不是由该根证书签发: %w", err)
+	}
+	issuingKey, err := parseUploadedKey([]byte(opts.IssuingKeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("中间证书私钥: %w", err)
+	}
+	if !publicKeysEqual(issuing.PublicKey, issuingKey.Public()) {
+		return nil, fmt.Errorf("中间证书私钥与中间证书不匹配")
+	}
+
+	ca.CertPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuing.Raw}))
+	if ca.KeyPEM, err = encodeKeyString(issuingKey); err != nil {
+		return nil, err
+	}
+	return ca, saveInternalCA(ca)
+}
+
+// createIssuingCA 用根证书签发中间证书
+func createIssuingCA(ca *database.InternalCA, root *x509.Certificate, rootKey crypto.Signer) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("生成中间证书私钥失败: %w", err)
+	}
+	template, err := caTemplate(ca.Name+" Intermediate", issuingCAValidity, 0)
+	if err != nil {
+		return err
+	}
+	if template.NotAfter.After(root.NotAfter) {
+		template.NotAfter = root.NotAfter
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, root, key.Public(), rootKey)
+	if err != nil {
+		return fmt.Errorf("签发中间证书失败: %w", err)
+	}
+
+	ca.CertPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	ca.KeyPEM, err = encodeKeyString(key)
+	return err
+}
+
+// caTemplate CA 证书模板，maxPathLen 为 0 时不能再签发下级 CA
+func caTemplate(commonName string, validity time.Duration, maxPathLen int) (*x509.Certificate, error) {
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"Hop"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            maxPathLen,
+		MaxPathLenZero:        maxPathLen == 0,
+	}, nil
+}
+
+// randomSerial 生成 128 位随机序列号
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("生成序列号失败: %w", err)
+	}
+	return serial, nil
+}
+
+// encodeKeyString 以 PKCS#8 PEM 字符串编码私钥
+func encodeKeyString(key crypto.Signer) (string, error) {
+	data, err := encodePrivateKey(key)
+	return string(data), err
+}
+
+// saveInternalCA 校验有效天数后保存 CA，并写出根证书和证书链文件
+func saveInternalCA(ca *database.InternalCA) error {
+	if ca.LeafDays <= 0 {
+		ca.LeafDays = defaultLeafDays
+	}
+	if ca.LeafDays > maxLeafDays {
+		return fmt.Errorf("证书有效期不能超过 %d 天", maxLeafDays)
+	}
+
+	if err := database.SaveInternalCA(ca); err != nil {
+		return fmt.Errorf("保存内部 CA 失败: %w", err)
+	}
+
+	if err := writeInternalCAFiles(ca); err != nil {
+		return err
+	}
+
+	log.Info("内部 CA 已更新", map[string]interface{}{
+		"name":     ca.Name,
+		"leafDays": ca.LeafDays,
+	})
+	return nil
+}
+
+// writeInternalCAFiles 写出根证书文件和 nginx 校验客户端证书使用的证书链
+func writeInternalCAFiles(ca *database.InternalCA) error {
+	dataDir := config.Get().Data.Dir
+	certFile := filepath.Join(dataDir, database.InternalCACertPath)
+	if err := os.MkdirAll(filepath.Dir(certFile), 0755); err != nil {
+		return fmt.Errorf("创建 SSL 目录失败: %w", err)
+	}
+	if err := os.WriteFile(certFile, []byte(ca.RootCertPEM), 0644); err != nil {
+		return fmt.Errorf("写入根证书失败: %w", err)
+	}
+	chain := strings.TrimSpace(ca.RootCertPEM) + "\n" + strings.TrimSpace(ca.CertPEM) + "\n"
+	if err := os.WriteFile(filepath.Join(dataDir, database.InternalCAChainPath), []byte(chain), 0644); err != nil {
+		return fmt.Errorf("写入证书链失败: %w", err)
+	}
+	return nil
+}
+
+// SyncInternalCAFiles 按数据库重新写出内部 CA 的证书文件，启动时调用，
+// 避免旧版本或手动恢复的数据缺少 nginx 引用的证书链
+func SyncInternalCAFiles() {
+	ca, err := database.GetInternalCA()
+	if err != nil {
+		return
+	}
+	if err := writeInternalCAFiles(ca); err != nil {
+		log.Error("写入内部 CA 证书文件失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// loadIssuer 读取内部 CA 的中间证书和私钥
+func loadIssuer() (*database.InternalCA, *x509.Certificate, crypto.Signer, error) {
+	ca, err := database.GetInternalCA()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("内部 CA 未配置")
+	}
+	certs, err := parseCertificateChain([]byte(ca.CertPEM))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("解析中间证书失败: %w", err)
+	}
+	key, err := parsePrivateKey([]byte(ca.KeyPEM))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("解析中间证书私钥失败: %w", err)
+	}
+	return ca, certs[0], key, nil
+}
+
+// signLeafCertificate 用内部 CA 签发服务器或客户端证书，返回证书链（不含根证书）和私钥
+func signLeafCertificate(domains []string, usage string, key crypto.Signer) (*issuedCertificate, error) {
+	ca, issuer, issuerKey, err := loadIssuer()
+	if err != nil {
+		return nil, err
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: domains[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(0, 0, ca.LeafDays),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	if _, ok := key.Public().(*rsa.PublicKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if template.NotAfter.After(issuer.NotAfter) {
+		template.NotAfter = issuer.NotAfter
+	}
+	if usage == database.CertUsageClient {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	for _, d := range domains {
+		if ip := net.ParseIP(d); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, d)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+	if err != nil {
+		return nil, fmt.Errorf("签发证书失败: %w", err)
+	}
+
+	var certPEM bytes.Buffer
+	pem.Encode(&certPEM, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	pem.Encode(&certPEM, &pem.Block{Type: "CERTIFICATE", Bytes: issuer.Raw})
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &issuedCertificate{CertPEM: certPEM.Bytes(), KeyPEM: keyPEM}, nil
+}
+
+// signInternalCertificates 按证书的私钥设置重新签发内部证书
+func signInternalCertificates(cert *database.Certificate, domains []string) (*issuedCertificates, error) {
+	key, err := certificateKey(cert.KeyType, cert.ReuseKey, cert.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	issued, err := signLeafCertificate(domains, cert.Usage, key)
+	if err != nil {
+		return nil, err
+	}
+	return &issuedCertificates{Primary: issued}, nil
+}
+
+// InternalIssueOptions 内部证书签发参数
+type InternalIssueOptions struct {
+	Domains  []string // 域名或 IP；客户端证书时第一个为证书名称
+	Usage    string   // server（默认）或 client
+	KeyType  string
+	ReuseKey bool
+	Replace  string // 可选，签发成功后替换的证书 ID，引用它的站点改用新证书
+}
+
+// validateInternalName 校验内部证书的名称
+func validateInternalName(name string) error {
+	if net.ParseIP(name) != nil {
+		return nil
+	}
+	if name == "" || strings.ContainsAny(name, " /\\") || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("无效的名称: %s", name)
+	}
+	return nil
+}
+
+// IssueInternalCertificate 用内部 CA 签发证书，签发的证书由定时任务自动续期
+func IssueInternalCertificate(opts InternalIssueOptions) (*database.Certificate, error) {
+	cert, deploy, err := issueInternalCertificate(opts)
+	if err != nil {
+		return nil, err
+	}
+	deploy.run(context.Background())
+	return cert, nil
+}
+
+// issueInternalCertificate 持有 renewMutex 签发证书，返回释放锁后需要执行的部署钩子
+func issueInternalCertificate(opts InternalIssueOptions) (*database.Certificate, *deployment, error) {
+	renewMutex.Lock()
+	defer renewMutex.Unlock()
+
+	if len(opts.Domains) == 0 {
+		return nil, nil, fmt.Errorf("至少需要一个域名")
+	}
+	for _, d := range opts.Domains {
+		if err := validateInternalName(d); err != nil {
+			return nil, nil, err
+		}
+	}
+	switch opts.Usage {
+	case "":
+		opts.Usage = database.CertUsageServer
+	case database.CertUsageServer, database.CertUsageClient:
+	default:
+		return nil, nil, fmt.Errorf("不支持的证书用途: %s", opts.Usage)
+	}
+	if err := ValidateKeyOptions(opts.KeyType, false); err != nil {
+		return nil, nil, err
+	}
+	if opts.KeyType == "" {
+		opts.KeyType = defaultKeyType
+	}
+
+	var replaced *database.Certificate
+	if opts.Replace != "" {
+		var err error
+		if replaced, err = replaceableCertificate(opts.Replace, opts.Usage); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	mainDomain := opts.Domains[0]
+
+	// 客户端证书单独存放，避免与同名的服务器证书文件混淆
+	name := mainDomain
+	if opts.Usage == database.CertUsageClient {
+		name = "client-" + name
+	}
+	cert := &database.Certificate{
+		ID:       uuid.New().String(),
+		Domain:   mainDomain,
+		Source:   database.CertSourceInternal,
+		Usage:    opts.Usage,
+		KeyType:  opts.KeyType,
+		ReuseKey: opts.ReuseKey,
+	}
+	cert.CertPath, cert.KeyPath = certificatePaths(name, cert.ID)
+
+	issued, err := signInternalCertificates(cert, opts.Domains)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certInfo, err := installCertificates(context.Background(), cert, issued)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if replaced != nil {
+		cert.RenewBefore = replaced.RenewBefore
+	}
+	domainsJSON, _ := json.Marshal(opts.Domains)
+	now := time.Now()
+	cert.Domains = string(domainsJSON)
+	cert.Issuer = certInfo.Issuer
+	cert.NotBefore = certInfo.NotBefore
+	cert.NotAfter = certInfo.NotAfter
+	cert.AutoRenew = true
+	cert.LastRenewAt = &now
+	cert.Status = "active"
+
+	if err := database.CreateCertificate(cert); err != nil {
+		return nil, nil, fmt.Errorf("保存证书记录失败: %w", err)
+	}
+
+	database.CreateCertificateLog(&database.CertificateLog{
+		ID:            uuid.New().String(),
+		CertificateID: cert.ID,
+		Action:        "create",
+		Message:       fmt.Sprintf("内部 CA 签发证书，有效期至 %s", cert.NotAfter.Format("2006-01-02")),
+	})
+	if replaced != nil {
+		replaceCertificate(context.Background(), replaced, cert)
+	}
+	linkPendingSites(context.Background(), cert)
+
+	scheduleReload(cert.ID)
+
+	log.Info("内部证书签发成功", map[string]interface{}{
+		"domains":  opts.Domains,
+		"usage":    opts.Usage,
+		"notAfter": cert.NotAfter.Format("2006-01-02"),
+	})
+	return cert, prepareDeployHooks(cert), nil
+}
+
+// === 内部 CA API ===
+
+// InternalCAResponse 内部 CA 信息（不包含私钥）
+type InternalCAResponse struct {
+	Configured          bool   `json:"configured"`
+	ID                  string `json:"id,omitempty"`
+	Name                string `json:"name,omitempty"`
+	RootSubject         string `json:"rootSubject,omitempty"`
+	RootNotAfter        string `json:"rootNotAfter,omitempty"`
+	RootFingerprint     string `json:"rootFingerprint,omitempty"` // 根证书 SHA-256 指纹
+	HasRootKey          bool   `json:"hasRootKey"`
+	IssuerSubject       string `json:"issuerSubject,omitempty"`
+	IssuerNotAfter      string `json:"issuerNotAfter,omitempty"`
+	LeafDays            int    `json:"leafDays,omitempty"`
+	CertificateCount    int    `json:"certificateCount"`
+	RootCertificatePath string `json:"rootCertificatePath,omitempty"`
+}
+
+// GenerateCARequest 生成 CA 请求
+type GenerateCARequest struct {
+	Name     string `json:"name"`
+	LeafDays int    `json:"leafDays"`
+}
+
+// ImportCARequest 导入 CA 请求
+type ImportCARequest struct {
+	Name               string `json:"name"`
+	RootCertificate    string `json:"rootCertificate"`
+	RootKey            string `json:"rootKey"`
+	IssuingCertificate string `json:"issuingCertificate"`
+	IssuingKey         string `json:"issuingKey"`
+	LeafDays           int    `json:"leafDays"`
+}
+
+// UpdateCARequest 修改 CA 设置请求
+type UpdateCARequest struct {
+	LeafDays int `json:"leafDays"`
+}
+
+// IssueInternalRequest 签发内部证书请求
+type IssueInternalRequest struct {
+	Domains  []string `json:"domains"`
+	Usage    string   `json:"usage"` // server（默认）或 client
+	KeyType  string   `json:"keyType"`
+	ReuseKey bool     `json:"reuseKey"`
+	Replace  string   `json:"replace"` // 可选，签发成功后替换的证书 ID
+}
+
+func handleGetCA(w http.ResponseWriter, r *http.Request) {
+	ca, err := database.GetInternalCA()
+	if err != nil {
+		jsonResponse(w, InternalCAResponse{})
+		return
+	}
+	jsonResponse(w, caToResponse(ca))
+}
+
+func handleGenerateCA(w http.ResponseWriter, r *http.Request) {
+	var req GenerateCARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if !confirmReplaceCA(w) {
+		return
+	}
+
+	ca, err := GenerateInternalCA(req.Name, req.LeafDays)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"ca":      caToResponse(ca),
+	})
+}
+
+func handleImportCA(w http.ResponseWriter, r *http.Request) {
+	var req ImportCARequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadSize)).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if !confirmReplaceCA(w) {
+		return
+	}
+
+	ca, err := ImportInternalCA(ImportCAOptions{
+		Name:           req.Name,
+		RootCertPEM:    req.RootCertificate,
+		RootKeyPEM:     req.RootKey,
+		IssuingCertPEM: req.IssuingCertificate,
+		IssuingKeyPEM:  req.IssuingKey,
+		LeafDays:       req.LeafDays,
+	})
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"ca":      caToResponse(ca),
+	})
+}
+
+// confirmReplaceCA 已有内部证书时不允许替换 CA，避免这些证书续期后换了信任链
+func confirmReplaceCA(w http.ResponseWriter) bool {
+	count, err := database.CountCertificatesBySource(database.CertSourceInternal)
+	if err != nil {
+		jsonError(w, "统计内部证书失败: "+err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if count > 0 {
+		jsonError(w, fmt.Sprintf("仍有 %d 个证书由内部 CA 签发，请先删除", count), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+func handleUpdateCA(w http.ResponseWriter, r *http.Request) {
+	var req UpdateCARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	ca, err := database.GetInternalCA()
+	if err != nil {
+		jsonError(w, "内部 CA 未配置", http.StatusNotFound)
+		return
+	}
+	if req.LeafDays <= 0 || req.LeafDays > maxLeafDays {
+		jsonError(w, fmt.Sprintf("证书有效期需要在 1 到 %d 天之间", maxLeafDays), http.StatusBadRequest)
+		return
+	}
+	if err := database.UpdateInternalCALeafDays(ca.ID, req.LeafDays); err != nil {
+		jsonError(w, "更新内部 CA 失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ca.LeafDays = req.LeafDays
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"ca":      caToResponse(ca),
+	})
+}
+
+// handleDeleteCA 删除内部 CA，仍有内部证书或站点用它校验客户端证书时拒绝删除
+func handleDeleteCA(w http.ResponseWriter, r *http.Request) {
+	if !confirmReplaceCA(w) {
+		return
+	}
+	if sites := sitesUsingClientCA(nginx.ClientCAInternal, ""); len(sites) > 0 {
+		jsonError(w, "以下站点使用内部 CA 校验客户端证书: "+strings.Join(sites, ", "), http.StatusConflict)
+		return
+	}
+	if err := database.DeleteInternalCA(); err != nil {
+		jsonError(w, "删除内部 CA 失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	os.Remove(filepath.Join(config.Get().Data.Dir, database.InternalCACertPath))
+	os.Remove(filepath.Join(config.Get().Data.Dir, database.InternalCAChainPath))
+
+	jsonResponse(w, map[string]bool{"success": true})
+}
+
+// handleDownloadCA 下载根证书，用于安装到客户端的信任列表
+func handleDownloadCA(w http.ResponseWriter, r *http.Request) {
+	ca, err := database.GetInternalCA()
+	if err != nil {
+		jsonError(w, "内部 CA 未配置", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Header().Set("Content-Disposition", `attachment; filename="hop-internal-ca.crt"`)
+	w.Write([]byte(ca.RootCertPEM))
+}
+
+func handleIssueInternalCertificate(w http.ResponseWriter, r *http.Request) {
+	var req IssueInternalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	cert, err := IssueInternalCertificate(InternalIssueOptions{
+		Domains:  req.Domains,
+		Usage:    req.Usage,
+		KeyType:  req.KeyType,
+		ReuseKey: req.ReuseKey,
+		Replace:  req.Replace,
+	})
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success":     true,
+		"certificate": certToResponse(cert),
+	})
+}
+
+func caToResponse(ca *database.InternalCA) InternalCAResponse {
+	resp := InternalCAResponse{
+		Configured:          true,
+		ID:                  ca.ID,
+		Name:                ca.Name,
+		HasRootKey:          ca.RootKeyPEM != "",
+		LeafDays:            ca.LeafDays,
+		RootCertificatePath: database.InternalCACertPath,
+	}
+	if certs, err := parseCertificateChain([]byte(ca.RootCertPEM)); err == nil {
+		sum := sha256.Sum256(certs[0].Raw)
+		resp.RootSubject = certs[0].Subject.CommonName
+		resp.RootNotAfter = certs[0].NotAfter.Format("2006-01-02T15:04:05Z07:00")
+		resp.RootFingerprint = strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+	if certs, err := parseCertificateChain([]byte(ca.CertPEM)); err == nil {
+		resp.IssuerSubject = certs[0].Subject.CommonName
+		resp.IssuerNotAfter = certs[0].NotAfter.Format("2006-01-02T15:04:05Z07:00")
+	}
+	resp.CertificateCount, _ = database.CountCertificatesBySource(database.CertSourceInternal)
+	return resp
+}
@@ -0,0 +1,259 @@
+package ssl
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+// normalizeCABundle 解析 CA 证书包，只保留 CA 证书并重新编码
+func normalizeCABundle(data string) (string, error) {
+	certs, err := parseCertificateChain([]byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for _, c := range certs {
+		if !c.IsCA {
+			return "", fmt.Errorf("%s 不是 CA 证书", c.Subject.CommonName)
+		}
+		if time.Now().After(c.NotAfter) {
+			return "", fmt.Errorf("%s 已于 %s 过期", c.Subject.CommonName, c.NotAfter.Format("2006-01-02"))
+		}
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	return buf.String(), nil
+}
+
+// writeClientCAFile 写出证书包文件
+func writeClientCAFile(ca *database.ClientCA) error {
+	certFile := filepath.Join(config.Get().Data.Dir, ca.CertPath)
+	if err := os.MkdirAll(filepath.Dir(certFile), 0755); err != nil {
+		return fmt.Errorf("创建 CA 目录失败: %w", err)
+	}
+	if err := os.WriteFile(certFile, []byte(ca.CertPEM), 0644); err != nil {
+		return fmt.Errorf("写入 CA 证书失败: %w", err)
+	}
+	return nil
+}
+
+// sitesUsingClientCA 列出使用该 CA 校验客户端证书或上游证书的站点（按 ID 或名称引用）
+func sitesUsingClientCA(id, name string) []string {
+	sites, err := nginx.ListProxySites()
+	if err != nil {
+		return nil
+	}
+	matches := func(ref string) bool {
+		return ref != "" && (ref == id || ref == name)
+	}
+	var names []string
+	for _, site := range sites {
+		if (site.ClientAuth != "" && matches(site.ClientCA)) ||
+			(site.UpstreamScheme == "https" && site.UpstreamSSLVerify && matches(site.UpstreamCA)) {
+			names = append(names, site.ServerName)
+		}
+	}
+	return names
+}
+
+// === HTTP Handlers ===
+
+// ClientCARequest 创建或修改客户端 CA 请求
+type ClientCARequest struct {
+	Name        string `json:"name"`
+	Certificate string `json:"certificate"` // 一个或多个 CA 证书（PEM）
+}
+
+// ClientCAResponse 客户端 CA 信息
+type ClientCAResponse struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Subjects  []string `json:"subjects"` // 证书包中各 CA 的名称
+	NotAfter  string   `json:"notAfter"` // 最早过期的 CA 证书的过期时间
+	CertPath  string   `json:"certPath"`
+	Sites     []string `json:"sites"` // 使用该 CA 的站点
+	CreatedAt string   `json:"createdAt"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+func handleListClientCAs(w http.ResponseWriter, r *http.Request) {
+	cas, err := database.ListClientCAs()
+	if err != nil {
+		jsonError(w, "获取客户端 CA 列表失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ClientCAResponse, 0, len(cas))
+	for i := range cas {
+		resp = append(resp, clientCAToResponse(&cas[i]))
+	}
+	jsonResponse(w, map[string]interface{}{
+		"clientCas": resp,
+	})
+}
+
+func handleCreateClientCA(w http.ResponseWriter, r *http.Request) {
+	var req ClientCARequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadSize)).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		jsonError(w, "名称不能为空", http.StatusBadRequest)
+		return
+	}
+	if req.Name == nginx.ClientCAInternal {
+		jsonError(w, "名称 internal 保留给内部 CA", http.StatusBadRequest)
+		return
+	}
+	if _, err := database.GetClientCAByName(req.Name); err == nil {
+		jsonError(w, "名称已存在", http.StatusBadRequest)
+		return
+	}
+	bundle, err := normalizeCABundle(req.Certificate)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := uuid.New().String()
+	ca := &database.ClientCA{
+		ID:       id,
+		Name:     req.Name,
+		CertPEM:  bundle,
+		CertPath: filepath.Join("nginx", "ssl", "client-ca", id+".crt"),
+	}
+	if err := writeClientCAFile(ca); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := database.CreateClientCA(ca); err != nil {
+		jsonError(w, "保存客户端 CA 失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success":  true,
+		"clientCa": clientCAToResponse(ca),
+	})
+}
+
+// handleUpdateClientCA 替换证书包，引用它的站点需要重新加载 nginx 后生效
+func handleUpdateClientCA(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req ClientCARequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadSize)).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	ca, err := database.GetClientCA(id)
+	if err != nil {
+		jsonError(w, "客户端 CA 不存在", http.StatusNotFound)
+		return
+	}
+	// 按名称引用的站点在改名后会失效
+	if name := strings.TrimSpace(req.Name); name != "" && name != ca.Name {
+		if sites := sitesUsingClientCA("", ca.Name); len(sites) > 0 {
+			jsonError(w, "以下站点按名称引用该 CA，不能改名: "+strings.Join(sites, ", "), http.StatusConflict)
+			return
+		}
+		if name == nginx.ClientCAInternal {
+			jsonError(w, "名称 internal 保留给内部 CA", http.StatusBadRequest)
+			return
+		}
+		if _, err := database.GetClientCAByName(name); err == nil {
+			jsonError(w, "名称已存在", http.StatusBadRequest)
+			return
+		}
+		ca.Name = name
+	}
+	if strings.TrimSpace(req.Certificate) != "" {
+		bundle, err := normalizeCABundle(req.Certificate)
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ca.CertPEM = bundle
+		if err := writeClientCAFile(ca); err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := database.UpdateClientCA(ca); err != nil {
+		jsonError(w, "更新客户端 CA 失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success":  true,
+		"clientCa": clientCAToResponse(ca),
+	})
+}
+
+// handleDeleteClientCA 删除客户端 CA，仍有站点使用时拒绝删除
+func handleDeleteClientCA(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	ca, err := database.GetClientCA(id)
+	if err != nil {
+		jsonError(w, "客户端 CA 不存在", http.StatusNotFound)
+		return
+	}
+	if sites := sitesUsingClientCA(ca.ID, ca.Name); len(sites) > 0 {
+		jsonError(w, "以下站点仍在使用该 CA: "+strings.Join(sites, ", "), http.StatusConflict)
+		return
+	}
+
+	if err := database.DeleteClientCA(id); err != nil {
+		jsonError(w, "删除客户端 CA 失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	os.Remove(filepath.Join(config.Get().Data.Dir, ca.CertPath))
+
+	jsonResponse(w, map[string]bool{"success": true})
+}
+
+func clientCAToResponse(ca *database.ClientCA) ClientCAResponse {
+	resp := ClientCAResponse{
+		ID:        ca.ID,
+		Name:      ca.Name,
+		Subjects:  []string{},
+		CertPath:  ca.CertPath,
+		Sites:     sitesUsingClientCA(ca.ID, ca.Name),
+		CreatedAt: ca.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: ca.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if resp.Sites == nil {
+		resp.Sites = []string{}
+	}
+	if certs, err := parseCertificateChain([]byte(ca.CertPEM)); err == nil {
+		var notAfter time.Time
+		for _, c := range certs {
+			resp.Subjects = append(resp.Subjects, c.Subject.CommonName)
+			if notAfter.IsZero() || c.NotAfter.Before(notAfter) {
+				notAfter = c.NotAfter
+			}
+		}
+		resp.NotAfter = notAfter.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
@@ -0,0 +1,124 @@
+package ssl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+// CertificateInUseError 证书仍被站点引用，不能删除
+type CertificateInUseError struct {
+	Sites []string
+}
+
+func (e *CertificateInUseError) Error() string {
+	return "以下站点仍在使用该证书: " + strings.Join(e.Sites, ", ")
+}
+
+// sitesUsingCertificate 列出引用该证书的站点（站点证书或上游客户端证书）
+func sitesUsingCertificate(certID string) ([]nginx.ProxySite, error) {
+	index, err := nginx.CertificateSites()
+	if err != nil {
+		return nil, fmt.Errorf("获取站点列表失败: %w", err)
+	}
+	return index[certID], nil
+}
+
+// siteNames 站点的域名列表
+func siteNames(sites []nginx.ProxySite) []string {
+	names := make([]string, 0, len(sites))
+	for _, site := range sites {
+		names = append(names, site.ServerName)
+	}
+	return names
+}
+
+// certificateFilesChanged 证书文件路径是否变化，例如开启或关闭双证书
+func certificateFilesChanged(old, cert *database.Certificate) bool {
+	return old.CertPath != cert.CertPath || old.KeyPath != cert.KeyPath ||
+		old.RSACertPath != cert.RSACertPath || old.RSAKeyPath != cert.RSAKeyPath
+}
+
+// refreshCertificateSites 证书文件路径变化后重新生成引用该证书的站点配置
+// 站点配置生成时已写入证书路径，不重新生成会继续使用旧文件
+func refreshCertificateSites(ctx context.Context, cert *database.Certificate) {
+	sites, err := sitesUsingCertificate(cert.ID)
+	if err != nil {
+		addCertificateLog(ctx, cert.ID, "sites", err.Error())
+		return
+	}
+
+	for _, site := range sites {
+		change := nginx.SystemChange(fmt.Sprintf("证书 %s 的文件已变更，重新生成站点 %s 的配置", cert.Domain, site.ServerName))
+		if err := nginx.SaveProxySite(site, change); err != nil {
+			addCertificateLog(ctx, cert.ID, "sites", fmt.Sprintf("站点 %s 重新生成配置失败: %s", site.ServerName, err.Error()))
+			continue
+		}
+		addCertificateLog(ctx, cert.ID, "sites", fmt.Sprintf("站点 %s 已改用新的证书文件", site.ServerName))
+	}
+}
+
+// detachCertificateSites 停止站点对证书的引用：站点证书停用 HTTPS，上游客户端证书不再发送
+// reason 说明原因，例如"已撤销"，记录在配置历史中；返回未能处理的站点
+func detachCertificateSites(ctx context.Context, cert *database.Certificate, sites []nginx.ProxySite, action, reason string) []string {
+	var failed []string
+	for _, site := range sites {
+		var changes []string
+		if site.CertificateID == cert.ID {
+			site.SSL = false
+			site.CertificateID = ""
+			site.ClientAuth = ""
+			changes = append(changes, "停用 HTTPS")
+		}
+		if site.UpstreamClientCertID == cert.ID {
+			site.UpstreamClientCertID = ""
+			changes = append(changes, "不再向上游发送客户端证书")
+		}
+		summary := strings.Join(changes, "，")
+
+		change := nginx.SystemChange(fmt.Sprintf("证书 %s %s，站点 %s %s", cert.Domain, reason, site.ServerName, summary))
+		if err := nginx.SaveProxySite(site, change); err != nil {
+			addCertificateLog(ctx, cert.ID, action, fmt.Sprintf("站点 %s %s 失败: %s", site.ServerName, summary, err.Error()))
+			failed = append(failed, site.ServerName)
+			continue
+		}
+		addCertificateLog(ctx, cert.ID, action, fmt.Sprintf("站点 %s 已%s", site.ServerName, summary))
+	}
+	return failed
+}
+
+// DeleteCertificate 删除证书记录
+// 仍有站点引用时返回 CertificateInUseError；cascade 为 true 时先解除这些站点的引用，有站点未能处理时不删除
+func DeleteCertificate(certID string, cascade bool) error {
+	renewMutex.Lock()
+	defer renewMutex.Unlock()
+
+	cert, err := database.GetCertificate(certID)
+	if err != nil {
+		return fmt.Errorf("证书不存在")
+	}
+	sites, err := sitesUsingCertificate(cert.ID)
+	if err != nil {
+		return err
+	}
+	if len(sites) > 0 {
+		if !cascade {
+			return &CertificateInUseError{Sites: siteNames(sites)}
+		}
+		if failed := detachCertificateSites(context.Background(), cert, sites, "delete", "将被删除"); len(failed) > 0 {
+			return fmt.Errorf("以下站点未能解除对该证书的引用，证书未删除: %s", strings.Join(failed, ", "))
+		}
+	}
+
+	if err := database.DeleteCertificate(cert.ID); err != nil {
+		return fmt.Errorf("删除证书失败: %w", err)
+	}
+	log.Info("证书已删除", map[string]interface{}{
+		"domain": cert.Domain,
+		"sites":  len(sites),
+	})
+	return nil
+}
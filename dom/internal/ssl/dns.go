@@ -0,0 +1,142 @@
+package ssl
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hop/backend/internal/config"
+)
+
+// DNSSolver 管理 dns-01 验证所需的 TXT 记录
+type DNSSolver interface {
+	// Present 添加 TXT 记录
+	Present(ctx context.Context, fqdn, value string) error
+	// CleanUp 删除 TXT 记录；value 为空时删除该名称下的所有 TXT 记录
+	CleanUp(ctx context.Context, fqdn, value string) error
+}
+
+// dnsHTTPClient DNS 提供商 API 使用的 HTTP 客户端
+var dnsHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// challengeFQDN 返回域名对应的验证记录名（通配符域名与主域名共用）
+func challengeFQDN(domain string) string {
+	return "_acme-challenge." + strings.TrimPrefix(domain, "*.")
+}
+
+// relativeName 计算记录相对于区域的主机名
+// 例如 _acme-challenge.www.example.com 在 example.com 区域中为 _acme-challenge.www
+func relativeName(fqdn, zone string) string {
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	zone = strings.TrimSuffix(zone, ".")
+	if fqdn == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(fqdn, "."+zone)
+}
+
+// parentDomains 返回候选区域，从最长到最短
+// 例如 _acme-challenge.a.example.com -> a.example.com, example.com
+func parentDomains(fqdn string) []string {
+	labels := strings.Split(strings.TrimSuffix(fqdn, "."), ".")
+	var result []string
+	for i := 1; i < len(labels)-1; i++ {
+		result = append(result, strings.Join(labels[i:], "."))
+	}
+	return result
+}
+
+// quoteTXT 按区域文件格式给 TXT 记录值加上引号，Route53、PowerDNS 等 API 要求这种格式
+func quoteTXT(value string) string {
+	return `"` + value + `"`
+}
+
+// unquoteTXT 去掉 TXT 记录值两端的引号
+func unquoteTXT(value string) string {
+	return strings.Trim(value, `"`)
+}
+
+// waitForPropagation 等待 TXT 记录在解析服务器上可见
+// 超时只记录警告，由 CA 的验证结果决定成败
+func waitForPropagation(ctx context.Context, fqdn, value string) {
+	cfg := config.Get().ACME
+	if cfg.PropagationTimeout <= 0 {
+		return
+	}
+
+	resolvers := []*net.Resolver{net.DefaultResolver}
+	if len(cfg.DNSResolvers) > 0 {
+		resolvers = resolvers[:0]
+		for _, addr := range cfg.DNSResolvers {
+			resolvers = append(resolvers, resolverFor(addr))
+		}
+	}
+
+	deadline := time.Now().Add(time.Duration(cfg.PropagationTimeout) * time.Second)
+	for {
+		if txtVisible(ctx, resolvers, fqdn, value) {
+			log.Info("DNS 记录已生效", map[string]interface{}{"fqdn": fqdn})
+			return
+		}
+		if time.Now().After(deadline) {
+			log.Warn("等待 DNS 记录生效超时，继续验证", map[string]interface{}{
+				"fqdn":    fqdn,
+				"timeout": cfg.PropagationTimeout,
+			})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// txtVisible 所有解析服务器都能查询到记录时返回 true
+func txtVisible(ctx context.Context, resolvers []*net.Resolver, fqdn, value string) bool {
+	for _, r := range resolvers {
+		lookupCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		records, err := r.LookupTXT(lookupCtx, fqdn)
+		cancel()
+		if err != nil || !containsString(records, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolverFor 创建使用指定服务器的解析器
+func resolverFor(addr string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// newSolver 根据 DNS 提供商记录创建验证器
+func newSolver(providerType, configJSON string) (DNSSolver, error) {
+	providerConfig, err := ParseDNSProviderConfig(providerType, configJSON)
+	if err != nil {
+		return nil, err
+	}
+	return providerConfig.NewSolver()
+}
+
+func containsString(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
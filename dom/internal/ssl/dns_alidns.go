@@ -0,0 +1,205 @@
+package ssl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const aliDNSEndpoint = "https://alidns.aliyuncs.com/"
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderAliDNS,
+		Name:        "阿里云 DNS",
+		Description: "使用阿里云 AccessKey 调用云解析 DNS OpenAPI，RAM 用户需要 AliyunDNSFullAccess 权限",
+		Fields: []DNSProviderField{
+			{Name: "accessKeyId", Label: "AccessKey ID", Type: FieldText, Required: true, Placeholder: "LTAI..."},
+			{Name: "accessKeySecret", Label: "AccessKey Secret", Type: FieldPassword, Required: true},
+			{Name: "regionId", Label: "Region ID", Type: FieldText, Placeholder: "cn-hangzhou"},
+		},
+	}, func() DNSProviderConfig { return &AliDNSConfig{} })
+}
+
+// AliDNSConfig 阿里云 DNS 配置
+type AliDNSConfig struct {
+	AccessKeyID     string `json:"accessKeyId"`
+	AccessKeySecret string `json:"accessKeySecret"`
+	RegionID        string `json:"regionId,omitempty"` // 可选，默认 cn-hangzhou
+}
+
+func (c *AliDNSConfig) GetProviderName() string {
+	return "alidns"
+}
+
+func (c *AliDNSConfig) NewSolver() (DNSSolver, error) {
+	if c.AccessKeyID == "" || c.AccessKeySecret == "" {
+		return nil, fmt.Errorf("阿里云 AccessKey 不能为空")
+	}
+	return &aliDNSSolver{cfg: c}, nil
+}
+
+// aliDNSSolver 通过阿里云 DNS OpenAPI（RPC 风格，签名版本 1.0）管理 TXT 记录
+type aliDNSSolver struct {
+	cfg *AliDNSConfig
+}
+
+type aliDNSRecord struct {
+	RecordID string `json:"RecordId"`
+	RR       string `json:"RR"`
+	Type     string `json:"Type"`
+	Value    string `json:"Value"`
+}
+
+func (s *aliDNSSolver) Present(ctx context.Context, fqdn, value string) error {
+	domain, rr, err := s.mainDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	return s.call(ctx, "AddDomainRecord", map[string]string{
+		"DomainName": domain,
+		"RR":         rr,
+		"Type":       "TXT",
+		"Value":      value,
+		"TTL":        "600",
+	}, nil)
+}
+
+func (s *aliDNSSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	domain, rr, err := s.mainDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		DomainRecords struct {
+			Record []aliDNSRecord `json:"Record"`
+		} `json:"DomainRecords"`
+	}
+	err = s.call(ctx, "DescribeDomainRecords", map[string]string{
+		"DomainName":  domain,
+		"RRKeyWord":   rr,
+		"TypeKeyWord": "TXT",
+		"PageSize":    "500",
+	}, &resp)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range resp.DomainRecords.Record {
+		if record.RR != rr || record.Type != "TXT" || (value != "" && record.Value != value) {
+			continue
+		}
+		if err := s.call(ctx, "DeleteDomainRecord", map[string]string{"RecordId": record.RecordID}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mainDomain 解析记录所属的主域名和主机记录
+func (s *aliDNSSolver) mainDomain(ctx context.Context, fqdn string) (string, string, error) {
+	var resp struct {
+		DomainName string `json:"DomainName"`
+		RR         string `json:"RR"`
+	}
+	if err := s.call(ctx, "GetMainDomainName", map[string]string{"InputString": fqdn}, &resp); err != nil {
+		return "", "", err
+	}
+	if resp.DomainName == "" {
+		return "", "", &DNSProviderError{Provider: "alidns", Op: "GetMainDomainName", Message: "找不到 " + fqdn + " 所属的域名"}
+	}
+	return resp.DomainName, relativeName(fqdn, resp.DomainName), nil
+}
+
+// call 调用 OpenAPI
+func (s *aliDNSSolver) call(ctx context.Context, action string, params map[string]string, out interface{}) error {
+	query := map[string]string{
+		"Action":           action,
+		"Format":           "JSON",
+		"Version":          "2015-01-09",
+		"AccessKeyId":      s.cfg.AccessKeyID,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   uuid.New().String(),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	for k, v := range params {
+		query[k] = v
+	}
+
+	canonical := aliCanonicalQuery(query)
+	stringToSign := "GET&" + aliPercentEncode("/") + "&" + aliPercentEncode(canonical)
+	mac := hmac.New(sha1.New, []byte(s.cfg.AccessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	reqURL := aliDNSEndpoint + "?" + canonical + "&Signature=" + aliPercentEncode(signature)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "alidns", Op: action, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return &DNSProviderError{
+			Provider: "alidns",
+			Op:       action,
+			Code:     apiErr.Code,
+			Message:  fmt.Sprintf("HTTP %d %s", resp.StatusCode, apiErr.Message),
+			Auth: strings.HasPrefix(apiErr.Code, "InvalidAccessKeyId") ||
+				strings.HasPrefix(apiErr.Code, "SignatureDoesNotMatch") ||
+				strings.HasPrefix(apiErr.Code, "Forbidden"),
+		}
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// aliCanonicalQuery 按参数名排序并编码
+func aliCanonicalQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, aliPercentEncode(k)+"="+aliPercentEncode(params[k]))
+	}
+	return strings.Join(parts, "&")
+}
+
+// aliPercentEncode 阿里云签名要求的 RFC 3986 编码
+func aliPercentEncode(s string) string {
+	encoded := url.QueryEscape(s)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	encoded = strings.ReplaceAll(encoded, "%7E", "~")
+	return encoded
+}
@@ -0,0 +1,186 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderCloudflare,
+		Name:        "Cloudflare",
+		Description: "推荐使用具有 Zone.DNS 编辑权限的 API Token；使用 Global API Key 时需同时填写邮箱",
+		Fields: []DNSProviderField{
+			{Name: "apiToken", Label: "API Token", Type: FieldPassword, Help: "推荐使用 API Token。如使用 Global API Key，请填写下面两项"},
+			{Name: "email", Label: "Email", Type: FieldText, Placeholder: "your@email.com", Help: "使用 Global API Key 时填写"},
+			{Name: "apiKey", Label: "Global API Key", Type: FieldPassword, Help: "使用 Global API Key 时填写"},
+		},
+	}, func() DNSProviderConfig { return &CloudflareConfig{} })
+}
+
+// CloudflareConfig Cloudflare DNS 配置
+type CloudflareConfig struct {
+	APIToken string `json:"apiToken,omitempty"` // 推荐使用 API Token
+	Email    string `json:"email,omitempty"`    // 可选，使用 Global API Key 时需要
+	APIKey   string `json:"apiKey,omitempty"`   // Global API Key (不推荐)
+}
+
+func (c *CloudflareConfig) GetProviderName() string {
+	return "cloudflare"
+}
+
+func (c *CloudflareConfig) NewSolver() (DNSSolver, error) {
+	if c.APIToken == "" && (c.APIKey == "" || c.Email == "") {
+		return nil, fmt.Errorf("Cloudflare 需要 API Token，或同时提供邮箱和 Global API Key")
+	}
+	return &cloudflareSolver{cfg: c}, nil
+}
+
+// cloudflareSolver 通过 Cloudflare API v4 管理 TXT 记录
+type cloudflareSolver struct {
+	cfg *CloudflareConfig
+}
+
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+func (s *cloudflareSolver) Present(ctx context.Context, fqdn, value string) error {
+	zoneID, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	record := map[string]interface{}{
+		"type":    "TXT",
+		"name":    fqdn,
+		"content": value,
+		"ttl":     120,
+	}
+	err = s.request(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", record, nil)
+	// 81058: 已存在相同的记录（上次申请中断后残留），可以直接复用
+	var dnsErr *DNSProviderError
+	if errors.As(err, &dnsErr) && dnsErr.Code == "81058" {
+		return nil
+	}
+	return err
+}
+
+func (s *cloudflareSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	zoneID, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"type": {"TXT"}, "name": {fqdn}}
+	var records []cloudflareRecord
+	if err := s.request(ctx, http.MethodGet, "/zones/"+zoneID+"/dns_records?"+query.Encode(), nil, &records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if value != "" && strings.Trim(record.Content, `"`) != value {
+			continue
+		}
+		if err := s.request(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+record.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findZone 从最长的父域名开始查找托管区域
+func (s *cloudflareSolver) findZone(ctx context.Context, fqdn string) (string, error) {
+	for _, candidate := range parentDomains(fqdn) {
+		var zones []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := s.request(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(candidate), nil, &zones); err != nil {
+			return "", err
+		}
+		if len(zones) > 0 {
+			return zones[0].ID, nil
+		}
+	}
+	return "", &DNSProviderError{Provider: "cloudflare", Op: "查找区域", Message: "找不到 " + fqdn + " 所在的区域"}
+}
+
+func (s *cloudflareSolver) request(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cloudflareAPI+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.APIToken)
+	} else {
+		req.Header.Set("X-Auth-Email", s.cfg.Email)
+		req.Header.Set("X-Auth-Key", s.cfg.APIKey)
+	}
+
+	op := method + " " + strings.SplitN(path, "?", 2)[0]
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "cloudflare", Op: op, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	var result cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &DNSProviderError{Provider: "cloudflare", Op: op, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+	}
+
+	if !result.Success {
+		dnsErr := &DNSProviderError{
+			Provider: "cloudflare",
+			Op:       op,
+			Message:  fmt.Sprintf("HTTP %d", resp.StatusCode),
+			Auth:     resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		}
+		if len(result.Errors) > 0 {
+			dnsErr.Code = fmt.Sprintf("%d", result.Errors[0].Code)
+			dnsErr.Message = result.Errors[0].Message
+			// 10000: Authentication error, 9109: Invalid access token
+			switch result.Errors[0].Code {
+			case 10000, 9109, 6003, 6111:
+				dnsErr.Auth = true
+			}
+		}
+		return dnsErr
+	}
+
+	if out != nil {
+		return json.Unmarshal(result.Result, out)
+	}
+	return nil
+}
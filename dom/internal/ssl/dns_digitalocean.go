@@ -0,0 +1,162 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const digitalOceanAPI = "https://api.digitalocean.com/v2"
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderDigitalOcean,
+		Name:        "DigitalOcean",
+		Description: "使用具有 domain 读写权限的 DigitalOcean 个人访问令牌",
+		Fields: []DNSProviderField{
+			{Name: "token", Label: "API Token", Type: FieldPassword, Required: true, Placeholder: "dop_v1_..."},
+		},
+	}, func() DNSProviderConfig { return &DigitalOceanConfig{} })
+}
+
+// DigitalOceanConfig DigitalOcean DNS 配置
+type DigitalOceanConfig struct {
+	Token string `json:"token"`
+}
+
+func (c *DigitalOceanConfig) GetProviderName() string {
+	return "digitalocean"
+}
+
+func (c *DigitalOceanConfig) NewSolver() (DNSSolver, error) {
+	if c.Token == "" {
+		return nil, fmt.Errorf("DigitalOcean API Token 不能为空")
+	}
+	return &digitalOceanSolver{cfg: c}, nil
+}
+
+// digitalOceanSolver 通过 DigitalOcean API v2 管理 TXT 记录
+type digitalOceanSolver struct {
+	cfg *DigitalOceanConfig
+}
+
+type digitalOceanRecord struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+func (s *digitalOceanSolver) Present(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	return s.request(ctx, http.MethodPost, "/domains/"+url.PathEscape(domain)+"/records", map[string]interface{}{
+		"type": "TXT",
+		"name": relativeName(fqdn, domain),
+		"data": value,
+		"ttl":  30,
+	}, nil)
+}
+
+func (s *digitalOceanSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	// name 过滤需要完整的记录名
+	var resp struct {
+		Records []digitalOceanRecord `json:"domain_records"`
+	}
+	query := url.Values{"type": {"TXT"}, "name": {strings.TrimSuffix(fqdn, ".")}, "per_page": {"200"}}
+	if err := s.request(ctx, http.MethodGet, "/domains/"+url.PathEscape(domain)+"/records?"+query.Encode(), nil, &resp); err != nil {
+		return err
+	}
+
+	for _, record := range resp.Records {
+		if record.Type != "TXT" || record.Name != relativeName(fqdn, domain) || (value != "" && record.Data != value) {
+			continue
+		}
+		path := "/domains/" + url.PathEscape(domain) + "/records/" + strconv.FormatInt(record.ID, 10)
+		if err := s.request(ctx, http.MethodDelete, path, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDomain 从最长的父域名开始查找账号下的域名
+func (s *digitalOceanSolver) findDomain(ctx context.Context, fqdn string) (string, error) {
+	for _, candidate := range parentDomains(fqdn) {
+		err := s.request(ctx, http.MethodGet, "/domains/"+url.PathEscape(candidate), nil, nil)
+		if err == nil {
+			return candidate, nil
+		}
+		if !isNotFound(err) {
+			return "", err
+		}
+	}
+	return "", &DNSProviderError{Provider: "digitalocean", Op: "查找域名", Message: "找不到 " + fqdn + " 所属的域名"}
+}
+
+func (s *digitalOceanSolver) request(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, digitalOceanAPI+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+
+	op := method + " " + strings.SplitN(path, "?", 2)[0]
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "digitalocean", Op: op, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		// 错误响应: {"id": "unauthorized", "message": "Unable to authenticate you"}
+		var apiErr struct {
+			ID      string `json:"id"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		dnsErr := &DNSProviderError{
+			Provider: "digitalocean",
+			Op:       op,
+			Code:     strconv.Itoa(resp.StatusCode),
+			Message:  apiErr.Message,
+			Auth:     resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		}
+		if dnsErr.Message == "" {
+			dnsErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return dnsErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return &DNSProviderError{Provider: "digitalocean", Op: op, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+		}
+	}
+	return nil
+}
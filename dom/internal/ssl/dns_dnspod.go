@@ -0,0 +1,179 @@
+package ssl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const dnspodTokenAPI = "https://dnsapi.cn/"
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderDNSPod,
+		Name:        "DNSPod Token",
+		Description: "使用 DNSPod 控制台创建的 API Token（ID 和 Token）调用 dnsapi.cn，无需腾讯云 API 密钥",
+		Fields: []DNSProviderField{
+			{Name: "tokenId", Label: "ID", Type: FieldText, Required: true, Placeholder: "123456"},
+			{Name: "token", Label: "Token", Type: FieldPassword, Required: true},
+		},
+	}, func() DNSProviderConfig { return &DNSPodConfig{} })
+}
+
+// DNSPodConfig DNSPod Token 配置
+type DNSPodConfig struct {
+	TokenID string `json:"tokenId"`
+	Token   string `json:"token"`
+}
+
+func (c *DNSPodConfig) GetProviderName() string {
+	return "dnspod"
+}
+
+func (c *DNSPodConfig) NewSolver() (DNSSolver, error) {
+	if c.TokenID == "" || c.Token == "" {
+		return nil, fmt.Errorf("DNSPod Token 的 ID 和 Token 不能为空")
+	}
+	return &dnspodTokenSolver{cfg: c}, nil
+}
+
+// dnspodTokenSolver 通过 DNSPod 用户 API（dnsapi.cn，login_token 认证）管理 TXT 记录
+type dnspodTokenSolver struct {
+	cfg *DNSPodConfig
+}
+
+type dnspodTokenRecord struct {
+	ID    json.Number `json:"id"`
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value string      `json:"value"`
+}
+
+func (s *dnspodTokenSolver) Present(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	return s.call(ctx, "Record.Create", url.Values{
+		"domain":      {domain},
+		"sub_domain":  {relativeName(fqdn, domain)},
+		"record_type": {"TXT"},
+		"record_line": {"默认"},
+		"value":       {value},
+		"ttl":         {"600"},
+	}, nil)
+}
+
+func (s *dnspodTokenSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Records []dnspodTokenRecord `json:"records"`
+	}
+	err = s.call(ctx, "Record.List", url.Values{
+		"domain":      {domain},
+		"sub_domain":  {relativeName(fqdn, domain)},
+		"record_type": {"TXT"},
+	}, &resp)
+	if err != nil {
+		// 10: 记录列表为空
+		var dnsErr *DNSProviderError
+		if errors.As(err, &dnsErr) && dnsErr.Code == "10" {
+			return nil
+		}
+		return err
+	}
+
+	for _, record := range resp.Records {
+		if value != "" && record.Value != value {
+			continue
+		}
+		err := s.call(ctx, "Record.Remove", url.Values{
+			"domain":    {domain},
+			"record_id": {record.ID.String()},
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDomain 在账号下的域名中查找记录所属的域名（最长匹配）
+func (s *dnspodTokenSolver) findDomain(ctx context.Context, fqdn string) (string, error) {
+	var resp struct {
+		Domains []struct {
+			Name string `json:"name"`
+		} `json:"domains"`
+	}
+	if err := s.call(ctx, "Domain.List", url.Values{"length": {"3000"}}, &resp); err != nil {
+		return "", err
+	}
+
+	for _, candidate := range parentDomains(fqdn) {
+		for _, d := range resp.Domains {
+			if strings.EqualFold(d.Name, candidate) {
+				return d.Name, nil
+			}
+		}
+	}
+	return "", &DNSProviderError{Provider: "dnspod", Op: "Domain.List", Message: "找不到 " + fqdn + " 所属的域名"}
+}
+
+// call 调用 API，参数以表单提交
+func (s *dnspodTokenSolver) call(ctx context.Context, action string, params url.Values, out interface{}) error {
+	params.Set("login_token", s.cfg.TokenID+","+s.cfg.Token)
+	params.Set("format", "json")
+	params.Set("lang", "cn")
+	params.Set("error_on_empty", "yes")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dnspodTokenAPI+action, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// DNSPod 要求 User-Agent 标明程序名称，否则可能被封禁
+	req.Header.Set("User-Agent", "hop/1.0")
+
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "dnspod", Op: action, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return &DNSProviderError{Provider: "dnspod", Op: action, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+	}
+
+	var result struct {
+		Status struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"status"`
+	}
+	json.Unmarshal(body, &result)
+	if result.Status.Code != "1" {
+		return &DNSProviderError{
+			Provider: "dnspod",
+			Op:       action,
+			Code:     result.Status.Code,
+			Message:  result.Status.Message,
+			// -1: 登录失败（Token 错误或已删除）
+			Auth: result.Status.Code == "-1",
+		}
+	}
+
+	if out != nil {
+		return json.Unmarshal(body, out)
+	}
+	return nil
+}
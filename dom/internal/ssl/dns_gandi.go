@@ -0,0 +1,203 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const gandiAPI = "https://api.gandi.net/v5/livedns"
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderGandi,
+		Name:        "Gandi LiveDNS",
+		Description: "使用 Gandi 个人访问令牌（PAT）调用 LiveDNS API，令牌需要“管理域名技术配置”权限",
+		Fields: []DNSProviderField{
+			{Name: "token", Label: "Personal Access Token", Type: FieldPassword, Required: true},
+		},
+	}, func() DNSProviderConfig { return &GandiConfig{} })
+}
+
+// GandiConfig Gandi LiveDNS 配置
+type GandiConfig struct {
+	Token string `json:"token"`
+}
+
+func (c *GandiConfig) GetProviderName() string {
+	return "gandi"
+}
+
+func (c *GandiConfig) NewSolver() (DNSSolver, error) {
+	if c.Token == "" {
+		return nil, fmt.Errorf("Gandi Personal Access Token 不能为空")
+	}
+	return &gandiSolver{cfg: c}, nil
+}
+
+// gandiSolver 通过 Gandi LiveDNS API v5 管理 TXT 记录
+// LiveDNS 以记录集为单位读写，同名 TXT 记录的所有值在一个记录集中
+type gandiSolver struct {
+	cfg *GandiConfig
+}
+
+type gandiRecordSet struct {
+	TTL    int      `json:"rrset_ttl,omitempty"`
+	Values []string `json:"rrset_values"`
+}
+
+func (s *gandiSolver) Present(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	path := s.recordPath(domain, fqdn)
+	rs, err := s.getRecordSet(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	values := []string{quoteTXT(value)}
+	if rs != nil {
+		for _, v := range rs.Values {
+			if unquoteTXT(v) == value {
+				return nil
+			}
+			values = append(values, v)
+		}
+	}
+	// LiveDNS 最小 TTL 为 300
+	return s.request(ctx, http.MethodPut, path, gandiRecordSet{TTL: 300, Values: values}, nil)
+}
+
+func (s *gandiSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	path := s.recordPath(domain, fqdn)
+	rs, err := s.getRecordSet(ctx, path)
+	if err != nil || rs == nil {
+		return err
+	}
+
+	var remaining []string
+	for _, v := range rs.Values {
+		if value != "" && unquoteTXT(v) != value {
+			remaining = append(remaining, v)
+		}
+	}
+	if len(remaining) == len(rs.Values) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return s.request(ctx, http.MethodDelete, path, nil, nil)
+	}
+	return s.request(ctx, http.MethodPut, path, gandiRecordSet{TTL: rs.TTL, Values: remaining}, nil)
+}
+
+func (s *gandiSolver) recordPath(domain, fqdn string) string {
+	return "/domains/" + url.PathEscape(domain) + "/records/" + url.PathEscape(relativeName(fqdn, domain)) + "/TXT"
+}
+
+// findDomain 从最长的父域名开始查找使用 LiveDNS 的域名
+func (s *gandiSolver) findDomain(ctx context.Context, fqdn string) (string, error) {
+	for _, candidate := range parentDomains(fqdn) {
+		found, err := s.exists(ctx, "/domains/"+url.PathEscape(candidate))
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return candidate, nil
+		}
+	}
+	return "", &DNSProviderError{Provider: "gandi", Op: "查找域名", Message: "找不到 " + fqdn + " 所属的域名，请确认域名使用 LiveDNS 解析"}
+}
+
+// getRecordSet 获取记录集，不存在时返回 nil
+func (s *gandiSolver) getRecordSet(ctx context.Context, path string) (*gandiRecordSet, error) {
+	var rs gandiRecordSet
+	err := s.request(ctx, http.MethodGet, path, nil, &rs)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// exists 资源是否存在（HTTP 404 视为不存在）
+func (s *gandiSolver) exists(ctx context.Context, path string) (bool, error) {
+	err := s.request(ctx, http.MethodGet, path, nil, nil)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *gandiSolver) request(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, gandiAPI+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+
+	op := method + " " + path
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "gandi", Op: op, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+			Cause   string `json:"cause"`
+		}
+		json.Unmarshal(data, &apiErr)
+		dnsErr := &DNSProviderError{
+			Provider: "gandi",
+			Op:       op,
+			Code:     fmt.Sprintf("%d", resp.StatusCode),
+			Message:  apiErr.Message,
+			Auth:     resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		}
+		if dnsErr.Message == "" {
+			dnsErr.Message = apiErr.Cause
+		}
+		if dnsErr.Message == "" {
+			dnsErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return dnsErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return &DNSProviderError{Provider: "gandi", Op: op, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+		}
+	}
+	return nil
+}
+
+// isNotFound 是否为 HTTP 404 错误，使用 HTTP 状态码作为错误码的提供商适用
+func isNotFound(err error) bool {
+	var dnsErr *DNSProviderError
+	return errors.As(err, &dnsErr) && dnsErr.Code == "404"
+}
@@ -0,0 +1,308 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderHuaweiCloud,
+		Name:        "华为云 DNS",
+		Description: "使用华为云访问密钥（AK/SK）调用云解析服务 API，IAM 用户需要 DNS FullAccess 权限",
+		Fields: []DNSProviderField{
+			{Name: "accessKeyId", Label: "Access Key ID", Type: FieldText, Required: true},
+			{Name: "secretAccessKey", Label: "Secret Access Key", Type: FieldPassword, Required: true},
+			{Name: "region", Label: "区域", Type: FieldText, Placeholder: "cn-south-1", Help: "云解析服务终端节点所在区域"},
+			{Name: "projectId", Label: "项目 ID", Type: FieldText, Help: "IAM 用户仅授权了某个项目时填写"},
+		},
+	}, func() DNSProviderConfig { return &HuaweiCloudConfig{} })
+}
+
+// HuaweiCloudConfig 华为云 DNS 配置
+type HuaweiCloudConfig struct {
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	Region          string `json:"region,omitempty"`    // 默认 cn-south-1
+	ProjectID       string `json:"projectId,omitempty"` // 可选
+}
+
+func (c *HuaweiCloudConfig) GetProviderName() string {
+	return "huaweicloud"
+}
+
+func (c *HuaweiCloudConfig) NewSolver() (DNSSolver, error) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, fmt.Errorf("华为云 Access Key ID 和 Secret Access Key 不能为空")
+	}
+	region := c.Region
+	if region == "" {
+		region = "cn-south-1"
+	}
+	if !isDNSLabel(region) {
+		return nil, fmt.Errorf("无效的华为云区域: %s", c.Region)
+	}
+	return &huaweiCloudSolver{cfg: c, endpoint: "https://dns." + region + ".myhuaweicloud.com"}, nil
+}
+
+// huaweiCloudSolver 通过华为云 DNS API v2（SDK-HMAC-SHA256 签名）管理 TXT 记录
+// 同名 TXT 记录在华为云是一个记录集，添加和删除都是修改记录集中的值
+type huaweiCloudSolver struct {
+	cfg      *HuaweiCloudConfig
+	endpoint string
+}
+
+type huaweiRecordSet struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int      `json:"ttl"`
+	Records []string `json:"records"`
+}
+
+func (s *huaweiCloudSolver) Present(ctx context.Context, fqdn, value string) error {
+	zoneID, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	rs, err := s.findRecordSet(ctx, zoneID, fqdn)
+	if err != nil {
+		return err
+	}
+
+	if rs == nil {
+		return s.request(ctx, http.MethodPost, "/v2/zones/"+zoneID+"/recordsets", map[string]interface{}{
+			"name":    dns.Fqdn(fqdn),
+			"type":    "TXT",
+			"ttl":     300,
+			"records": []string{quoteTXT(value)},
+		}, nil)
+	}
+
+	for _, record := range rs.Records {
+		if unquoteTXT(record) == value {
+			return nil
+		}
+	}
+	return s.request(ctx, http.MethodPut, "/v2/zones/"+zoneID+"/recordsets/"+rs.ID, map[string]interface{}{
+		"records": append(rs.Records, quoteTXT(value)),
+	}, nil)
+}
+
+func (s *huaweiCloudSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	zoneID, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	rs, err := s.findRecordSet(ctx, zoneID, fqdn)
+	if err != nil || rs == nil {
+		return err
+	}
+
+	var remaining []string
+	for _, record := range rs.Records {
+		if value != "" && unquoteTXT(record) != value {
+			remaining = append(remaining, record)
+		}
+	}
+	if len(remaining) == len(rs.Records) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return s.request(ctx, http.MethodDelete, "/v2/zones/"+zoneID+"/recordsets/"+rs.ID, nil, nil)
+	}
+	return s.request(ctx, http.MethodPut, "/v2/zones/"+zoneID+"/recordsets/"+rs.ID, map[string]interface{}{
+		"records": remaining,
+	}, nil)
+}
+
+// findZone 从最长的父域名开始查找公网区域（name 参数是模糊匹配，需要再比较一次）
+func (s *huaweiCloudSolver) findZone(ctx context.Context, fqdn string) (string, error) {
+	for _, candidate := range parentDomains(fqdn) {
+		var resp struct {
+			Zones []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"zones"`
+		}
+		query := url.Values{"type": {"public"}, "name": {candidate}}
+		if err := s.request(ctx, http.MethodGet, "/v2/zones?"+query.Encode(), nil, &resp); err != nil {
+			return "", err
+		}
+		for _, zone := range resp.Zones {
+			if strings.EqualFold(strings.TrimSuffix(zone.Name, "."), candidate) {
+				return zone.ID, nil
+			}
+		}
+	}
+	return "", &DNSProviderError{Provider: "huaweicloud", Op: "查找区域", Message: "找不到 " + fqdn + " 所在的区域"}
+}
+
+// findRecordSet 查找记录名对应的 TXT 记录集，不存在时返回 nil
+func (s *huaweiCloudSolver) findRecordSet(ctx context.Context, zoneID, fqdn string) (*huaweiRecordSet, error) {
+	var resp struct {
+		RecordSets []huaweiRecordSet `json:"recordsets"`
+	}
+	query := url.Values{"type": {"TXT"}, "name": {dns.Fqdn(fqdn)}}
+	if err := s.request(ctx, http.MethodGet, "/v2/zones/"+zoneID+"/recordsets?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	for i, rs := range resp.RecordSets {
+		if rs.Type == "TXT" && strings.EqualFold(rs.Name, dns.Fqdn(fqdn)) {
+			return &resp.RecordSets[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *huaweiCloudSolver) request(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = data
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.ProjectID != "" {
+		req.Header.Set("X-Project-Id", s.cfg.ProjectID)
+	}
+	s.sign(req, payload)
+
+	op := method + " " + strings.SplitN(path, "?", 2)[0]
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "huaweicloud", Op: op, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		// 业务错误为 code/message，网关错误（如签名错误）为 error_code/error_msg
+		var apiErr struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			ErrorCode string `json:"error_code"`
+			ErrorMsg  string `json:"error_msg"`
+		}
+		json.Unmarshal(data, &apiErr)
+		dnsErr := &DNSProviderError{
+			Provider: "huaweicloud",
+			Op:       op,
+			Code:     apiErr.Code,
+			Message:  apiErr.Message,
+			Auth:     resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		}
+		if apiErr.ErrorCode != "" {
+			dnsErr.Code = apiErr.ErrorCode
+			dnsErr.Message = apiErr.ErrorMsg
+		}
+		if dnsErr.Message == "" {
+			dnsErr.Message = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		}
+		return dnsErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return &DNSProviderError{Provider: "huaweicloud", Op: op, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+		}
+	}
+	return nil
+}
+
+// sign 按华为云 API 网关 SDK-HMAC-SHA256 规则签名
+func (s *huaweiCloudSolver) sign(req *http.Request, payload []byte) {
+	date := time.Now().UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Sdk-Date", date)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// 路径必须以 / 结尾
+	canonicalURI := req.URL.EscapedPath()
+	if !strings.HasSuffix(canonicalURI, "/") {
+		canonicalURI += "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	stringToSign := "SDK-HMAC-SHA256\n" + date + "\n" + sha256Hex([]byte(canonicalRequest))
+	signature := hex.EncodeToString(hmacSHA256([]byte(s.cfg.SecretAccessKey), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("SDK-HMAC-SHA256 Access=%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, signedHeaders, signature))
+}
+
+// canonicalQuery 按参数名排序并使用 RFC 3986 编码（空格为 %20）的查询字符串
+// 华为云和 AWS 签名都使用这种格式
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, rfc3986Escape(k)+"="+rfc3986Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func rfc3986Escape(s string) string {
+	return strings.NewReplacer("+", "%20", "%7E", "~").Replace(url.QueryEscape(s))
+}
+
+// isDNSLabel 是否为合法的单个域名标签，用于拼接区域终端节点
+func isDNSLabel(s string) bool {
+	if s == "" || len(s) > 63 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderPowerDNS,
+		Name:        "PowerDNS",
+		Description: "通过 PowerDNS 权威服务器的 HTTP API 管理记录，需要在 pdns.conf 中启用 api 和 api-key",
+		Fields: []DNSProviderField{
+			{Name: "apiUrl", Label: "API 地址", Type: FieldText, Required: true, Placeholder: "http://127.0.0.1:8081", Help: "webserver-address 和 webserver-port 对应的地址，不含 /api/v1"},
+			{Name: "apiKey", Label: "API Key", Type: FieldPassword, Required: true},
+			{Name: "serverId", Label: "Server ID", Type: FieldText, Default: "localhost", Placeholder: "localhost"},
+		},
+	}, func() DNSProviderConfig { return &PowerDNSConfig{} })
+}
+
+// PowerDNSConfig PowerDNS 配置
+type PowerDNSConfig struct {
+	APIURL   string `json:"apiUrl"`
+	APIKey   string `json:"apiKey"`
+	ServerID string `json:"serverId,omitempty"` // 默认 localhost
+}
+
+func (c *PowerDNSConfig) GetProviderName() string {
+	return "powerdns"
+}
+
+func (c *PowerDNSConfig) NewSolver() (DNSSolver, error) {
+	if c.APIURL == "" || c.APIKey == "" {
+		return nil, fmt.Errorf("PowerDNS API 地址和 API Key 不能为空")
+	}
+	u, err := url.Parse(c.APIURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("无效的 PowerDNS API 地址: %s", c.APIURL)
+	}
+	serverID := c.ServerID
+	if serverID == "" {
+		serverID = "localhost"
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(c.APIURL, "/"), "/api/v1")
+	return &powerDNSSolver{cfg: c, baseURL: base + "/api/v1/servers/" + url.PathEscape(serverID)}, nil
+}
+
+// powerDNSSolver 通过 PowerDNS HTTP API 管理 TXT 记录
+// 记录以 RRset 为单位修改，REPLACE 时需要提交完整的值列表
+type powerDNSSolver struct {
+	cfg     *PowerDNSConfig
+	baseURL string
+}
+
+type powerDNSRRSet struct {
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	TTL        int              `json:"ttl,omitempty"`
+	ChangeType string           `json:"changetype,omitempty"`
+	Records    []powerDNSRecord `json:"records,omitempty"`
+}
+
+type powerDNSRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+func (s *powerDNSSolver) Present(ctx context.Context, fqdn, value string) error {
+	zone, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	rs, err := s.getRRSet(ctx, zone, fqdn)
+	if err != nil {
+		return err
+	}
+
+	values := []string{quoteTXT(value)}
+	if rs != nil {
+		for _, record := range rs.Records {
+			if unquoteTXT(record.Content) == value {
+				return nil
+			}
+			values = append(values, record.Content)
+		}
+	}
+	return s.patch(ctx, zone, fqdn, "REPLACE", values)
+}
+
+func (s *powerDNSSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	zone, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	rs, err := s.getRRSet(ctx, zone, fqdn)
+	if err != nil || rs == nil {
+		return err
+	}
+
+	var remaining []string
+	for _, record := range rs.Records {
+		if value != "" && unquoteTXT(record.Content) != value {
+			remaining = append(remaining, record.Content)
+		}
+	}
+	if len(remaining) == len(rs.Records) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return s.patch(ctx, zone, fqdn, "DELETE", nil)
+	}
+	return s.patch(ctx, zone, fqdn, "REPLACE", remaining)
+}
+
+// findZone 从最长的父域名开始查找服务器上的区域，返回区域 ID
+func (s *powerDNSSolver) findZone(ctx context.Context, fqdn string) (string, error) {
+	for _, candidate := range parentDomains(fqdn) {
+		var zones []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := s.request(ctx, http.MethodGet, "/zones?zone="+url.QueryEscape(dns.Fqdn(candidate)), nil, &zones); err != nil {
+			return "", err
+		}
+		for _, zone := range zones {
+			if strings.EqualFold(zone.Name, dns.Fqdn(candidate)) {
+				return zone.ID, nil
+			}
+		}
+	}
+	return "", &DNSProviderError{Provider: "powerdns", Op: "查找区域", Message: "找不到 " + fqdn + " 所在的区域"}
+}
+
+// getRRSet 获取记录名对应的 TXT 记录集，不存在时返回 nil
+func (s *powerDNSSolver) getRRSet(ctx context.Context, zone, fqdn string) (*powerDNSRRSet, error) {
+	var resp struct {
+		RRSets []powerDNSRRSet `json:"rrsets"`
+	}
+	// rrset_name 和 rrset_type 过滤需要 4.9 以上版本，旧版本会忽略并返回整个区域
+	query := url.Values{"rrset_name": {dns.Fqdn(fqdn)}, "rrset_type": {"TXT"}}
+	if err := s.request(ctx, http.MethodGet, "/zones/"+url.PathEscape(zone)+"?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	for i, rs := range resp.RRSets {
+		if rs.Type == "TXT" && strings.EqualFold(rs.Name, dns.Fqdn(fqdn)) {
+			return &resp.RRSets[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// patch 替换或删除记录集
+func (s *powerDNSSolver) patch(ctx context.Context, zone, fqdn, changeType string, values []string) error {
+	rs := powerDNSRRSet{Name: dns.Fqdn(fqdn), Type: "TXT", ChangeType: changeType}
+	if changeType == "REPLACE" {
+		rs.TTL = 60
+	}
+	for _, v := range values {
+		rs.Records = append(rs.Records, powerDNSRecord{Content: v})
+	}
+	body := map[string]interface{}{"rrsets": []powerDNSRRSet{rs}}
+	return s.request(ctx, http.MethodPatch, "/zones/"+url.PathEscape(zone), body, nil)
+}
+
+func (s *powerDNSSolver) request(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", s.cfg.APIKey)
+
+	op := method + " " + strings.SplitN(path, "?", 2)[0]
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "powerdns", Op: op, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		dnsErr := &DNSProviderError{
+			Provider: "powerdns",
+			Op:       op,
+			Code:     strconv.Itoa(resp.StatusCode),
+			Message:  apiErr.Error,
+			Auth:     resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		}
+		if dnsErr.Message == "" {
+			dnsErr.Message = strings.TrimSpace(string(data))
+		}
+		if dnsErr.Message == "" {
+			dnsErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return dnsErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return &DNSProviderError{Provider: "powerdns", Op: op, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+		}
+	}
+	return nil
+}
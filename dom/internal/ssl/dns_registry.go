@@ -0,0 +1,125 @@
+package ssl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DNSProviderType DNS 提供商类型
+type DNSProviderType string
+
+const (
+	DNSProviderAliDNS       DNSProviderType = "alidns"
+	DNSProviderTencentCloud DNSProviderType = "tencentcloud"
+	DNSProviderCloudflare   DNSProviderType = "cloudflare"
+	DNSProviderRFC2136      DNSProviderType = "rfc2136"
+	DNSProviderDNSPod       DNSProviderType = "dnspod"
+	DNSProviderHuaweiCloud  DNSProviderType = "huaweicloud"
+	DNSProviderRoute53      DNSProviderType = "route53"
+	DNSProviderGandi        DNSProviderType = "gandi"
+	DNSProviderDigitalOcean DNSProviderType = "digitalocean"
+	DNSProviderPowerDNS     DNSProviderType = "powerdns"
+)
+
+// DNSProviderConfig DNS 提供商配置接口
+type DNSProviderConfig interface {
+	GetProviderName() string
+	// NewSolver 创建管理 dns-01 验证记录的验证器
+	NewSolver() (DNSSolver, error)
+}
+
+// 配置字段类型，决定界面使用的输入控件
+const (
+	FieldText     = "text"
+	FieldPassword = "password" // 密钥等敏感信息
+	FieldNumber   = "number"
+	FieldSelect   = "select"
+)
+
+// DNSProviderFieldOption 下拉选项
+type DNSProviderFieldOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// DNSProviderField 配置字段说明，界面据此生成表单
+// Name 与配置 JSON 中的字段名一致
+type DNSProviderField struct {
+	Name        string                   `json:"name"`
+	Label       string                   `json:"label"`
+	Type        string                   `json:"type"`
+	Required    bool                     `json:"required"`
+	Placeholder string                   `json:"placeholder,omitempty"`
+	Help        string                   `json:"help,omitempty"`
+	Default     string                   `json:"default,omitempty"`
+	Options     []DNSProviderFieldOption `json:"options,omitempty"`
+}
+
+// DNSProviderDefinition DNS 提供商定义
+type DNSProviderDefinition struct {
+	Type        DNSProviderType    `json:"type"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Fields      []DNSProviderField `json:"fields"`
+
+	// newConfig 创建空的配置，用于解析配置 JSON
+	newConfig func() DNSProviderConfig
+}
+
+var (
+	dnsProvidersMu sync.RWMutex
+	dnsProviders   []DNSProviderDefinition
+)
+
+// RegisterDNSProvider 注册 DNS 提供商，各提供商在 init 中调用
+func RegisterDNSProvider(def DNSProviderDefinition, newConfig func() DNSProviderConfig) {
+	dnsProvidersMu.Lock()
+	defer dnsProvidersMu.Unlock()
+
+	for _, existing := range dnsProviders {
+		if existing.Type == def.Type {
+			panic("DNS 提供商重复注册: " + string(def.Type))
+		}
+	}
+	def.newConfig = newConfig
+	dnsProviders = append(dnsProviders, def)
+}
+
+// DNSProviderDefinitions 返回所有已注册的 DNS 提供商，按类型排序
+func DNSProviderDefinitions() []DNSProviderDefinition {
+	dnsProvidersMu.RLock()
+	defer dnsProvidersMu.RUnlock()
+
+	result := make([]DNSProviderDefinition, len(dnsProviders))
+	copy(result, dnsProviders)
+	sort.Slice(result, func(i, j int) bool { return result[i].Type < result[j].Type })
+	return result
+}
+
+// LookupDNSProvider 按类型查找 DNS 提供商
+func LookupDNSProvider(providerType string) (DNSProviderDefinition, bool) {
+	dnsProvidersMu.RLock()
+	defer dnsProvidersMu.RUnlock()
+
+	for _, def := range dnsProviders {
+		if string(def.Type) == providerType {
+			return def, true
+		}
+	}
+	return DNSProviderDefinition{}, false
+}
+
+// ParseDNSProviderConfig 解析 DNS 提供商配置
+func ParseDNSProviderConfig(providerType string, configJSON string) (DNSProviderConfig, error) {
+	def, ok := LookupDNSProvider(providerType)
+	if !ok {
+		return nil, fmt.Errorf("不支持的 DNS 提供商类型: %s", providerType)
+	}
+	cfg := def.newConfig()
+	if err := json.Unmarshal([]byte(configJSON), cfg); err != nil {
+		return nil, fmt.Errorf("解析 %s 配置失败: %w", def.Name, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,100 @@
+package ssl
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleConfig 按字段定义生成一份完整的配置
+func sampleConfig(def DNSProviderDefinition) map[string]interface{} {
+	cfg := map[string]interface{}{}
+	for _, f := range def.Fields {
+		switch f.Type {
+		case FieldNumber:
+			cfg[f.Name] = float64(120)
+		case FieldSelect:
+			cfg[f.Name] = f.Options[len(f.Options)-1].Value
+		default:
+			cfg[f.Name] = "sample-" + strings.ToLower(f.Name)
+		}
+	}
+	return cfg
+}
+
+func TestDNSProviderRegistryRoundTrip(t *testing.T) {
+	defs := DNSProviderDefinitions()
+	if len(defs) != 10 {
+		t.Errorf("注册了 %d 个提供商, want 10", len(defs))
+	}
+
+	for i, def := range defs {
+		if i > 0 && defs[i-1].Type >= def.Type {
+			t.Errorf("定义未按类型排序: %s, %s", defs[i-1].Type, def.Type)
+		}
+		if def.Name == "" || len(def.Fields) == 0 {
+			t.Errorf("%s: 名称或字段为空", def.Type)
+		}
+		if found, ok := LookupDNSProvider(string(def.Type)); !ok || found.Name != def.Name {
+			t.Errorf("%s: LookupDNSProvider 失败", def.Type)
+		}
+
+		seen := map[string]bool{}
+		for _, f := range def.Fields {
+			if seen[f.Name] {
+				t.Errorf("%s: 字段 %s 重复", def.Type, f.Name)
+			}
+			seen[f.Name] = true
+			if f.Type == FieldSelect {
+				if len(f.Options) == 0 {
+					t.Errorf("%s.%s: 下拉字段没有选项", def.Type, f.Name)
+				}
+				valid := f.Default == ""
+				for _, o := range f.Options {
+					valid = valid || o.Value == f.Default
+				}
+				if !valid {
+					t.Errorf("%s.%s: 默认值 %s 不在选项中", def.Type, f.Name, f.Default)
+				}
+			}
+		}
+
+		// 每个字段都要能解析进配置结构并原样序列化回来，否则界面填写的值会丢失
+		want := sampleConfig(def)
+		data, _ := json.Marshal(want)
+		cfg, err := ParseDNSProviderConfig(string(def.Type), string(data))
+		if err != nil {
+			t.Errorf("%s: %v", def.Type, err)
+			continue
+		}
+		if cfg.GetProviderName() != string(def.Type) {
+			t.Errorf("%s: GetProviderName = %s", def.Type, cfg.GetProviderName())
+		}
+
+		data, _ = json.Marshal(cfg)
+		var got map[string]interface{}
+		json.Unmarshal(data, &got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: 往返后的配置 = %v, want %v", def.Type, got, want)
+		}
+	}
+}
+
+func TestParseDNSProviderConfigErrors(t *testing.T) {
+	if _, err := ParseDNSProviderConfig("unknown", "{}"); err == nil {
+		t.Error("未知类型应返回错误")
+	}
+	if _, err := ParseDNSProviderConfig(string(DNSProviderCloudflare), "{"); err == nil {
+		t.Error("无效的 JSON 应返回错误")
+	}
+}
+
+func TestRegisterDNSProviderDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("重复注册应 panic")
+		}
+	}()
+	RegisterDNSProvider(DNSProviderDefinition{Type: DNSProviderCloudflare}, func() DNSProviderConfig { return &CloudflareConfig{} })
+}
@@ -0,0 +1,204 @@
+package ssl
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderRFC2136,
+		Name:        "RFC 2136",
+		Description: "通过 DNS UPDATE 动态更新自建的 BIND、Knot、PowerDNS 等权威服务器，使用 TSIG 密钥认证",
+		Fields: []DNSProviderField{
+			{Name: "nameserver", Label: "权威服务器", Type: FieldText, Required: true, Placeholder: "ns1.example.com:53", Help: "接受动态更新的主服务器，未填端口时使用 53"},
+			{Name: "tsigKeyName", Label: "TSIG 密钥名", Type: FieldText, Placeholder: "acme-update", Help: "留空则不签名（仅适用于按 IP 授权更新的服务器）"},
+			{Name: "tsigSecret", Label: "TSIG 密钥", Type: FieldPassword, Help: "Base64 格式，与 tsig-keygen 或 knotc 生成的 secret 相同"},
+			{Name: "tsigAlgorithm", Label: "TSIG 算法", Type: FieldSelect, Default: "hmac-sha256", Options: []DNSProviderFieldOption{
+				{Value: "hmac-sha1", Label: "HMAC-SHA1"},
+				{Value: "hmac-sha224", Label: "HMAC-SHA224"},
+				{Value: "hmac-sha256", Label: "HMAC-SHA256"},
+				{Value: "hmac-sha384", Label: "HMAC-SHA384"},
+				{Value: "hmac-sha512", Label: "HMAC-SHA512"},
+			}},
+			{Name: "zone", Label: "区域", Type: FieldText, Placeholder: "example.com", Help: "留空时向服务器查询 SOA 自动确定"},
+			{Name: "ttl", Label: "TTL", Type: FieldNumber, Placeholder: "60"},
+		},
+	}, func() DNSProviderConfig { return &RFC2136Config{} })
+}
+
+// RFC2136Config RFC 2136 动态更新配置
+type RFC2136Config struct {
+	Nameserver    string `json:"nameserver"`
+	TSIGKeyName   string `json:"tsigKeyName,omitempty"`
+	TSIGSecret    string `json:"tsigSecret,omitempty"`    // Base64
+	TSIGAlgorithm string `json:"tsigAlgorithm,omitempty"` // 默认 hmac-sha256
+	Zone          string `json:"zone,omitempty"`          // 可选，默认通过 SOA 查询确定
+	TTL           int    `json:"ttl,omitempty"`           // 默认 60
+}
+
+// tsigAlgorithms 支持的 TSIG 算法（hmac-md5 已不被 miekg/dns 支持）
+var tsigAlgorithms = map[string]string{
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha224": dns.HmacSHA224,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha384": dns.HmacSHA384,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+func (c *RFC2136Config) GetProviderName() string {
+	return "rfc2136"
+}
+
+func (c *RFC2136Config) NewSolver() (DNSSolver, error) {
+	if c.Nameserver == "" {
+		return nil, fmt.Errorf("RFC 2136 权威服务器不能为空")
+	}
+	nameserver := c.Nameserver
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(strings.Trim(nameserver, "[]"), "53")
+	}
+	if c.TTL < 0 {
+		return nil, fmt.Errorf("TTL 不能为负数")
+	}
+
+	s := &rfc2136Solver{cfg: c, nameserver: nameserver}
+	if c.TSIGKeyName == "" && c.TSIGSecret == "" {
+		return s, nil
+	}
+	if c.TSIGKeyName == "" || c.TSIGSecret == "" {
+		return nil, fmt.Errorf("TSIG 密钥名和密钥需要同时填写")
+	}
+	if _, err := base64.StdEncoding.DecodeString(c.TSIGSecret); err != nil {
+		return nil, fmt.Errorf("TSIG 密钥不是有效的 Base64")
+	}
+	algorithm := strings.ToLower(strings.TrimSuffix(c.TSIGAlgorithm, "."))
+	if algorithm == "" {
+		algorithm = "hmac-sha256"
+	}
+	s.algorithm = tsigAlgorithms[algorithm]
+	if s.algorithm == "" {
+		return nil, fmt.Errorf("不支持的 TSIG 算法: %s", c.TSIGAlgorithm)
+	}
+	s.keyName = dns.CanonicalName(c.TSIGKeyName)
+	return s, nil
+}
+
+// rfc2136Solver 通过 DNS UPDATE（RFC 2136）管理 TXT 记录，使用 TSIG（RFC 8945）签名
+type rfc2136Solver struct {
+	cfg        *RFC2136Config
+	nameserver string
+	keyName    string // 为空不签名
+	algorithm  string
+}
+
+func (s *rfc2136Solver) Present(ctx context.Context, fqdn, value string) error {
+	zone, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(zone)
+	m.Insert([]dns.RR{s.txtRecord(fqdn, value)})
+	return s.update(ctx, m)
+}
+
+func (s *rfc2136Solver) CleanUp(ctx context.Context, fqdn, value string) error {
+	zone, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(zone)
+	if value == "" {
+		m.RemoveRRset([]dns.RR{s.txtRecord(fqdn, "")})
+	} else {
+		m.Remove([]dns.RR{s.txtRecord(fqdn, value)})
+	}
+	return s.update(ctx, m)
+}
+
+func (s *rfc2136Solver) txtRecord(fqdn, value string) *dns.TXT {
+	ttl := s.cfg.TTL
+	if ttl == 0 {
+		ttl = 60
+	}
+	return &dns.TXT{
+		Hdr: dns.RR_Header{Name: dns.Fqdn(fqdn), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: uint32(ttl)},
+		Txt: []string{value},
+	}
+}
+
+// findZone 确定记录所属的区域
+// 向权威服务器查询 SOA：记录名本身不是区域时，应答的授权部分会带回所属区域的 SOA
+func (s *rfc2136Solver) findZone(ctx context.Context, fqdn string) (string, error) {
+	if s.cfg.Zone != "" {
+		return dns.Fqdn(s.cfg.Zone), nil
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(fqdn), dns.TypeSOA)
+	client := &dns.Client{Timeout: 10 * time.Second}
+	resp, _, err := client.ExchangeContext(ctx, m, s.nameserver)
+	if err != nil {
+		return "", &DNSProviderError{Provider: "rfc2136", Op: "查询 SOA", Message: err.Error()}
+	}
+	for _, rr := range append(resp.Answer, resp.Ns...) {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return soa.Hdr.Name, nil
+		}
+	}
+	return "", &DNSProviderError{
+		Provider: "rfc2136",
+		Op:       "查询 SOA",
+		Code:     dns.RcodeToString[resp.Rcode],
+		Message:  "找不到 " + fqdn + " 所在的区域，请确认服务器对该区域有权威或手动填写区域",
+	}
+}
+
+// update 发送 UPDATE 请求
+func (s *rfc2136Solver) update(ctx context.Context, m *dns.Msg) error {
+	client := &dns.Client{Timeout: 10 * time.Second}
+	if s.keyName != "" {
+		m.SetTsig(s.keyName, s.algorithm, 300, time.Now().Unix())
+		client.TsigSecret = map[string]string{s.keyName: s.cfg.TSIGSecret}
+	}
+
+	resp, _, err := client.ExchangeContext(ctx, m, s.nameserver)
+	if err != nil {
+		// 服务器以 NOTAUTH 拒绝签名请求（BADSIG、BADKEY）或应答签名校验失败，通常是密钥不一致
+		if errors.Is(err, dns.ErrAuth) || errors.Is(err, dns.ErrSig) || errors.Is(err, dns.ErrTime) {
+			return &DNSProviderError{Provider: "rfc2136", Op: "UPDATE", Code: "NOTAUTH",
+				Message: "TSIG 校验失败，请检查密钥名、密钥、算法和服务器时间", Auth: true}
+		}
+		return &DNSProviderError{Provider: "rfc2136", Op: "UPDATE", Message: err.Error()}
+	}
+	if resp.Rcode == dns.RcodeSuccess {
+		return nil
+	}
+
+	dnsErr := &DNSProviderError{
+		Provider: "rfc2136",
+		Op:       "UPDATE",
+		Code:     dns.RcodeToString[resp.Rcode],
+		Message:  "服务器拒绝了更新请求",
+		// REFUSED: 未授权更新该区域；NOTAUTH: 服务器对区域无权威或 TSIG 校验失败
+		Auth: resp.Rcode == dns.RcodeRefused || resp.Rcode == dns.RcodeNotAuth,
+	}
+	if t := resp.IsTsig(); t != nil && t.Error != dns.RcodeSuccess {
+		// BADSIG、BADKEY、BADTIME 在 TSIG 记录中返回
+		dnsErr.Code = dns.RcodeToString[int(t.Error)]
+		dnsErr.Message = "TSIG 校验失败，请检查密钥名、密钥和算法"
+		dnsErr.Auth = true
+	}
+	return dnsErr
+}
@@ -0,0 +1,225 @@
+package ssl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const testTSIGSecret = "IwBTJx9wrDp4Y1RyC3H0gA==" // Base64
+
+// testTSIGKeys 测试服务器上的密钥名及其算法，与 BIND 一样密钥绑定算法
+var testTSIGKeys = map[string]string{
+	"acme-update.": dns.HmacSHA256,
+	"acme-sha1.":   dns.HmacSHA1,
+	"acme-sha512.": dns.HmacSHA512,
+}
+
+// testUpdateServer 进程内的权威服务器，区域为 example.com，只接受 TSIG 签名的 UPDATE
+type testUpdateServer struct {
+	addr string
+
+	mu      sync.Mutex
+	updates []string // 校验通过的更新记录
+}
+
+func newTestUpdateServer(t *testing.T) *testUpdateServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testUpdateServer{addr: conn.LocalAddr().String()}
+
+	secrets := map[string]string{}
+	for name := range testTSIGKeys {
+		secrets[name] = testTSIGSecret
+	}
+	srv := &dns.Server{
+		PacketConn: conn,
+		Handler:    dns.HandlerFunc(s.serveDNS),
+		TsigSecret: secrets,
+		// 默认的 MsgAcceptFunc 以 NOTIMP 拒绝 UPDATE
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+	go srv.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { srv.Shutdown() })
+	return s
+}
+
+func (s *testUpdateServer) serveDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+
+	if r.Opcode == dns.OpcodeQuery {
+		// 记录名不是区域，按权威服务器的行为在授权部分返回区域的 SOA
+		soa, _ := dns.NewRR("example.com. 3600 IN SOA ns1.example.com. admin.example.com. 1 3600 600 86400 60")
+		if !dns.IsSubDomain("example.com.", r.Question[0].Name) {
+			m.Rcode = dns.RcodeRefused
+		} else if r.Question[0].Name == "example.com." {
+			m.Answer = []dns.RR{soa}
+		} else {
+			m.Rcode = dns.RcodeNameError
+			m.Ns = []dns.RR{soa}
+		}
+		w.WriteMsg(m)
+		return
+	}
+
+	tsig := r.IsTsig()
+	switch {
+	case tsig == nil:
+		m.Rcode = dns.RcodeRefused
+	case w.TsigStatus() != nil, testTSIGKeys[tsig.Hdr.Name] != tsig.Algorithm:
+		// 签名无效（BADSIG、BADKEY）时不能用该密钥签名应答
+		m.Rcode = dns.RcodeNotAuth
+	case r.Question[0].Name != "example.com.":
+		m.Rcode = dns.RcodeNotAuth
+		m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	default:
+		s.mu.Lock()
+		for _, rr := range r.Ns {
+			h := rr.Header()
+			class := dns.Class(h.Class).String()
+			if h.Class == dns.ClassANY {
+				class = "ANY"
+			}
+			update := fmt.Sprintf("%s %d %s %s", h.Name, h.Ttl, class, dns.Type(h.Rrtype))
+			if txt, ok := rr.(*dns.TXT); ok && len(txt.Txt) > 0 {
+				update += " " + strings.Join(txt.Txt, " ")
+			}
+			s.updates = append(s.updates, update)
+		}
+		s.mu.Unlock()
+		m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	}
+	w.WriteMsg(m)
+}
+
+func (s *testUpdateServer) takeUpdates() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updates := s.updates
+	s.updates = nil
+	return updates
+}
+
+func TestRFC2136Solver(t *testing.T) {
+	server := newTestUpdateServer(t)
+	ctx := context.Background()
+	fqdn := "_acme-challenge.www.example.com."
+
+	for _, tc := range []struct {
+		keyName   string
+		algorithm string
+	}{
+		{"acme-update", ""}, // 默认 hmac-sha256
+		{"acme-sha1", "hmac-sha1"},
+		{"ACME-SHA512.", "HMAC-SHA512"},
+	} {
+		cfg := &RFC2136Config{
+			Nameserver:    server.addr,
+			TSIGKeyName:   tc.keyName,
+			TSIGSecret:    testTSIGSecret,
+			TSIGAlgorithm: tc.algorithm,
+			TTL:           120,
+		}
+		solver, err := cfg.NewSolver()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := solver.Present(ctx, fqdn, "token"); err != nil {
+			t.Fatalf("%s: Present: %v", tc.keyName, err)
+		}
+		if err := solver.CleanUp(ctx, fqdn, "token"); err != nil {
+			t.Fatalf("%s: CleanUp: %v", tc.keyName, err)
+		}
+		if err := solver.CleanUp(ctx, fqdn, ""); err != nil {
+			t.Fatalf("%s: CleanUp all: %v", tc.keyName, err)
+		}
+
+		// 插入使用区域类 IN，删除单条记录使用 NONE，删除记录集使用 ANY（RFC 2136 2.5）
+		want := []string{
+			"_acme-challenge.www.example.com. 120 IN TXT token",
+			"_acme-challenge.www.example.com. 0 NONE TXT token",
+			"_acme-challenge.www.example.com. 0 ANY TXT",
+		}
+		if got := server.takeUpdates(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s: updates =\n%s\nwant\n%s", tc.keyName, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestRFC2136SolverAuthErrors(t *testing.T) {
+	server := newTestUpdateServer(t)
+	ctx := context.Background()
+	fqdn := "_acme-challenge.www.example.com."
+
+	for _, tc := range []struct {
+		name string
+		cfg  RFC2136Config
+		code string
+	}{
+		{"错误的密钥", RFC2136Config{TSIGKeyName: "acme-update", TSIGSecret: "d3Jvbmcta2V5LXZhbHVlIQ=="}, "NOTAUTH"},
+		{"未知的密钥名", RFC2136Config{TSIGKeyName: "other-key", TSIGSecret: testTSIGSecret}, "NOTAUTH"},
+		{"算法不一致", RFC2136Config{TSIGKeyName: "acme-update", TSIGSecret: testTSIGSecret, TSIGAlgorithm: "hmac-sha384", Zone: "example.com"}, ""},
+		{"未签名", RFC2136Config{}, "REFUSED"},
+		{"区域错误", RFC2136Config{TSIGKeyName: "acme-update", TSIGSecret: testTSIGSecret, Zone: "www.example.com"}, "NOTAUTH"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Nameserver = server.addr
+			solver, err := tc.cfg.NewSolver()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = solver.Present(ctx, fqdn, "token")
+			dnsErr, ok := err.(*DNSProviderError)
+			if !ok || !dnsErr.Auth {
+				t.Fatalf("err = %#v, want auth error", err)
+			}
+			if tc.code != "" && dnsErr.Code != tc.code {
+				t.Errorf("code = %s, want %s", dnsErr.Code, tc.code)
+			}
+			if got := server.takeUpdates(); len(got) != 0 {
+				t.Errorf("服务器接受了更新: %v", got)
+			}
+		})
+	}
+}
+
+func TestRFC2136Config(t *testing.T) {
+	for _, tc := range []struct {
+		cfg RFC2136Config
+		ok  bool
+	}{
+		{RFC2136Config{Nameserver: "ns1.example.com"}, true},
+		{RFC2136Config{Nameserver: "2001:db8::1"}, true},
+		{RFC2136Config{}, false},
+		{RFC2136Config{Nameserver: "ns1.example.com", TSIGKeyName: "key"}, false},
+		{RFC2136Config{Nameserver: "ns1.example.com", TSIGKeyName: "key", TSIGSecret: "not base64!"}, false},
+		{RFC2136Config{Nameserver: "ns1.example.com", TSIGKeyName: "key", TSIGSecret: testTSIGSecret, TSIGAlgorithm: "hmac-md5"}, false},
+		{RFC2136Config{Nameserver: "ns1.example.com", TTL: -1}, false},
+	} {
+		solver, err := tc.cfg.NewSolver()
+		if (err == nil) != tc.ok {
+			t.Errorf("%+v: err = %v", tc.cfg, err)
+			continue
+		}
+		if err == nil {
+			// 未填端口时使用 53
+			if _, port, _ := net.SplitHostPort(solver.(*rfc2136Solver).nameserver); port != "53" {
+				t.Errorf("%s: nameserver = %s", tc.cfg.Nameserver, solver.(*rfc2136Solver).nameserver)
+			}
+		}
+	}
+}
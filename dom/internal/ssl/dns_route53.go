@@ -0,0 +1,291 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	route53Endpoint = "https://route53.amazonaws.com"
+	route53Version  = "/2013-04-01"
+	route53Region   = "us-east-1" // Route53 是全局服务，签名固定使用 us-east-1
+	route53Service  = "route53"
+)
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderRoute53,
+		Name:        "Amazon Route 53",
+		Description: "使用 IAM 访问密钥调用 Route 53 API，需要 route53:ListHostedZonesByName、ListResourceRecordSets 和 ChangeResourceRecordSets 权限",
+		Fields: []DNSProviderField{
+			{Name: "accessKeyId", Label: "Access Key ID", Type: FieldText, Required: true, Placeholder: "AKIA..."},
+			{Name: "secretAccessKey", Label: "Secret Access Key", Type: FieldPassword, Required: true},
+			{Name: "hostedZoneId", Label: "Hosted Zone ID", Type: FieldText, Placeholder: "Z0123456789ABC", Help: "留空时按域名自动查找公有托管区域"},
+		},
+	}, func() DNSProviderConfig { return &Route53Config{} })
+}
+
+// Route53Config Amazon Route 53 配置
+type Route53Config struct {
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	HostedZoneID    string `json:"hostedZoneId,omitempty"` // 可选
+}
+
+func (c *Route53Config) GetProviderName() string {
+	return "route53"
+}
+
+func (c *Route53Config) NewSolver() (DNSSolver, error) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, fmt.Errorf("Route 53 Access Key ID 和 Secret Access Key 不能为空")
+	}
+	return &route53Solver{cfg: c}, nil
+}
+
+// route53Solver 通过 Route 53 API（AWS Signature Version 4）管理 TXT 记录
+// 同名 TXT 记录是一个记录集，更新时需要提交完整的值列表
+type route53Solver struct {
+	cfg *Route53Config
+}
+
+type route53RecordSet struct {
+	Name            string `xml:"Name"`
+	Type            string `xml:"Type"`
+	TTL             int    `xml:"TTL"`
+	ResourceRecords []struct {
+		Value string `xml:"Value"`
+	} `xml:"ResourceRecords>ResourceRecord"`
+}
+
+func (s *route53Solver) Present(ctx context.Context, fqdn, value string) error {
+	zoneID, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	rs, err := s.findRecordSet(ctx, zoneID, fqdn)
+	if err != nil {
+		return err
+	}
+
+	values := []string{quoteTXT(value)}
+	ttl := 60
+	if rs != nil {
+		for _, record := range rs.ResourceRecords {
+			if unquoteTXT(record.Value) == value {
+				return nil
+			}
+			values = append(values, record.Value)
+		}
+		ttl = rs.TTL
+	}
+	return s.change(ctx, zoneID, "UPSERT", fqdn, ttl, values)
+}
+
+func (s *route53Solver) CleanUp(ctx context.Context, fqdn, value string) error {
+	zoneID, err := s.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	rs, err := s.findRecordSet(ctx, zoneID, fqdn)
+	if err != nil || rs == nil {
+		return err
+	}
+
+	var current, remaining []string
+	for _, record := range rs.ResourceRecords {
+		current = append(current, record.Value)
+		if value != "" && unquoteTXT(record.Value) != value {
+			remaining = append(remaining, record.Value)
+		}
+	}
+	if len(remaining) == len(current) {
+		return nil
+	}
+	// DELETE 必须与现有记录集完全一致（包括 TTL）
+	if len(remaining) == 0 {
+		return s.change(ctx, zoneID, "DELETE", fqdn, rs.TTL, current)
+	}
+	return s.change(ctx, zoneID, "UPSERT", fqdn, rs.TTL, remaining)
+}
+
+// findZone 查找记录所在的公有托管区域，返回不带 /hostedzone/ 前缀的 ID
+func (s *route53Solver) findZone(ctx context.Context, fqdn string) (string, error) {
+	if s.cfg.HostedZoneID != "" {
+		return strings.TrimPrefix(s.cfg.HostedZoneID, "/hostedzone/"), nil
+	}
+
+	for _, candidate := range parentDomains(fqdn) {
+		var resp struct {
+			HostedZones []struct {
+				ID     string `xml:"Id"`
+				Name   string `xml:"Name"`
+				Config struct {
+					PrivateZone bool `xml:"PrivateZone"`
+				} `xml:"Config"`
+			} `xml:"HostedZones>HostedZone"`
+		}
+		query := url.Values{"dnsname": {candidate}, "maxitems": {"10"}}
+		if err := s.request(ctx, http.MethodGet, "/hostedzonesbyname?"+query.Encode(), nil, &resp); err != nil {
+			return "", err
+		}
+		// 结果从 dnsname 开始按名称排序，需要比较是否完全一致
+		for _, zone := range resp.HostedZones {
+			if strings.EqualFold(zone.Name, dns.Fqdn(candidate)) && !zone.Config.PrivateZone {
+				return strings.TrimPrefix(zone.ID, "/hostedzone/"), nil
+			}
+		}
+	}
+	return "", &DNSProviderError{Provider: "route53", Op: "ListHostedZonesByName", Message: "找不到 " + fqdn + " 所在的托管区域"}
+}
+
+// findRecordSet 查找记录名对应的 TXT 记录集，不存在时返回 nil
+func (s *route53Solver) findRecordSet(ctx context.Context, zoneID, fqdn string) (*route53RecordSet, error) {
+	var resp struct {
+		RecordSets []route53RecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+	}
+	query := url.Values{"name": {dns.Fqdn(fqdn)}, "type": {"TXT"}, "maxitems": {"1"}}
+	if err := s.request(ctx, http.MethodGet, "/hostedzone/"+zoneID+"/rrset?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	for i, rs := range resp.RecordSets {
+		if rs.Type == "TXT" && strings.EqualFold(rs.Name, dns.Fqdn(fqdn)) {
+			return &resp.RecordSets[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// change 提交记录集变更
+func (s *route53Solver) change(ctx context.Context, zoneID, action, fqdn string, ttl int, values []string) error {
+	type resourceRecord struct {
+		Value string `xml:"Value"`
+	}
+	type change struct {
+		Action          string           `xml:"Action"`
+		Name            string           `xml:"ResourceRecordSet>Name"`
+		Type            string           `xml:"ResourceRecordSet>Type"`
+		TTL             int              `xml:"ResourceRecordSet>TTL"`
+		ResourceRecords []resourceRecord `xml:"ResourceRecordSet>ResourceRecords>ResourceRecord"`
+	}
+	body := struct {
+		XMLName xml.Name `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+		Comment string   `xml:"ChangeBatch>Comment"`
+		Changes []change `xml:"ChangeBatch>Changes>Change"`
+	}{
+		Comment: "ACME dns-01 challenge",
+		Changes: []change{{Action: action, Name: dns.Fqdn(fqdn), Type: "TXT", TTL: ttl}},
+	}
+	for _, v := range values {
+		body.Changes[0].ResourceRecords = append(body.Changes[0].ResourceRecords, resourceRecord{Value: v})
+	}
+
+	return s.request(ctx, http.MethodPost, "/hostedzone/"+zoneID+"/rrset", body, nil)
+}
+
+func (s *route53Solver) request(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		data, err := xml.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = append([]byte(xml.Header), data...)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, route53Endpoint+route53Version+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+	s.sign(req, payload)
+
+	op := method + " " + strings.SplitN(path, "?", 2)[0]
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "route53", Op: op, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		xml.Unmarshal(data, &apiErr)
+		dnsErr := &DNSProviderError{
+			Provider: "route53",
+			Op:       op,
+			Code:     apiErr.Code,
+			Message:  apiErr.Message,
+			Auth:     resp.StatusCode == http.StatusForbidden,
+		}
+		switch apiErr.Code {
+		case "InvalidClientTokenId", "SignatureDoesNotMatch", "AccessDenied", "IncompleteSignature", "UnrecognizedClientException":
+			dnsErr.Auth = true
+		}
+		if dnsErr.Message == "" {
+			dnsErr.Message = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		}
+		return dnsErr
+	}
+
+	if out != nil {
+		if err := xml.Unmarshal(data, out); err != nil {
+			return &DNSProviderError{Provider: "route53", Op: op, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+		}
+	}
+	return nil
+}
+
+// sign 按 AWS Signature Version 4 签名
+func (s *route53Solver) sign(req *http.Request, payload []byte) {
+	sigV4Sign(req, payload, s.cfg.AccessKeyID, s.cfg.SecretAccessKey, route53Region, route53Service, time.Now())
+}
+
+// sigV4Sign 计算 AWS Signature Version 4 签名，签名头部为 host 和 x-amz-date
+func sigV4Sign(req *http.Request, payload []byte, accessKeyID, secretAccessKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\nx-amz-date:" + amzDate + "\n",
+		"host;x-amz-date",
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	secretDate := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	secretRegion := hmacSHA256(secretDate, region)
+	secretService := hmacSHA256(secretRegion, service)
+	secretSigning := hmacSHA256(secretService, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-date, Signature=%s",
+		accessKeyID, scope, signature))
+}
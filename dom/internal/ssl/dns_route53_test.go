@@ -0,0 +1,158 @@
+package ssl
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AWS Signature Version 4 测试套件（aws-sig-v4-test-suite）中的凭据和时间
+const (
+	sigV4AccessKey = "AKIDEXAMPLE"
+	sigV4SecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	sigV4Region    = "us-east-1"
+	sigV4Service   = "service"
+)
+
+func TestSigV4TestSuite(t *testing.T) {
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name      string
+		method    string
+		url       string
+		signature string
+	}{
+		{"get-vanilla", http.MethodGet, "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", http.MethodGet, "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"post-vanilla", http.MethodPost, "https://example.amazonaws.com/", "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sigV4Sign(req, nil, sigV4AccessKey, sigV4SecretKey, sigV4Region, sigV4Service, now)
+
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %s", got)
+			}
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tc.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// rewriteTransport 将请求转发到测试服务器，保留原始的 Host 用于签名校验
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Original-Host", req.URL.Host)
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// useDNSTestServer 让提供商的 API 请求发往测试服务器
+func useDNSTestServer(t *testing.T, handler http.Handler) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	target, _ := url.Parse(srv.URL)
+	original := dnsHTTPClient
+	dnsHTTPClient = &http.Client{Transport: rewriteTransport{target: target}, Timeout: 10 * time.Second}
+	t.Cleanup(func() {
+		dnsHTTPClient = original
+		srv.Close()
+	})
+}
+
+func TestRoute53Solver(t *testing.T) {
+	var changes []string
+	useDNSTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Original-Host") != "route53.amazonaws.com" {
+			t.Errorf("Host = %s", r.Header.Get("X-Original-Host"))
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-east-1/route53/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `<ErrorResponse><Error><Code>SignatureDoesNotMatch</Code><Message>bad signature</Message></Error></ErrorResponse>`)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/hostedzonesbyname":
+			// 查询从 dnsname 开始按名称排序，先返回的私有区域和不匹配的区域需要跳过
+			io.WriteString(w, `<ListHostedZonesByNameResponse><HostedZones>
+<HostedZone><Id>/hostedzone/ZPRIVATE</Id><Name>example.com.</Name><Config><PrivateZone>true</PrivateZone></Config></HostedZone>
+<HostedZone><Id>/hostedzone/ZPUBLIC</Id><Name>example.com.</Name><Config><PrivateZone>false</PrivateZone></Config></HostedZone>
+</HostedZones></ListHostedZonesByNameResponse>`)
+		case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/hostedzone/ZPUBLIC/rrset":
+			io.WriteString(w, `<ListResourceRecordSetsResponse><ResourceRecordSets>
+<ResourceRecordSet><Name>_acme-challenge.www.example.com.</Name><Type>TXT</Type><TTL>120</TTL>
+<ResourceRecords><ResourceRecord><Value>"existing"</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>
+</ResourceRecordSets></ListResourceRecordSetsResponse>`)
+		case r.Method == http.MethodPost && r.URL.Path == "/2013-04-01/hostedzone/ZPUBLIC/rrset":
+			var body struct {
+				Changes []struct {
+					Action string   `xml:"Action"`
+					Name   string   `xml:"ResourceRecordSet>Name"`
+					TTL    int      `xml:"ResourceRecordSet>TTL"`
+					Values []string `xml:"ResourceRecordSet>ResourceRecords>ResourceRecord>Value"`
+				} `xml:"ChangeBatch>Changes>Change"`
+			}
+			if err := xml.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Changes) != 1 {
+				t.Errorf("变更请求无效: %v", err)
+			}
+			c := body.Changes[0]
+			changes = append(changes, c.Action+" "+c.Name+" "+strings.Join(c.Values, ","))
+			if c.TTL != 120 {
+				t.Errorf("TTL = %d, want 120", c.TTL)
+			}
+			io.WriteString(w, `<ChangeResourceRecordSetsResponse><ChangeInfo><Status>PENDING</Status></ChangeInfo></ChangeResourceRecordSetsResponse>`)
+		default:
+			t.Errorf("意外的请求 %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	solver, err := (&Route53Config{AccessKeyID: "AKID", SecretAccessKey: "secret"}).NewSolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fqdn := "_acme-challenge.www.example.com."
+	if err := solver.Present(ctx, fqdn, "token"); err != nil {
+		t.Fatal(err)
+	}
+	// 已有值时只删除本次写入的值
+	if err := solver.CleanUp(ctx, fqdn, "existing"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`UPSERT _acme-challenge.www.example.com. "token","existing"`,
+		`DELETE _acme-challenge.www.example.com. "existing"`,
+	}
+	if strings.Join(changes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes =\n%s\nwant\n%s", strings.Join(changes, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 签名被拒绝时返回认证错误
+	solver, _ = (&Route53Config{AccessKeyID: "WRONG", SecretAccessKey: "secret"}).NewSolver()
+	err = solver.Present(ctx, fqdn, "token")
+	if dnsErr, ok := err.(*DNSProviderError); !ok || !dnsErr.Auth || dnsErr.Code != "SignatureDoesNotMatch" {
+		t.Errorf("err = %#v, want SignatureDoesNotMatch", err)
+	}
+}
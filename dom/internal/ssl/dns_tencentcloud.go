@@ -0,0 +1,235 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dnspodHost    = "dnspod.tencentcloudapi.com"
+	dnspodService = "dnspod"
+	dnspodVersion = "2021-03-23"
+)
+
+func init() {
+	RegisterDNSProvider(DNSProviderDefinition{
+		Type:        DNSProviderTencentCloud,
+		Name:        "腾讯云 DNSPod",
+		Description: "使用腾讯云 API 密钥调用 DNSPod API 3.0",
+		Fields: []DNSProviderField{
+			{Name: "secretId", Label: "SecretId", Type: FieldText, Required: true, Placeholder: "AKID..."},
+			{Name: "secretKey", Label: "SecretKey", Type: FieldPassword, Required: true},
+		},
+	}, func() DNSProviderConfig { return &TencentCloudConfig{} })
+}
+
+// TencentCloudConfig 腾讯云 DNS 配置
+type TencentCloudConfig struct {
+	SecretID  string `json:"secretId"`
+	SecretKey string `json:"secretKey"`
+}
+
+func (c *TencentCloudConfig) GetProviderName() string {
+	return "tencentcloud"
+}
+
+func (c *TencentCloudConfig) NewSolver() (DNSSolver, error) {
+	if c.SecretID == "" || c.SecretKey == "" {
+		return nil, fmt.Errorf("腾讯云 SecretId 和 SecretKey 不能为空")
+	}
+	return &tencentCloudSolver{cfg: c}, nil
+}
+
+// tencentCloudSolver 通过腾讯云 DNSPod API 3.0（TC3-HMAC-SHA256 签名）管理 TXT 记录
+type tencentCloudSolver struct {
+	cfg *TencentCloudConfig
+}
+
+type dnspodRecord struct {
+	RecordID uint64 `json:"RecordId"`
+	Name     string `json:"Name"`
+	Type     string `json:"Type"`
+	Value    string `json:"Value"`
+}
+
+func (s *tencentCloudSolver) Present(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	return s.call(ctx, "CreateRecord", map[string]interface{}{
+		"Domain":     domain,
+		"SubDomain":  relativeName(fqdn, domain),
+		"RecordType": "TXT",
+		"RecordLine": "默认",
+		"Value":      value,
+		"TTL":        600,
+	}, nil)
+}
+
+func (s *tencentCloudSolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	domain, err := s.findDomain(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		RecordList []dnspodRecord `json:"RecordList"`
+	}
+	err = s.call(ctx, "DescribeRecordList", map[string]interface{}{
+		"Domain":     domain,
+		"Subdomain":  relativeName(fqdn, domain),
+		"RecordType": "TXT",
+	}, &resp)
+	if err != nil {
+		var dnsErr *DNSProviderError
+		if errors.As(err, &dnsErr) && dnsErr.Code == "ResourceNotFound.NoDataOfRecord" {
+			return nil
+		}
+		return err
+	}
+
+	for _, record := range resp.RecordList {
+		if value != "" && record.Value != value {
+			continue
+		}
+		err := s.call(ctx, "DeleteRecord", map[string]interface{}{
+			"Domain":   domain,
+			"RecordId": record.RecordID,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDomain 在账号下的域名中查找记录所属的域名（最长匹配）
+func (s *tencentCloudSolver) findDomain(ctx context.Context, fqdn string) (string, error) {
+	var resp struct {
+		DomainList []struct {
+			Name string `json:"Name"`
+		} `json:"DomainList"`
+	}
+	if err := s.call(ctx, "DescribeDomainList", map[string]interface{}{"Limit": 3000}, &resp); err != nil {
+		return "", err
+	}
+
+	for _, candidate := range parentDomains(fqdn) {
+		for _, d := range resp.DomainList {
+			if strings.EqualFold(d.Name, candidate) {
+				return d.Name, nil
+			}
+		}
+	}
+	return "", &DNSProviderError{Provider: "tencentcloud", Op: "DescribeDomainList", Message: "找不到 " + fqdn + " 所属的域名"}
+}
+
+// call 调用 API 3.0
+func (s *tencentCloudSolver) call(ctx context.Context, action string, params map[string]interface{}, out interface{}) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	date := now.Format("2006-01-02")
+	contentType := "application/json; charset=utf-8"
+
+	// 1. 规范请求
+	canonicalRequest := strings.Join([]string{
+		"POST",
+		"/",
+		"",
+		"content-type:" + contentType + "\nhost:" + dnspodHost + "\n",
+		"content-type;host",
+		sha256Hex(payload),
+	}, "\n")
+
+	// 2. 待签名字符串
+	scope := date + "/" + dnspodService + "/tc3_request"
+	stringToSign := strings.Join([]string{
+		"TC3-HMAC-SHA256",
+		timestamp,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	// 3. 签名
+	secretDate := hmacSHA256([]byte("TC3"+s.cfg.SecretKey), date)
+	secretService := hmacSHA256(secretDate, dnspodService)
+	secretSigning := hmacSHA256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+
+	authorization := fmt.Sprintf("TC3-HMAC-SHA256 Credential=%s/%s, SignedHeaders=content-type;host, Signature=%s",
+		s.cfg.SecretID, scope, signature)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+dnspodHost, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Host", dnspodHost)
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Timestamp", timestamp)
+	req.Header.Set("X-TC-Version", dnspodVersion)
+
+	resp, err := dnsHTTPClient.Do(req)
+	if err != nil {
+		return &DNSProviderError{Provider: "tencentcloud", Op: action, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Response json.RawMessage `json:"Response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &DNSProviderError{Provider: "tencentcloud", Op: action, Message: fmt.Sprintf("HTTP %d，无法解析响应", resp.StatusCode)}
+	}
+
+	var apiErr struct {
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+	}
+	json.Unmarshal(result.Response, &apiErr)
+	if apiErr.Error != nil {
+		return &DNSProviderError{
+			Provider: "tencentcloud",
+			Op:       action,
+			Code:     apiErr.Error.Code,
+			Message:  apiErr.Error.Message,
+			Auth:     strings.HasPrefix(apiErr.Error.Code, "AuthFailure") || strings.HasPrefix(apiErr.Error.Code, "UnauthorizedOperation"),
+		}
+	}
+
+	if out != nil {
+		return json.Unmarshal(result.Response, out)
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
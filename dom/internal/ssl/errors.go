@@ -0,0 +1,110 @@
+package ssl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hop/backend/internal/acme"
+)
+
+// ErrDNSAuth DNS API 认证失败
+var ErrDNSAuth = errors.New("DNS API 认证失败")
+
+// DNSProviderError DNS 提供商 API 返回的错误
+type DNSProviderError struct {
+	Provider string // 提供商类型，如 alidns、cloudflare、rfc2136
+	Op       string // 操作，如 AddDomainRecord
+	Code     string // 提供商错误码
+	Message  string
+	Auth     bool // 是否为认证/权限错误
+}
+
+func (e *DNSProviderError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s %s 失败: [%s] %s", e.Provider, e.Op, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s %s 失败: %s", e.Provider, e.Op, e.Message)
+}
+
+// Is 认证错误可通过 errors.Is(err, ErrDNSAuth) 判断
+func (e *DNSProviderError) Is(target error) bool {
+	return target == ErrDNSAuth && e.Auth
+}
+
+// IssueStage 证书申请阶段
+type IssueStage string
+
+const (
+	StageAccount    IssueStage = "account"    // 注册 ACME 账户
+	StageOrder      IssueStage = "order"      // 创建订单
+	StageChallenge  IssueStage = "challenge"  // 设置验证记录
+	StageValidation IssueStage = "validation" // CA 验证域名
+	StageFinalize   IssueStage = "finalize"   // 提交 CSR 并等待签发
+	StageDownload   IssueStage = "download"   // 下载证书
+	StageSave       IssueStage = "save"       // 保存证书文件和记录
+)
+
+// IssueError 证书申请或续期失败
+type IssueError struct {
+	Stage  IssueStage
+	Domain string
+	Err    error
+}
+
+func (e *IssueError) Error() string {
+	return fmt.Sprintf("%s（%s）: %v", e.Summary(), e.Stage, e.Err)
+}
+
+func (e *IssueError) Unwrap() error {
+	return e.Err
+}
+
+// Summary 面向用户的错误概述
+func (e *IssueError) Summary() string {
+	switch {
+	case errors.Is(e.Err, ErrDNSAuth):
+		return "DNS API 认证失败，请检查 DNS 提供商配置"
+	case e.Stage == StageAccount && acme.IsProblem(e.Err, acme.ErrUnauthorized):
+		return "CA 拒绝注册账户，请检查 EAB 凭据"
+	case acme.IsProblem(e.Err, acme.ErrRateLimited):
+		return "触发 CA 速率限制，请稍后再试"
+	case acme.IsProblem(e.Err, acme.ErrCAA):
+		return "域名的 CAA 记录不允许该 CA 签发证书"
+	case acme.IsProblem(e.Err, acme.ErrRejectedIdentifier):
+		return "CA 拒绝为该域名签发证书"
+	case acme.IsProblem(e.Err, acme.ErrDNS), acme.IsProblem(e.Err, acme.ErrIncorrectResponse), acme.IsProblem(e.Err, acme.ErrUnauthorized):
+		return "域名验证失败，CA 未能查询到正确的验证记录"
+	case acme.IsProblem(e.Err, acme.ErrConnection):
+		return "CA 无法连接到域名进行验证"
+	}
+
+	var dnsErr *DNSProviderError
+	if errors.As(e.Err, &dnsErr) {
+		return "DNS 提供商 API 调用失败"
+	}
+
+	switch e.Stage {
+	case StageAccount:
+		return "注册 ACME 账户失败"
+	case StageOrder:
+		return "创建证书订单失败"
+	case StageChallenge:
+		return "设置域名验证失败"
+	case StageValidation:
+		return "域名验证失败"
+	case StageFinalize:
+		return "证书签发失败"
+	case StageDownload:
+		return "下载证书失败"
+	}
+	return "保存证书失败"
+}
+
+// issueErrorSummary 面向用户的错误信息，申请或续期失败时使用概述
+func issueErrorSummary(err error) string {
+	var issueErr *IssueError
+	if errors.As(err, &issueErr) {
+		return issueErr.Summary()
+	}
+	return err.Error()
+}
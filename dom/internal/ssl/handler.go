@@ -2,13 +2,21 @@ package ssl
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/hop/backend/internal/acme"
+	"github.com/hop/backend/internal/config"
 	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
 )
 
 // Router 创建 SSL 路由
@@ -16,20 +24,60 @@ func Router() chi.Router {
 	r := chi.NewRouter()
 
 	// DNS 提供商管理
+	r.Get("/dns-provider-types", handleListDNSProviderTypes)
 	r.Get("/dns-providers", handleListDNSProviders)
 	r.Post("/dns-providers", handleCreateDNSProvider)
 	r.Get("/dns-providers/{id}", handleGetDNSProvider)
 	r.Put("/dns-providers/{id}", handleUpdateDNSProvider)
 	r.Delete("/dns-providers/{id}", handleDeleteDNSProvider)
 
+	// ACME 账户管理
+	r.Get("/accounts", handleListAccounts)
+	r.Post("/accounts", handleCreateAccount)
+	r.Put("/accounts/{id}", handleUpdateAccount)
+	r.Delete("/accounts/{id}", handleDeleteAccount)
+
 	// 证书管理
 	r.Get("/certificates", handleListCertificates)
 	r.Post("/certificates", handleIssueCertificate)
+	r.Post("/certificates/upload", handleUploadCertificate)
 	r.Get("/certificates/{id}", handleGetCertificate)
+	r.Put("/certificates/{id}", handleUpdateCertificate)
+	r.Put("/certificates/{id}/domains", handleUpdateCertificateDomains)
 	r.Post("/certificates/{id}/renew", handleRenewCertificate)
 	r.Post("/certificates/{id}/cleanup", handleCleanupCertificate)
+	r.Post("/certificates/{id}/revoke", handleRevokeCertificate)
 	r.Delete("/certificates/{id}", handleDeleteCertificate)
 	r.Get("/certificates/{id}/logs", handleGetCertificateLogs)
+	r.Get("/certificates/{id}/hooks", handleListHooks)
+	r.Post("/certificates/{id}/hooks", handleCreateHook)
+	r.Put("/certificates/{id}/hooks/{hookId}", handleUpdateHook)
+	r.Delete("/certificates/{id}/hooks/{hookId}", handleDeleteHook)
+	r.Post("/certificates/{id}/hooks/{hookId}/run", handleRunHook)
+
+	// 申请和续期任务
+	r.Get("/jobs", handleListJobs)
+	r.Get("/jobs/{id}", handleGetJob)
+	r.Get("/jobs/{id}/events", handleJobEvents)
+
+	// 续期调度
+	r.Get("/scheduler", handleGetScheduler)
+	r.Post("/scheduler/run", handleRunScheduler)
+
+	// 内部 CA
+	r.Get("/ca", handleGetCA)
+	r.Post("/ca", handleGenerateCA)
+	r.Put("/ca", handleUpdateCA)
+	r.Delete("/ca", handleDeleteCA)
+	r.Post("/ca/import", handleImportCA)
+	r.Get("/ca/root.crt", handleDownloadCA)
+	r.Post("/certificates/internal", handleIssueInternalCertificate)
+
+	// 客户端 CA（mTLS）
+	r.Get("/client-cas", handleListClientCAs)
+	r.Post("/client-cas", handleCreateClientCA)
+	r.Put("/client-cas/{id}", handleUpdateClientCA)
+	r.Delete("/client-cas/{id}", handleDeleteClientCA)
 
 	// 系统信息
 	r.Get("/status", handleGetStatus)
@@ -55,6 +103,13 @@ type DNSProviderResponse struct {
 	UpdatedAt string `json:"updatedAt"`
 }
 
+// handleListDNSProviderTypes 支持的 DNS 提供商及其配置字段，界面据此生成配置表单
+func handleListDNSProviderTypes(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, map[string]interface{}{
+		"types": DNSProviderDefinitions(),
+	})
+}
+
 func handleListDNSProviders(w http.ResponseWriter, r *http.Request) {
 	providers, err := database.ListDNSProviders()
 	if err != nil {
@@ -97,21 +152,13 @@ func handleCreateDNSProvider(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 验证提供商类型
-	validTypes := []string{"alidns", "tencentcloud", "cloudflare"}
-	isValid := false
-	for _, t := range validTypes {
-		if req.Type == t {
-			isValid = true
-			break
-		}
-	}
-	if !isValid {
+	if _, ok := LookupDNSProvider(req.Type); !ok {
 		jsonError(w, "不支持的 DNS 提供商类型", http.StatusBadRequest)
 		return
 	}
 
 	// 验证配置格式
-	if _, err := ParseDNSProviderConfig(req.Type, string(req.Config)); err != nil {
+	if _, err := newSolver(req.Type, string(req.Config)); err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -172,13 +219,22 @@ func handleUpdateDNSProvider(w http.ResponseWriter, r *http.Request) {
 		provider.Name = req.Name
 	}
 
-	if req.Type != "" {
+	if req.Type != "" && req.Type != provider.Type {
+		if _, ok := LookupDNSProvider(req.Type); !ok {
+			jsonError(w, "不支持的 DNS 提供商类型", http.StatusBadRequest)
+			return
+		}
+		// 原配置的字段与新类型不对应
+		if len(req.Config) == 0 {
+			jsonError(w, "修改类型时需要提供新类型的配置", http.StatusBadRequest)
+			return
+		}
 		provider.Type = req.Type
 	}
 
 	if len(req.Config) > 0 {
 		// 验证配置格式
-		if _, err := ParseDNSProviderConfig(provider.Type, string(req.Config)); err != nil {
+		if _, err := newSolver(provider.Type, string(req.Config)); err != nil {
 			jsonError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -209,8 +265,17 @@ func handleDeleteDNSProvider(w http.ResponseWriter, r *http.Request) {
 // IssueCertificateRequest 申请证书请求
 type IssueCertificateRequest struct {
 	Domains       []string `json:"domains"`
+	ChallengeType string   `json:"challengeType"` // dns-01（默认）或 http-01
 	DNSProviderID string   `json:"dnsProviderId"`
+	AccountID     string   `json:"accountId"` // 使用已有账户，为空时按邮箱查找或注册
 	Email         string   `json:"email"`
+	ACMEDirectory string   `json:"acmeDirectory"` // 预设名称或目录地址，为空使用默认目录
+	EABKID        string   `json:"eabKid"`
+	EABHMACKey    string   `json:"eabHmacKey"`
+	KeyType       string   `json:"keyType"`  // ec256（默认）、ec384、rsa2048、rsa3072、rsa4096
+	ReuseKey      bool     `json:"reuseKey"` // 续期时复用私钥
+	Dual          bool     `json:"dual"`     // 同时签发 RSA 证书，需使用 ECDSA 私钥
+	Replace       string   `json:"replace"`  // 可选，签发成功后替换的证书 ID
 }
 
 // CertificateResponse 证书响应
@@ -218,17 +283,32 @@ type CertificateResponse struct {
 	ID            string   `json:"id"`
 	Domain        string   `json:"domain"`
 	Domains       []string `json:"domains"`
+	Source        string   `json:"source"` // acme、upload 或 internal
+	Usage         string   `json:"usage"`  // server 或 client
 	DNSProviderID string   `json:"dnsProviderId"`
+	ChallengeType string   `json:"challengeType"`
+	ACMEDirectory string   `json:"acmeDirectory"`
+	CAName        string   `json:"caName"` // 预设 CA 的名称，自定义目录为空
+	EABKID        string   `json:"eabKid"`
+	AccountID     string   `json:"accountId"`
+	AccountEmail  string   `json:"accountEmail"`
+	KeyType       string   `json:"keyType"`
+	ReuseKey      bool     `json:"reuseKey"`
+	Dual          bool     `json:"dual"`
 	CertPath      string   `json:"certPath"`
 	KeyPath       string   `json:"keyPath"`
+	RSACertPath   string   `json:"rsaCertPath,omitempty"`
+	RSAKeyPath    string   `json:"rsaKeyPath,omitempty"`
 	Issuer        string   `json:"issuer"`
 	NotBefore     string   `json:"notBefore"`
 	NotAfter      string   `json:"notAfter"`
 	AutoRenew     bool     `json:"autoRenew"`
+	RenewBefore   int      `json:"renewBefore"` // 提前续期天数，0 表示自动
 	LastRenewAt   *string  `json:"lastRenewAt"`
 	Status        string   `json:"status"`
 	Error         *string  `json:"error"`
 	DaysRemaining int      `json:"daysRemaining"`
+	Sites         []string `json:"sites"` // 引用该证书的站点（站点证书或上游客户端证书）
 	CreatedAt     string   `json:"createdAt"`
 	UpdatedAt     string   `json:"updatedAt"`
 }
@@ -240,9 +320,16 @@ func handleListCertificates(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	index, err := nginx.CertificateSites()
+	if err != nil {
+		index = map[string][]nginx.ProxySite{}
+	}
+
 	response := make([]CertificateResponse, 0, len(certs))
 	for _, c := range certs {
-		response = append(response, certToResponse(&c))
+		item := certToResponse(&c)
+		item.Sites = siteNames(index[c.ID])
+		response = append(response, item)
 	}
 
 	jsonResponse(w, map[string]interface{}{
@@ -262,31 +349,70 @@ func handleIssueCertificate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.DNSProviderID == "" {
-		jsonError(w, "DNS 提供商不能为空", http.StatusBadRequest)
+	switch req.ChallengeType {
+	case "", database.ChallengeDNS01:
+		if req.DNSProviderID == "" {
+			jsonError(w, "DNS 提供商不能为空", http.StatusBadRequest)
+			return
+		}
+	case database.ChallengeHTTP01:
+		if err := validateHTTPChallengeDomains(req.Domains); err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.DNSProviderID = ""
+	default:
+		jsonError(w, "不支持的验证方式", http.StatusBadRequest)
 		return
 	}
 
-	if req.Email == "" {
+	if req.Email == "" && req.AccountID == "" {
 		jsonError(w, "邮箱不能为空", http.StatusBadRequest)
 		return
 	}
 
-	// 检查 lego 是否已安装
-	if !CheckLegoInstalled() {
-		jsonError(w, "lego 未安装，请先运行: go install github.com/go-acme/lego/v4/cmd/lego@latest", http.StatusInternalServerError)
+	if req.ACMEDirectory != "" {
+		if _, err := acme.ResolveDirectory(req.ACMEDirectory); err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := ValidateKeyOptions(req.KeyType, req.Dual); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	cert, err := IssueCertificate(req.Domains, req.DNSProviderID, req.Email)
+	if req.Replace != "" {
+		if _, err := replaceableCertificate(req.Replace, database.CertUsageServer); err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	job, err := QueueIssue(IssueOptions{
+		Domains:       req.Domains,
+		ChallengeType: req.ChallengeType,
+		DNSProviderID: req.DNSProviderID,
+		AccountID:     req.AccountID,
+		Email:         req.Email,
+		Directory:     req.ACMEDirectory,
+		EABKID:        req.EABKID,
+		EABHMACKey:    req.EABHMACKey,
+		KeyType:       req.KeyType,
+		ReuseKey:      req.ReuseKey,
+		Dual:          req.Dual,
+		Replace:       req.Replace,
+	})
 	if err != nil {
-		jsonError(w, err.Error(), http.StatusInternalServerError)
+		jsonError(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
 
+	// 申请在后台执行，进度通过任务接口查询
 	jsonResponse(w, map[string]interface{}{
-		"success":     true,
-		"certificate": certToResponse(cert),
+		"success": true,
+		"job":     jobToResponse(job.Record()),
 	})
 }
 
@@ -299,45 +425,186 @@ func handleGetCertificate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonResponse(w, certToResponse(cert))
+	response := certToResponse(cert)
+	if sites, err := sitesUsingCertificate(cert.ID); err == nil {
+		response.Sites = siteNames(sites)
+	}
+	jsonResponse(w, response)
 }
 
-// RenewCertificateRequest 续期证书请求
-type RenewCertificateRequest struct {
-	Email string `json:"email"`
+// UpdateCertificateRequest 修改证书私钥设置和续期窗口请求，私钥设置在下次续期时生效
+// 所有字段均为可选，只修改请求中出现的字段
+type UpdateCertificateRequest struct {
+	KeyType     *string `json:"keyType"`
+	ReuseKey    *bool   `json:"reuseKey"`
+	Dual        *bool   `json:"dual"`
+	RenewBefore *int    `json:"renewBefore"` // 提前续期天数，0 表示自动（优先使用 CA 建议的续期窗口）
 }
 
-func handleRenewCertificate(w http.ResponseWriter, r *http.Request) {
+func handleUpdateCertificate(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	var req RenewCertificateRequest
+	var req UpdateCertificateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, "无效的请求格式", http.StatusBadRequest)
 		return
 	}
 
-	if req.Email == "" {
-		jsonError(w, "邮箱不能为空", http.StatusBadRequest)
+	cert, status, err := updateCertificateSettings(id, req)
+	if err != nil {
+		jsonError(w, err.Error(), status)
 		return
 	}
+	// 重新计算续期时间可能需要查询 CA，放到后台执行
+	go refreshRenewalPlan(cert)
 
-	// 检查 lego 是否已安装
-	if !CheckLegoInstalled() {
-		jsonError(w, "lego 未安装，请先运行: go install github.com/go-acme/lego/v4/cmd/lego@latest", http.StatusInternalServerError)
+	jsonResponse(w, map[string]interface{}{
+		"success":     true,
+		"certificate": certToResponse(cert),
+	})
+}
+
+// updateCertificateSettings 持有 renewMutex 修改证书设置，避免与续期同时读写证书记录
+// 出错时返回对应的 HTTP 状态码
+func updateCertificateSettings(id string, req UpdateCertificateRequest) (*database.Certificate, int, error) {
+	renewMutex.Lock()
+	defer renewMutex.Unlock()
+
+	cert, err := database.GetCertificate(id)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("证书不存在")
+	}
+	if cert.Source == database.CertSourceUpload {
+		return nil, http.StatusBadRequest, fmt.Errorf("上传的证书不支持修改私钥设置")
+	}
+
+	if req.KeyType != nil || req.ReuseKey != nil || req.Dual != nil {
+		if req.KeyType != nil {
+			cert.KeyType = *req.KeyType
+			if cert.KeyType == "" {
+				cert.KeyType = defaultKeyType
+			}
+		}
+		if req.ReuseKey != nil {
+			cert.ReuseKey = *req.ReuseKey
+		}
+		if req.Dual != nil {
+			cert.Dual = *req.Dual
+		}
+		// 与未修改的字段一起校验，例如已是双证书时不能单独改为 RSA 私钥
+		if err := ValidateKeyOptions(cert.KeyType, cert.Dual); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if cert.Source == database.CertSourceInternal && cert.Dual {
+			return nil, http.StatusBadRequest, fmt.Errorf("内部证书不支持双证书模式")
+		}
+	}
+
+	if req.RenewBefore != nil {
+		lifetime := int(cert.NotAfter.Sub(cert.NotBefore).Hours() / 24)
+		if *req.RenewBefore < 0 || *req.RenewBefore >= lifetime {
+			return nil, http.StatusBadRequest, fmt.Errorf("提前续期天数必须在 0 到 %d 之间", lifetime-1)
+		}
+		cert.RenewBefore = *req.RenewBefore
+	}
+
+	if err := database.UpdateCertificate(cert); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("更新证书失败: %w", err)
+	}
+	return cert, http.StatusOK, nil
+}
+
+// UpdateCertificateDomainsRequest 修改证书域名请求，第一个域名为主域名
+type UpdateCertificateDomainsRequest struct {
+	Domains []string `json:"domains"`
+}
+
+// handleUpdateCertificateDomains 修改证书的域名列表并重新签发，成功后才替换原证书
+func handleUpdateCertificateDomains(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req UpdateCertificateDomainsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
 		return
 	}
 
-	if err := RenewCertificate(id, req.Email); err != nil {
-		jsonError(w, err.Error(), http.StatusInternalServerError)
+	cert, err := database.GetCertificate(id)
+	if err != nil {
+		jsonError(w, "证书不存在", http.StatusNotFound)
 		return
 	}
 
-	// 获取更新后的证书信息
-	cert, _ := database.GetCertificate(id)
+	domains := make([]string, 0, len(req.Domains))
+	seen := map[string]bool{}
+	for _, d := range req.Domains {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		if seen[d] {
+			jsonError(w, "域名重复: "+d, http.StatusBadRequest)
+			return
+		}
+		seen[d] = true
+		domains = append(domains, d)
+	}
+	if len(domains) == 0 {
+		jsonError(w, "至少需要一个域名", http.StatusBadRequest)
+		return
+	}
 
+	var current []string
+	json.Unmarshal([]byte(cert.Domains), &current)
+	if slices.Equal(current, domains) {
+		jsonError(w, "域名没有变化，如需重新签发请使用续期", http.StatusBadRequest)
+		return
+	}
+	if cert.Source == database.CertSourceACME && cert.ChallengeType == database.ChallengeHTTP01 {
+		if err := validateHTTPChallengeDomains(domains); err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	job, err := QueueReissue(id, domains)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 重新签发在后台执行，进度通过任务接口查询
 	jsonResponse(w, map[string]interface{}{
-		"success":     true,
-		"certificate": certToResponse(cert),
+		"success": true,
+		"job":     jobToResponse(job.Record()),
+	})
+}
+
+// RenewCertificateRequest 续期证书请求
+type RenewCertificateRequest struct {
+	Email string `json:"email"` // 可选，指定时切换到该邮箱的 ACME 账户
+}
+
+func handleRenewCertificate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req RenewCertificateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	// 邮箱为空时使用证书关联的账户
+	job, err := QueueRenew(id, req.Email)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 续期在后台执行，进度通过任务接口查询
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"job":     jobToResponse(job.Record()),
 	})
 }
 
@@ -350,8 +617,18 @@ func handleCleanupCertificate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 强制清理 lego 数据
-	if err := ForceCleanup(cert.Domain); err != nil {
+	if cert.ChallengeType == database.ChallengeHTTP01 {
+		jsonError(w, "HTTP-01 验证不会产生 DNS 记录，无需清理", http.StatusBadRequest)
+		return
+	}
+
+	var domains []string
+	if err := json.Unmarshal([]byte(cert.Domains), &domains); err != nil || len(domains) == 0 {
+		domains = []string{cert.Domain}
+	}
+
+	// 删除残留的 _acme-challenge 记录
+	if err := CleanupDNSRecords(domains, cert.DNSProviderID); err != nil {
 		jsonError(w, "清理失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -361,18 +638,64 @@ func handleCleanupCertificate(w http.ResponseWriter, r *http.Request) {
 		ID:            uuid.New().String(),
 		CertificateID: cert.ID,
 		Action:        "cleanup",
-		Message:       "已清理 lego 数据，可以重新申请证书",
+		Message:       "已清理残留的 DNS 验证记录，可以重新申请证书",
 	}
 	database.CreateCertificateLog(logEntry)
 
 	jsonResponse(w, map[string]bool{"success": true})
 }
 
-func handleDeleteCertificate(w http.ResponseWriter, r *http.Request) {
+// RevokeCertificateRequest 撤销证书请求
+type RevokeCertificateRequest struct {
+	Reason int  `json:"reason"` // 撤销原因代码（RFC 5280），0 未指定，1 私钥泄露，3 从属关系变更，4 已被取代，5 停止使用
+	Detach bool `json:"detach"` // 停用引用该证书的站点的 HTTPS，否则只记录提醒
+}
+
+func handleRevokeCertificate(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	if err := database.DeleteCertificate(id); err != nil {
-		jsonError(w, "删除证书失败: "+err.Error(), http.StatusInternalServerError)
+	var req RevokeCertificateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.GetCertificate(id); err != nil {
+		jsonError(w, "证书不存在", http.StatusNotFound)
+		return
+	}
+
+	sites, err := RevokeCertificate(id, req.Reason, req.Detach)
+	if err != nil {
+		jsonError(w, "撤销证书失败: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"sites":   sites,
+	})
+}
+
+// handleDeleteCertificate 删除证书
+// 仍有站点引用时返回 409 和站点列表；cascade=true 时先停用这些站点的 HTTPS（或上游客户端证书）再删除
+func handleDeleteCertificate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	cascade := r.URL.Query().Get("cascade") == "true"
+
+	if err := DeleteCertificate(id, cascade); err != nil {
+		var inUse *CertificateInUseError
+		if errors.As(err, &inUse) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   err.Error(),
+				"success": false,
+				"sites":   inUse.Sites,
+			})
+			return
+		}
+		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -382,6 +705,7 @@ func handleDeleteCertificate(w http.ResponseWriter, r *http.Request) {
 // CertificateLogResponse 证书日志响应
 type CertificateLogResponse struct {
 	ID        string `json:"id"`
+	JobID     string `json:"jobId,omitempty"`
 	Action    string `json:"action"`
 	Message   string `json:"message"`
 	CreatedAt string `json:"createdAt"`
@@ -400,6 +724,7 @@ func handleGetCertificateLogs(w http.ResponseWriter, r *http.Request) {
 	for _, l := range logs {
 		response = append(response, CertificateLogResponse{
 			ID:        l.ID,
+			JobID:     l.JobID,
 			Action:    l.Action,
 			Message:   l.Message,
 			CreatedAt: l.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -415,20 +740,22 @@ func handleGetCertificateLogs(w http.ResponseWriter, r *http.Request) {
 
 // StatusResponse 系统状态响应
 type StatusResponse struct {
-	LegoInstalled bool   `json:"legoInstalled"`
-	LegoVersion   string `json:"legoVersion"`
+	ACMEDirectory string        `json:"acmeDirectory"` // 默认目录
+	Staging       bool          `json:"staging"`
+	Directories   []acme.Preset `json:"directories"` // 可选的预设 CA
 }
 
 func handleGetStatus(w http.ResponseWriter, r *http.Request) {
-	installed := CheckLegoInstalled()
-	version := ""
-	if installed {
-		version = GetLegoVersion()
+	directory, err := defaultDirectory()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	jsonResponse(w, StatusResponse{
-		LegoInstalled: installed,
-		LegoVersion:   version,
+		ACMEDirectory: directory,
+		Staging:       config.Get().ACME.Staging,
+		Directories:   acme.Presets,
 	})
 }
 
@@ -450,21 +777,56 @@ func certToResponse(c *database.Certificate) CertificateResponse {
 		daysRemaining = 0
 	}
 
+	var caName string
+	if preset, ok := acme.PresetByURL(c.ACMEDirectory); ok {
+		caName = preset.Label
+	}
+
+	var accountEmail string
+	if c.AccountID != "" {
+		if account, err := database.GetACMEAccount(c.AccountID); err == nil {
+			accountEmail = account.Email
+		}
+	}
+
+	// 旧版本签发的证书未记录私钥算法，从证书文件识别
+	keyType := c.KeyType
+	if keyType == "" {
+		if info, err := ParseCertificate(filepath.Join(config.Get().Data.Dir, c.CertPath)); err == nil {
+			keyType = info.KeyType
+		}
+	}
+
 	return CertificateResponse{
 		ID:            c.ID,
 		Domain:        c.Domain,
 		Domains:       domains,
+		Source:        c.Source,
+		Usage:         c.Usage,
 		DNSProviderID: c.DNSProviderID,
+		ChallengeType: c.ChallengeType,
+		ACMEDirectory: c.ACMEDirectory,
+		CAName:        caName,
+		EABKID:        c.EABKID,
+		AccountID:     c.AccountID,
+		AccountEmail:  accountEmail,
+		KeyType:       keyType,
+		ReuseKey:      c.ReuseKey,
+		Dual:          c.Dual,
 		CertPath:      c.CertPath,
 		KeyPath:       c.KeyPath,
+		RSACertPath:   c.RSACertPath,
+		RSAKeyPath:    c.RSAKeyPath,
 		Issuer:        c.Issuer,
 		NotBefore:     c.NotBefore.Format("2006-01-02T15:04:05Z07:00"),
 		NotAfter:      c.NotAfter.Format("2006-01-02T15:04:05Z07:00"),
 		AutoRenew:     c.AutoRenew,
+		RenewBefore:   c.RenewBefore,
 		LastRenewAt:   lastRenewAt,
 		Status:        c.Status,
 		Error:         c.Error,
 		DaysRemaining: daysRemaining,
+		Sites:         []string{},
 		CreatedAt:     c.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:     c.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
@@ -0,0 +1,96 @@
+package ssl
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/database"
+)
+
+// createTestCertificate 写入一条有效期 90 天的 ACME 证书记录
+func createTestCertificate(t *testing.T, domain string, modify func(*database.Certificate)) *database.Certificate {
+	t.Helper()
+	now := time.Now().Truncate(time.Second)
+	cert := &database.Certificate{
+		ID:        uuid.New().String(),
+		Domain:    domain,
+		Domains:   `["` + domain + `"]`,
+		CertPath:  "nginx/ssl/" + domain + ".crt",
+		KeyPath:   "nginx/ssl/" + domain + ".key",
+		NotBefore: now,
+		NotAfter:  now.AddDate(0, 0, 90),
+		AutoRenew: true,
+		Status:    "active",
+	}
+	if modify != nil {
+		modify(cert)
+	}
+	if err := database.CreateCertificate(cert); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestUpdateCertificateSettingsPartial(t *testing.T) {
+	useTestDB(t)
+	cert := createTestCertificate(t, "dual.example.com", func(c *database.Certificate) {
+		c.KeyType = database.KeyTypeEC384
+		c.ReuseKey = true
+		c.Dual = true
+	})
+
+	// 只修改续期窗口时私钥设置保持不变
+	days := 20
+	updated, _, err := updateCertificateSettings(cert.ID, UpdateCertificateRequest{RenewBefore: &days})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := database.GetCertificate(cert.ID)
+	for _, c := range []*database.Certificate{updated, stored} {
+		if c.KeyType != database.KeyTypeEC384 || !c.ReuseKey || !c.Dual || c.RenewBefore != 20 {
+			t.Errorf("证书 = %+v", c)
+		}
+	}
+
+	// 只关闭私钥复用
+	reuse := false
+	if _, _, err := updateCertificateSettings(cert.ID, UpdateCertificateRequest{ReuseKey: &reuse}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ = database.GetCertificate(cert.ID)
+	if stored.ReuseKey || !stored.Dual || stored.RenewBefore != 20 {
+		t.Errorf("证书 = %+v", stored)
+	}
+
+	// 双证书模式下不能只把私钥改为 RSA
+	rsa := database.KeyTypeRSA2048
+	if _, status, err := updateCertificateSettings(cert.ID, UpdateCertificateRequest{KeyType: &rsa}); err == nil || status != http.StatusBadRequest {
+		t.Errorf("status = %d, err = %v", status, err)
+	}
+
+	tooLong := 90
+	if _, status, err := updateCertificateSettings(cert.ID, UpdateCertificateRequest{RenewBefore: &tooLong}); err == nil || status != http.StatusBadRequest {
+		t.Errorf("status = %d, err = %v", status, err)
+	}
+	if _, status, _ := updateCertificateSettings("missing", UpdateCertificateRequest{RenewBefore: &days}); status != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", status)
+	}
+}
+
+func TestUpdateCertificateSettingsUpload(t *testing.T) {
+	useTestDB(t)
+	cert := createTestCertificate(t, "upload.example.com", func(c *database.Certificate) {
+		c.Source = database.CertSourceUpload
+	})
+
+	days := 10
+	dual := true
+	for _, req := range []UpdateCertificateRequest{{RenewBefore: &days}, {Dual: &dual}} {
+		if _, status, err := updateCertificateSettings(cert.ID, req); err == nil || status != http.StatusBadRequest {
+			t.Errorf("status = %d, err = %v", status, err)
+		}
+	}
+}
@@ -0,0 +1,605 @@
+package ssl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+const (
+	// defaultHookTimeout 命令钩子默认超时秒数
+	defaultHookTimeout = 60
+	// maxHookTimeout 命令钩子最长超时秒数
+	maxHookTimeout = 600
+	// maxHookOutput 日志中保留的命令输出长度
+	maxHookOutput = 500
+)
+
+// deployment 证书文件更新后待执行的部署钩子
+// 在持有 renewMutex 时由 prepareDeployHooks 读取证书和钩子，释放锁后再执行，
+// 避免耗时的命令阻塞其他证书的申请和续期
+type deployment struct {
+	cert  database.Certificate
+	hooks []database.CertificateHook
+}
+
+// prepareDeployHooks 读取证书启用的部署钩子，没有钩子时返回 nil
+func prepareDeployHooks(cert *database.Certificate) *deployment {
+	hooks, err := database.ListCertificateHooks(cert.ID)
+	if err != nil {
+		log.Error("获取部署钩子失败", map[string]interface{}{
+			"domain": cert.Domain,
+			"error":  err.Error(),
+		})
+		return nil
+	}
+	d := &deployment{cert: *cert}
+	for _, hook := range hooks {
+		if hook.Enabled {
+			d.hooks = append(d.hooks, hook)
+		}
+	}
+	if len(d.hooks) == 0 {
+		return nil
+	}
+	return d
+}
+
+// run 依次执行部署钩子，调用方不能持有 renewMutex
+// 钩子失败只记录日志，不影响申请或续期的结果
+func (d *deployment) run(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	for i := range d.hooks {
+		runDeployHook(ctx, &d.cert, &d.hooks[i])
+	}
+}
+
+// runDeployHook 执行一个部署钩子，结果记录到钩子和证书日志
+func runDeployHook(ctx context.Context, cert *database.Certificate, hook *database.CertificateHook) error {
+	err := executeHook(ctx, cert, hook)
+
+	now := time.Now()
+	hook.LastRunAt = &now
+	hook.LastError = ""
+	if err != nil {
+		hook.LastError = err.Error()
+	}
+	database.UpdateCertificateHookResult(hook.ID, now, hook.LastError)
+
+	if err != nil {
+		log.Error("部署钩子执行失败", map[string]interface{}{
+			"domain": cert.Domain,
+			"hook":   describeHook(hook),
+			"error":  err.Error(),
+		})
+		addCertificateLog(ctx, cert.ID, "deploy", fmt.Sprintf("%s 失败: %s", describeHook(hook), err.Error()))
+		return err
+	}
+	log.Info("部署钩子执行成功", map[string]interface{}{
+		"domain": cert.Domain,
+		"hook":   describeHook(hook),
+	})
+	addCertificateLog(ctx, cert.ID, "deploy", describeHook(hook)+" 成功")
+	return nil
+}
+
+// describeHook 钩子的简短描述，用于日志
+func describeHook(hook *database.CertificateHook) string {
+	switch hook.Type {
+	case database.HookCopy:
+		targets := make([]string, 0, 2)
+		for _, p := range []string{hook.CertPath, hook.KeyPath} {
+			if p != "" {
+				targets = append(targets, p)
+			}
+		}
+		return fmt.Sprintf("复制到 %s", strings.Join(targets, "、"))
+	case database.HookCommand:
+		return fmt.Sprintf("执行命令 %q", hook.Command)
+	case database.HookPKCS12:
+		return fmt.Sprintf("导出 PKCS#12 到 %s", hook.CertPath)
+	}
+	return hook.Type
+}
+
+func executeHook(ctx context.Context, cert *database.Certificate, hook *database.CertificateHook) error {
+	switch hook.Type {
+	case database.HookCopy:
+		return copyHookFiles(cert, hook)
+	case database.HookCommand:
+		return runHookCommand(ctx, cert, hook)
+	case database.HookPKCS12:
+		return exportHookPKCS12(cert, hook)
+	}
+	return fmt.Errorf("不支持的钩子类型: %s", hook.Type)
+}
+
+// copyHookFiles 复制证书（含证书链）和私钥
+func copyHookFiles(cert *database.Certificate, hook *database.CertificateHook) error {
+	dataDir := config.Get().Data.Dir
+	files := []struct {
+		src, dest string
+		mode      os.FileMode
+	}{
+		{cert.CertPath, hook.CertPath, 0644},
+		{cert.KeyPath, hook.KeyPath, 0600},
+	}
+	for _, f := range files {
+		if f.dest == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dataDir, f.src))
+		if err != nil {
+			return fmt.Errorf("读取证书文件失败: %w", err)
+		}
+		if err := writeHookFile(hook, f.dest, data, f.mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHookCommand 通过 sh -c 执行命令，证书路径通过环境变量传入
+func runHookCommand(ctx context.Context, cert *database.Certificate, hook *database.CertificateHook) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	// 钩子在申请任务的超时之外单独计时
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	dataDir := config.Get().Data.Dir
+	abs := func(rel string) string {
+		if rel == "" {
+			return ""
+		}
+		p, _ := filepath.Abs(filepath.Join(dataDir, rel))
+		return p
+	}
+	var domains []string
+	if err := json.Unmarshal([]byte(cert.Domains), &domains); err != nil || len(domains) == 0 {
+		domains = []string{cert.Domain}
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	cmd.Dir = dataDir
+	cmd.WaitDelay = 5 * time.Second
+	cmd.Env = append(os.Environ(),
+		"HOP_CERT_ID="+cert.ID,
+		"HOP_CERT_DOMAIN="+cert.Domain,
+		"HOP_CERT_DOMAINS="+strings.Join(domains, ","),
+		"HOP_CERT_PATH="+abs(cert.CertPath),
+		"HOP_KEY_PATH="+abs(cert.KeyPath),
+		"HOP_RSA_CERT_PATH="+abs(cert.RSACertPath),
+		"HOP_RSA_KEY_PATH="+abs(cert.RSAKeyPath),
+		"HOP_CERT_NOT_AFTER="+cert.NotAfter.Format(time.RFC3339),
+	)
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("命令执行超时（%d 秒）", timeout)
+	}
+	if err != nil {
+		out := strings.TrimSpace(string(output))
+		if len(out) > maxHookOutput {
+			out = out[len(out)-maxHookOutput:]
+		}
+		if out == "" {
+			return fmt.Errorf("命令执行失败: %w", err)
+		}
+		return fmt.Errorf("命令执行失败: %w: %s", err, out)
+	}
+	return nil
+}
+
+// exportHookPKCS12 导出包含私钥和证书链的 PKCS#12 文件
+func exportHookPKCS12(cert *database.Certificate, hook *database.CertificateHook) error {
+	dataDir := config.Get().Data.Dir
+	certPEM, err := os.ReadFile(filepath.Join(dataDir, cert.CertPath))
+	if err != nil {
+		return fmt.Errorf("读取证书文件失败: %w", err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dataDir, cert.KeyPath))
+	if err != nil {
+		return fmt.Errorf("读取私钥文件失败: %w", err)
+	}
+	certs, err := parseCertificateChain(certPEM)
+	if err != nil {
+		return err
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return fmt.Errorf("解析私钥失败: %w", err)
+	}
+
+	encoder := pkcs12.Modern2023
+	if hook.Legacy {
+		encoder = pkcs12.LegacyDES
+	}
+	data, err := encoder.Encode(key, certs[0], certs[1:], hook.Password)
+	if err != nil {
+		return fmt.Errorf("生成 PKCS#12 失败: %w", err)
+	}
+	return writeHookFile(hook, hook.CertPath, data, 0600)
+}
+
+// writeHookFile 先写临时文件再替换，读取方不会看到写了一半的文件
+func writeHookFile(hook *database.CertificateHook, dest string, data []byte, mode os.FileMode) error {
+	if hook.Mode != "" {
+		m, err := parseHookMode(hook.Mode)
+		if err != nil {
+			return err
+		}
+		mode = m
+	}
+
+	tmp := dest + ".hop-tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", dest, err)
+	}
+	// 不受 umask 影响
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("设置 %s 权限失败: %w", dest, err)
+	}
+	if hook.Owner != "" {
+		uid, gid, err := lookupOwner(hook.Owner)
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err := os.Chown(tmp, uid, gid); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("设置 %s 所有者失败: %w", dest, err)
+		}
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入 %s 失败: %w", dest, err)
+	}
+	return nil
+}
+
+// parseHookMode 解析八进制的文件权限
+func parseHookMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, fmt.Errorf("无效的文件权限: %s", s)
+	}
+	return os.FileMode(m), nil
+}
+
+// lookupOwner 解析 user 或 user:group，也支持数字 ID；未指定组时 gid 为 -1（不修改）
+func lookupOwner(owner string) (int, int, error) {
+	userName, groupName, _ := strings.Cut(owner, ":")
+	uid, gid := -1, -1
+
+	if userName != "" {
+		if id, err := strconv.Atoi(userName); err == nil {
+			uid = id
+		} else {
+			u, err := user.Lookup(userName)
+			if err != nil {
+				return 0, 0, fmt.Errorf("用户不存在: %s", userName)
+			}
+			uid, _ = strconv.Atoi(u.Uid)
+		}
+	}
+	if groupName != "" {
+		if id, err := strconv.Atoi(groupName); err == nil {
+			gid = id
+		} else {
+			g, err := user.LookupGroup(groupName)
+			if err != nil {
+				return 0, 0, fmt.Errorf("用户组不存在: %s", groupName)
+			}
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	}
+	return uid, gid, nil
+}
+
+// validateHookPath 目标路径必须是绝对路径、目录已存在，且不能覆盖 Hop 管理的证书目录
+func validateHookPath(p string) (string, error) {
+	if !filepath.IsAbs(p) {
+		return "", fmt.Errorf("目标路径必须是绝对路径: %s", p)
+	}
+	p = filepath.Clean(p)
+	sslDir, _ := filepath.Abs(filepath.Join(config.Get().Data.Dir, "nginx", "ssl"))
+	if p == sslDir || strings.HasPrefix(p, sslDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("不能写入 Hop 管理的证书目录: %s", sslDir)
+	}
+	if info, err := os.Stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("目录不存在: %s", filepath.Dir(p))
+	}
+	return p, nil
+}
+
+// validateHook 校验并规范化钩子设置
+func validateHook(hook *database.CertificateHook) error {
+	var err error
+	switch hook.Type {
+	case database.HookCopy:
+		if hook.CertPath == "" && hook.KeyPath == "" {
+			return fmt.Errorf("请填写证书或私钥的目标路径")
+		}
+		for _, p := range []*string{&hook.CertPath, &hook.KeyPath} {
+			if *p == "" {
+				continue
+			}
+			if *p, err = validateHookPath(*p); err != nil {
+				return err
+			}
+		}
+		hook.Command, hook.Password, hook.Timeout, hook.Legacy = "", "", 0, false
+	case database.HookPKCS12:
+		if hook.CertPath == "" {
+			return fmt.Errorf("请填写 PKCS#12 文件路径")
+		}
+		if hook.CertPath, err = validateHookPath(hook.CertPath); err != nil {
+			return err
+		}
+		if hook.Password == "" {
+			return fmt.Errorf("PKCS#12 文件需要设置密码")
+		}
+		hook.KeyPath, hook.Command, hook.Timeout = "", "", 0
+	case database.HookCommand:
+		hook.Command = strings.TrimSpace(hook.Command)
+		if hook.Command == "" {
+			return fmt.Errorf("命令不能为空")
+		}
+		if hook.Timeout < 0 || hook.Timeout > maxHookTimeout {
+			return fmt.Errorf("超时时间应在 1-%d 秒之间", maxHookTimeout)
+		}
+		if hook.Timeout == 0 {
+			hook.Timeout = defaultHookTimeout
+		}
+		hook.CertPath, hook.KeyPath, hook.Owner, hook.Mode, hook.Password, hook.Legacy = "", "", "", "", "", false
+	default:
+		return fmt.Errorf("不支持的钩子类型: %s", hook.Type)
+	}
+
+	if hook.Mode != "" {
+		if _, err := parseHookMode(hook.Mode); err != nil {
+			return err
+		}
+	}
+	if hook.Owner != "" {
+		if _, _, err := lookupOwner(hook.Owner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// === HTTP Handlers ===
+
+// HookRequest 创建或修改部署钩子请求
+type HookRequest struct {
+	Type     string `json:"type"`
+	Enabled  *bool  `json:"enabled"` // 不填时创建为启用，修改时保持不变
+	CertPath string `json:"certPath"`
+	KeyPath  string `json:"keyPath"`
+	Owner    string `json:"owner"`
+	Mode     string `json:"mode"`
+	Command  string `json:"command"`
+	Timeout  int    `json:"timeout"`
+	Password string `json:"password"` // 修改时留空保持原密码
+	Legacy   bool   `json:"legacy"`
+}
+
+// HookResponse 部署钩子信息
+type HookResponse struct {
+	ID            string  `json:"id"`
+	CertificateID string  `json:"certificateId"`
+	Type          string  `json:"type"`
+	Enabled       bool    `json:"enabled"`
+	CertPath      string  `json:"certPath"`
+	KeyPath       string  `json:"keyPath"`
+	Owner         string  `json:"owner"`
+	Mode          string  `json:"mode"`
+	Command       string  `json:"command"`
+	Timeout       int     `json:"timeout"`
+	HasPassword   bool    `json:"hasPassword"`
+	Legacy        bool    `json:"legacy"`
+	LastRunAt     *string `json:"lastRunAt"`
+	LastError     string  `json:"lastError"`
+	CreatedAt     string  `json:"createdAt"`
+	UpdatedAt     string  `json:"updatedAt"`
+}
+
+func hookToResponse(hook *database.CertificateHook) HookResponse {
+	resp := HookResponse{
+		ID:            hook.ID,
+		CertificateID: hook.CertificateID,
+		Type:          hook.Type,
+		Enabled:       hook.Enabled,
+		CertPath:      hook.CertPath,
+		KeyPath:       hook.KeyPath,
+		Owner:         hook.Owner,
+		Mode:          hook.Mode,
+		Command:       hook.Command,
+		Timeout:       hook.Timeout,
+		HasPassword:   hook.Password != "",
+		Legacy:        hook.Legacy,
+		LastError:     hook.LastError,
+		CreatedAt:     hook.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     hook.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if hook.LastRunAt != nil {
+		s := hook.LastRunAt.Format("2006-01-02T15:04:05Z07:00")
+		resp.LastRunAt = &s
+	}
+	return resp
+}
+
+// certificateHookFromRequest 查找 URL 中的证书和钩子，钩子不属于该证书时视为不存在
+func certificateHookFromRequest(w http.ResponseWriter, r *http.Request) (*database.Certificate, *database.CertificateHook, bool) {
+	cert, err := database.GetCertificate(chi.URLParam(r, "id"))
+	if err != nil {
+		jsonError(w, "证书不存在", http.StatusNotFound)
+		return nil, nil, false
+	}
+	hook, err := database.GetCertificateHook(chi.URLParam(r, "hookId"))
+	if err != nil || hook.CertificateID != cert.ID {
+		jsonError(w, "部署钩子不存在", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return cert, hook, true
+}
+
+func handleListHooks(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	hooks, err := database.ListCertificateHooks(id)
+	if err != nil {
+		jsonError(w, "获取部署钩子失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := make([]HookResponse, 0, len(hooks))
+	for i := range hooks {
+		resp = append(resp, hookToResponse(&hooks[i]))
+	}
+	jsonResponse(w, map[string]interface{}{
+		"hooks": resp,
+	})
+}
+
+func handleCreateHook(w http.ResponseWriter, r *http.Request) {
+	cert, err := database.GetCertificate(chi.URLParam(r, "id"))
+	if err != nil {
+		jsonError(w, "证书不存在", http.StatusNotFound)
+		return
+	}
+
+	var req HookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	hook := &database.CertificateHook{
+		ID:            uuid.New().String(),
+		CertificateID: cert.ID,
+		Type:          req.Type,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+		CertPath:      strings.TrimSpace(req.CertPath),
+		KeyPath:       strings.TrimSpace(req.KeyPath),
+		Owner:         strings.TrimSpace(req.Owner),
+		Mode:          strings.TrimSpace(req.Mode),
+		Command:       req.Command,
+		Timeout:       req.Timeout,
+		Password:      req.Password,
+		Legacy:        req.Legacy,
+	}
+	if err := validateHook(hook); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := database.CreateCertificateHook(hook); err != nil {
+		jsonError(w, "保存部署钩子失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"hook":    hookToResponse(hook),
+	})
+}
+
+func handleUpdateHook(w http.ResponseWriter, r *http.Request) {
+	_, hook, ok := certificateHookFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req HookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	if req.Type != "" {
+		hook.Type = req.Type
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	hook.CertPath = strings.TrimSpace(req.CertPath)
+	hook.KeyPath = strings.TrimSpace(req.KeyPath)
+	hook.Owner = strings.TrimSpace(req.Owner)
+	hook.Mode = strings.TrimSpace(req.Mode)
+	hook.Command = req.Command
+	hook.Timeout = req.Timeout
+	if req.Password != "" {
+		hook.Password = req.Password
+	}
+	hook.Legacy = req.Legacy
+	if err := validateHook(hook); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := database.UpdateCertificateHook(hook); err != nil {
+		jsonError(w, "更新部署钩子失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"hook":    hookToResponse(hook),
+	})
+}
+
+func handleDeleteHook(w http.ResponseWriter, r *http.Request) {
+	_, hook, ok := certificateHookFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteCertificateHook(hook.ID); err != nil {
+		jsonError(w, "删除部署钩子失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]bool{"success": true})
+}
+
+// handleRunHook 立即执行部署钩子，用于验证设置
+func handleRunHook(w http.ResponseWriter, r *http.Request) {
+	cert, hook, ok := certificateHookFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if cert.Status != "active" {
+		jsonError(w, "证书当前不可用，无法执行", http.StatusBadRequest)
+		return
+	}
+
+	if err := runDeployHook(r.Context(), cert, hook); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"hook":    hookToResponse(hook),
+	})
+}
@@ -0,0 +1,106 @@
+package ssl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+// httpTokens 正在进行的 HTTP-01 验证：token -> key authorization
+var httpTokens sync.Map
+
+// HTTPChallengeHandler 响应 /.well-known/acme-challenge/{token}
+// nginx 在 80 端口将验证请求转发到 Hop
+func HTTPChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	keyAuth, ok := httpTokens.Load(token)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	log.Info("响应 HTTP-01 验证请求", map[string]interface{}{
+		"host":   r.Host,
+		"remote": r.RemoteAddr,
+	})
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(keyAuth.(string)))
+}
+
+// httpChallenge 通过 Hop 进程响应 http-01 验证
+type httpChallenge struct{}
+
+func (httpChallenge) challengeType() string {
+	return database.ChallengeHTTP01
+}
+
+func (httpChallenge) present(ctx context.Context, identifier, token, keyAuth string) error {
+	httpTokens.Store(token, keyAuth)
+	return nil
+}
+
+func (httpChallenge) cleanUp(ctx context.Context, identifier, token, keyAuth string) error {
+	httpTokens.Delete(token)
+	return nil
+}
+
+// validateHTTPChallengeDomains HTTP-01 不支持通配符域名
+func validateHTTPChallengeDomains(domains []string) error {
+	for _, d := range domains {
+		if strings.HasPrefix(d, "*.") {
+			return fmt.Errorf("通配符域名 %s 只能使用 DNS 验证", d)
+		}
+	}
+	return nil
+}
+
+// ensureHTTPChallenge 确保 nginx 在 80 端口转发验证请求，首次开启时重新生成配置并重载 nginx
+func ensureHTTPChallenge() error {
+	if config.Get().Nginx.HTTPChallenge {
+		return nil
+	}
+
+	params := nginx.LoadTemplateParams()
+	params.HTTPChallenge = true
+
+	streamRoutes, err := nginx.ListStreamRoutes()
+	if err != nil {
+		return fmt.Errorf("读取 stream 路由失败: %w", err)
+	}
+
+	fullParams := nginx.FullTemplateParams{
+		TemplateParams: params,
+		StreamRoutes:   streamRoutes,
+	}
+	if err := nginx.GenerateAndSaveNginxConf(fullParams, nginx.SystemChange("启用 HTTP-01 验证")); err != nil {
+		return fmt.Errorf("生成 nginx.conf 失败: %w", err)
+	}
+
+	// 未安装 nginx 时（例如 nginx 运行在其他容器中）跳过校验和重载
+	if err := testNginxConfig(); err != nil {
+		return err
+	}
+	if output, err := nginx.Reload(); errors.Is(err, exec.ErrNotFound) {
+		log.Warn("未找到 nginx，跳过重新加载", nil)
+	} else if err != nil {
+		return fmt.Errorf("重载 nginx 失败: %s", strings.TrimSpace(output))
+	}
+
+	// nginx 已生效后再写入配置文件，失败时下次申请会重新尝试
+	if err := nginx.SaveTemplateParams(params); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	log.Info("已启用 HTTP-01 验证", nil)
+	return nil
+}
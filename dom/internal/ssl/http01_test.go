@@ -0,0 +1,42 @@
+package ssl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/nginx"
+)
+
+func TestEnsureHTTPChallengeWithoutNginx(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("[data]\ndir = \"data\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := nginx.EnsureNginxDirs(); err != nil {
+		t.Fatal(err)
+	}
+	// PATH 中没有 nginx
+	t.Setenv("PATH", t.TempDir())
+
+	if err := ensureHTTPChallenge(); err != nil {
+		t.Fatalf("未安装 nginx 时应跳过校验和重载: %v", err)
+	}
+	if !config.Get().Nginx.HTTPChallenge {
+		t.Error("未保存 http_challenge 设置")
+	}
+	saved, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(saved), "http_challenge = true") {
+		t.Errorf("配置文件 =\n%s", saved)
+	}
+	conf, _ := os.ReadFile(nginx.GetNginxPaths().ConfigPath)
+	if !strings.Contains(string(conf), "/.well-known/acme-challenge/") {
+		t.Errorf("nginx.conf 未转发验证请求:\n%s", conf)
+	}
+}
@@ -0,0 +1,573 @@
+package ssl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/hop/backend/internal/database"
+)
+
+// 任务类型
+const (
+	JobIssue   = "issue"
+	JobRenew   = "renew"
+	JobReissue = "reissue" // 修改域名后重新签发
+)
+
+// maxQueuedJobs 排队任务上限
+const maxQueuedJobs = 64
+
+// Job 排队执行的证书申请或续期任务
+// 任务依次执行，执行过程中的各阶段写入 certificate_log 并推送给订阅者
+type Job struct {
+	mu          sync.Mutex
+	record      database.CertificateJob
+	run         func(ctx context.Context) error
+	logs        []database.CertificateLog
+	subscribers map[chan database.CertificateLog]struct{}
+	done        chan struct{}
+}
+
+// jobs 排队和执行中的任务
+var jobs = struct {
+	sync.Mutex
+	active map[string]*Job
+	queue  chan *Job
+}{
+	active: map[string]*Job{},
+	queue:  make(chan *Job, maxQueuedJobs),
+}
+
+var startWorkerOnce sync.Once
+
+// workerGate 执行任务时持有读锁，PauseJobs 持有写锁
+var workerGate sync.RWMutex
+
+// RecoverJobs 将上次运行未完成的任务标记为失败，服务启动时调用
+func RecoverJobs() {
+	n, err := database.FailUnfinishedCertificateJobs("服务重启，任务已中断")
+	if err != nil {
+		log.Error("清理未完成的证书任务失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if n > 0 {
+		log.Warn("上次运行未完成的证书任务已标记为失败", map[string]interface{}{
+			"count": n,
+		})
+	}
+}
+
+// startWorker 首次提交任务时启动执行协程，任务依次执行
+func startWorker() {
+	startWorkerOnce.Do(func() {
+		go func() {
+			for job := range jobs.queue {
+				workerGate.RLock()
+				job.execute()
+				workerGate.RUnlock()
+			}
+		}()
+	})
+}
+
+// QueueIssue 将证书申请加入队列
+func QueueIssue(opts IssueOptions) (*Job, error) {
+	return queueIssue(opts, false)
+}
+
+// queueIssue 将证书申请加入队列
+// reuse 为 true 时，同一主域名已有未完成的申请任务则直接返回该任务
+func queueIssue(opts IssueOptions, reuse bool) (*Job, error) {
+	if len(opts.Domains) == 0 {
+		return nil, fmt.Errorf("至少需要一个域名")
+	}
+	var same func(*Job) bool
+	if reuse {
+		same = sameIssueDomain(opts.Domains[0])
+	}
+	job, err := submitJob(JobIssue, "", opts.Domains[0], same, func(ctx context.Context) error {
+		_, err := IssueCertificate(ctx, opts)
+		return err
+	})
+	if errors.Is(err, errJobExists) {
+		return job, nil
+	}
+	return job, err
+}
+
+// QueueRenew 将证书续期加入队列，该证书已有未完成的任务时直接返回该任务
+func QueueRenew(certID string, email string) (*Job, error) {
+	cert, err := database.GetCertificate(certID)
+	if err != nil {
+		return nil, fmt.Errorf("证书不存在")
+	}
+	if cert.Source == database.CertSourceUpload {
+		return nil, fmt.Errorf("上传的证书不支持续期，请上传新证书")
+	}
+
+	job, err := submitJob(JobRenew, certID, cert.Domain, sameCertificate(certID), func(ctx context.Context) error {
+		err := RenewCertificate(ctx, certID, email)
+		recordRenewalResult(certID, err)
+		return err
+	})
+	if errors.Is(err, errJobExists) {
+		return job, nil
+	}
+	return job, err
+}
+
+// QueueReissue 将修改域名后的重新签发加入队列，该证书已有未完成的任务时拒绝
+func QueueReissue(certID string, domains []string) (*Job, error) {
+	cert, err := database.GetCertificate(certID)
+	if err != nil {
+		return nil, fmt.Errorf("证书不存在")
+	}
+	if cert.Source == database.CertSourceUpload {
+		return nil, fmt.Errorf("上传的证书不支持修改域名，请上传新证书")
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("至少需要一个域名")
+	}
+	if cert.Source == database.CertSourceInternal {
+		for _, d := range domains {
+			if err := validateInternalName(d); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	job, err := submitJob(JobReissue, certID, domains[0], sameCertificate(certID), func(ctx context.Context) error {
+		err := ReissueCertificate(ctx, certID, domains)
+		if err == nil {
+			// 新证书按新的有效期重新计算续期时间
+			recordRenewalResult(certID, nil)
+		}
+		return err
+	})
+	if errors.Is(err, errJobExists) {
+		return nil, fmt.Errorf("证书已有未完成的任务，请稍后再试")
+	}
+	return job, err
+}
+
+// errJobExists 已有相同的未完成任务，submitJob 同时返回该任务
+var errJobExists = errors.New("已有未完成的任务")
+
+// sameCertificate 匹配同一证书的任务
+func sameCertificate(certID string) func(*Job) bool {
+	return func(job *Job) bool {
+		return job.CertificateID() == certID
+	}
+}
+
+// sameIssueDomain 匹配同一主域名的申请任务
+func sameIssueDomain(domain string) func(*Job) bool {
+	return func(job *Job) bool {
+		record := job.Record()
+		return record.Type == JobIssue && record.Domain == domain
+	}
+}
+
+// submitJob 创建任务记录并加入队列
+// same 不为 nil 时，已有匹配的未完成任务则返回该任务和 errJobExists
+// 查找与加入队列在同一把锁内完成，并发提交时不会产生重复任务
+func submitJob(jobType, certID, domain string, same func(*Job) bool, run func(ctx context.Context) error) (*Job, error) {
+	jobs.Lock()
+	defer jobs.Unlock()
+
+	if same != nil {
+		for _, existing := range jobs.active {
+			if same(existing) {
+				return existing, errJobExists
+			}
+		}
+	}
+
+	job := &Job{
+		record: database.CertificateJob{
+			ID:            uuid.New().String(),
+			Type:          jobType,
+			CertificateID: certID,
+			Domain:        domain,
+			Status:        database.JobQueued,
+		},
+		run:         run,
+		subscribers: map[chan database.CertificateLog]struct{}{},
+		done:        make(chan struct{}),
+	}
+	if err := database.CreateCertificateJob(&job.record); err != nil {
+		return nil, fmt.Errorf("创建任务失败: %w", err)
+	}
+	startWorker()
+
+	if len(jobs.queue) == cap(jobs.queue) {
+		err := fmt.Errorf("任务队列已满，请稍后再试")
+		job.record.Status = database.JobFailed
+		job.record.Error = err.Error()
+		database.UpdateCertificateJob(&job.record)
+		return nil, err
+	}
+	if waiting := len(jobs.active); waiting > 0 {
+		job.addLog("queued", fmt.Sprintf("已加入队列，前面还有 %d 个任务", waiting))
+	} else {
+		job.addLog("queued", "已加入队列")
+	}
+	jobs.active[job.record.ID] = job
+	jobs.queue <- job
+
+	return job, nil
+}
+
+// execute 执行任务并记录结果
+func (j *Job) execute() {
+	now := time.Now()
+	j.mu.Lock()
+	j.record.Status = database.JobRunning
+	j.record.StartedAt = &now
+	database.UpdateCertificateJob(&j.record)
+	j.mu.Unlock()
+
+	ctx := context.WithValue(context.Background(), jobContextKey{}, j)
+	j.finish(j.run(ctx))
+}
+
+// finish 保存任务结果并通知订阅者
+func (j *Job) finish(err error) {
+	now := time.Now()
+
+	j.mu.Lock()
+	j.record.FinishedAt = &now
+	if err != nil {
+		j.record.Status = database.JobFailed
+		j.record.Error = err.Error()
+	} else {
+		j.record.Status = database.JobSucceeded
+	}
+	database.UpdateCertificateJob(&j.record)
+
+	for ch := range j.subscribers {
+		close(ch)
+	}
+	j.subscribers = nil
+	j.mu.Unlock()
+
+	jobs.Lock()
+	delete(jobs.active, j.record.ID)
+	jobs.Unlock()
+	close(j.done)
+}
+
+// Record 返回任务当前状态
+func (j *Job) Record() database.CertificateJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.record
+}
+
+// CertificateID 任务对应的证书，申请任务开始执行前为空
+func (j *Job) CertificateID() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.record.CertificateID
+}
+
+// Wait 等待任务结束，成功时返回证书
+func (j *Job) Wait() (*database.Certificate, error) {
+	<-j.done
+
+	record := j.Record()
+	if record.Status != database.JobSucceeded {
+		return nil, fmt.Errorf("%s", record.Error)
+	}
+	return database.GetCertificate(record.CertificateID)
+}
+
+// addLog 写入证书日志并推送给订阅者
+func (j *Job) addLog(action, message string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	logEntry := database.CertificateLog{
+		ID:            uuid.New().String(),
+		CertificateID: j.record.CertificateID,
+		JobID:         j.record.ID,
+		Action:        action,
+		Message:       message,
+	}
+	database.CreateCertificateLog(&logEntry)
+	j.logs = append(j.logs, logEntry)
+
+	for ch := range j.subscribers {
+		select {
+		case ch <- logEntry:
+		default:
+			// 订阅者处理过慢时丢弃，日志仍可从数据库查询
+		}
+	}
+}
+
+// subscribe 返回已有日志和后续日志的通道，任务结束时通道关闭
+func (j *Job) subscribe() ([]database.CertificateLog, chan database.CertificateLog) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	logs := append([]database.CertificateLog{}, j.logs...)
+	if j.subscribers == nil {
+		return logs, nil
+	}
+	ch := make(chan database.CertificateLog, 64)
+	j.subscribers[ch] = struct{}{}
+	return logs, ch
+}
+
+// unsubscribe 取消订阅
+func (j *Job) unsubscribe(ch chan database.CertificateLog) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.subscribers[ch]; ok {
+		delete(j.subscribers, ch)
+		close(ch)
+	}
+}
+
+// PauseJobs 等待执行中的任务完成后暂停任务队列，并阻止其他修改证书的操作
+// 用于恢复快照等替换整个数据目录的操作，返回的函数恢复执行
+func PauseJobs() (resume func()) {
+	workerGate.Lock()
+	renewMutex.Lock()
+	return func() {
+		renewMutex.Unlock()
+		workerGate.Unlock()
+	}
+}
+
+// hasActiveJobs 是否有排队或执行中的任务
+func hasActiveJobs() bool {
+	jobs.Lock()
+	defer jobs.Unlock()
+	return len(jobs.active) > 0
+}
+
+// activeJob 查找排队或执行中的任务
+func activeJob(id string) *Job {
+	jobs.Lock()
+	defer jobs.Unlock()
+	return jobs.active[id]
+}
+
+// jobContextKey 在 context 中传递当前任务
+type jobContextKey struct{}
+
+func jobFromContext(ctx context.Context) *Job {
+	job, _ := ctx.Value(jobContextKey{}).(*Job)
+	return job
+}
+
+// setJobCertificate 申请任务确定证书 ID 后记录到任务中
+func setJobCertificate(ctx context.Context, certID string) {
+	job := jobFromContext(ctx)
+	if job == nil {
+		return
+	}
+	job.mu.Lock()
+	job.record.CertificateID = certID
+	database.UpdateCertificateJob(&job.record)
+	job.mu.Unlock()
+}
+
+// reportProgress 记录申请或续期所处的阶段，不在任务中执行时只写入运行日志
+func reportProgress(ctx context.Context, stage IssueStage, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if job := jobFromContext(ctx); job != nil {
+		job.addLog(string(stage), message)
+		return
+	}
+	log.Info(message, map[string]interface{}{
+		"stage": string(stage),
+	})
+}
+
+// addCertificateLog 写入证书日志，在任务中执行时关联到任务
+func addCertificateLog(ctx context.Context, certID, action, message string) {
+	if job := jobFromContext(ctx); job != nil && job.CertificateID() == certID {
+		job.addLog(action, message)
+		return
+	}
+	database.CreateCertificateLog(&database.CertificateLog{
+		ID:            uuid.New().String(),
+		CertificateID: certID,
+		Action:        action,
+		Message:       message,
+	})
+}
+
+// === HTTP Handlers ===
+
+// JobResponse 任务信息
+type JobResponse struct {
+	ID            string  `json:"id"`
+	Type          string  `json:"type"`
+	CertificateID string  `json:"certificateId"`
+	Domain        string  `json:"domain"`
+	Status        string  `json:"status"`
+	Error         string  `json:"error"`
+	CreatedAt     string  `json:"createdAt"`
+	StartedAt     *string `json:"startedAt"`
+	FinishedAt    *string `json:"finishedAt"`
+}
+
+func jobToResponse(job database.CertificateJob) JobResponse {
+	resp := JobResponse{
+		ID:            job.ID,
+		Type:          job.Type,
+		CertificateID: job.CertificateID,
+		Domain:        job.Domain,
+		Status:        job.Status,
+		Error:         job.Error,
+		CreatedAt:     job.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if job.StartedAt != nil {
+		s := job.StartedAt.Format("2006-01-02T15:04:05Z07:00")
+		resp.StartedAt = &s
+	}
+	if job.FinishedAt != nil {
+		s := job.FinishedAt.Format("2006-01-02T15:04:05Z07:00")
+		resp.FinishedAt = &s
+	}
+	return resp
+}
+
+func logToResponse(l database.CertificateLog) CertificateLogResponse {
+	return CertificateLogResponse{
+		ID:        l.ID,
+		JobID:     l.JobID,
+		Action:    l.Action,
+		Message:   l.Message,
+		CreatedAt: l.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// handleListJobs 列出最近的任务，排队和执行中的任务使用内存中的最新状态
+func handleListJobs(w http.ResponseWriter, r *http.Request) {
+	records, err := database.ListCertificateJobs(50)
+	if err != nil {
+		jsonError(w, "获取任务列表失败", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]JobResponse, 0, len(records))
+	for _, record := range records {
+		if job := activeJob(record.ID); job != nil {
+			record = job.Record()
+		}
+		resp = append(resp, jobToResponse(record))
+	}
+	jsonResponse(w, map[string]interface{}{
+		"jobs": resp,
+	})
+}
+
+// handleGetJob 获取任务状态和日志
+func handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	record, err := database.GetCertificateJob(id)
+	if err != nil {
+		jsonError(w, "任务不存在", http.StatusNotFound)
+		return
+	}
+	if job := activeJob(id); job != nil {
+		*record = job.Record()
+	}
+
+	logs, _ := database.GetJobLogs(id)
+	logResp := make([]CertificateLogResponse, 0, len(logs))
+	for _, l := range logs {
+		logResp = append(logResp, logToResponse(l))
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"job":  jobToResponse(*record),
+		"logs": logResp,
+	})
+}
+
+// handleJobEvents 以 Server-Sent Events 推送任务日志
+// 先发送已有日志（log 事件），任务结束时发送 done 事件（任务信息）并关闭连接
+func handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	record, err := database.GetCertificateJob(id)
+	if err != nil {
+		jsonError(w, "任务不存在", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, "不支持流式响应", http.StatusInternalServerError)
+		return
+	}
+
+	// 任务可能持续较长时间，取消写超时
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // 经 nginx 代理时不缓冲
+
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	var logs []database.CertificateLog
+	var ch chan database.CertificateLog
+	job := activeJob(id)
+	if job != nil {
+		logs, ch = job.subscribe()
+	} else {
+		logs, _ = database.GetJobLogs(id)
+	}
+	for _, l := range logs {
+		send("log", logToResponse(l))
+	}
+
+	if ch != nil {
+		defer job.unsubscribe(ch)
+
+		ping := time.NewTicker(15 * time.Second)
+		defer ping.Stop()
+	stream:
+		for {
+			select {
+			case l, ok := <-ch:
+				if !ok {
+					break stream
+				}
+				send("log", logToResponse(l))
+			case <-ping.C:
+				fmt.Fprint(w, ": ping\n\n")
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	if job != nil {
+		*record = job.Record()
+	}
+	send("done", jobToResponse(*record))
+}
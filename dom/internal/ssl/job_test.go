@@ -0,0 +1,144 @@
+package ssl
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// useTestDB 在临时数据目录中初始化数据库，测试结束后关闭
+func useTestDB(t *testing.T) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Data.Dir
+	cfg.Data.Dir = t.TempDir()
+	if err := database.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		cfg.Data.Dir = original
+	})
+}
+
+func TestSubmitJobConcurrentDuplicates(t *testing.T) {
+	useTestDB(t)
+
+	release := make(chan struct{})
+	var runs sync.WaitGroup
+	submit := func() (*Job, error) {
+		return queueIssueFunc("dup.example.com", func(ctx context.Context) error {
+			runs.Done()
+			<-release
+			return nil
+		})
+	}
+
+	// 并发提交同一域名的申请，只能产生一个任务
+	runs.Add(1)
+	results := make(chan *Job, 16)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job, err := submit()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results <- job
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var first *Job
+	for job := range results {
+		if first == nil {
+			first = job
+		} else if job != first {
+			t.Errorf("产生了重复任务 %s 和 %s", first.Record().ID, job.Record().ID)
+		}
+	}
+	runs.Wait()
+	close(release)
+	<-first.done
+
+	// 任务完成后可以再次提交
+	runs.Add(1)
+	release = make(chan struct{})
+	close(release)
+	job, err := submit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job == first {
+		t.Error("已完成的任务不应被复用")
+	}
+	<-job.done
+}
+
+// queueIssueFunc 以指定的执行函数提交申请任务，与 queueIssue 使用相同的去重规则
+func queueIssueFunc(domain string, run func(ctx context.Context) error) (*Job, error) {
+	job, err := submitJob(JobIssue, "", domain, sameIssueDomain(domain), run)
+	if err == errJobExists {
+		return job, nil
+	}
+	return job, err
+}
+
+func TestPauseJobs(t *testing.T) {
+	useTestDB(t)
+
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	first, err := queueIssueFunc("pause-1.example.com", func(ctx context.Context) error {
+		started <- "first"
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	// 等待执行中的任务完成后才暂停
+	paused := make(chan func())
+	go func() { paused <- PauseJobs() }()
+	select {
+	case <-paused:
+		t.Fatal("任务执行中不应暂停")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-first.done
+	resume := <-paused
+
+	// 暂停期间提交的任务不执行，也不能取得 renewMutex
+	second, err := queueIssueFunc("pause-2.example.com", func(ctx context.Context) error {
+		started <- "second"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewMutex.TryLock() {
+		t.Error("暂停期间 renewMutex 应被持有")
+	}
+	select {
+	case <-started:
+		t.Fatal("暂停期间任务不应执行")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	resume()
+	<-second.done
+	if got := <-started; got != "second" {
+		t.Errorf("started = %s", got)
+	}
+}
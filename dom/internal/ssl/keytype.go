@@ -0,0 +1,107 @@
+package ssl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// defaultKeyType 未指定私钥算法时使用 ECDSA P-256
+const defaultKeyType = database.KeyTypeEC256
+
+// dualKeyType 双证书模式下 RSA 证书使用的私钥算法
+const dualKeyType = database.KeyTypeRSA2048
+
+// ValidateKeyOptions 校验私钥算法和双证书设置
+func ValidateKeyOptions(keyType string, dual bool) error {
+	switch keyType {
+	case "", database.KeyTypeEC256, database.KeyTypeEC384:
+	case database.KeyTypeRSA2048, database.KeyTypeRSA3072, database.KeyTypeRSA4096:
+		if dual {
+			return fmt.Errorf("双证书模式需要使用 ECDSA 私钥")
+		}
+	default:
+		return fmt.Errorf("不支持的私钥算法: %s", keyType)
+	}
+	return nil
+}
+
+// generateKey 按算法生成证书私钥
+func generateKey(keyType string) (crypto.Signer, error) {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case database.KeyTypeEC256, "":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case database.KeyTypeEC384:
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case database.KeyTypeRSA2048:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case database.KeyTypeRSA3072:
+		key, err = rsa.GenerateKey(rand.Reader, 3072)
+	case database.KeyTypeRSA4096:
+		key, err = rsa.GenerateKey(rand.Reader, 4096)
+	default:
+		return nil, fmt.Errorf("不支持的私钥算法: %s", keyType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("生成证书私钥失败: %w", err)
+	}
+	return key, nil
+}
+
+// keyTypeOf 识别公钥对应的私钥算法，无法识别时返回空
+func keyTypeOf(pub crypto.PublicKey) string {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return database.KeyTypeEC256
+		case elliptic.P384():
+			return database.KeyTypeEC384
+		}
+	case *rsa.PublicKey:
+		switch k.N.BitLen() {
+		case 2048:
+			return database.KeyTypeRSA2048
+		case 3072:
+			return database.KeyTypeRSA3072
+		case 4096:
+			return database.KeyTypeRSA4096
+		}
+	}
+	return ""
+}
+
+// certificateKey 续期使用的私钥：开启复用且已有私钥算法一致时沿用，否则生成新私钥
+func certificateKey(keyType string, reuse bool, relKeyPath string) (crypto.Signer, error) {
+	if keyType == "" {
+		keyType = defaultKeyType
+	}
+	if reuse && relKeyPath != "" {
+		data, err := os.ReadFile(filepath.Join(config.Get().Data.Dir, relKeyPath))
+		if os.IsNotExist(err) {
+			// 首次签发，没有可复用的私钥
+			return generateKey(keyType)
+		}
+		if err == nil {
+			key, err := parsePrivateKey(data)
+			if err == nil && keyTypeOf(key.Public()) == keyType {
+				return key, nil
+			}
+		}
+		log.Warn("无法复用已有私钥，将生成新私钥", map[string]interface{}{
+			"keyPath": relKeyPath,
+			"keyType": keyType,
+		})
+	}
+	return generateKey(keyType)
+}
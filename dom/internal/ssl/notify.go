@@ -0,0 +1,30 @@
+package ssl
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/notify"
+)
+
+// notifyCertificate 发送证书相关的通知
+func notifyCertificate(eventType string, cert *database.Certificate, message string, err error) {
+	var domains []string
+	if jsonErr := json.Unmarshal([]byte(cert.Domains), &domains); jsonErr != nil || len(domains) == 0 {
+		domains = []string{cert.Domain}
+	}
+	e := notify.Event{
+		Type:          eventType,
+		Message:       message,
+		CertificateID: cert.ID,
+		Domain:        cert.Domain,
+		Domains:       domains,
+		NotAfter:      cert.NotAfter,
+		DaysRemaining: int(time.Until(cert.NotAfter).Hours() / 24),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	notify.Notify(e)
+}
@@ -0,0 +1,179 @@
+package ssl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+const (
+	// reloadDelay 证书更新后等待的时间，期间有其他证书更新时合并为一次重新加载
+	reloadDelay = 5 * time.Second
+	// maxReloadDelay 队列中一直有任务时最长的等待时间
+	maxReloadDelay = 5 * time.Minute
+)
+
+// fileBackup 覆盖前的文件内容，用于回滚
+type fileBackup struct {
+	path    string
+	content []byte
+	mode    os.FileMode
+	existed bool
+}
+
+// backupFiles 读取 data 目录下的文件当前内容
+func backupFiles(relPaths []string) []fileBackup {
+	dataDir := config.Get().Data.Dir
+	backups := make([]fileBackup, 0, len(relPaths))
+	for _, rel := range relPaths {
+		path := filepath.Join(dataDir, rel)
+		backup := fileBackup{path: path}
+		if info, err := os.Stat(path); err == nil {
+			if content, err := os.ReadFile(path); err == nil {
+				backup.content = content
+				backup.mode = info.Mode().Perm()
+				backup.existed = true
+			}
+		}
+		backups = append(backups, backup)
+	}
+	return backups
+}
+
+// restoreFiles 恢复备份的文件，原本不存在的文件被删除
+func restoreFiles(backups []fileBackup) error {
+	var errs []error
+	for _, b := range backups {
+		if !b.existed {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(b.path, b.content, b.mode); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// installCertificates 保存签发的证书并执行 nginx -t 校验，校验失败时恢复原有文件和证书记录中的路径
+func installCertificates(ctx context.Context, cert *database.Certificate, issued *issuedCertificates) (*CertificateInfo, error) {
+	rsaCert, rsaKey := rsaCertificatePaths(cert.CertPath)
+	backups := backupFiles([]string{cert.CertPath, cert.KeyPath, rsaCert, rsaKey})
+	orig := *cert
+
+	reportProgress(ctx, StageSave, "保存证书文件")
+	certInfo, err := saveIssuedCertificates(cert, issued)
+	if err == nil {
+		reportProgress(ctx, StageSave, "校验 nginx 配置")
+		err = testNginxConfig()
+	}
+	if err != nil {
+		if rbErr := restoreFiles(backups); rbErr != nil {
+			log.Error("恢复证书文件失败", map[string]interface{}{
+				"domain": cert.Domain,
+				"error":  rbErr.Error(),
+			})
+		}
+		cert.KeyType = orig.KeyType
+		cert.RSACertPath = orig.RSACertPath
+		cert.RSAKeyPath = orig.RSAKeyPath
+		return nil, fmt.Errorf("%w，已恢复原证书文件", err)
+	}
+	return certInfo, nil
+}
+
+// testNginxConfig 执行 nginx -t，未安装 nginx 时跳过
+func testNginxConfig() error {
+	output, err := nginx.TestConfig()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("nginx 配置校验失败: %s", strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// reloader 合并证书更新后的 nginx 重新加载
+var reloader struct {
+	sync.Mutex
+	pending []string // 等待生效的证书 ID
+	timer   *time.Timer
+	since   time.Time
+}
+
+// scheduleReload 证书文件更新后安排重新加载 nginx
+// 在 reloadDelay 内没有新的证书更新且任务队列为空时执行，结果记录到各证书的日志
+func scheduleReload(certID string) {
+	reloader.Lock()
+	defer reloader.Unlock()
+
+	reloader.pending = append(reloader.pending, certID)
+	if reloader.timer == nil {
+		reloader.since = time.Now()
+		reloader.timer = time.AfterFunc(reloadDelay, flushReload)
+		return
+	}
+	reloader.timer.Reset(reloadDelay)
+}
+
+// flushReload 校验并重新加载 nginx
+func flushReload() {
+	reloader.Lock()
+	// 一次检查续期的多个证书依次执行，全部完成后再重新加载
+	if hasActiveJobs() && time.Since(reloader.since) < maxReloadDelay {
+		reloader.timer.Reset(reloadDelay)
+		reloader.Unlock()
+		return
+	}
+	pending := reloader.pending
+	reloader.pending = nil
+	reloader.timer = nil
+	reloader.Unlock()
+
+	ctx := context.Background()
+	record := func(action, message string) {
+		for _, certID := range pending {
+			addCertificateLog(ctx, certID, action, message)
+		}
+	}
+
+	if err := testNginxConfig(); err != nil {
+		log.Error("证书更新后重新加载 nginx 失败", map[string]interface{}{
+			"certificates": len(pending),
+			"error":        err.Error(),
+		})
+		record("reload", err.Error()+"，未重新加载")
+		return
+	}
+	output, err := nginx.Reload()
+	if errors.Is(err, exec.ErrNotFound) {
+		log.Warn("未找到 nginx，跳过重新加载", nil)
+		return
+	}
+	if err != nil {
+		log.Error("证书更新后重新加载 nginx 失败", map[string]interface{}{
+			"certificates": len(pending),
+			"error":        strings.TrimSpace(output),
+		})
+		record("reload", "重新加载 nginx 失败: "+strings.TrimSpace(output))
+		return
+	}
+
+	log.Info("证书更新后已重新加载 nginx", map[string]interface{}{
+		"certificates": len(pending),
+	})
+	record("reload", "已重新加载 nginx，新证书已生效")
+}
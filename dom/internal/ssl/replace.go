@@ -0,0 +1,147 @@
+package ssl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/nginx"
+)
+
+// pendingIDSuffix 预览时代替证书 ID 前 8 位的占位符
+const pendingIDSuffix = "<id>"
+
+// certificatePaths 新证书的文件路径，例如 nginx/ssl/example.com.crt
+// 同一名称已有证书使用该路径时加上证书 ID 的前 8 位区分，例如 nginx/ssl/example.com.1a2b3c4d.crt
+func certificatePaths(name, certID string) (string, string) {
+	base := certificateBase(name, certID[:8])
+	return base + ".crt", base + ".key"
+}
+
+// certificateBase 新证书文件不含扩展名的路径，名称已被占用时加上 suffix
+func certificateBase(name, suffix string) string {
+	// 通配符域名 *.example.com 在文件名中会变成 _.example.com
+	base := filepath.Join("nginx", "ssl", strings.Replace(name, "*", "_", -1))
+	if used, err := database.CertificatePathInUse(base + ".crt"); err != nil || used {
+		base += "." + suffix
+	}
+	return base
+}
+
+// PlannedCertificatePaths 预览尚未签发的证书将使用的路径，规则与签发时相同
+// 名称已被占用时证书 ID 要到签发后才能确定，路径中以 <id> 占位，例如 nginx/ssl/example.com.<id>.crt
+func PlannedCertificatePaths(name string, dual bool) (certPath, keyPath, rsaCertPath, rsaKeyPath string) {
+	base := certificateBase(name, pendingIDSuffix)
+	certPath, keyPath = base+".crt", base+".key"
+	if dual {
+		rsaCertPath, rsaKeyPath = rsaCertificatePaths(certPath)
+	}
+	return certPath, keyPath, rsaCertPath, rsaKeyPath
+}
+
+// replaceableCertificate 检查要替换的证书，用途不同的证书不能互相替换
+func replaceableCertificate(id, usage string) (*database.Certificate, error) {
+	old, err := database.GetCertificate(id)
+	if err != nil {
+		return nil, fmt.Errorf("要替换的证书不存在")
+	}
+	if usage == "" {
+		usage = database.CertUsageServer
+	}
+	if old.Usage != usage {
+		return nil, fmt.Errorf("%s 的用途与新证书不同，不能替换", old.Domain)
+	}
+	return old, nil
+}
+
+// replaceCertificate 新证书签发成功后替换原证书
+// 引用原证书的站点改为引用新证书，全部切换后把部署钩子转移到新证书，然后删除原证书的记录和文件
+// 有站点未能切换时保留原证书及其部署钩子，避免站点引用不存在的证书
+func replaceCertificate(ctx context.Context, old, cert *database.Certificate) {
+	switched, errs := switchCertificateSites(old, cert)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			addCertificateLog(ctx, cert.ID, "replace", err.Error())
+		}
+		addCertificateLog(ctx, cert.ID, "replace",
+			fmt.Sprintf("部分站点未能改用新证书，已保留原证书 %s（%s）", old.Domain, old.ID))
+		return
+	}
+
+	if err := database.MoveCertificateHooks(old.ID, cert.ID); err != nil {
+		log.Error("转移部署钩子失败", map[string]interface{}{
+			"domain": old.Domain,
+			"error":  err.Error(),
+		})
+	}
+	if err := database.DeleteCertificate(old.ID); err != nil {
+		log.Error("删除被替换的证书失败", map[string]interface{}{
+			"domain": old.Domain,
+			"error":  err.Error(),
+		})
+	} else {
+		removeCertificateFiles(old)
+	}
+	addCertificateLog(ctx, cert.ID, "replace",
+		fmt.Sprintf("已替换原证书 %s（%s），%d 个站点改用新证书", old.Domain, old.ID, switched))
+	log.Info("证书已替换", map[string]interface{}{
+		"domain": cert.Domain,
+		"old":    old.ID,
+		"sites":  switched,
+	})
+}
+
+// removeCertificateFiles 删除证书记录后清理其证书和私钥文件，仍被其他证书使用的文件保留
+func removeCertificateFiles(cert *database.Certificate) {
+	dataDir := config.Get().Data.Dir
+	for _, paths := range [][2]string{{cert.CertPath, cert.KeyPath}, {cert.RSACertPath, cert.RSAKeyPath}} {
+		if paths[0] == "" {
+			continue
+		}
+		if used, err := database.CertificatePathInUse(paths[0]); err != nil || used {
+			continue
+		}
+		for _, path := range paths {
+			if err := os.Remove(filepath.Join(dataDir, path)); err != nil && !os.IsNotExist(err) {
+				log.Warn("删除证书文件失败", map[string]interface{}{
+					"path":  path,
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+// switchCertificateSites 把引用原证书的站点改为引用新证书并重新生成站点配置
+// 站点证书和上游客户端证书的引用都会切换，返回切换的站点数量和失败原因
+func switchCertificateSites(old, cert *database.Certificate) (int, []error) {
+	sites, err := nginx.ListProxySites()
+	if err != nil {
+		return 0, []error{fmt.Errorf("获取站点列表失败: %w", err)}
+	}
+
+	switched := 0
+	var errs []error
+	for _, site := range sites {
+		if site.CertificateID != old.ID && site.UpstreamClientCertID != old.ID {
+			continue
+		}
+		if site.CertificateID == old.ID {
+			site.CertificateID = cert.ID
+		}
+		if site.UpstreamClientCertID == old.ID {
+			site.UpstreamClientCertID = cert.ID
+		}
+		change := nginx.SystemChange(fmt.Sprintf("证书 %s 已替换，站点 %s 改用新证书", old.Domain, site.ServerName))
+		if err := nginx.SaveProxySite(site, change); err != nil {
+			errs = append(errs, fmt.Errorf("站点 %s 改用新证书失败: %w", site.ServerName, err))
+			continue
+		}
+		switched++
+	}
+	return switched, errs
+}
@@ -0,0 +1,142 @@
+package ssl
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hop/backend/internal/acme"
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// revokeTimeout 撤销请求的超时时间
+const revokeTimeout = 2 * time.Minute
+
+// revokeReasons 可选的撤销原因
+var revokeReasons = map[int]string{
+	acme.RevokeUnspecified:          "未指定",
+	acme.RevokeKeyCompromise:        "私钥泄露",
+	acme.RevokeAffiliationChanged:   "从属关系变更",
+	acme.RevokeSuperseded:           "已被取代",
+	acme.RevokeCessationOfOperation: "停止使用",
+}
+
+// RevokeCertificate 向 ACME CA 撤销证书，双证书模式下的 RSA 证书一并撤销
+// 撤销后证书状态变为 revoked，不再自动续期；手动续期会签发新证书并恢复为 active
+// 引用该证书的站点默认保持不变并记录提醒，detach 为 true 时改为停用这些站点的 HTTPS
+// 返回引用该证书的站点
+func RevokeCertificate(certID string, reason int, detach bool) ([]string, error) {
+	reasonLabel, ok := revokeReasons[reason]
+	if !ok {
+		return nil, fmt.Errorf("不支持的撤销原因: %d", reason)
+	}
+
+	// 与续期互斥，避免撤销过程中证书文件被替换
+	renewMutex.Lock()
+	defer renewMutex.Unlock()
+
+	cert, err := database.GetCertificate(certID)
+	if err != nil {
+		return nil, fmt.Errorf("证书不存在")
+	}
+	if cert.Source != database.CertSourceACME {
+		return nil, fmt.Errorf("只能撤销 ACME 签发的证书")
+	}
+	if cert.Status == "revoked" {
+		return nil, fmt.Errorf("证书已撤销")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), revokeTimeout)
+	defer cancel()
+
+	if err := revokeCertificateFile(ctx, cert, cert.CertPath, cert.KeyPath, reason); err != nil {
+		log.Error("撤销证书失败", map[string]interface{}{
+			"domain": cert.Domain,
+			"error":  err.Error(),
+		})
+		addCertificateLog(ctx, cert.ID, "error", fmt.Sprintf("撤销失败: %s", err.Error()))
+		return nil, err
+	}
+	if cert.RSACertPath != "" {
+		if err := revokeCertificateFile(ctx, cert, cert.RSACertPath, cert.RSAKeyPath, reason); err != nil {
+			// 主证书已撤销，RSA 证书失败时仍标记为已撤销
+			addCertificateLog(ctx, cert.ID, "error", fmt.Sprintf("撤销 RSA 证书失败: %s", err.Error()))
+		}
+	}
+
+	cert.Status = "revoked"
+	cert.Error = nil
+	if reason == acme.RevokeKeyCompromise {
+		// 私钥已泄露，之后续期必须生成新私钥
+		cert.ReuseKey = false
+	}
+	if err := database.UpdateCertificate(cert); err != nil {
+		return nil, fmt.Errorf("更新证书记录失败: %w", err)
+	}
+	addCertificateLog(ctx, cert.ID, "revoke", fmt.Sprintf("已撤销证书，原因：%s", reasonLabel))
+	log.Info("证书已撤销", map[string]interface{}{
+		"domain": cert.Domain,
+		"reason": reasonLabel,
+	})
+
+	return revokedCertificateSites(ctx, cert, detach), nil
+}
+
+// revokeCertificateFile 撤销单个证书文件
+// 优先以证书关联的账户签名；私钥泄露或账户不可用时使用证书私钥签名
+func revokeCertificateFile(ctx context.Context, cert *database.Certificate, certPath, keyPath string, reason int) error {
+	leaf, err := loadCertificate(certPath)
+	if err != nil {
+		return fmt.Errorf("读取证书文件失败: %w", err)
+	}
+
+	var client *acme.Client
+	var certKey crypto.Signer
+	if reason != acme.RevokeKeyCompromise && cert.AccountID != "" {
+		if account, err := database.GetACMEAccount(cert.AccountID); err == nil {
+			client, _ = accountClient(account)
+		}
+	}
+	if client == nil {
+		data, err := os.ReadFile(filepath.Join(config.Get().Data.Dir, keyPath))
+		if err != nil {
+			return fmt.Errorf("读取证书私钥失败: %w", err)
+		}
+		if certKey, err = parsePrivateKey(data); err != nil {
+			return fmt.Errorf("解析证书私钥失败: %w", err)
+		}
+		if client, err = newACMEClient(cert.ACMEDirectory); err != nil {
+			return err
+		}
+	}
+
+	err = client.RevokeCertificate(ctx, leaf.Raw, reason, certKey)
+	if acme.IsProblem(err, acme.ErrAlreadyRevoked) {
+		return nil
+	}
+	return err
+}
+
+// revokedCertificateSites 处理引用已撤销证书的站点，返回这些站点的域名
+// detach 为 false 时只记录提醒，站点配置保持不变，直到为其选择其他证书
+func revokedCertificateSites(ctx context.Context, cert *database.Certificate, detach bool) []string {
+	sites, err := sitesUsingCertificate(cert.ID)
+	if err != nil {
+		addCertificateLog(ctx, cert.ID, "revoke", err.Error())
+		return []string{}
+	}
+
+	if detach {
+		detachCertificateSites(ctx, cert, sites, "revoke", "已撤销")
+		return siteNames(sites)
+	}
+	for _, site := range sites {
+		addCertificateLog(ctx, cert.ID, "revoke",
+			fmt.Sprintf("站点 %s 仍在使用已撤销的证书，请为其更换证书", site.ServerName))
+	}
+	return siteNames(sites)
+}
@@ -1,25 +1,70 @@
 package ssl
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
+
+	"github.com/hop/backend/internal/acme"
+	"github.com/hop/backend/internal/config"
+	"github.com/hop/backend/internal/database"
+)
+
+// 续期重试和 ARI 查询间隔
+const (
+	retryBaseDelay   = time.Hour      // 首次失败后的重试间隔，之后每次失败翻倍
+	retryMaxDelay    = 24 * time.Hour // 重试间隔上限
+	ariMinRecheck    = time.Hour      // 两次查询续期窗口的最短间隔
+	ariMaxRecheck    = 24 * time.Hour // 两次查询续期窗口的最长间隔，CA 不支持 ARI 时也按此间隔重新确认
+	ariQueryTimeout  = 30 * time.Second
+	manualCheckDelay = 24 * time.Hour // 不会自动续期的证书每天提醒一次
+)
+
+// 续期时间的依据
+const (
+	RenewBasisARI      = "ari"      // CA 建议的续期窗口（ARI），在窗口内随机选取
+	RenewBasisCustom   = "custom"   // 证书设置的提前续期天数
+	RenewBasisLifetime = "lifetime" // 有效期较短的证书在剩余三分之一时续期
+	RenewBasisDefault  = "default"  // 默认提前天数
 )
 
-// Scheduler 定时任务调度器
+// scheduler 当前运行的调度器，供状态接口使用
+var scheduler *Scheduler
+
+// Scheduler 证书续期调度器
+// 定时检查每个证书的续期计划，到期的证书加入任务队列；失败后按指数退避重试
 type Scheduler struct {
-	email    string
 	days     int
 	interval time.Duration
 	stop     chan struct{}
+	trigger  chan struct{}
+
+	mu              sync.Mutex
+	running         bool
+	lastRunAt       *time.Time
+	nextRunAt       time.Time
+	lastQueued      int
+	lastManualCheck time.Time
 }
 
-// NewScheduler 创建调度器
-func NewScheduler(email string, days int, interval time.Duration) *Scheduler {
-	return &Scheduler{
-		email:    email,
+// NewScheduler 创建调度器，days 为默认提前续期天数，interval 为检查间隔
+func NewScheduler(days int, interval time.Duration) *Scheduler {
+	s := &Scheduler{
 		days:     days,
 		interval: interval,
 		stop:     make(chan struct{}),
+		trigger:  make(chan struct{}, 1),
 	}
+	scheduler = s
+	return s
 }
 
 // Start 启动定时任务
@@ -29,6 +74,8 @@ func (s *Scheduler) Start() {
 		"interval": s.interval.String(),
 	})
 
+	s.setNextRun()
+
 	// 立即执行一次检查
 	go s.check()
 
@@ -39,6 +86,9 @@ func (s *Scheduler) Start() {
 	for {
 		select {
 		case <-ticker.C:
+			s.setNextRun()
+			go s.check()
+		case <-s.trigger:
 			go s.check()
 		case <-s.stop:
 			log.Info("停止证书自动续期检查")
@@ -52,13 +102,434 @@ func (s *Scheduler) Stop() {
 	close(s.stop)
 }
 
-// check 执行检查
+// RunNow 立即执行一次检查，检查正在进行时返回 false
+func (s *Scheduler) RunNow() bool {
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+	if running {
+		return false
+	}
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (s *Scheduler) setNextRun() {
+	s.mu.Lock()
+	s.nextRunAt = time.Now().Add(s.interval)
+	s.mu.Unlock()
+}
+
+// check 执行检查，同一时间只执行一次
 func (s *Scheduler) check() {
-	log.Info("执行证书过期检查...")
-	CheckAndRenewCertificates(s.email, s.days)
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	now := time.Now()
+	s.lastRunAt = &now
+	manual := now.Sub(s.lastManualCheck) >= manualCheckDelay
+	if manual {
+		s.lastManualCheck = now
+	}
+	s.mu.Unlock()
+
+	log.Info("执行证书续期检查...")
+	queued := CheckAndRenewCertificates(s.days)
+	if manual {
+		CheckManualCertificates(s.days)
+	}
+
+	s.mu.Lock()
+	s.running = false
+	s.lastQueued = queued
+	s.mu.Unlock()
+}
+
+// defaultRenewDays 默认提前续期天数
+func defaultRenewDays() int {
+	if scheduler != nil {
+		return scheduler.days
+	}
+	return 30
 }
 
-// SetEmail 更新邮箱
-func (s *Scheduler) SetEmail(email string) {
-	s.email = email
+// CheckAndRenewCertificates 按续期计划把到期的证书加入任务队列，返回加入的数量
+// 续期使用各证书关联的 ACME 账户
+func CheckAndRenewCertificates(days int) int {
+	certs, err := database.ListAutoRenewCertificates()
+	if err != nil {
+		log.Error("获取自动续期的证书失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return 0
+	}
+	renewals, err := database.ListCertificateRenewals()
+	if err != nil {
+		log.Error("获取续期计划失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return 0
+	}
+
+	now := time.Now()
+	var due []database.Certificate
+	for _, cert := range certs {
+		r := renewals[cert.ID]
+		if r == nil {
+			r = &database.CertificateRenewal{CertificateID: cert.ID}
+		}
+		planRenewal(&cert, r, days, now)
+		if err := database.SaveCertificateRenewal(r); err != nil {
+			log.Error("保存续期计划失败", map[string]interface{}{
+				"domain": cert.Domain,
+				"error":  err.Error(),
+			})
+		}
+		if next := nextRenewalAttempt(r); next != nil && !next.After(now) {
+			due = append(due, cert)
+		}
+	}
+
+	if len(due) == 0 {
+		log.Info("没有需要续期的证书")
+		return 0
+	}
+
+	log.Info("发现需要续期的证书", map[string]interface{}{
+		"count": len(due),
+	})
+
+	// 加入任务队列依次续期，已在队列中的证书不会重复加入
+	queued := 0
+	for _, cert := range due {
+		if _, err := QueueRenew(cert.ID, ""); err != nil {
+			log.Error("证书续期任务创建失败", map[string]interface{}{
+				"domain": cert.Domain,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		queued++
+	}
+	return queued
+}
+
+// nextRenewalAttempt 下次尝试续期的时间：计划续期时间与失败重试时间中较晚的一个
+func nextRenewalAttempt(r *database.CertificateRenewal) *time.Time {
+	next := r.RenewAt
+	if next != nil && r.RetryAt != nil && r.RetryAt.After(*next) {
+		next = r.RetryAt
+	}
+	return next
+}
+
+// planRenewal 计算证书的续期时间
+//   - 设置了提前续期天数时按设置计算，提前天数不短于有效期时忽略该设置，避免签发后立即续期
+//   - 内部证书，以及有效期不足默认提前天数三倍的短期证书，在剩余三分之一时续期
+//   - ACME 证书优先使用 CA 建议的续期窗口（ARI），在窗口内随机选取时间，避免同时续期
+func planRenewal(cert *database.Certificate, r *database.CertificateRenewal, days int, now time.Time) {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	if custom := time.Duration(cert.RenewBefore) * 24 * time.Hour; custom > 0 && custom < lifetime {
+		at := cert.NotAfter.Add(-custom)
+		r.RenewAt, r.Basis, r.ARICheckAt, r.ExplanationURL = &at, RenewBasisCustom, nil, ""
+		return
+	}
+
+	// 默认提前天数，短期证书改为有效期的三分之一
+	before := time.Duration(days) * 24 * time.Hour
+	basis := RenewBasisDefault
+	if cert.Source == database.CertSourceInternal || lifetime/3 < before {
+		before, basis = lifetime/3, RenewBasisLifetime
+	}
+	fallback := cert.NotAfter.Add(-before)
+
+	if cert.Source != database.CertSourceACME {
+		r.RenewAt, r.Basis, r.ARICheckAt, r.ExplanationURL = &fallback, basis, nil, ""
+		return
+	}
+	if r.ARICheckAt != nil && now.Before(*r.ARICheckAt) && r.RenewAt != nil {
+		// 未到下次查询时间，沿用已有计划
+		return
+	}
+
+	info, err := queryRenewalInfo(cert)
+	if err != nil {
+		unsupported := errors.Is(err, acme.ErrRenewalInfoUnsupported)
+		if !unsupported {
+			log.Warn("查询续期窗口失败", map[string]interface{}{
+				"domain": cert.Domain,
+				"error":  err.Error(),
+			})
+		}
+		if !unsupported && r.Basis == RenewBasisARI && r.RenewAt != nil {
+			// 临时错误时保留 CA 上次建议的时间，稍后再查询
+			recheck := now.Add(ariMinRecheck)
+			r.ARICheckAt = &recheck
+			return
+		}
+		recheck := now.Add(ariMaxRecheck)
+		r.RenewAt, r.Basis, r.ARICheckAt, r.ExplanationURL = &fallback, basis, &recheck, ""
+		return
+	}
+	applyRenewalInfo(cert, r, info, now)
+}
+
+// applyRenewalInfo 按 CA 建议的续期窗口更新续期计划
+// 已有计划仍在窗口内时保留，否则在窗口内随机选取；窗口已过时立即续期
+func applyRenewalInfo(cert *database.Certificate, r *database.CertificateRenewal, info *acme.RenewalInfo, now time.Time) {
+	start, end := info.SuggestedWindow.Start, info.SuggestedWindow.End
+	if r.Basis != RenewBasisARI || r.RenewAt == nil || r.RenewAt.Before(start) || r.RenewAt.After(end) {
+		// 窗口变化时重新选取；窗口已过（例如证书将被吊销）时立即续期
+		at := start
+		if span := end.Sub(start); span > 0 {
+			at = start.Add(time.Duration(rand.Int64N(int64(span))))
+		}
+		if end.Before(now) {
+			at = now
+		}
+		r.RenewAt = &at
+		if info.ExplanationURL != "" {
+			log.Info("CA 调整了证书的续期窗口", map[string]interface{}{
+				"domain":      cert.Domain,
+				"start":       start.Format(time.RFC3339),
+				"end":         end.Format(time.RFC3339),
+				"explanation": info.ExplanationURL,
+			})
+		}
+	}
+	recheck := now.Add(min(max(info.RetryAfter, ariMinRecheck), ariMaxRecheck))
+	r.Basis, r.ARICheckAt, r.ExplanationURL = RenewBasisARI, &recheck, info.ExplanationURL
+}
+
+// queryRenewalInfo 向签发证书的 CA 查询续期窗口
+func queryRenewalInfo(cert *database.Certificate) (*acme.RenewalInfo, error) {
+	leaf, err := loadCertificate(cert.CertPath)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newACMEClient(cert.ACMEDirectory)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ariQueryTimeout)
+	defer cancel()
+	return client.GetRenewalInfo(ctx, leaf)
+}
+
+// loadCertificate 读取 data 目录下证书文件中的第一张证书
+func loadCertificate(relPath string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(filepath.Join(config.Get().Data.Dir, relPath))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("无法解析 PEM 格式")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// replacesID 续期时被替换证书的 ARI 标识，无法读取时返回空
+func replacesID(relPath string) string {
+	if relPath == "" {
+		return ""
+	}
+	leaf, err := loadCertificate(relPath)
+	if err != nil {
+		return ""
+	}
+	id, err := acme.RenewalCertID(leaf)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// retryDelay 第 n 次失败后的重试间隔：指数增长，取上半区间的随机值
+func retryDelay(failures int) time.Duration {
+	d := retryMaxDelay
+	if failures < 10 {
+		d = min(retryBaseDelay<<(failures-1), retryMaxDelay)
+	}
+	return d/2 + time.Duration(rand.Int64N(int64(d/2)))
+}
+
+// recordRenewalResult 记录续期结果：成功时重新计算续期时间，失败时安排重试
+// 读取计划失败时从空计划开始，保存时会创建或覆盖该行，避免失败次数和退避丢失
+func recordRenewalResult(certID string, renewErr error) {
+	r, err := database.GetCertificateRenewal(certID)
+	if err != nil {
+		log.Error("读取续期计划失败", map[string]interface{}{
+			"certificateId": certID,
+			"error":         err.Error(),
+		})
+		r = &database.CertificateRenewal{CertificateID: certID}
+	}
+
+	now := time.Now()
+	r.LastAttemptAt = &now
+	if renewErr != nil {
+		r.Failures++
+		retry := now.Add(retryDelay(r.Failures))
+		r.RetryAt = &retry
+		r.LastError = issueErrorSummary(renewErr)
+		log.Warn("证书续期失败，稍后重试", map[string]interface{}{
+			"certificateId": certID,
+			"failures":      r.Failures,
+			"retryAt":       retry.Format(time.RFC3339),
+		})
+	} else {
+		r.Failures = 0
+		r.RetryAt = nil
+		r.LastError = ""
+		// 新证书需要重新查询续期窗口
+		r.RenewAt = nil
+		r.ARICheckAt = nil
+		if cert, err := database.GetCertificate(certID); err == nil {
+			planRenewal(cert, r, defaultRenewDays(), now)
+		}
+	}
+	if err := database.SaveCertificateRenewal(r); err != nil {
+		log.Error("保存续期计划失败", map[string]interface{}{
+			"certificateId": certID,
+			"error":         err.Error(),
+		})
+	}
+}
+
+// refreshRenewalPlan 证书设置变化后立即重新计算续期时间
+func refreshRenewalPlan(cert *database.Certificate) {
+	r, err := database.GetCertificateRenewal(cert.ID)
+	if err != nil {
+		return
+	}
+	r.ARICheckAt = nil
+	planRenewal(cert, r, defaultRenewDays(), time.Now())
+	database.SaveCertificateRenewal(r)
+}
+
+// SchedulerStatusResponse 调度器状态
+type SchedulerStatusResponse struct {
+	Running         bool                    `json:"running"`
+	Interval        string                  `json:"interval"`
+	RenewBeforeDays int                     `json:"renewBeforeDays"` // 默认提前续期天数
+	LastRunAt       *string                 `json:"lastRunAt"`
+	NextRunAt       *string                 `json:"nextRunAt"`
+	LastQueued      int                     `json:"lastQueued"` // 上次检查加入队列的证书数量
+	Certificates    []RenewalStatusResponse `json:"certificates"`
+}
+
+// RenewalStatusResponse 单个证书的续期计划
+type RenewalStatusResponse struct {
+	ID             string  `json:"id"`
+	Domain         string  `json:"domain"`
+	Source         string  `json:"source"`
+	AutoRenew      bool    `json:"autoRenew"`
+	Status         string  `json:"status"`
+	NotAfter       string  `json:"notAfter"`
+	RenewBefore    int     `json:"renewBefore"`
+	RenewAt        *string `json:"renewAt"`
+	Basis          string  `json:"basis"`
+	ARICheckAt     *string `json:"ariCheckAt"`
+	ExplanationURL string  `json:"explanationUrl"`
+	Failures       int     `json:"failures"`
+	RetryAt        *string `json:"retryAt"`
+	NextAttemptAt  *string `json:"nextAttemptAt"` // 下次尝试续期的时间，未开启自动续期时为空
+	LastAttemptAt  *string `json:"lastAttemptAt"`
+	LastError      string  `json:"lastError"`
+}
+
+// handleGetScheduler 获取调度器状态和每个证书的续期计划
+func handleGetScheduler(w http.ResponseWriter, r *http.Request) {
+	if scheduler == nil {
+		jsonError(w, "续期调度器未启动", http.StatusServiceUnavailable)
+		return
+	}
+
+	certs, err := database.ListCertificates()
+	if err != nil {
+		jsonError(w, "获取证书列表失败", http.StatusInternalServerError)
+		return
+	}
+	renewals, err := database.ListCertificateRenewals()
+	if err != nil {
+		jsonError(w, "获取续期计划失败", http.StatusInternalServerError)
+		return
+	}
+
+	scheduler.mu.Lock()
+	resp := SchedulerStatusResponse{
+		Running:         scheduler.running,
+		Interval:        scheduler.interval.String(),
+		RenewBeforeDays: scheduler.days,
+		LastRunAt:       formatTimePtr(scheduler.lastRunAt),
+		LastQueued:      scheduler.lastQueued,
+	}
+	if !scheduler.nextRunAt.IsZero() {
+		resp.NextRunAt = formatTimePtr(&scheduler.nextRunAt)
+	}
+	scheduler.mu.Unlock()
+
+	resp.Certificates = make([]RenewalStatusResponse, 0, len(certs))
+	for _, cert := range certs {
+		// 上传的证书不会自动续期
+		if cert.Source == database.CertSourceUpload {
+			continue
+		}
+		item := RenewalStatusResponse{
+			ID:          cert.ID,
+			Domain:      cert.Domain,
+			Source:      cert.Source,
+			AutoRenew:   cert.AutoRenew,
+			Status:      cert.Status,
+			NotAfter:    cert.NotAfter.Format("2006-01-02T15:04:05Z07:00"),
+			RenewBefore: cert.RenewBefore,
+		}
+		if rn := renewals[cert.ID]; rn != nil {
+			item.RenewAt = formatTimePtr(rn.RenewAt)
+			item.Basis = rn.Basis
+			item.ARICheckAt = formatTimePtr(rn.ARICheckAt)
+			item.ExplanationURL = rn.ExplanationURL
+			item.Failures = rn.Failures
+			item.RetryAt = formatTimePtr(rn.RetryAt)
+			item.LastAttemptAt = formatTimePtr(rn.LastAttemptAt)
+			item.LastError = rn.LastError
+			if cert.AutoRenew {
+				item.NextAttemptAt = formatTimePtr(nextRenewalAttempt(rn))
+			}
+		}
+		resp.Certificates = append(resp.Certificates, item)
+	}
+
+	jsonResponse(w, resp)
+}
+
+// handleRunScheduler 立即执行一次续期检查
+func handleRunScheduler(w http.ResponseWriter, r *http.Request) {
+	if scheduler == nil {
+		jsonError(w, "续期调度器未启动", http.StatusServiceUnavailable)
+		return
+	}
+	if !scheduler.RunNow() {
+		jsonError(w, "检查正在进行中", http.StatusConflict)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+	})
+}
+
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format("2006-01-02T15:04:05Z07:00")
+	return &s
 }
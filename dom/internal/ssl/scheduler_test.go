@@ -0,0 +1,257 @@
+package ssl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hop/backend/internal/acme"
+	"github.com/hop/backend/internal/database"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for _, tc := range []struct {
+		failures int
+		max      time.Duration
+	}{
+		{1, time.Hour},
+		{2, 2 * time.Hour},
+		{3, 4 * time.Hour},
+		{5, 16 * time.Hour},
+		{6, retryMaxDelay}, // 32 小时超过上限
+		{9, retryMaxDelay},
+		{10, retryMaxDelay},
+		{64, retryMaxDelay}, // 移位溢出前已按上限处理
+	} {
+		// 随机值落在上半区间 [max/2, max)
+		for i := 0; i < 200; i++ {
+			d := retryDelay(tc.failures)
+			if d < tc.max/2 || d >= tc.max {
+				t.Fatalf("retryDelay(%d) = %s, want [%s, %s)", tc.failures, d, tc.max/2, tc.max)
+			}
+		}
+	}
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	// 同时失败的证书不应在同一时间重试
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		seen[retryDelay(3)] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("50 次只产生了 %d 个不同的重试间隔", len(seen))
+	}
+}
+
+// testCertificate 指定来源和有效期的证书，证书文件不存在
+func testCertificate(source string, notBefore time.Time, lifetime time.Duration, renewBefore int) *database.Certificate {
+	return &database.Certificate{
+		Domain:      "example.com",
+		Source:      source,
+		CertPath:    "nginx/ssl/missing.example.com.crt",
+		NotBefore:   notBefore,
+		NotAfter:    notBefore.Add(lifetime),
+		RenewBefore: renewBefore,
+	}
+}
+
+func TestPlanRenewal(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	for _, tc := range []struct {
+		name        string
+		cert        *database.Certificate
+		renewAt     time.Time
+		basis       string
+		ariRecheck  bool
+		explanation string
+	}{
+		{
+			name:    "自定义提前天数",
+			cert:    testCertificate(database.CertSourceACME, now, 90*day, 20),
+			renewAt: now.Add(70 * day),
+			basis:   RenewBasisCustom,
+		},
+		{
+			name:    "上传证书使用默认提前天数",
+			cert:    testCertificate(database.CertSourceUpload, now, 90*day, 0),
+			renewAt: now.Add(60 * day),
+			basis:   RenewBasisDefault,
+		},
+		{
+			name:    "短期证书在剩余三分之一时续期",
+			cert:    testCertificate(database.CertSourceUpload, now, 30*day, 0),
+			renewAt: now.Add(20 * day),
+			basis:   RenewBasisLifetime,
+		},
+		{
+			name:    "内部证书总是按有效期计算",
+			cert:    testCertificate(database.CertSourceInternal, now, 365*day, 0),
+			renewAt: now.Add(365 * day * 2 / 3),
+			basis:   RenewBasisLifetime,
+		},
+		{
+			// 提前天数超过有效期时会在签发后立即续期，改为按有效期计算
+			name:    "自定义提前天数超过有效期",
+			cert:    testCertificate(database.CertSourceInternal, now, 6*day, 10),
+			renewAt: now.Add(4 * day),
+			basis:   RenewBasisLifetime,
+		},
+		{
+			name:    "自定义提前天数等于有效期",
+			cert:    testCertificate(database.CertSourceUpload, now, 90*day, 90),
+			renewAt: now.Add(60 * day),
+			basis:   RenewBasisDefault,
+		},
+		{
+			// 无法读取证书查询续期窗口时使用默认提前天数，并在一天后重新查询
+			name:       "ACME 证书自定义提前天数超过有效期",
+			cert:       testCertificate(database.CertSourceACME, now, 90*day, 120),
+			renewAt:    now.Add(60 * day),
+			basis:      RenewBasisDefault,
+			ariRecheck: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &database.CertificateRenewal{ExplanationURL: "https://example.com/old"}
+			planRenewal(tc.cert, r, 30, now)
+
+			if r.RenewAt == nil || !r.RenewAt.Equal(tc.renewAt) {
+				t.Errorf("RenewAt = %v, want %s", r.RenewAt, tc.renewAt)
+			}
+			if r.Basis != tc.basis {
+				t.Errorf("Basis = %s, want %s", r.Basis, tc.basis)
+			}
+			if tc.ariRecheck {
+				if r.ARICheckAt == nil || !r.ARICheckAt.Equal(now.Add(ariMaxRecheck)) {
+					t.Errorf("ARICheckAt = %v", r.ARICheckAt)
+				}
+			} else if r.ARICheckAt != nil {
+				t.Errorf("ARICheckAt = %v, want nil", r.ARICheckAt)
+			}
+			if r.ExplanationURL != "" {
+				t.Errorf("ExplanationURL = %s", r.ExplanationURL)
+			}
+		})
+	}
+}
+
+func TestPlanRenewalKeepsPlanUntilRecheck(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	renewAt := now.Add(40 * 24 * time.Hour)
+	recheck := now.Add(time.Hour)
+	r := &database.CertificateRenewal{RenewAt: &renewAt, Basis: RenewBasisARI, ARICheckAt: &recheck}
+
+	// 未到下次查询时间，不查询 CA，沿用已有计划
+	planRenewal(testCertificate(database.CertSourceACME, now, 90*24*time.Hour, 0), r, 30, now)
+	if !r.RenewAt.Equal(renewAt) || r.Basis != RenewBasisARI || !r.ARICheckAt.Equal(recheck) {
+		t.Errorf("计划被修改: %+v", r)
+	}
+}
+
+// renewalInfo 构造 CA 建议的续期窗口
+func renewalInfo(start, end time.Time, retryAfter time.Duration) *acme.RenewalInfo {
+	info := &acme.RenewalInfo{RetryAfter: retryAfter}
+	info.SuggestedWindow.Start = start
+	info.SuggestedWindow.End = end
+	return info
+}
+
+func TestApplyRenewalInfo(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	cert := testCertificate(database.CertSourceACME, now.Add(-30*day), 90*day, 0)
+	start, end := now.Add(30*day), now.Add(32*day)
+	inside := now.Add(31 * day)
+	outside := now.Add(50 * day)
+
+	for _, tc := range []struct {
+		name       string
+		previous   database.CertificateRenewal
+		info       *acme.RenewalInfo
+		exact      *time.Time // 期望的续期时间，为空时只要求落在窗口内
+		ariRecheck time.Duration
+	}{
+		{
+			name:       "窗口在未来时在窗口内随机选取",
+			info:       renewalInfo(start, end, 6*time.Hour),
+			ariRecheck: 6 * time.Hour,
+		},
+		{
+			name:       "已有计划在窗口内时保留",
+			previous:   database.CertificateRenewal{RenewAt: &inside, Basis: RenewBasisARI},
+			info:       renewalInfo(start, end, 0),
+			exact:      &inside,
+			ariRecheck: ariMinRecheck, // Retry-After 过短时使用最短间隔
+		},
+		{
+			name:       "窗口移动后重新选取",
+			previous:   database.CertificateRenewal{RenewAt: &outside, Basis: RenewBasisARI},
+			info:       renewalInfo(start, end, 72*time.Hour),
+			ariRecheck: ariMaxRecheck, // Retry-After 过长时使用最长间隔
+		},
+		{
+			name:       "之前按默认天数计算时改用窗口",
+			previous:   database.CertificateRenewal{RenewAt: &inside, Basis: RenewBasisDefault},
+			info:       renewalInfo(start, end, time.Hour),
+			ariRecheck: time.Hour,
+		},
+		{
+			// 例如证书将被吊销，CA 把窗口提前到过去
+			name:       "窗口已过时立即续期",
+			info:       renewalInfo(now.Add(-2*day), now.Add(-day), time.Hour),
+			exact:      &now,
+			ariRecheck: time.Hour,
+		},
+		{
+			name:       "窗口起止相同",
+			info:       renewalInfo(start, start, time.Hour),
+			exact:      &start,
+			ariRecheck: time.Hour,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tc.previous
+			tc.info.ExplanationURL = "https://ca.example.com/why"
+			applyRenewalInfo(cert, &r, tc.info, now)
+
+			if r.RenewAt == nil {
+				t.Fatal("RenewAt = nil")
+			}
+			if tc.exact != nil {
+				if !r.RenewAt.Equal(*tc.exact) {
+					t.Errorf("RenewAt = %s, want %s", r.RenewAt, tc.exact)
+				}
+			} else if r.RenewAt.Before(tc.info.SuggestedWindow.Start) || !r.RenewAt.Before(tc.info.SuggestedWindow.End) {
+				t.Errorf("RenewAt = %s, 不在窗口 [%s, %s) 内", r.RenewAt, tc.info.SuggestedWindow.Start, tc.info.SuggestedWindow.End)
+			}
+			if r.Basis != RenewBasisARI {
+				t.Errorf("Basis = %s", r.Basis)
+			}
+			if r.ARICheckAt == nil || !r.ARICheckAt.Equal(now.Add(tc.ariRecheck)) {
+				t.Errorf("ARICheckAt = %v, want %s", r.ARICheckAt, now.Add(tc.ariRecheck))
+			}
+			if r.ExplanationURL != "https://ca.example.com/why" {
+				t.Errorf("ExplanationURL = %s", r.ExplanationURL)
+			}
+		})
+	}
+}
+
+func TestApplyRenewalInfoJitter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	cert := testCertificate(database.CertSourceACME, now, 90*24*time.Hour, 0)
+	info := renewalInfo(now.Add(24*time.Hour), now.Add(48*time.Hour), time.Hour)
+
+	// 同一窗口内的多张证书不应选在同一时间
+	seen := map[time.Time]bool{}
+	for i := 0; i < 50; i++ {
+		r := &database.CertificateRenewal{}
+		applyRenewalInfo(cert, r, info, now)
+		seen[*r.RenewAt] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("50 次只产生了 %d 个不同的续期时间", len(seen))
+	}
+}
@@ -1,13 +1,12 @@
 package ssl
 
 import (
-	"bytes"
+	"context"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -15,484 +14,304 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/hop/backend/internal/acme"
 	"github.com/hop/backend/internal/config"
 	"github.com/hop/backend/internal/database"
 	"github.com/hop/backend/internal/logger"
-	"github.com/hop/backend/internal/nginx"
+	"github.com/hop/backend/internal/notify"
 )
 
 var log = logger.WithTag("ssl")
 var renewMutex sync.Mutex
 
-// DNSProviderType DNS 提供商类型
-type DNSProviderType string
-
-const (
-	DNSProviderAliDNS       DNSProviderType = "alidns"
-	DNSProviderTencentCloud DNSProviderType = "tencentcloud"
-	DNSProviderCloudflare   DNSProviderType = "cloudflare"
-)
-
-// DNSProviderConfig DNS 提供商配置接口
-type DNSProviderConfig interface {
-	GetEnvVars() map[string]string
-	GetProviderName() string
-}
-
-// AliDNSConfig 阿里云 DNS 配置
-type AliDNSConfig struct {
-	AccessKeyID     string `json:"accessKeyId"`
-	AccessKeySecret string `json:"accessKeySecret"`
-	RegionID        string `json:"regionId,omitempty"` // 可选，默认 cn-hangzhou
+// IssueOptions 证书申请参数
+type IssueOptions struct {
+	Domains       []string
+	ChallengeType string // dns-01（默认）或 http-01
+	DNSProviderID string // dns-01 验证时必填
+	AccountID     string // 使用已有的 ACME 账户，指定时忽略 Email、Directory 和 EAB
+	Email         string // 账户联系邮箱，该 CA 下没有此邮箱的账户时自动注册
+	Directory     string // ACME 目录地址或预设名称，为空使用默认目录
+	EABKID        string // External Account Binding，CA 要求时填写
+	EABHMACKey    string
+	KeyType       string // 私钥算法，为空使用 ECDSA P-256
+	ReuseKey      bool   // 续期时复用私钥
+	Dual          bool   // 同时签发 RSA 证书
+	Replace       string // 可选，签发成功后替换的证书 ID，引用它的站点改用新证书
 }
 
-func (c *AliDNSConfig) GetEnvVars() map[string]string {
-	env := map[string]string{
-		"ALICLOUD_ACCESS_KEY": c.AccessKeyID,
-		"ALICLOUD_SECRET_KEY": c.AccessKeySecret,
-	}
-	if c.RegionID != "" {
-		env["ALICLOUD_REGION_ID"] = c.RegionID
+// IssueCertificate 申请新证书
+// 通常通过 QueueIssue 在任务中执行，ctx 中的任务用于记录申请进度
+func IssueCertificate(ctx context.Context, opts IssueOptions) (*database.Certificate, error) {
+	cert, deploy, err := issueCertificate(ctx, opts)
+	if err != nil {
+		return nil, err
 	}
-	return env
-}
-
-func (c *AliDNSConfig) GetProviderName() string {
-	return "alidns"
+	deploy.run(ctx)
+	return cert, nil
 }
 
-// TencentCloudConfig 腾讯云 DNS 配置
-type TencentCloudConfig struct {
-	SecretID  string `json:"secretId"`
-	SecretKey string `json:"secretKey"`
-}
+// issueCertificate 持有 renewMutex 申请证书，返回释放锁后需要执行的部署钩子
+func issueCertificate(ctx context.Context, opts IssueOptions) (*database.Certificate, *deployment, error) {
+	renewMutex.Lock()
+	defer renewMutex.Unlock()
 
-func (c *TencentCloudConfig) GetEnvVars() map[string]string {
-	return map[string]string{
-		"TENCENTCLOUD_SECRET_ID":  c.SecretID,
-		"TENCENTCLOUD_SECRET_KEY": c.SecretKey,
+	domains := opts.Domains
+	if len(domains) == 0 {
+		return nil, nil, fmt.Errorf("至少需要一个域名")
 	}
-}
-
-func (c *TencentCloudConfig) GetProviderName() string {
-	return "tencentcloud"
-}
-
-// CloudflareConfig Cloudflare DNS 配置
-type CloudflareConfig struct {
-	APIToken string `json:"apiToken,omitempty"` // 推荐使用 API Token
-	Email    string `json:"email,omitempty"`    // 可选，使用 Global API Key 时需要
-	APIKey   string `json:"apiKey,omitempty"`   // Global API Key (不推荐)
-}
-
-func (c *CloudflareConfig) GetEnvVars() map[string]string {
-	env := map[string]string{}
-	if c.APIToken != "" {
-		env["CF_DNS_API_TOKEN"] = c.APIToken
-	} else if c.APIKey != "" && c.Email != "" {
-		env["CF_API_EMAIL"] = c.Email
-		env["CF_API_KEY"] = c.APIKey
+	if opts.ChallengeType == "" {
+		opts.ChallengeType = database.ChallengeDNS01
 	}
-	return env
-}
 
-func (c *CloudflareConfig) GetProviderName() string {
-	return "cloudflare"
-}
-
-// ParseDNSProviderConfig 解析 DNS 提供商配置
-func ParseDNSProviderConfig(providerType string, configJSON string) (DNSProviderConfig, error) {
-	switch DNSProviderType(providerType) {
-	case DNSProviderAliDNS:
-		var cfg AliDNSConfig
-		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
-			return nil, fmt.Errorf("解析阿里云配置失败: %w", err)
+	var directory string
+	var account *database.ACMEAccount
+	if opts.AccountID != "" {
+		var err error
+		if account, err = database.GetACMEAccount(opts.AccountID); err != nil {
+			return nil, nil, fmt.Errorf("ACME 账户不存在")
 		}
-		return &cfg, nil
-	case DNSProviderTencentCloud:
-		var cfg TencentCloudConfig
-		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
-			return nil, fmt.Errorf("解析腾讯云配置失败: %w", err)
+		directory = account.Directory
+	} else {
+		var err error
+		if directory, err = resolveDirectory(opts.Directory); err != nil {
+			return nil, nil, err
 		}
-		return &cfg, nil
-	case DNSProviderCloudflare:
-		var cfg CloudflareConfig
-		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
-			return nil, fmt.Errorf("解析 Cloudflare 配置失败: %w", err)
+		if (opts.EABKID == "") != (opts.EABHMACKey == "") {
+			return nil, nil, fmt.Errorf("EAB key ID 和 HMAC 密钥需要同时填写")
 		}
-		return &cfg, nil
-	default:
-		return nil, fmt.Errorf("不支持的 DNS 提供商类型: %s", providerType)
 	}
-}
-
-// IssueCertificate 申请新证书
-func IssueCertificate(domains []string, dnsProviderID string, email string) (*database.Certificate, error) {
-	renewMutex.Lock()
-	defer renewMutex.Unlock()
 
-	if len(domains) == 0 {
-		return nil, fmt.Errorf("至少需要一个域名")
-	}
-
-	// 获取 DNS 提供商配置
-	provider, err := database.GetDNSProvider(dnsProviderID)
-	if err != nil {
-		return nil, fmt.Errorf("获取 DNS 提供商失败: %w", err)
+	if err := ValidateKeyOptions(opts.KeyType, opts.Dual); err != nil {
+		return nil, nil, err
 	}
-
-	providerConfig, err := ParseDNSProviderConfig(provider.Type, provider.Config)
-	if err != nil {
-		return nil, err
+	if opts.KeyType == "" {
+		opts.KeyType = defaultKeyType
 	}
 
-	cfg := config.Get()
-	paths := nginx.GetNginxPaths()
-	sslDir := paths.SSLDir
-
-	// 确保 SSL 目录存在
-	if err := os.MkdirAll(sslDir, 0755); err != nil {
-		return nil, fmt.Errorf("创建 SSL 目录失败: %w", err)
+	var replaced *database.Certificate
+	if opts.Replace != "" {
+		var err error
+		if replaced, err = replaceableCertificate(opts.Replace, database.CertUsageServer); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	// 创建 lego 工作目录
-	legoPath := filepath.Join(cfg.Data.Dir, "lego")
-	if err := os.MkdirAll(legoPath, 0755); err != nil {
-		return nil, fmt.Errorf("创建 lego 目录失败: %w", err)
+	solver, err := challengeSolverFor(opts.ChallengeType, opts.DNSProviderID, domains)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// 主域名
 	mainDomain := domains[0]
 
-	// 构建 lego 命令
-	args := []string{
-		"--accept-tos",
-		"--email", email,
-		"--dns", providerConfig.GetProviderName(),
-		"--path", legoPath,
-		"--dns.disable-cp", // 禁用证书传播检查，避免 DNS 传播延迟问题
-	}
-
-	// 添加所有域名
-	for _, domain := range domains {
-		args = append(args, "--domains", domain)
+	// 同一主域名可以有多个证书（例如不同的 CA 或私钥算法），各自使用独立的文件
+	cert := &database.Certificate{
+		ID:            uuid.New().String(),
+		Domain:        mainDomain,
+		DNSProviderID: opts.DNSProviderID,
+		ChallengeType: opts.ChallengeType,
+		ACMEDirectory: directory,
+		EABKID:        opts.EABKID,
+		EABHMACKey:    opts.EABHMACKey,
+		KeyType:       opts.KeyType,
+		ReuseKey:      opts.ReuseKey,
+		Dual:          opts.Dual,
+		AutoRenew:     true,
+		Status:        "active",
 	}
-
-	args = append(args, "run")
-
-	// 设置环境变量
-	env := os.Environ()
-	for k, v := range providerConfig.GetEnvVars() {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	cert.CertPath, cert.KeyPath = certificatePaths(mainDomain, cert.ID)
+	if cert.Dual {
+		cert.RSACertPath, cert.RSAKeyPath = rsaCertificatePaths(cert.CertPath)
 	}
+	setJobCertificate(ctx, cert.ID)
 
 	log.Info("开始申请证书", map[string]interface{}{
-		"domains":  domains,
-		"provider": provider.Type,
+		"domains":   domains,
+		"challenge": opts.ChallengeType,
+		"directory": directory,
 	})
 
-	// 检查是否已存在该域名的证书（避免重复申请）
-	existingCert, _ := database.GetCertificateByDomain(mainDomain)
-	if existingCert != nil {
-		log.Info("域名证书已存在，将先删除旧记录", map[string]interface{}{
-			"domain": mainDomain,
-		})
-		// 删除数据库中的旧证书记录
-		database.DeleteCertificate(existingCert.ID)
-
-		// 删除旧的 lego 数据
-		// 注意：通配符域名 *.example.com 在文件名中会变成 _.example.com
-		certDomain := strings.Replace(mainDomain, "*", "_", -1)
-		oldCertDir := filepath.Join(legoPath, "certificates")
-		oldCertFile := filepath.Join(oldCertDir, certDomain+".crt")
-		oldKeyFile := filepath.Join(oldCertDir, certDomain+".key")
-		oldJsonFile := filepath.Join(oldCertDir, certDomain+".json")
-		oldIssuerFile := filepath.Join(oldCertDir, certDomain+".issuer.crt")
-		os.Remove(oldCertFile)
-		os.Remove(oldKeyFile)
-		os.Remove(oldJsonFile)
-		os.Remove(oldIssuerFile)
-	}
-
-	// 执行 lego 命令
-	cmd := exec.Command("lego", args...)
-	cmd.Env = env
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		errMsg := stderr.String()
-		if errMsg == "" {
-			errMsg = stdout.String()
-		}
-		log.Error("申请证书失败", map[string]interface{}{
-			"error":  err.Error(),
-			"stderr": errMsg,
-			"stdout": stdout.String(),
-		})
-
-		// 检查是否是 DNS 记录已存在的错误
-		if strings.Contains(errMsg, "already exists") || strings.Contains(errMsg, "已存在") {
-			return nil, fmt.Errorf("DNS 验证记录已存在，请稍后重试或手动清理 DNS 记录后再试")
-		}
-
-		// 检查是否是权限问题
-		if strings.Contains(errMsg, "authentication") || strings.Contains(errMsg, "unauthorized") {
-			return nil, fmt.Errorf("DNS API 认证失败，请检查 API 密钥是否正确")
-		}
+	ctx, cancel := context.WithTimeout(ctx, issueTimeout)
+	defer cancel()
 
-		// 检查是否是速率限制
-		if strings.Contains(errMsg, "rate limit") || strings.Contains(errMsg, "too many") {
-			return nil, fmt.Errorf("触发 Let's Encrypt 速率限制，请稍后再试")
+	if account == nil {
+		reportProgress(ctx, StageAccount, "查找或注册 %s 的 ACME 账户: %s", opts.Email, directory)
+		account, err = ensureAccount(ctx, directory, opts.Email, eabOf(opts.EABKID, opts.EABHMACKey))
+		if err != nil {
+			err = &IssueError{Stage: StageAccount, Domain: mainDomain, Err: err}
+			addCertificateLog(ctx, cert.ID, "error", fmt.Sprintf("申请失败: %s", err.Error()))
+			return nil, nil, err
 		}
-
-		return nil, fmt.Errorf("申请证书失败: %s", errMsg)
-	}
-
-	log.Info("证书申请成功", map[string]interface{}{
-		"domains": domains,
-		"output":  stdout.String(),
-	})
-
-	// 查找生成的证书文件
-	// 注意：通配符域名 *.example.com 在文件名中会变成 _.example.com
-	certDomain := strings.Replace(mainDomain, "*", "_", -1)
-	certDir := filepath.Join(legoPath, "certificates")
-	certFile := filepath.Join(certDir, certDomain+".crt")
-	keyFile := filepath.Join(certDir, certDomain+".key")
-
-	log.Info("查找证书文件", map[string]interface{}{
-		"certFile": certFile,
-		"keyFile":  keyFile,
-	})
-
-	// 检查文件是否存在
-	if _, err := os.Stat(certFile); os.IsNotExist(err) {
-		return nil, fmt.Errorf("证书文件未生成: %s", certFile)
-	}
-	if _, err := os.Stat(keyFile); os.IsNotExist(err) {
-		return nil, fmt.Errorf("私钥文件未生成: %s", keyFile)
 	}
+	reportProgress(ctx, StageAccount, "使用 ACME 账户 %s", account.Email)
 
-	// 复制证书到 SSL 目录
-	destCertFile := filepath.Join(sslDir, certDomain+".crt")
-	destKeyFile := filepath.Join(sslDir, certDomain+".key")
-
-	log.Info("复制证书到 SSL 目录", map[string]interface{}{
-		"destCertFile": destCertFile,
-		"destKeyFile":  destKeyFile,
-	})
-
-	if err := copyFile(certFile, destCertFile); err != nil {
-		return nil, fmt.Errorf("复制证书文件失败: %w", err)
-	}
-	if err := copyFile(keyFile, destKeyFile); err != nil {
-		return nil, fmt.Errorf("复制私钥文件失败: %w", err)
-	}
-
-	// 解析证书信息
-	certInfo, err := ParseCertificate(destCertFile)
+	issued, err := obtainCertificates(ctx, domains, solver, account, cert, false)
 	if err != nil {
-		return nil, fmt.Errorf("解析证书失败: %w", err)
+		log.Error("申请证书失败", map[string]interface{}{
+			"domains": domains,
+			"error":   err.Error(),
+		})
+		addCertificateLog(ctx, cert.ID, "error", fmt.Sprintf("申请失败: %s", err.Error()))
+		return nil, nil, err
 	}
 
-	// 计算相对于 data 目录的路径
-	// 例如: /path/to/data/nginx/ssl/example.com.crt -> nginx/ssl/example.com.crt
-	relCertPath, err := filepath.Rel(cfg.Data.Dir, destCertFile)
+	certInfo, err := installCertificates(ctx, cert, issued)
 	if err != nil {
-		relCertPath = destCertFile // 如果失败，回退使用绝对路径
-	}
-	relKeyPath, err := filepath.Rel(cfg.Data.Dir, destKeyFile)
-	if err != nil {
-		relKeyPath = destKeyFile
+		err = &IssueError{Stage: StageSave, Domain: mainDomain, Err: err}
+		addCertificateLog(ctx, cert.ID, "error", fmt.Sprintf("申请失败: %s", err.Error()))
+		return nil, nil, err
 	}
 
+	log.Info("证书申请成功", map[string]interface{}{
+		"domains":  domains,
+		"notAfter": certInfo.NotAfter.Format("2006-01-02"),
+	})
+
 	// 创建证书记录
 	domainsJSON, _ := json.Marshal(domains)
 	now := time.Now()
 
-	cert := &database.Certificate{
-		ID:            uuid.New().String(),
-		Domain:        mainDomain,
-		Domains:       string(domainsJSON),
-		DNSProviderID: dnsProviderID,
-		CertPath:      relCertPath,
-		KeyPath:       relKeyPath,
-		Issuer:        certInfo.Issuer,
-		NotBefore:     certInfo.NotBefore,
-		NotAfter:      certInfo.NotAfter,
-		AutoRenew:     true,
-		LastRenewAt:   &now,
-		Status:        "active",
+	cert.Domains = string(domainsJSON)
+	cert.AccountID = account.ID
+	cert.Issuer = certInfo.Issuer
+	cert.NotBefore = certInfo.NotBefore
+	cert.NotAfter = certInfo.NotAfter
+	cert.LastRenewAt = &now
+	if replaced != nil {
+		cert.RenewBefore = replaced.RenewBefore
 	}
 
 	if err := database.CreateCertificate(cert); err != nil {
-		return nil, fmt.Errorf("保存证书记录失败: %w", err)
+		return nil, nil, &IssueError{Stage: StageSave, Domain: mainDomain, Err: fmt.Errorf("保存证书记录失败: %w", err)}
 	}
 
 	// 记录日志
-	logEntry := &database.CertificateLog{
-		ID:            uuid.New().String(),
-		CertificateID: cert.ID,
-		Action:        "create",
-		Message:       fmt.Sprintf("成功申请证书，有效期至 %s", cert.NotAfter.Format("2006-01-02")),
+	addCertificateLog(ctx, cert.ID, "create", fmt.Sprintf("成功申请证书，有效期至 %s", cert.NotAfter.Format("2006-01-02")))
+	if replaced != nil {
+		replaceCertificate(ctx, replaced, cert)
 	}
-	database.CreateCertificateLog(logEntry)
+	linkPendingSites(ctx, cert)
+	scheduleReload(cert.ID)
 
-	return cert, nil
+	return cert, prepareDeployHooks(cert), nil
 }
 
 // RenewCertificate 续期证书
-func RenewCertificate(certID string, email string) error {
+// email 为空时使用证书关联的 ACME 账户，指定时切换到该邮箱的账户
+// 通常通过 QueueRenew 在任务中执行，ctx 中的任务用于记录续期进度
+func RenewCertificate(ctx context.Context, certID string, email string) error {
+	return renewCertificate(ctx, certID, email, nil)
+}
+
+// ReissueCertificate 按新的域名列表重新签发证书，证书 ID 和文件路径不变
+// 签发成功后才更新证书记录，失败时原证书保持不变；引用该证书的站点在重新加载 nginx 后使用新证书
+// 通常通过 QueueReissue 在任务中执行
+func ReissueCertificate(ctx context.Context, certID string, domains []string) error {
+	return renewCertificate(ctx, certID, "", domains)
+}
+
+// renewCertificate 重新签发证书，domains 为空时沿用证书原有的域名（续期）
+func renewCertificate(ctx context.Context, certID string, email string, newDomains []string) error {
+	deploy, err := doRenewCertificate(ctx, certID, email, newDomains)
+	deploy.run(ctx)
+	return err
+}
+
+// doRenewCertificate 持有 renewMutex 重新签发证书，成功时返回释放锁后需要执行的部署钩子
+func doRenewCertificate(ctx context.Context, certID string, email string, newDomains []string) (*deployment, error) {
 	renewMutex.Lock()
 	defer renewMutex.Unlock()
 
 	cert, err := database.GetCertificate(certID)
 	if err != nil {
-		return fmt.Errorf("获取证书失败: %w", err)
+		return nil, fmt.Errorf("获取证书失败: %w", err)
 	}
-
-	provider, err := database.GetDNSProvider(cert.DNSProviderID)
-	if err != nil {
-		return fmt.Errorf("获取 DNS 提供商失败: %w", err)
-	}
-
-	providerConfig, err := ParseDNSProviderConfig(provider.Type, provider.Config)
-	if err != nil {
-		return err
+	if cert.Source == database.CertSourceUpload {
+		return nil, fmt.Errorf("上传的证书不支持续期，请上传新证书")
 	}
 
-	cfg := config.Get()
-	legoPath := filepath.Join(cfg.Data.Dir, "lego")
-
 	// 解析域名列表
 	var domains []string
-	if err := json.Unmarshal([]byte(cert.Domains), &domains); err != nil {
+	if err := json.Unmarshal([]byte(cert.Domains), &domains); err != nil || len(domains) == 0 {
 		domains = []string{cert.Domain}
 	}
-
-	// 构建 lego 命令
-	args := []string{
-		"--accept-tos",
-		"--email", email,
-		"--dns", providerConfig.GetProviderName(),
-		"--path", legoPath,
-	}
-
-	for _, domain := range domains {
-		args = append(args, "--domains", domain)
-	}
-
-	args = append(args, "renew", "--days", "30", "--renew-hook", "echo 'Certificate renewed'")
-
-	// 设置环境变量
-	env := os.Environ()
-	for k, v := range providerConfig.GetEnvVars() {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	reissue := len(newDomains) > 0
+	if reissue {
+		domains = newDomains
+		reportProgress(ctx, StageOrder, "按新的域名列表重新签发: %s", strings.Join(domains, ", "))
 	}
 
-	log.Info("开始续期证书", map[string]interface{}{
-		"domain":   cert.Domain,
-		"provider": provider.Type,
-	})
+	var account *database.ACMEAccount
+	var issued *issuedCertificates
+	if cert.Source == database.CertSourceInternal {
+		// 内部 CA 签发的证书直接重新签发，不需要验证
+		log.Info("开始续期内部证书", map[string]interface{}{
+			"domain": cert.Domain,
+		})
+		reportProgress(ctx, StageFinalize, "由内部 CA 重新签发")
+		issued, err = signInternalCertificates(cert, domains)
+	} else {
+		var solver challengeSolver
+		solver, err = challengeSolverFor(cert.ChallengeType, cert.DNSProviderID, domains)
+		if err != nil {
+			return nil, err
+		}
 
-	// 执行 lego 命令
-	cmd := exec.Command("lego", args...)
-	cmd.Env = env
+		log.Info("开始续期证书", map[string]interface{}{
+			"domain":    cert.Domain,
+			"challenge": cert.ChallengeType,
+		})
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+		ctx, cancel := context.WithTimeout(ctx, issueTimeout)
+		defer cancel()
 
-	if err := cmd.Run(); err != nil {
-		errMsg := stderr.String()
-		if errMsg == "" {
-			errMsg = stdout.String()
+		account, err = certificateAccount(ctx, cert, email)
+		if err != nil {
+			err = &IssueError{Stage: StageAccount, Domain: cert.Domain, Err: err}
 		}
-
-		// 检查是否是 "no renewal needed" 的情况
-		if strings.Contains(errMsg, "no renewal") || strings.Contains(stdout.String(), "no renewal") {
-			log.Info("证书无需续期", map[string]interface{}{
-				"domain": cert.Domain,
-			})
-			return nil
+		if err == nil {
+			reportProgress(ctx, StageAccount, "使用 ACME 账户 %s", account.Email)
+			issued, err = obtainCertificates(ctx, domains, solver, account, cert, true)
 		}
-
+	}
+	var certInfo *CertificateInfo
+	orig := *cert
+	if err == nil {
+		certInfo, err = installCertificates(ctx, cert, issued)
+		if err != nil {
+			err = &IssueError{Stage: StageSave, Domain: cert.Domain, Err: err}
+		}
+	}
+	if err != nil && reissue {
+		// 修改域名失败不影响原证书
+		log.Error("重新签发证书失败", map[string]interface{}{
+			"domain":  cert.Domain,
+			"domains": domains,
+			"error":   err.Error(),
+		})
+		addCertificateLog(ctx, cert.ID, "error", fmt.Sprintf("重新签发失败，原证书保持不变: %s", err.Error()))
+		return nil, err
+	}
+	if err != nil {
 		log.Error("续期证书失败", map[string]interface{}{
+			"domain": cert.Domain,
 			"error":  err.Error(),
-			"stderr": errMsg,
-			"stdout": stdout.String(),
 		})
 
-		// 检查是否是 DNS 记录已存在的错误
-		if strings.Contains(errMsg, "already exists") || strings.Contains(errMsg, "已存在") {
-			// 更新证书状态但不标记为 error（可以稍后重试）
-			log.Warn("DNS 验证记录已存在，将在下次定时任务时重试", map[string]interface{}{
-				"domain": cert.Domain,
-			})
-			return fmt.Errorf("DNS 验证记录已存在，请稍后自动重试")
-		}
-
-		// 检查是否是权限问题
-		if strings.Contains(errMsg, "authentication") || strings.Contains(errMsg, "unauthorized") {
-			errMsgPtr := "DNS API 认证失败"
-			cert.Status = "error"
-			cert.Error = &errMsgPtr
-			database.UpdateCertificate(cert)
-
-			logEntry := &database.CertificateLog{
-				ID:            uuid.New().String(),
-				CertificateID: cert.ID,
-				Action:        "error",
-				Message:       errMsgPtr,
-			}
-			database.CreateCertificateLog(logEntry)
-
-			return fmt.Errorf("DNS API 认证失败，请检查 DNS 提供商配置")
-		}
+		errMsg := issueErrorSummary(err)
 
 		// 更新证书状态
-		errMsgPtr := errMsg
 		cert.Status = "error"
-		cert.Error = &errMsgPtr
+		cert.Error = &errMsg
 		database.UpdateCertificate(cert)
 
 		// 记录日志
-		logEntry := &database.CertificateLog{
-			ID:            uuid.New().String(),
-			CertificateID: cert.ID,
-			Action:        "error",
-			Message:       fmt.Sprintf("续期失败: %s", errMsg),
-		}
-		database.CreateCertificateLog(logEntry)
-
-		return fmt.Errorf("续期证书失败: %s", errMsg)
-	}
+		addCertificateLog(ctx, cert.ID, "error", fmt.Sprintf("续期失败: %s", err.Error()))
+		notifyCertificate(notify.EventRenewFailed, cert,
+			fmt.Sprintf("%s 续期失败: %s\n当前证书将于 %s 过期，请尽快处理。", cert.Domain, errMsg, cert.NotAfter.Format("2006-01-02")), err)
 
-	// 复制新证书到 SSL 目录
-	// 通配符域名 *.example.com 在文件名中会变成 _.example.com
-	certDomain := strings.Replace(cert.Domain, "*", "_", -1)
-	certDir := filepath.Join(legoPath, "certificates")
-	srcCertFile := filepath.Join(certDir, certDomain+".crt")
-	srcKeyFile := filepath.Join(certDir, certDomain+".key")
-
-	// 将相对路径转换为绝对路径
-	destCertPath := filepath.Join(cfg.Data.Dir, cert.CertPath)
-	destKeyPath := filepath.Join(cfg.Data.Dir, cert.KeyPath)
-
-	if err := copyFile(srcCertFile, destCertPath); err != nil {
-		return fmt.Errorf("复制证书文件失败: %w", err)
-	}
-	if err := copyFile(srcKeyFile, destKeyPath); err != nil {
-		return fmt.Errorf("复制私钥文件失败: %w", err)
-	}
-
-	// 解析新证书信息
-	certInfo, err := ParseCertificate(destCertPath)
-	if err != nil {
-		return fmt.Errorf("解析证书失败: %w", err)
+		return nil, err
 	}
 
 	// 更新证书记录
@@ -503,26 +322,181 @@ func RenewCertificate(certID string, email string) error {
 	cert.LastRenewAt = &now
 	cert.Status = "active"
 	cert.Error = nil
+	if account != nil {
+		cert.AccountID = account.ID
+		cert.ACMEDirectory = account.Directory
+	}
+	if reissue {
+		domainsJSON, _ := json.Marshal(domains)
+		cert.Domain = domains[0]
+		cert.Domains = string(domainsJSON)
+	}
 
 	if err := database.UpdateCertificate(cert); err != nil {
-		return fmt.Errorf("更新证书记录失败: %w", err)
+		return nil, fmt.Errorf("更新证书记录失败: %w", err)
+	}
+	// 开启或关闭双证书后 RSA 证书路径变化，引用该证书的站点需要重新生成配置
+	if certificateFilesChanged(&orig, cert) {
+		refreshCertificateSites(ctx, cert)
 	}
 
 	// 记录日志
-	logEntry := &database.CertificateLog{
-		ID:            uuid.New().String(),
-		CertificateID: cert.ID,
-		Action:        "renew",
-		Message:       fmt.Sprintf("成功续期证书，有效期至 %s", cert.NotAfter.Format("2006-01-02")),
+	if reissue {
+		addCertificateLog(ctx, cert.ID, "renew", fmt.Sprintf("已按新的域名列表重新签发证书，有效期至 %s", cert.NotAfter.Format("2006-01-02")))
+		scheduleReload(cert.ID)
+		log.Info("证书重新签发成功", map[string]interface{}{
+			"domains":  domains,
+			"notAfter": cert.NotAfter.Format("2006-01-02"),
+		})
+		return prepareDeployHooks(cert), nil
 	}
-	database.CreateCertificateLog(logEntry)
+	addCertificateLog(ctx, cert.ID, "renew", fmt.Sprintf("成功续期证书，有效期至 %s", cert.NotAfter.Format("2006-01-02")))
+	scheduleReload(cert.ID)
+	notifyCertificate(notify.EventRenewSucceeded, cert,
+		fmt.Sprintf("%s 已续期，新证书有效期至 %s。", cert.Domain, cert.NotAfter.Format("2006-01-02")), nil)
 
 	log.Info("证书续期成功", map[string]interface{}{
 		"domain":   cert.Domain,
 		"notAfter": cert.NotAfter.Format("2006-01-02"),
 	})
 
-	return nil
+	return prepareDeployHooks(cert), nil
+}
+
+// eabOf 未填写 EAB 时返回 nil
+func eabOf(kid, hmacKey string) *acme.ExternalAccountBinding {
+	if kid == "" || hmacKey == "" {
+		return nil
+	}
+	return &acme.ExternalAccountBinding{KID: kid, HMACKey: hmacKey}
+}
+
+// challengeSolverFor 根据验证方式创建验证器
+func challengeSolverFor(challengeType, dnsProviderID string, domains []string) (challengeSolver, error) {
+	switch challengeType {
+	case database.ChallengeHTTP01:
+		if err := validateHTTPChallengeDomains(domains); err != nil {
+			return nil, err
+		}
+		if err := ensureHTTPChallenge(); err != nil {
+			return nil, fmt.Errorf("启用 HTTP-01 验证失败: %w", err)
+		}
+		return httpChallenge{}, nil
+	case database.ChallengeDNS01, "":
+		provider, err := database.GetDNSProvider(dnsProviderID)
+		if err != nil {
+			return nil, fmt.Errorf("获取 DNS 提供商失败: %w", err)
+		}
+		solver, err := newSolver(provider.Type, provider.Config)
+		if err != nil {
+			return nil, err
+		}
+		return dnsChallenge{solver: solver}, nil
+	default:
+		return nil, fmt.Errorf("不支持的验证方式: %s", challengeType)
+	}
+}
+
+// issuedCertificates 一次申请签发的证书，双证书模式下额外包含 RSA 证书
+type issuedCertificates struct {
+	Primary *issuedCertificate
+	RSA     *issuedCertificate
+}
+
+// obtainCertificates 按证书的私钥设置申请证书，双证书模式下再用 RSA 私钥申请一次
+// renewing 为 true 时在订单中标明替换的原证书（ARI）
+func obtainCertificates(ctx context.Context, domains []string, solver challengeSolver, account *database.ACMEAccount, cert *database.Certificate, renewing bool) (*issuedCertificates, error) {
+	fail := func(err error) (*issuedCertificates, error) {
+		return nil, &IssueError{Stage: StageFinalize, Domain: domains[0], Err: err}
+	}
+
+	key, err := certificateKey(cert.KeyType, cert.ReuseKey, cert.KeyPath)
+	if err != nil {
+		return fail(err)
+	}
+	// 已撤销的证书不再由 CA 跟踪续期，按新订单签发
+	renewing = renewing && cert.Status != "revoked"
+	var replaces string
+	if renewing {
+		replaces = replacesID(cert.CertPath)
+	}
+	primary, err := obtainCertificate(ctx, domains, solver, account, key, replaces)
+	if err != nil {
+		return nil, err
+	}
+	result := &issuedCertificates{Primary: primary}
+	if !cert.Dual {
+		return result, nil
+	}
+
+	// 同一账户下授权仍然有效，第二张证书通常无需再次验证
+	reportProgress(ctx, StageOrder, "申请双证书模式下的 RSA 证书")
+	rsaKey, err := certificateKey(dualKeyType, cert.ReuseKey, cert.RSAKeyPath)
+	if err != nil {
+		return fail(err)
+	}
+	if renewing {
+		replaces = replacesID(cert.RSACertPath)
+	}
+	if result.RSA, err = obtainCertificate(ctx, domains, solver, account, rsaKey, replaces); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveIssuedCertificates 保存签发的证书并更新证书记录中的文件路径，返回主证书信息
+func saveIssuedCertificates(cert *database.Certificate, issued *issuedCertificates) (*CertificateInfo, error) {
+	certInfo, err := saveCertificateFiles(issued.Primary, cert.CertPath, cert.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if keyType := certInfo.KeyType; keyType != "" {
+		cert.KeyType = keyType
+	}
+
+	if issued.RSA == nil {
+		// 关闭双证书后不再引用 RSA 证书
+		cert.RSACertPath = ""
+		cert.RSAKeyPath = ""
+		return certInfo, nil
+	}
+
+	cert.RSACertPath, cert.RSAKeyPath = rsaCertificatePaths(cert.CertPath)
+	if _, err := saveCertificateFiles(issued.RSA, cert.RSACertPath, cert.RSAKeyPath); err != nil {
+		return nil, fmt.Errorf("保存 RSA 证书失败: %w", err)
+	}
+	return certInfo, nil
+}
+
+// rsaCertificatePaths 双证书模式下 RSA 证书和私钥的路径，例如 nginx/ssl/example.com.rsa.crt
+func rsaCertificatePaths(certPath string) (string, string) {
+	base := strings.TrimSuffix(certPath, ".crt")
+	return base + ".rsa.crt", base + ".rsa.key"
+}
+
+// saveCertificateFiles 将证书链和私钥写入 data 目录下的相对路径
+func saveCertificateFiles(issued *issuedCertificate, relCertPath, relKeyPath string) (*CertificateInfo, error) {
+	dataDir := config.Get().Data.Dir
+	certFile := filepath.Join(dataDir, relCertPath)
+	keyFile := filepath.Join(dataDir, relKeyPath)
+
+	// 确保 SSL 目录存在
+	if err := os.MkdirAll(filepath.Dir(certFile), 0755); err != nil {
+		return nil, fmt.Errorf("创建 SSL 目录失败: %w", err)
+	}
+
+	if err := os.WriteFile(certFile, issued.CertPEM, 0644); err != nil {
+		return nil, fmt.Errorf("写入证书文件失败: %w", err)
+	}
+	if err := os.WriteFile(keyFile, issued.KeyPEM, 0600); err != nil {
+		return nil, fmt.Errorf("写入私钥文件失败: %w", err)
+	}
+
+	certInfo, err := ParseCertificate(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("解析证书失败: %w", err)
+	}
+	return certInfo, nil
 }
 
 // CertificateInfo 证书信息
@@ -532,6 +506,7 @@ type CertificateInfo struct {
 	NotBefore time.Time
 	NotAfter  time.Time
 	DNSNames  []string
+	KeyType   string // 私钥算法，无法识别时为空
 }
 
 // ParseCertificate 解析证书文件
@@ -557,138 +532,42 @@ func ParseCertificate(certPath string) (*CertificateInfo, error) {
 		NotBefore: cert.NotBefore,
 		NotAfter:  cert.NotAfter,
 		DNSNames:  cert.DNSNames,
+		KeyType:   keyTypeOf(cert.PublicKey),
 	}, nil
 }
 
-// CheckAndRenewCertificates 检查并续期即将过期的证书
-func CheckAndRenewCertificates(email string, days int) {
-	certs, err := database.ListCertificatesExpiringSoon(days)
-	if err != nil {
-		log.Error("获取即将过期的证书失败", map[string]interface{}{
-			"error": err.Error(),
-		})
-		return
-	}
-
-	if len(certs) == 0 {
-		log.Info("没有需要续期的证书")
-		return
-	}
-
-	log.Info("发现需要续期的证书", map[string]interface{}{
-		"count": len(certs),
-	})
-
-	for _, cert := range certs {
-		if err := RenewCertificate(cert.ID, email); err != nil {
-			log.Error("证书续期失败", map[string]interface{}{
-				"domain": cert.Domain,
-				"error":  err.Error(),
-			})
-		}
-	}
-}
-
-// copyFile 复制文件
-func copyFile(src, dst string) error {
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(dst, data, 0600)
-}
-
-// CheckLegoInstalled 检查 lego 是否已安装
-func CheckLegoInstalled() bool {
-	_, err := exec.LookPath("lego")
-	return err == nil
-}
-
-// GetLegoVersion 获取 lego 版本
-func GetLegoVersion() string {
-	cmd := exec.Command("lego", "--version")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return strings.TrimSpace(string(output))
-}
-
 // CleanupDNSRecords 清理可能残留的 DNS 验证记录
-// 注意：这个函数仅用于清理 _acme-challenge 记录，需要谨慎使用
+// 删除每个域名的 _acme-challenge TXT 记录，用于申请中断后的手动恢复
 func CleanupDNSRecords(domains []string, dnsProviderID string) error {
 	provider, err := database.GetDNSProvider(dnsProviderID)
 	if err != nil {
 		return fmt.Errorf("获取 DNS 提供商失败: %w", err)
 	}
 
-	providerConfig, err := ParseDNSProviderConfig(provider.Type, provider.Config)
+	solver, err := newSolver(provider.Type, provider.Config)
 	if err != nil {
 		return err
 	}
 
-	cfg := config.Get()
-	legoPath := filepath.Join(cfg.Data.Dir, "lego")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
 
-	// 使用 lego 的 revoke 命令来清理（如果有旧证书的话）
-	// 这会触发 DNS 记录的清理
+	// 通配符域名与主域名共用同一个验证记录名
+	seen := make(map[string]bool)
 	for _, domain := range domains {
-		certFile := filepath.Join(legoPath, "certificates", domain+".crt")
-		if _, err := os.Stat(certFile); err == nil {
-			// 有旧证书，尝试撤销（这会清理 DNS 记录）
-			args := []string{
-				"--email", "cleanup@example.com",
-				"--dns", providerConfig.GetProviderName(),
-				"--path", legoPath,
-				"revoke",
-				"--cert", certFile,
-			}
-
-			env := os.Environ()
-			for k, v := range providerConfig.GetEnvVars() {
-				env = append(env, fmt.Sprintf("%s=%s", k, v))
-			}
-
-			cmd := exec.Command("lego", args...)
-			cmd.Env = env
-
-			// 忽略错误，因为证书可能已经过期或被撤销
-			cmd.Run()
+		fqdn := challengeFQDN(domain)
+		if seen[fqdn] {
+			continue
 		}
-	}
+		seen[fqdn] = true
 
-	return nil
-}
-
-// ForceCleanup 强制清理域名的所有 lego 数据
-func ForceCleanup(domain string) error {
-	cfg := config.Get()
-	legoPath := filepath.Join(cfg.Data.Dir, "lego")
-	certDir := filepath.Join(legoPath, "certificates")
-
-	// 通配符域名 *.example.com 在文件名中会变成 _.example.com
-	certDomain := strings.Replace(domain, "*", "_", -1)
-
-	files := []string{
-		filepath.Join(certDir, certDomain+".crt"),
-		filepath.Join(certDir, certDomain+".key"),
-		filepath.Join(certDir, certDomain+".json"),
-		filepath.Join(certDir, certDomain+".issuer.crt"),
-	}
-
-	for _, file := range files {
-		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
-			log.Warn("删除文件失败", map[string]interface{}{
-				"file":  file,
-				"error": err.Error(),
-			})
+		if err := solver.CleanUp(ctx, fqdn, ""); err != nil {
+			return err
 		}
 	}
 
-	log.Info("已清理域名的 lego 数据", map[string]interface{}{
-		"domain":     domain,
-		"certDomain": certDomain,
+	log.Info("已清理残留的 DNS 验证记录", map[string]interface{}{
+		"domains": domains,
 	})
-
 	return nil
 }
@@ -0,0 +1,380 @@
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/hop/backend/internal/database"
+	"github.com/hop/backend/internal/notify"
+)
+
+// maxUploadSize 上传证书请求体的最大长度
+const maxUploadSize = 1 << 20
+
+// UploadOptions 上传证书参数，PEM 和 PKCS#12 二选一
+type UploadOptions struct {
+	CertificatePEM string // 证书链（PEM），服务器证书在前或在后均可
+	PrivateKeyPEM  string // 私钥（PEM），不支持加密的私钥
+	PKCS12         []byte // PKCS#12（.p12/.pfx）文件内容
+	Password       string // PKCS#12 密码
+}
+
+// uploadedCertificate 解析后的上传证书
+type uploadedCertificate struct {
+	Leaf  *x509.Certificate
+	Chain []*x509.Certificate // 中间证书，按签发顺序排列
+	Key   crypto.Signer
+}
+
+// UploadCertificate 导入外部签发的证书
+// 主域名已有上传的证书时替换该证书（保留 ID，引用它的站点无需修改），否则与同域名的其他证书并存
+func UploadCertificate(opts UploadOptions) (*database.Certificate, error) {
+	cert, deploy, err := uploadCertificate(opts)
+	if err != nil {
+		return nil, err
+	}
+	deploy.run(context.Background())
+	return cert, nil
+}
+
+// uploadCertificate 持有 renewMutex 导入证书，替换已有证书时返回释放锁后需要执行的部署钩子
+func uploadCertificate(opts UploadOptions) (*database.Certificate, *deployment, error) {
+	renewMutex.Lock()
+	defer renewMutex.Unlock()
+
+	uploaded, err := parseUpload(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if time.Now().After(uploaded.Leaf.NotAfter) {
+		return nil, nil, fmt.Errorf("证书已于 %s 过期", uploaded.Leaf.NotAfter.Format("2006-01-02"))
+	}
+
+	domains := certificateDomains(uploaded.Leaf)
+	if len(domains) == 0 {
+		return nil, nil, fmt.Errorf("证书中没有域名")
+	}
+	mainDomain := domains[0]
+
+	var cert *database.Certificate
+	existing, _ := database.ListCertificatesByDomain(mainDomain)
+	for i := range existing {
+		if existing[i].Source == database.CertSourceUpload {
+			cert = &existing[i]
+			break
+		}
+	}
+	replacing := cert != nil
+	if !replacing {
+		cert = &database.Certificate{
+			ID:     uuid.New().String(),
+			Domain: mainDomain,
+			Source: database.CertSourceUpload,
+		}
+		cert.CertPath, cert.KeyPath = certificatePaths(mainDomain, cert.ID)
+	}
+
+	var certPEM bytes.Buffer
+	for _, c := range append([]*x509.Certificate{uploaded.Leaf}, uploaded.Chain...) {
+		pem.Encode(&certPEM, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	keyPEM, err := encodePrivateKey(uploaded.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certInfo, err := saveCertificateFiles(&issuedCertificate{CertPEM: certPEM.Bytes(), KeyPEM: keyPEM}, cert.CertPath, cert.KeyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	domainsJSON, _ := json.Marshal(domains)
+	now := time.Now()
+	cert.Domains = string(domainsJSON)
+	cert.KeyType = certInfo.KeyType
+	cert.Issuer = certInfo.Issuer
+	cert.NotBefore = certInfo.NotBefore
+	cert.NotAfter = certInfo.NotAfter
+	cert.AutoRenew = false
+	cert.LastRenewAt = &now
+	cert.Status = "active"
+	cert.Error = nil
+
+	action, message := "create", "上传证书"
+	if replacing {
+		action, message = "renew", "替换上传的证书"
+		err = database.UpdateCertificate(cert)
+	} else {
+		err = database.CreateCertificate(cert)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("保存证书记录失败: %w", err)
+	}
+
+	database.CreateCertificateLog(&database.CertificateLog{
+		ID:            uuid.New().String(),
+		CertificateID: cert.ID,
+		Action:        action,
+		Message:       fmt.Sprintf("%s（%s 签发），有效期至 %s", message, cert.Issuer, cert.NotAfter.Format("2006-01-02")),
+	})
+	// 替换上传的证书相当于手动续期，重新加载 nginx 并推送到其他服务
+	var deploy *deployment
+	if replacing {
+		scheduleReload(cert.ID)
+		deploy = prepareDeployHooks(cert)
+	} else {
+		linkPendingSites(context.Background(), cert)
+	}
+
+	log.Info("证书上传成功", map[string]interface{}{
+		"domains":  domains,
+		"issuer":   cert.Issuer,
+		"notAfter": cert.NotAfter.Format("2006-01-02"),
+	})
+	return cert, deploy, nil
+}
+
+// parseUpload 解析上传内容并校验私钥与证书匹配
+func parseUpload(opts UploadOptions) (*uploadedCertificate, error) {
+	var certs []*x509.Certificate
+	var key crypto.Signer
+
+	if len(opts.PKCS12) > 0 {
+		privateKey, leaf, caCerts, err := pkcs12.DecodeChain(opts.PKCS12, opts.Password)
+		if err != nil {
+			return nil, fmt.Errorf("解析 PKCS#12 失败（请检查密码）: %w", err)
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("不支持的私钥类型")
+		}
+		key = signer
+		certs = append([]*x509.Certificate{leaf}, caCerts...)
+	} else {
+		if strings.TrimSpace(opts.CertificatePEM) == "" || strings.TrimSpace(opts.PrivateKeyPEM) == "" {
+			return nil, fmt.Errorf("请提供证书和私钥")
+		}
+		var err error
+		if certs, err = parseCertificateChain([]byte(opts.CertificatePEM)); err != nil {
+			return nil, err
+		}
+		if key, err = parseUploadedKey([]byte(opts.PrivateKeyPEM)); err != nil {
+			return nil, err
+		}
+	}
+
+	return orderChain(certs, key)
+}
+
+// parseCertificateChain 解析 PEM 中的所有证书
+func parseCertificateChain(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书失败: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("未找到 PEM 格式的证书")
+	}
+	return certs, nil
+}
+
+// parseUploadedKey 解析上传的私钥，加密的私钥给出明确提示
+func parseUploadedKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("私钥不是 PEM 格式")
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] != "" {
+		return nil, fmt.Errorf("不支持加密的私钥，请先解密（openssl pkey -in key.pem -out key-plain.pem）")
+	}
+	return parsePrivateKey(data)
+}
+
+// orderChain 找出与私钥匹配的服务器证书，并按签发关系排列中间证书
+// 根证书和与服务器证书无关的证书会被忽略
+func orderChain(certs []*x509.Certificate, key crypto.Signer) (*uploadedCertificate, error) {
+	result := &uploadedCertificate{Key: key}
+	rest := make([]*x509.Certificate, 0, len(certs))
+	for _, c := range certs {
+		if result.Leaf == nil && publicKeysEqual(c.PublicKey, key.Public()) {
+			result.Leaf = c
+			continue
+		}
+		rest = append(rest, c)
+	}
+	if result.Leaf == nil {
+		return nil, fmt.Errorf("私钥与证书不匹配")
+	}
+
+	current := result.Leaf
+	for len(rest) > 0 {
+		next := -1
+		for i, c := range rest {
+			if bytes.Equal(current.RawIssuer, c.RawSubject) && current.CheckSignatureFrom(c) == nil {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		issuer := rest[next]
+		rest = append(rest[:next], rest[next+1:]...)
+		// 自签名的根证书不需要由服务器发送
+		if bytes.Equal(issuer.RawIssuer, issuer.RawSubject) {
+			break
+		}
+		result.Chain = append(result.Chain, issuer)
+		current = issuer
+	}
+
+	if len(rest) > 0 {
+		log.Warn("忽略证书链中无法关联的证书", map[string]interface{}{
+			"domain": result.Leaf.Subject.CommonName,
+			"count":  len(rest),
+		})
+	}
+	return result, nil
+}
+
+// publicKeysEqual 比较两个公钥
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// certificateDomains 证书中的域名，CN 在 SAN 中时作为主域名排在最前，SAN 为空时使用 CN
+func certificateDomains(cert *x509.Certificate) []string {
+	cn := cert.Subject.CommonName
+	var domains []string
+	for _, d := range cert.DNSNames {
+		if d == cn {
+			domains = append([]string{d}, domains...)
+		} else {
+			domains = append(domains, d)
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		domains = append(domains, ip.String())
+	}
+	if len(domains) == 0 && cn != "" {
+		domains = append(domains, cn)
+	}
+	return domains
+}
+
+// CheckManualCertificates 检查不会自动续期的证书的有效期：即将过期时记录提醒，已过期时标记状态
+func CheckManualCertificates(days int) {
+	certs, err := database.ListManualCertificatesExpiringSoon(days)
+	if err != nil {
+		log.Error("获取即将过期的证书失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	for _, cert := range certs {
+		kind, action := "上传的证书", "请上传新证书"
+		if cert.Source != database.CertSourceUpload {
+			kind, action = "证书未开启自动续期，", "请手动续期"
+		}
+		daysLeft := int(cert.NotAfter.Sub(now).Hours() / 24)
+		message := fmt.Sprintf("%s将于 %s 过期（剩余 %d 天），%s", kind, cert.NotAfter.Format("2006-01-02"), daysLeft, action)
+		// 每天记录提醒，通知只在进入提醒期和过期时各发送一次
+		since := cert.NotBefore
+		if cert.LastRenewAt != nil {
+			since = *cert.LastRenewAt
+		}
+		reminded, err := database.HasCertificateLogSince(cert.ID, "expiring", since)
+		notifyNow := err == nil && !reminded
+		if now.After(cert.NotAfter) {
+			if cert.Status == "expired" {
+				continue
+			}
+			message = fmt.Sprintf("%s已于 %s 过期，%s", kind, cert.NotAfter.Format("2006-01-02"), action)
+			cert.Status = "expired"
+			database.UpdateCertificate(&cert)
+			notifyNow = true
+		}
+		if notifyNow {
+			notifyCertificate(notify.EventExpiring, &cert, cert.Domain+" "+message, nil)
+		}
+
+		log.Warn(message, map[string]interface{}{
+			"domain": cert.Domain,
+		})
+		database.CreateCertificateLog(&database.CertificateLog{
+			ID:            uuid.New().String(),
+			CertificateID: cert.ID,
+			Action:        "expiring",
+			Message:       message,
+		})
+	}
+}
+
+// === HTTP Handlers ===
+
+// UploadCertificateRequest 上传证书请求，PEM 和 PKCS#12 二选一
+type UploadCertificateRequest struct {
+	Certificate string `json:"certificate"` // 证书链（PEM）
+	PrivateKey  string `json:"privateKey"`  // 私钥（PEM）
+	PKCS12      string `json:"pkcs12"`      // base64 编码的 PKCS#12 文件
+	Password    string `json:"password"`    // PKCS#12 密码
+}
+
+func handleUploadCertificate(w http.ResponseWriter, r *http.Request) {
+	var req UploadCertificateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadSize)).Decode(&req); err != nil {
+		jsonError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	opts := UploadOptions{
+		CertificatePEM: req.Certificate,
+		PrivateKeyPEM:  req.PrivateKey,
+		Password:       req.Password,
+	}
+	if req.PKCS12 != "" {
+		data, err := base64.StdEncoding.DecodeString(req.PKCS12)
+		if err != nil {
+			jsonError(w, "PKCS#12 文件不是有效的 base64 编码", http.StatusBadRequest)
+			return
+		}
+		opts.PKCS12 = data
+	}
+
+	cert, err := UploadCertificate(opts)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"success":     true,
+		"certificate": certToResponse(cert),
+	})
+}
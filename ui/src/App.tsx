@@ -12,6 +12,11 @@ import NginxBrowsePage from '@/pages/nginx/BrowsePage';
 import ProxyEditPage from '@/pages/nginx/ProxyEditPage';
 import SSLPage from '@/pages/ssl/SSLPage';
 import DNSProvidersPage from '@/pages/ssl/DNSProvidersPage';
+import ACMEAccountsPage from '@/pages/ssl/ACMEAccountsPage';
+import InternalCAPage from '@/pages/ssl/InternalCAPage';
+import ClientCAsPage from '@/pages/ssl/ClientCAsPage';
+import NotificationsPage from '@/pages/ssl/NotificationsPage';
+import RenewalsPage from '@/pages/ssl/RenewalsPage';
 import StreamPage from '@/pages/stream/StreamPage';
 
 // 需要登录才能访问的路由守卫
@@ -130,6 +135,46 @@ function App() {
             </ProtectedRoute>
           }
         />
+        <Route
+          path="/ssl/accounts"
+          element={
+            <ProtectedRoute>
+              <ACMEAccountsPage />
+            </ProtectedRoute>
+          }
+        />
+        <Route
+          path="/ssl/ca"
+          element={
+            <ProtectedRoute>
+              <InternalCAPage />
+            </ProtectedRoute>
+          }
+        />
+        <Route
+          path="/ssl/client-cas"
+          element={
+            <ProtectedRoute>
+              <ClientCAsPage />
+            </ProtectedRoute>
+          }
+        />
+        <Route
+          path="/ssl/notifications"
+          element={
+            <ProtectedRoute>
+              <NotificationsPage />
+            </ProtectedRoute>
+          }
+        />
+        <Route
+          path="/ssl/renewals"
+          element={
+            <ProtectedRoute>
+              <RenewalsPage />
+            </ProtectedRoute>
+          }
+        />
         {/* SNI 分流管理 - 需要登录 */}
         <Route
           path="/stream"
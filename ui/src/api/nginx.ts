@@ -40,8 +40,22 @@ export interface TemplateParams {
     clientMaxBodySize: string;
     gzip: boolean;
     serverTokens: boolean;
+    httpChallenge: boolean;
+    tlsProfile: TLSProfile;   // TLS 配置档，默认 intermediate
+    ocspStapling: boolean;    // 启用 OCSP Stapling，需要配置 resolver
+    resolver: string;         // OCSP Stapling 使用的 DNS 解析服务器，空格分隔
+    dhParamBits: 0 | 2048 | 3072 | 4096; // DH 参数长度，0 表示不配置
 }
 
+// TLS 配置档，参考 Mozilla SSL Configuration Generator
+export type TLSProfile = 'modern' | 'intermediate' | 'old';
+
+export const TLS_PROFILES: { value: TLSProfile; label: string }[] = [
+    { value: 'modern', label: 'Modern（仅 TLS 1.3）' },
+    { value: 'intermediate', label: 'Intermediate（TLS 1.2 + 1.3，推荐）' },
+    { value: 'old', label: 'Old（兼容 TLS 1.0/1.1）' },
+];
+
 const API_BASE = '/api/nginx';
 
 // 获取环境配置
@@ -149,14 +163,27 @@ export interface ProxySite {
     ssl: boolean;            // 是否启用 SSL
     sslCert?: string;        // SSL 证书路径
     sslKey?: string;         // SSL 私钥路径
-    certificateId?: string;  // 关联的证书 ID
+    tlsProfile?: '' | TLSProfile; // 为空使用全局 TLS 配置档
+    tls13Only?: boolean;     // 只允许 TLS 1.3，优先于 tlsProfile
+    certificateId?: string;  // 关联的证书 ID，为空时自动选择覆盖站点域名的证书
+    certificateJobId?: string; // 等待自动申请的证书时为申请任务 ID，签发后自动关联
     upstreamScheme: 'http' | 'https'; // 上游协议
     upstreamHost: string;    // 上游主机名/IP
     upstreamPort: number;    // 上游端口
+    upstreamSslName?: string;      // HTTPS 上游的 SNI 名称，默认使用上游主机
+    upstreamSslVerify?: boolean;   // 校验上游证书
+    upstreamCa?: string;           // 校验上游的 CA：system（默认）、internal 或 CA 证书包 ID
+    upstreamClientCertId?: string; // 向上游出示的客户端证书 ID
     websocket: boolean;      // 是否支持 WebSocket
 
     // 认证配置（登录 URL 和 Cookie 域名从系统设置中全局配置）
     authEnabled: boolean;    // 是否启用访问认证
+
+    // 客户端证书认证（mTLS），需要启用 SSL
+    clientAuth?: '' | 'optional' | 'required'; // 为空不校验
+    clientCa?: string;           // 客户端 CA 的 ID，internal 表示内部 CA
+    clientVerifyDepth?: number;  // 证书链校验深度，默认 2
+    clientCertHeaders?: boolean; // 向上游转发客户端证书信息
 }
 
 // 获取代理站点列表
@@ -172,7 +199,10 @@ export async function getProxySite(id: string): Promise<ProxySite> {
 }
 
 // 保存代理站点
-export async function saveProxySite(site: ProxySite): Promise<{ success: boolean; id?: string; error?: string }> {
+// 启用 SSL 但没有可用证书且开启了自动申请时，返回申请任务 ID
+export async function saveProxySite(
+    site: ProxySite
+): Promise<{ success: boolean; id?: string; certificateJobId?: string; error?: string }> {
     const res = await fetch(`${API_BASE}/proxy/save`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
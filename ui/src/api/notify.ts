@@ -0,0 +1,125 @@
+// 通知渠道 API
+
+const API_BASE = '/api/notify';
+
+// 通知渠道类型
+export type ChannelType = 'webhook' | 'email' | 'slack' | 'feishu' | 'dingtalk';
+
+// 事件类型
+export type NotifyEvent = 'renew_failed' | 'expiring' | 'renew_succeeded';
+
+// 通用 webhook 配置
+export interface WebhookConfig {
+    url: string;
+    method?: 'POST' | 'PUT';
+    headers?: Record<string, string>;
+    body?: string; // Go 模板，为空时发送事件的 JSON
+}
+
+// SMTP 邮件配置
+export interface EmailConfig {
+    host: string;
+    port?: number;
+    security?: 'starttls' | 'tls' | 'none';
+    username?: string;
+    password?: string; // 修改时留空沿用原值
+    from: string;
+    to: string[];
+}
+
+// Slack、飞书、钉钉机器人配置
+export interface BotConfig {
+    url: string;
+    secret?: string; // 飞书、钉钉的签名密钥，修改时留空沿用原值
+}
+
+export type ChannelConfig = WebhookConfig | EmailConfig | BotConfig;
+
+// 通知渠道信息（不包含密钥）
+export interface NotificationChannel {
+    id: string;
+    name: string;
+    type: ChannelType;
+    config: ChannelConfig;
+    hasSecret: boolean;
+    events: NotifyEvent[];
+    enabled: boolean;
+    lastSentAt: string | null;
+    lastError: string;
+    createdAt: string;
+    updatedAt: string;
+}
+
+// 创建或修改通知渠道的数据
+export interface NotificationChannelData {
+    name: string;
+    type: ChannelType;
+    config: ChannelConfig;
+    events: NotifyEvent[];
+    enabled?: boolean;
+}
+
+// 获取可订阅的事件
+export async function listNotifyEvents(): Promise<{ events: { type: NotifyEvent; label: string }[] }> {
+    const res = await fetch(`${API_BASE}/events`);
+    return res.json();
+}
+
+// 获取通知渠道列表
+export async function listNotificationChannels(): Promise<{ channels: NotificationChannel[] }> {
+    const res = await fetch(`${API_BASE}/channels`);
+    return res.json();
+}
+
+// 创建通知渠道
+export async function createNotificationChannel(
+    data: NotificationChannelData
+): Promise<{ success: boolean; channel?: NotificationChannel; error?: string }> {
+    const res = await fetch(`${API_BASE}/channels`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data),
+    });
+    return res.json();
+}
+
+// 修改通知渠道
+export async function updateNotificationChannel(
+    id: string,
+    data: Partial<NotificationChannelData>
+): Promise<{ success: boolean; channel?: NotificationChannel; error?: string }> {
+    const res = await fetch(`${API_BASE}/channels/${id}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data),
+    });
+    return res.json();
+}
+
+// 删除通知渠道
+export async function deleteNotificationChannel(id: string): Promise<{ success: boolean; error?: string }> {
+    const res = await fetch(`${API_BASE}/channels/${id}`, {
+        method: 'DELETE',
+    });
+    return res.json();
+}
+
+// 发送测试通知
+export async function testNotificationChannel(id: string): Promise<{ success: boolean; error?: string }> {
+    const res = await fetch(`${API_BASE}/channels/${id}/test`, {
+        method: 'POST',
+    });
+    return res.json();
+}
+
+// 获取渠道类型显示名称
+export function getChannelTypeLabel(type: ChannelType): string {
+    const labels: Record<ChannelType, string> = {
+        webhook: 'Webhook',
+        email: '邮件',
+        slack: 'Slack',
+        feishu: '飞书',
+        dingtalk: '钉钉',
+    };
+    return labels[type] || type;
+}
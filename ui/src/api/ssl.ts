@@ -2,8 +2,8 @@
 
 const API_BASE = '/api/ssl';
 
-// DNS 提供商类型
-export type DNSProviderType = 'alidns' | 'tencentcloud' | 'cloudflare';
+// DNS 提供商类型，支持的类型由后端注册，见 listDNSProviderTypes
+export type DNSProviderType = string;
 
 // DNS 提供商信息
 export interface DNSProvider {
@@ -14,65 +14,155 @@ export interface DNSProvider {
     updatedAt: string;
 }
 
-// 阿里云 DNS 配置
-export interface AliDNSConfig {
-    accessKeyId: string;
-    accessKeySecret: string;
-    regionId?: string;
-}
+// DNS 提供商配置字段类型
+export type DNSProviderFieldType = 'text' | 'password' | 'number' | 'select';
 
-// 腾讯云 DNS 配置
-export interface TencentCloudConfig {
-    secretId: string;
-    secretKey: string;
+// DNS 提供商配置字段，name 与配置中的键一致
+export interface DNSProviderField {
+    name: string;
+    label: string;
+    type: DNSProviderFieldType;
+    required: boolean;
+    placeholder?: string;
+    help?: string;
+    default?: string;
+    options?: { value: string; label: string }[];
 }
 
-// Cloudflare DNS 配置
-export interface CloudflareConfig {
-    apiToken?: string;
-    email?: string;
-    apiKey?: string;
+// DNS 提供商定义
+export interface DNSProviderDefinition {
+    type: DNSProviderType;
+    name: string;
+    description?: string;
+    fields: DNSProviderField[];
 }
 
-// DNS 提供商配置
-export type DNSProviderConfig = AliDNSConfig | TencentCloudConfig | CloudflareConfig;
+// DNS 提供商配置，字段由提供商定义决定
+export type DNSProviderConfig = Record<string, string | number>;
+
+// 验证方式
+export type ChallengeType = 'dns-01' | 'http-01';
+
+// 私钥算法
+export type KeyType = 'ec256' | 'ec384' | 'rsa2048' | 'rsa3072' | 'rsa4096';
+
+// 证书用途：服务器证书或客户端证书（上游 mTLS）
+export type CertificateUsage = 'server' | 'client';
+
+// 证书私钥设置
+export interface CertificateKeyOptions {
+    keyType?: KeyType;
+    reuseKey?: boolean; // 续期时复用私钥
+    dual?: boolean; // 同时签发 RSA 证书，需使用 ECDSA 私钥
+}
 
 // 证书信息
 export interface Certificate {
     id: string;
     domain: string;
     domains: string[];
+    source: 'acme' | 'upload' | 'internal';
+    usage: CertificateUsage;
     dnsProviderId: string;
+    challengeType: ChallengeType | '';
+    acmeDirectory: string;
+    caName: string;
+    eabKid: string;
+    accountId: string;
+    accountEmail: string;
+    keyType: KeyType | '';
+    reuseKey: boolean;
+    dual: boolean;
     certPath: string;
     keyPath: string;
+    rsaCertPath?: string;
+    rsaKeyPath?: string;
     issuer: string;
     notBefore: string;
     notAfter: string;
     autoRenew: boolean;
+    renewBefore: number; // 提前续期天数，0 表示自动
     lastRenewAt: string | null;
-    status: 'pending' | 'active' | 'expired' | 'error';
+    status: 'pending' | 'active' | 'expired' | 'error' | 'revoked';
     error: string | null;
     daysRemaining: number;
+    sites: string[]; // 引用该证书的站点
     createdAt: string;
     updatedAt: string;
 }
 
+// 申请或续期的阶段
+export type IssueStage = 'queued' | 'account' | 'order' | 'challenge' | 'validation' | 'finalize' | 'download' | 'save';
+
 // 证书日志
 export interface CertificateLog {
     id: string;
-    action: 'create' | 'renew' | 'error' | 'cleanup';
+    jobId?: string; // 申请或续期任务产生的日志
+    action: 'create' | 'renew' | 'error' | 'cleanup' | 'expiring' | 'reload' | 'deploy' | 'replace' | 'revoke' | 'link' | 'sites' | 'delete' | IssueStage;
     message: string;
     createdAt: string;
 }
 
+// 证书申请、续期或按新域名列表重新签发的任务，依次在后台执行
+export interface CertificateJob {
+    id: string;
+    type: 'issue' | 'renew' | 'reissue';
+    certificateId: string; // 申请任务开始执行前为空
+    domain: string;
+    status: 'queued' | 'running' | 'succeeded' | 'failed';
+    error: string;
+    createdAt: string;
+    startedAt: string | null;
+    finishedAt: string | null;
+}
+
 // SSL 状态
 export interface SSLStatus {
-    legoInstalled: boolean;
-    legoVersion: string;
+    acmeDirectory: string;
+    staging: boolean;
+    directories: ACMEPreset[];
+}
+
+// 预设 CA
+export interface ACMEPreset {
+    name: string;
+    label: string;
+    url: string;
+    eabRequired: boolean;
+    staging: boolean;
+}
+
+// 申请证书时可选的 CA 设置
+export interface IssueACMEOptions {
+    accountId?: string; // 使用已有账户，为空时按邮箱查找或注册
+    acmeDirectory?: string; // 预设名称或目录地址，为空使用默认目录
+    eabKid?: string;
+    eabHmacKey?: string;
+    replace?: string; // 签发成功后替换的证书 ID，引用该证书的站点改用新证书
+}
+
+// ACME 账户
+export interface ACMEAccount {
+    id: string;
+    email: string;
+    directory: string;
+    caName: string;
+    url: string;
+    status: 'valid' | 'deactivated';
+    eabKid: string;
+    certificateCount: number;
+    createdAt: string;
+    updatedAt: string;
 }
 
 // === DNS 提供商 API ===
 
+// 获取支持的 DNS 提供商及其配置字段
+export async function listDNSProviderTypes(): Promise<{ types: DNSProviderDefinition[] }> {
+    const res = await fetch(`${API_BASE}/dns-provider-types`);
+    return res.json();
+}
+
 // 获取 DNS 提供商列表
 export async function listDNSProviders(): Promise<{ providers: DNSProvider[] }> {
     const res = await fetch(`${API_BASE}/dns-providers`);
@@ -120,6 +210,48 @@ export async function deleteDNSProvider(id: string): Promise<{ success: boolean;
     return res.json();
 }
 
+// === ACME 账户 API ===
+
+// 获取 ACME 账户列表
+export async function listACMEAccounts(): Promise<{ accounts: ACMEAccount[] }> {
+    const res = await fetch(`${API_BASE}/accounts`);
+    return res.json();
+}
+
+// 注册 ACME 账户
+export async function createACMEAccount(
+    email: string,
+    acmeOptions: IssueACMEOptions = {}
+): Promise<{ success: boolean; account?: ACMEAccount; error?: string }> {
+    const res = await fetch(`${API_BASE}/accounts`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ email, ...acmeOptions }),
+    });
+    return res.json();
+}
+
+// 更新 ACME 账户联系邮箱
+export async function updateACMEAccount(
+    id: string,
+    email: string
+): Promise<{ success: boolean; account?: ACMEAccount; error?: string }> {
+    const res = await fetch(`${API_BASE}/accounts/${id}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ email }),
+    });
+    return res.json();
+}
+
+// 停用并删除 ACME 账户
+export async function deleteACMEAccount(id: string): Promise<{ success: boolean; error?: string }> {
+    const res = await fetch(`${API_BASE}/accounts/${id}`, {
+        method: 'DELETE',
+    });
+    return res.json();
+}
+
 // === 证书 API ===
 
 // 获取证书列表
@@ -132,12 +264,35 @@ export async function listCertificates(): Promise<{ certificates: Certificate[]
 export async function issueCertificate(
     domains: string[],
     dnsProviderId: string,
-    email: string
-): Promise<{ success: boolean; certificate?: Certificate; error?: string }> {
+    email: string,
+    challengeType: ChallengeType = 'dns-01',
+    acmeOptions: IssueACMEOptions = {},
+    keyOptions: CertificateKeyOptions = {}
+): Promise<{ success: boolean; job?: CertificateJob; error?: string }> {
     const res = await fetch(`${API_BASE}/certificates`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ domains, dnsProviderId, email }),
+        body: JSON.stringify({ domains, dnsProviderId, email, challengeType, ...acmeOptions, ...keyOptions }),
+    });
+    return res.json();
+}
+
+// 上传证书：PEM 证书链 + 私钥，或 PKCS#12 文件（base64）
+export interface UploadCertificateData {
+    certificate?: string;
+    privateKey?: string;
+    pkcs12?: string;
+    password?: string;
+}
+
+// 上传外部签发的证书，主域名相同的上传证书会被原地替换
+export async function uploadCertificate(
+    data: UploadCertificateData
+): Promise<{ success: boolean; certificate?: Certificate; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/upload`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data),
     });
     return res.json();
 }
@@ -148,11 +303,38 @@ export async function getCertificate(id: string): Promise<Certificate> {
     return res.json();
 }
 
-// 续期证书
+// 修改证书私钥设置（下次续期时生效）
+export async function updateCertificateKey(
+    id: string,
+    keyOptions: CertificateKeyOptions
+): Promise<{ success: boolean; certificate?: Certificate; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${id}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(keyOptions),
+    });
+    return res.json();
+}
+
+// 修改证书的提前续期天数，0 表示自动（优先使用 CA 建议的续期窗口）
+export async function updateCertificateRenewBefore(
+    cert: Certificate,
+    renewBefore: number
+): Promise<{ success: boolean; certificate?: Certificate; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${cert.id}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        // 只提交续期窗口，私钥设置保持不变
+        body: JSON.stringify({ renewBefore }),
+    });
+    return res.json();
+}
+
+// 续期证书，该证书已有未完成的任务时返回该任务
 export async function renewCertificate(
     id: string,
     email: string
-): Promise<{ success: boolean; certificate?: Certificate; error?: string }> {
+): Promise<{ success: boolean; job?: CertificateJob; error?: string }> {
     const res = await fetch(`${API_BASE}/certificates/${id}/renew`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
@@ -161,7 +343,43 @@ export async function renewCertificate(
     return res.json();
 }
 
-// 清理证书的 lego 数据（用于解决 DNS 记录冲突）
+// 修改证书的域名列表，按新的域名列表重新签发，签发成功后证书 ID 不变
+export async function updateCertificateDomains(
+    id: string,
+    domains: string[]
+): Promise<{ success: boolean; job?: CertificateJob; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${id}/domains`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ domains }),
+    });
+    return res.json();
+}
+
+// 撤销原因（RFC 5280），ACME 只接受以下几种
+export const REVOKE_REASONS: { value: number; label: string }[] = [
+    { value: 0, label: '未指定' },
+    { value: 1, label: '私钥泄露' },
+    { value: 3, label: '从属关系变更' },
+    { value: 4, label: '已被取代' },
+    { value: 5, label: '停止使用' },
+];
+
+// 向 CA 撤销证书，返回引用该证书的站点；detach 为 true 时停用这些站点的 HTTPS
+export async function revokeCertificate(
+    id: string,
+    reason: number,
+    detach: boolean
+): Promise<{ success: boolean; sites?: string[]; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${id}/revoke`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ reason, detach }),
+    });
+    return res.json();
+}
+
+// 清理证书残留的 DNS 验证记录（用于解决 DNS 记录冲突）
 export async function cleanupCertificate(id: string): Promise<{ success: boolean; error?: string }> {
     const res = await fetch(`${API_BASE}/certificates/${id}/cleanup`, {
         method: 'POST',
@@ -170,8 +388,12 @@ export async function cleanupCertificate(id: string): Promise<{ success: boolean
 }
 
 // 删除证书
-export async function deleteCertificate(id: string): Promise<{ success: boolean; error?: string }> {
-    const res = await fetch(`${API_BASE}/certificates/${id}`, {
+// 删除证书，仍有站点引用时返回这些站点；cascade 为 true 时先停用这些站点的 HTTPS
+export async function deleteCertificate(
+    id: string,
+    cascade = false
+): Promise<{ success: boolean; sites?: string[]; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${id}${cascade ? '?cascade=true' : ''}`, {
         method: 'DELETE',
     });
     return res.json();
@@ -183,6 +405,384 @@ export async function getCertificateLogs(id: string): Promise<{ logs: Certificat
     return res.json();
 }
 
+// === 部署钩子 API ===
+
+// 部署钩子类型：复制文件、执行命令、导出 PKCS#12
+export type HookType = 'copy' | 'command' | 'pkcs12';
+
+// 证书申请或续期成功后执行的部署钩子
+export interface CertificateHook {
+    id: string;
+    certificateId: string;
+    type: HookType;
+    enabled: boolean;
+    certPath: string; // copy: 证书目标路径；pkcs12: 输出文件路径
+    keyPath: string; // copy: 私钥目标路径
+    owner: string; // user 或 user:group
+    mode: string; // 八进制权限，如 0640
+    command: string;
+    timeout: number; // 命令超时秒数
+    hasPassword: boolean;
+    legacy: boolean; // PKCS#12 使用 3DES 加密，兼容旧版 Java 和 Windows
+    lastRunAt: string | null;
+    lastError: string;
+    createdAt: string;
+    updatedAt: string;
+}
+
+// 创建或修改部署钩子的参数（修改时 password 留空保持原密码）
+export interface CertificateHookData {
+    type: HookType;
+    enabled?: boolean;
+    certPath?: string;
+    keyPath?: string;
+    owner?: string;
+    mode?: string;
+    command?: string;
+    timeout?: number;
+    password?: string;
+    legacy?: boolean;
+}
+
+// 获取证书的部署钩子
+export async function listCertificateHooks(certId: string): Promise<{ hooks: CertificateHook[] }> {
+    const res = await fetch(`${API_BASE}/certificates/${certId}/hooks`);
+    return res.json();
+}
+
+// 创建部署钩子
+export async function createCertificateHook(
+    certId: string,
+    data: CertificateHookData
+): Promise<{ success: boolean; hook?: CertificateHook; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${certId}/hooks`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data),
+    });
+    return res.json();
+}
+
+// 修改部署钩子
+export async function updateCertificateHook(
+    certId: string,
+    hookId: string,
+    data: CertificateHookData
+): Promise<{ success: boolean; hook?: CertificateHook; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${certId}/hooks/${hookId}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data),
+    });
+    return res.json();
+}
+
+// 删除部署钩子
+export async function deleteCertificateHook(certId: string, hookId: string): Promise<{ success: boolean; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${certId}/hooks/${hookId}`, {
+        method: 'DELETE',
+    });
+    return res.json();
+}
+
+// 立即执行部署钩子
+export async function runCertificateHook(
+    certId: string,
+    hookId: string
+): Promise<{ success: boolean; hook?: CertificateHook; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/${certId}/hooks/${hookId}/run`, {
+        method: 'POST',
+    });
+    return res.json();
+}
+
+// 获取部署钩子类型名称
+export function getHookTypeLabel(type: HookType): string {
+    const labels: Record<HookType, string> = {
+        copy: '复制文件',
+        command: '执行命令',
+        pkcs12: '导出 PKCS#12',
+    };
+    return labels[type] || type;
+}
+
+// === 任务 API ===
+
+// 最近的申请和续期任务
+export async function listCertificateJobs(): Promise<{ jobs: CertificateJob[] }> {
+    const res = await fetch(`${API_BASE}/jobs`);
+    return res.json();
+}
+
+// 获取任务状态和日志
+export async function getCertificateJob(id: string): Promise<{ job: CertificateJob; logs: CertificateLog[] }> {
+    const res = await fetch(`${API_BASE}/jobs/${id}`);
+    return res.json();
+}
+
+// 订阅任务日志，先收到已有日志，任务结束时回调 onDone，返回取消订阅的函数
+export function watchCertificateJob(
+    id: string,
+    onLog: (log: CertificateLog) => void,
+    onDone: (job: CertificateJob) => void
+): () => void {
+    const source = new EventSource(`${API_BASE}/jobs/${id}/events`);
+    source.addEventListener('log', (e) => onLog(JSON.parse((e as MessageEvent).data)));
+    source.addEventListener('done', (e) => {
+        source.close();
+        onDone(JSON.parse((e as MessageEvent).data));
+    });
+    return () => source.close();
+}
+
+// === 续期调度 API ===
+
+// 续期时间的依据
+export type RenewBasis = 'ari' | 'custom' | 'lifetime' | 'default';
+
+// 证书的续期计划
+export interface RenewalStatus {
+    id: string;
+    domain: string;
+    source: Certificate['source'];
+    autoRenew: boolean;
+    status: Certificate['status'];
+    notAfter: string;
+    renewBefore: number;
+    renewAt: string | null;
+    basis: RenewBasis | '';
+    ariCheckAt: string | null;
+    explanationUrl: string;
+    failures: number; // 连续失败次数
+    retryAt: string | null;
+    nextAttemptAt: string | null; // 下次尝试续期的时间
+    lastAttemptAt: string | null;
+    lastError: string;
+}
+
+// 续期调度器状态
+export interface SchedulerStatus {
+    running: boolean;
+    interval: string;
+    renewBeforeDays: number; // 默认提前续期天数
+    lastRunAt: string | null;
+    nextRunAt: string | null;
+    lastQueued: number;
+    certificates: RenewalStatus[];
+}
+
+// 获取续期调度器状态
+export async function getSchedulerStatus(): Promise<SchedulerStatus> {
+    const res = await fetch(`${API_BASE}/scheduler`);
+    return res.json();
+}
+
+// 立即执行一次续期检查
+export async function runScheduler(): Promise<{ success: boolean; error?: string }> {
+    const res = await fetch(`${API_BASE}/scheduler/run`, {
+        method: 'POST',
+    });
+    return res.json();
+}
+
+// 获取续期时间依据的显示名称
+export function getRenewBasisLabel(basis: RenewBasis | ''): string {
+    const labels: Record<string, string> = {
+        ari: 'CA 建议窗口',
+        custom: '自定义',
+        lifetime: '有效期三分之一',
+        default: '默认',
+    };
+    return labels[basis] || '未计划';
+}
+
+// 获取阶段名称
+export function getIssueStageLabel(action: CertificateLog['action']): string {
+    const labels: Record<string, string> = {
+        queued: '排队',
+        account: '账户',
+        order: '订单',
+        challenge: '验证设置',
+        validation: '域名验证',
+        finalize: '签发',
+        download: '下载',
+        save: '保存',
+        create: '完成',
+        renew: '完成',
+        error: '失败',
+        reload: '重新加载',
+        deploy: '部署',
+        replace: '替换',
+        revoke: '撤销',
+        link: '关联站点',
+        sites: '更新站点',
+        delete: '删除',
+    };
+    return labels[action] || action;
+}
+
+// 获取任务类型名称
+export function getJobTypeLabel(type: CertificateJob['type']): string {
+    const labels: Record<CertificateJob['type'], string> = {
+        issue: '申请',
+        renew: '续期',
+        reissue: '重新签发',
+    };
+    return labels[type] || type;
+}
+
+// === 内部 CA API ===
+
+// 内部 CA 信息
+export interface InternalCA {
+    configured: boolean;
+    id?: string;
+    name?: string;
+    rootSubject?: string;
+    rootNotAfter?: string;
+    rootFingerprint?: string; // 根证书 SHA-256 指纹
+    hasRootKey: boolean;
+    issuerSubject?: string;
+    issuerNotAfter?: string;
+    leafDays?: number; // 签发证书的有效天数
+    certificateCount: number;
+    rootCertificatePath?: string;
+}
+
+// 导入 CA：只提供根证书和私钥时自动生成中间证书
+export interface ImportCAData {
+    name?: string;
+    rootCertificate: string;
+    rootKey?: string;
+    issuingCertificate?: string;
+    issuingKey?: string;
+    leafDays?: number;
+}
+
+// 获取内部 CA
+export async function getInternalCA(): Promise<InternalCA> {
+    const res = await fetch(`${API_BASE}/ca`);
+    return res.json();
+}
+
+// 生成内部 CA
+export async function generateInternalCA(
+    name: string,
+    leafDays: number
+): Promise<{ success: boolean; ca?: InternalCA; error?: string }> {
+    const res = await fetch(`${API_BASE}/ca`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name, leafDays }),
+    });
+    return res.json();
+}
+
+// 导入已有的 CA
+export async function importInternalCA(
+    data: ImportCAData
+): Promise<{ success: boolean; ca?: InternalCA; error?: string }> {
+    const res = await fetch(`${API_BASE}/ca/import`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data),
+    });
+    return res.json();
+}
+
+// 修改签发证书的有效天数
+export async function updateInternalCA(
+    leafDays: number
+): Promise<{ success: boolean; ca?: InternalCA; error?: string }> {
+    const res = await fetch(`${API_BASE}/ca`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ leafDays }),
+    });
+    return res.json();
+}
+
+// 删除内部 CA（需先删除其签发的证书）
+export async function deleteInternalCA(): Promise<{ success: boolean; error?: string }> {
+    const res = await fetch(`${API_BASE}/ca`, {
+        method: 'DELETE',
+    });
+    return res.json();
+}
+
+// 根证书下载地址
+export const INTERNAL_CA_ROOT_URL = `${API_BASE}/ca/root.crt`;
+
+// 用内部 CA 签发证书，replace 为要替换的证书 ID
+export async function issueInternalCertificate(
+    domains: string[],
+    usage: CertificateUsage,
+    keyOptions: CertificateKeyOptions = {},
+    replace = ''
+): Promise<{ success: boolean; certificate?: Certificate; error?: string }> {
+    const res = await fetch(`${API_BASE}/certificates/internal`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ domains, usage, keyType: keyOptions.keyType, reuseKey: keyOptions.reuseKey, replace }),
+    });
+    return res.json();
+}
+
+// === 客户端 CA API ===
+
+// 校验客户端证书（mTLS）的 CA 证书包
+export interface ClientCA {
+    id: string;
+    name: string;
+    subjects: string[]; // 证书包中各 CA 的名称
+    notAfter: string; // 最早过期的 CA 证书的过期时间
+    certPath: string;
+    sites: string[]; // 使用该 CA 的站点
+    createdAt: string;
+    updatedAt: string;
+}
+
+// 获取客户端 CA 列表
+export async function listClientCAs(): Promise<{ clientCas: ClientCA[] }> {
+    const res = await fetch(`${API_BASE}/client-cas`);
+    return res.json();
+}
+
+// 创建客户端 CA
+export async function createClientCA(
+    name: string,
+    certificate: string
+): Promise<{ success: boolean; clientCa?: ClientCA; error?: string }> {
+    const res = await fetch(`${API_BASE}/client-cas`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name, certificate }),
+    });
+    return res.json();
+}
+
+// 修改客户端 CA（证书为空时只改名称）
+export async function updateClientCA(
+    id: string,
+    name: string,
+    certificate: string
+): Promise<{ success: boolean; clientCa?: ClientCA; error?: string }> {
+    const res = await fetch(`${API_BASE}/client-cas/${id}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name, certificate }),
+    });
+    return res.json();
+}
+
+// 删除客户端 CA
+export async function deleteClientCA(id: string): Promise<{ success: boolean; error?: string }> {
+    const res = await fetch(`${API_BASE}/client-cas/${id}`, {
+        method: 'DELETE',
+    });
+    return res.json();
+}
+
 // === 系统状态 API ===
 
 // 获取 SSL 系统状态
@@ -202,11 +802,43 @@ export function getDNSProviderLabel(type: DNSProviderType): string {
             return '腾讯云 DNSPod';
         case 'cloudflare':
             return 'Cloudflare';
+        case 'rfc2136':
+            return 'RFC 2136';
+        case 'dnspod':
+            return 'DNSPod Token';
+        case 'huaweicloud':
+            return '华为云 DNS';
+        case 'route53':
+            return 'Amazon Route 53';
+        case 'gandi':
+            return 'Gandi LiveDNS';
+        case 'digitalocean':
+            return 'DigitalOcean';
+        case 'powerdns':
+            return 'PowerDNS';
         default:
             return type;
     }
 }
 
+// 获取私钥算法显示名称
+export function getKeyTypeLabel(keyType: KeyType | ''): string {
+    switch (keyType) {
+        case 'ec256':
+            return 'ECDSA P-256';
+        case 'ec384':
+            return 'ECDSA P-384';
+        case 'rsa2048':
+            return 'RSA 2048';
+        case 'rsa3072':
+            return 'RSA 3072';
+        case 'rsa4096':
+            return 'RSA 4096';
+        default:
+            return '未知';
+    }
+}
+
 // 获取证书状态显示
 export function getCertificateStatusLabel(status: Certificate['status']): string {
     switch (status) {
@@ -218,6 +850,8 @@ export function getCertificateStatusLabel(status: Certificate['status']): string
             return '已过期';
         case 'error':
             return '错误';
+        case 'revoked':
+            return '已撤销';
         default:
             return status;
     }
@@ -234,6 +868,8 @@ export function getCertificateStatusColor(status: Certificate['status']): string
             return 'text-red-500';
         case 'error':
             return 'text-red-500';
+        case 'revoked':
+            return 'text-red-500';
         default:
             return 'text-gray-500';
     }
@@ -0,0 +1,431 @@
+import { useState, useEffect } from 'react';
+import { toast } from 'sonner';
+import { Loader2, Plus, Play, Pencil, Trash2, Webhook, CheckCircle2, XCircle } from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
+import { Switch } from '@/components/ui/switch';
+import {
+    Dialog,
+    DialogContent,
+    DialogDescription,
+    DialogFooter,
+    DialogHeader,
+    DialogTitle,
+} from '@/components/ui/dialog';
+import {
+    listCertificateHooks,
+    createCertificateHook,
+    updateCertificateHook,
+    deleteCertificateHook,
+    runCertificateHook,
+    getHookTypeLabel,
+    type Certificate,
+    type CertificateHook,
+    type CertificateHookData,
+    type HookType,
+} from '@/api/ssl';
+
+interface HooksDialogProps {
+    cert: Certificate | null;
+    open: boolean;
+    onOpenChange: (open: boolean) => void;
+}
+
+const emptyForm: CertificateHookData = {
+    type: 'copy',
+    certPath: '',
+    keyPath: '',
+    owner: '',
+    mode: '',
+    command: '',
+    timeout: 60,
+    password: '',
+    legacy: false,
+};
+
+// 钩子的目标或命令，用于列表展示
+function hookTarget(hook: CertificateHook): string {
+    if (hook.type === 'command') return hook.command;
+    return [hook.certPath, hook.keyPath].filter(Boolean).join(', ');
+}
+
+export function HooksDialog({ cert, open, onOpenChange }: HooksDialogProps) {
+    const [hooks, setHooks] = useState<CertificateHook[]>([]);
+    const [loading, setLoading] = useState(false);
+    const [editing, setEditing] = useState<CertificateHook | null>(null); // 正在修改的钩子
+    const [formOpen, setFormOpen] = useState(false);
+    const [form, setForm] = useState<CertificateHookData>(emptyForm);
+    const [saving, setSaving] = useState(false);
+    const [runningId, setRunningId] = useState('');
+
+    useEffect(() => {
+        if (open && cert) {
+            setFormOpen(false);
+            loadHooks();
+        }
+    }, [open, cert]);
+
+    const loadHooks = async () => {
+        if (!cert) return;
+        setLoading(true);
+        try {
+            const res = await listCertificateHooks(cert.id);
+            setHooks(res.hooks);
+        } catch (err) {
+            toast.error('加载部署钩子失败');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const openForm = (hook: CertificateHook | null) => {
+        setEditing(hook);
+        setForm(
+            hook
+                ? {
+                      type: hook.type,
+                      certPath: hook.certPath,
+                      keyPath: hook.keyPath,
+                      owner: hook.owner,
+                      mode: hook.mode,
+                      command: hook.command,
+                      timeout: hook.timeout || 60,
+                      password: '',
+                      legacy: hook.legacy,
+                  }
+                : emptyForm
+        );
+        setFormOpen(true);
+    };
+
+    const updateForm = (patch: Partial<CertificateHookData>) => setForm((prev) => ({ ...prev, ...patch }));
+
+    const handleSave = async () => {
+        if (!cert) return;
+        if (form.type === 'copy' && !form.certPath?.trim() && !form.keyPath?.trim()) {
+            toast.error('请填写证书或私钥的目标路径');
+            return;
+        }
+        if (form.type === 'pkcs12' && (!form.certPath?.trim() || (!editing?.hasPassword && !form.password))) {
+            toast.error('请填写文件路径和密码');
+            return;
+        }
+        if (form.type === 'command' && !form.command?.trim()) {
+            toast.error('请填写命令');
+            return;
+        }
+
+        setSaving(true);
+        try {
+            const result = editing
+                ? await updateCertificateHook(cert.id, editing.id, form)
+                : await createCertificateHook(cert.id, form);
+            if (result.success) {
+                toast.success(editing ? '部署钩子已更新' : '部署钩子已添加');
+                setFormOpen(false);
+                loadHooks();
+            } else {
+                toast.error(result.error || '保存失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleToggle = async (hook: CertificateHook, enabled: boolean) => {
+        if (!cert) return;
+        const result = await updateCertificateHook(cert.id, hook.id, { ...hook, enabled, password: '' });
+        if (result.success) {
+            setHooks((prev) => prev.map((h) => (h.id === hook.id ? { ...h, enabled } : h)));
+        } else {
+            toast.error(result.error || '更新失败');
+        }
+    };
+
+    const handleRun = async (hook: CertificateHook) => {
+        if (!cert) return;
+        setRunningId(hook.id);
+        try {
+            const result = await runCertificateHook(cert.id, hook.id);
+            if (result.success) {
+                toast.success('执行成功');
+            } else {
+                toast.error(result.error || '执行失败');
+            }
+            loadHooks();
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setRunningId('');
+        }
+    };
+
+    const handleDelete = async (hook: CertificateHook) => {
+        if (!cert) return;
+        const result = await deleteCertificateHook(cert.id, hook.id);
+        if (result.success) {
+            toast.success('部署钩子已删除');
+            loadHooks();
+        } else {
+            toast.error(result.error || '删除失败');
+        }
+    };
+
+    const labelClass = 'text-xs font-mono uppercase tracking-wider text-muted-foreground';
+
+    return (
+        <Dialog open={open} onOpenChange={onOpenChange}>
+            <DialogContent className="max-w-2xl">
+                <DialogHeader>
+                    <DialogTitle className="flex items-center gap-2">
+                        <Webhook className="h-5 w-5 text-primary" />
+                        部署钩子
+                    </DialogTitle>
+                    <DialogDescription className="font-mono">
+                        {cert?.domain} 申请或续期成功后，把新证书推送到邮件服务器、MQTT、Java 密钥库等其他服务
+                    </DialogDescription>
+                </DialogHeader>
+
+                {formOpen ? (
+                    <div className="space-y-4 py-2">
+                        <div className="space-y-2">
+                            <Label htmlFor="hookType" className={labelClass}>
+                                类型
+                            </Label>
+                            <select
+                                id="hookType"
+                                value={form.type}
+                                onChange={(e) => updateForm({ type: e.target.value as HookType })}
+                                className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                            >
+                                <option value="copy">复制证书和私钥</option>
+                                <option value="command">执行命令</option>
+                                <option value="pkcs12">导出 PKCS#12（Java 密钥库）</option>
+                            </select>
+                        </div>
+
+                        {form.type === 'copy' && (
+                            <>
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookCertPath" className={labelClass}>
+                                        证书路径
+                                    </Label>
+                                    <Input
+                                        id="hookCertPath"
+                                        placeholder="/etc/postfix/tls/cert.pem"
+                                        value={form.certPath}
+                                        onChange={(e) => updateForm({ certPath: e.target.value })}
+                                        className="font-mono"
+                                    />
+                                    <p className="text-xs text-muted-foreground">包含证书链，留空则不复制</p>
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookKeyPath" className={labelClass}>
+                                        私钥路径
+                                    </Label>
+                                    <Input
+                                        id="hookKeyPath"
+                                        placeholder="/etc/postfix/tls/key.pem"
+                                        value={form.keyPath}
+                                        onChange={(e) => updateForm({ keyPath: e.target.value })}
+                                        className="font-mono"
+                                    />
+                                </div>
+                            </>
+                        )}
+
+                        {form.type === 'pkcs12' && (
+                            <>
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookP12Path" className={labelClass}>
+                                        文件路径
+                                    </Label>
+                                    <Input
+                                        id="hookP12Path"
+                                        placeholder="/opt/app/conf/keystore.p12"
+                                        value={form.certPath}
+                                        onChange={(e) => updateForm({ certPath: e.target.value })}
+                                        className="font-mono"
+                                    />
+                                    <p className="text-xs text-muted-foreground">Java 9 及以上可直接作为 keystore 使用（storetype PKCS12）</p>
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookPassword" className={labelClass}>
+                                        密码
+                                    </Label>
+                                    <Input
+                                        id="hookPassword"
+                                        type="password"
+                                        placeholder={editing?.hasPassword ? '留空保持原密码' : ''}
+                                        value={form.password}
+                                        onChange={(e) => updateForm({ password: e.target.value })}
+                                        className="font-mono"
+                                    />
+                                </div>
+                                <div className="flex items-center justify-between">
+                                    <div>
+                                        <Label htmlFor="hookLegacy">兼容旧版</Label>
+                                        <p className="text-xs text-muted-foreground">使用 3DES 加密，Java 8 和 Windows Server 2016 等旧版本需要开启</p>
+                                    </div>
+                                    <Switch
+                                        id="hookLegacy"
+                                        checked={form.legacy}
+                                        onCheckedChange={(checked) => updateForm({ legacy: checked })}
+                                    />
+                                </div>
+                            </>
+                        )}
+
+                        {form.type === 'command' && (
+                            <>
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookCommand" className={labelClass}>
+                                        命令
+                                    </Label>
+                                    <textarea
+                                        id="hookCommand"
+                                        rows={3}
+                                        placeholder="systemctl reload postfix"
+                                        value={form.command}
+                                        onChange={(e) => updateForm({ command: e.target.value })}
+                                        className="w-full px-3 py-2 bg-background border rounded-md text-sm font-mono"
+                                    />
+                                    <p className="text-xs text-muted-foreground">
+                                        通过 sh -c 执行，可使用 $HOP_CERT_PATH、$HOP_KEY_PATH、$HOP_CERT_DOMAIN 等环境变量
+                                    </p>
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookTimeout" className={labelClass}>
+                                        超时（秒）
+                                    </Label>
+                                    <Input
+                                        id="hookTimeout"
+                                        type="number"
+                                        min={1}
+                                        max={600}
+                                        value={form.timeout}
+                                        onChange={(e) => updateForm({ timeout: Number(e.target.value) })}
+                                        className="font-mono"
+                                    />
+                                </div>
+                            </>
+                        )}
+
+                        {form.type !== 'command' && (
+                            <div className="grid grid-cols-2 gap-4">
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookOwner" className={labelClass}>
+                                        所有者
+                                    </Label>
+                                    <Input
+                                        id="hookOwner"
+                                        placeholder="postfix:postfix"
+                                        value={form.owner}
+                                        onChange={(e) => updateForm({ owner: e.target.value })}
+                                        className="font-mono"
+                                    />
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="hookMode" className={labelClass}>
+                                        权限
+                                    </Label>
+                                    <Input
+                                        id="hookMode"
+                                        placeholder={form.type === 'copy' ? '证书 0644，私钥 0600' : '0600'}
+                                        value={form.mode}
+                                        onChange={(e) => updateForm({ mode: e.target.value })}
+                                        className="font-mono"
+                                    />
+                                </div>
+                            </div>
+                        )}
+                    </div>
+                ) : (
+                    <div className="border rounded divide-y max-h-96 overflow-y-auto">
+                        {loading ? (
+                            <div className="flex items-center justify-center py-8">
+                                <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+                            </div>
+                        ) : hooks.length === 0 ? (
+                            <p className="text-sm text-muted-foreground text-center py-8">暂无部署钩子</p>
+                        ) : (
+                            hooks.map((hook) => (
+                                <div key={hook.id} className="flex items-center gap-3 px-3 py-2">
+                                    <Switch checked={hook.enabled} onCheckedChange={(checked) => handleToggle(hook, checked)} />
+                                    <div className="min-w-0 flex-1">
+                                        <p className="text-sm">
+                                            {getHookTypeLabel(hook.type)}
+                                            {hook.owner && <span className="text-xs text-muted-foreground font-mono"> · {hook.owner}</span>}
+                                            {hook.mode && <span className="text-xs text-muted-foreground font-mono"> · {hook.mode}</span>}
+                                        </p>
+                                        <p className="text-xs text-muted-foreground font-mono truncate" title={hookTarget(hook)}>
+                                            {hookTarget(hook)}
+                                        </p>
+                                        {hook.lastRunAt && (
+                                            <p
+                                                className={`text-xs flex items-center gap-1 truncate ${hook.lastError ? 'text-red-500' : 'text-green-500'}`}
+                                                title={hook.lastError}
+                                            >
+                                                {hook.lastError ? <XCircle className="h-3 w-3 shrink-0" /> : <CheckCircle2 className="h-3 w-3 shrink-0" />}
+                                                {new Date(hook.lastRunAt).toLocaleString('zh-CN')}
+                                                {hook.lastError && ` · ${hook.lastError}`}
+                                            </p>
+                                        )}
+                                    </div>
+                                    <Button
+                                        variant="ghost"
+                                        size="icon-sm"
+                                        onClick={() => handleRun(hook)}
+                                        disabled={runningId !== '' || cert?.status !== 'active'}
+                                        title="立即执行"
+                                    >
+                                        {runningId === hook.id ? <Loader2 className="h-4 w-4 animate-spin" /> : <Play className="h-4 w-4" />}
+                                    </Button>
+                                    <Button variant="ghost" size="icon-sm" onClick={() => openForm(hook)} title="修改">
+                                        <Pencil className="h-4 w-4" />
+                                    </Button>
+                                    <Button
+                                        variant="ghost"
+                                        size="icon-sm"
+                                        onClick={() => handleDelete(hook)}
+                                        className="text-muted-foreground hover:text-destructive"
+                                        title="删除"
+                                    >
+                                        <Trash2 className="h-4 w-4" />
+                                    </Button>
+                                </div>
+                            ))
+                        )}
+                    </div>
+                )}
+
+                <DialogFooter>
+                    {formOpen ? (
+                        <>
+                            <Button variant="outline" onClick={() => setFormOpen(false)} disabled={saving}>
+                                返回
+                            </Button>
+                            <Button onClick={handleSave} disabled={saving}>
+                                {saving && <Loader2 className="h-4 w-4 animate-spin mr-2" />}
+                                保存
+                            </Button>
+                        </>
+                    ) : (
+                        <>
+                            <Button variant="outline" onClick={() => onOpenChange(false)}>
+                                关闭
+                            </Button>
+                            <Button onClick={() => openForm(null)}>
+                                <Plus className="h-4 w-4 mr-2" />
+                                添加钩子
+                            </Button>
+                        </>
+                    )}
+                </DialogFooter>
+            </DialogContent>
+        </Dialog>
+    );
+}
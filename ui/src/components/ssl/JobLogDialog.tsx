@@ -0,0 +1,134 @@
+import { useState, useEffect, useRef } from 'react';
+import { Loader2, CheckCircle2, XCircle, Clock, ScrollText } from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import {
+    Dialog,
+    DialogContent,
+    DialogDescription,
+    DialogFooter,
+    DialogHeader,
+    DialogTitle,
+} from '@/components/ui/dialog';
+import {
+    watchCertificateJob,
+    getIssueStageLabel,
+    getJobTypeLabel,
+    type CertificateJob,
+    type CertificateLog,
+} from '@/api/ssl';
+
+interface JobLogDialogProps {
+    job: CertificateJob | null; // 打开时订阅该任务的日志
+    open: boolean;
+    onOpenChange: (open: boolean) => void;
+    onFinished?: (job: CertificateJob) => void; // 任务结束时调用，关闭弹窗后仍会调用
+}
+
+export function JobLogDialog({ job, open, onOpenChange, onFinished }: JobLogDialogProps) {
+    const [logs, setLogs] = useState<CertificateLog[]>([]);
+    const [current, setCurrent] = useState<CertificateJob | null>(job);
+    const bottomRef = useRef<HTMLDivElement>(null);
+    const onFinishedRef = useRef(onFinished);
+    onFinishedRef.current = onFinished;
+
+    // 任务结束前一直订阅，关闭弹窗不影响后台执行
+    useEffect(() => {
+        if (!job) return;
+        setLogs([]);
+        setCurrent(job);
+        return watchCertificateJob(
+            job.id,
+            (log) => {
+                setLogs((prev) => [...prev, log]);
+                setCurrent((prev) => (prev && prev.status === 'queued' && log.action !== 'queued' ? { ...prev, status: 'running' } : prev));
+            },
+            (finished) => {
+                setCurrent(finished);
+                onFinishedRef.current?.(finished);
+            }
+        );
+    }, [job]);
+
+    useEffect(() => {
+        bottomRef.current?.scrollIntoView({ block: 'end' });
+    }, [logs]);
+
+    const statusLabel = () => {
+        switch (current?.status) {
+            case 'queued':
+                return (
+                    <span className="flex items-center gap-1.5 text-yellow-500">
+                        <Clock className="h-4 w-4" />
+                        排队中
+                    </span>
+                );
+            case 'running':
+                return (
+                    <span className="flex items-center gap-1.5 text-primary">
+                        <Loader2 className="h-4 w-4 animate-spin" />
+                        执行中
+                    </span>
+                );
+            case 'succeeded':
+                return (
+                    <span className="flex items-center gap-1.5 text-green-500">
+                        <CheckCircle2 className="h-4 w-4" />
+                        已完成
+                    </span>
+                );
+            case 'failed':
+                return (
+                    <span className="flex items-center gap-1.5 text-red-500">
+                        <XCircle className="h-4 w-4" />
+                        失败
+                    </span>
+                );
+        }
+        return null;
+    };
+
+    return (
+        <Dialog open={open} onOpenChange={onOpenChange}>
+            <DialogContent className="max-w-2xl">
+                <DialogHeader>
+                    <DialogTitle className="flex items-center gap-2">
+                        <ScrollText className="h-5 w-5 text-primary" />
+                        {getJobTypeLabel(current?.type || 'issue')}证书
+                    </DialogTitle>
+                    <DialogDescription className="font-mono flex items-center justify-between">
+                        <span>{current?.domain}</span>
+                        {statusLabel()}
+                    </DialogDescription>
+                </DialogHeader>
+                <div className="bg-muted/50 border rounded h-72 overflow-y-auto p-3 font-mono text-xs space-y-1">
+                    {logs.map((log) => (
+                        <div key={log.id} className="flex gap-2">
+                            <span className="text-muted-foreground shrink-0">
+                                {new Date(log.createdAt).toLocaleTimeString('zh-CN')}
+                            </span>
+                            <span className={`shrink-0 w-16 ${log.action === 'error' ? 'text-red-500' : 'text-primary'}`}>
+                                {getIssueStageLabel(log.action)}
+                            </span>
+                            <span className="break-all">{log.message}</span>
+                        </div>
+                    ))}
+                    {current && (current.status === 'queued' || current.status === 'running') && (
+                        <div className="flex items-center gap-2 text-muted-foreground">
+                            <Loader2 className="h-3 w-3 animate-spin" />
+                            <span>等待中...</span>
+                        </div>
+                    )}
+                    <div ref={bottomRef} />
+                </div>
+                {current?.status === 'failed' && current.error && (
+                    <p className="text-sm text-red-500">{current.error}</p>
+                )}
+                <DialogFooter>
+                    <Button variant="outline" onClick={() => onOpenChange(false)}>
+                        {current?.status === 'queued' || current?.status === 'running' ? '后台运行' : '关闭'}
+                    </Button>
+                </DialogFooter>
+            </DialogContent>
+        </Dialog>
+    );
+}
@@ -0,0 +1,67 @@
+import { Label } from '@/components/ui/label';
+import { Switch } from '@/components/ui/switch';
+import { type CertificateKeyOptions, type KeyType, getKeyTypeLabel } from '@/api/ssl';
+
+const KEY_TYPES: KeyType[] = ['ec256', 'ec384', 'rsa2048', 'rsa3072', 'rsa4096'];
+
+interface KeyOptionsFieldsProps {
+    value: CertificateKeyOptions;
+    onChange: (value: CertificateKeyOptions) => void;
+    hideDual?: boolean; // 内部 CA 证书不支持双证书
+}
+
+// 证书私钥设置：算法、续期复用私钥、ECDSA + RSA 双证书
+export function KeyOptionsFields({ value, onChange, hideDual }: KeyOptionsFieldsProps) {
+    const keyType = value.keyType || 'ec256';
+    const isECDSA = keyType.startsWith('ec');
+
+    return (
+        <div className="space-y-3">
+            <div className="space-y-2">
+                <Label htmlFor="keyType" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                    私钥算法
+                </Label>
+                <select
+                    id="keyType"
+                    value={keyType}
+                    onChange={(e) => {
+                        const next = e.target.value as KeyType;
+                        onChange({ ...value, keyType: next, dual: next.startsWith('ec') && value.dual });
+                    }}
+                    className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                >
+                    {KEY_TYPES.map((t) => (
+                        <option key={t} value={t}>
+                            {getKeyTypeLabel(t)}{t === 'ec256' && '（默认）'}
+                        </option>
+                    ))}
+                </select>
+            </div>
+            <div className="flex items-center justify-between">
+                <div>
+                    <Label className="text-sm">续期时复用私钥</Label>
+                    <p className="text-xs text-muted-foreground">用于公钥固定（HPKP）或 DANE/TLSA 记录</p>
+                </div>
+                <Switch
+                    checked={!!value.reuseKey}
+                    onCheckedChange={(checked: boolean) => onChange({ ...value, reuseKey: checked })}
+                />
+            </div>
+            {!hideDual && (
+                <div className="flex items-center justify-between">
+                    <div>
+                        <Label className="text-sm">ECDSA + RSA 双证书</Label>
+                        <p className="text-xs text-muted-foreground">
+                            {isECDSA ? '额外签发一张 RSA 2048 证书，兼容不支持 ECDSA 的旧客户端' : '仅 ECDSA 私钥可启用'}
+                        </p>
+                    </div>
+                    <Switch
+                        checked={!!value.dual}
+                        disabled={!isECDSA}
+                        onCheckedChange={(checked: boolean) => onChange({ ...value, dual: checked })}
+                    />
+                </div>
+            )}
+        </div>
+    );
+}
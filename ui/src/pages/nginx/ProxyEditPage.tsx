@@ -13,7 +13,9 @@ import {
     Unlock,
     AlertCircle,
     ExternalLink,
-    KeyRound
+    KeyRound,
+    BadgeCheck,
+    Lock
 } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
@@ -32,8 +34,22 @@ import {
     DialogHeader,
     DialogTitle,
 } from '@/components/ui/dialog';
-import { getProxySite, saveProxySite, previewProxySite, type ProxySite } from '@/api/nginx';
-import { listCertificates, type Certificate, getCertificateStatusLabel } from '@/api/ssl';
+import {
+    getProxySite,
+    saveProxySite,
+    previewProxySite,
+    TLS_PROFILES,
+    type ProxySite,
+    type TLSProfile,
+} from '@/api/nginx';
+import {
+    listCertificates,
+    listClientCAs,
+    getInternalCA,
+    type Certificate,
+    type ClientCA,
+    getCertificateStatusLabel,
+} from '@/api/ssl';
 
 const defaultSite: ProxySite = {
     id: '',
@@ -65,10 +81,17 @@ export default function ProxyEditPage() {
     // 证书列表
     const [certificates, setCertificates] = useState<Certificate[]>([]);
     const [certificatesLoading, setCertificatesLoading] = useState(true);
+    // 可向 HTTPS 上游出示的客户端证书
+    const [clientCertificates, setClientCertificates] = useState<Certificate[]>([]);
+
+    // 客户端 CA 列表（mTLS）
+    const [clientCAs, setClientCAs] = useState<ClientCA[]>([]);
+    const [internalCAConfigured, setInternalCAConfigured] = useState(false);
 
     // 加载证书列表
     useEffect(() => {
         loadCertificates();
+        loadClientCAs();
     }, []);
 
     useEffect(() => {
@@ -82,7 +105,9 @@ export default function ProxyEditPage() {
         try {
             const result = await listCertificates();
             // 只显示有效的证书
-            setCertificates(result.certificates?.filter(c => c.status === 'active') || []);
+            const active = result.certificates?.filter(c => c.status === 'active') || [];
+            setCertificates(active.filter(c => c.usage !== 'client'));
+            setClientCertificates(active.filter(c => c.usage === 'client'));
         } catch (err) {
             console.error('加载证书列表失败:', err);
             setCertificates([]);
@@ -91,6 +116,16 @@ export default function ProxyEditPage() {
         }
     };
 
+    const loadClientCAs = async () => {
+        try {
+            const [result, ca] = await Promise.all([listClientCAs(), getInternalCA()]);
+            setClientCAs(result.clientCas || []);
+            setInternalCAConfigured(ca.configured);
+        } catch (err) {
+            console.error('加载客户端 CA 失败:', err);
+        }
+    };
+
     const loadSite = async (id: string) => {
         setLoading(true);
         try {
@@ -118,9 +153,13 @@ export default function ProxyEditPage() {
             toast.error('请输入上游端口');
             return;
         }
-        // SSL 验证：启用 SSL 时必须选择证书
-        if (site.ssl && !site.certificateId) {
-            toast.error('请选择一个 SSL 证书');
+        if (site.ssl && site.clientAuth && !site.clientCa) {
+            toast.error('请选择校验客户端证书的 CA');
+            return;
+        }
+
+        if (site.upstreamScheme === 'https' && site.upstreamSslVerify && site.upstreamCa === 'internal' && !internalCAConfigured) {
+            toast.error('内部 CA 尚未配置');
             return;
         }
 
@@ -134,7 +173,12 @@ export default function ProxyEditPage() {
         setSaving(true);
         try {
             const result = await saveProxySite(siteToSave);
-            if (result.success) {
+            if (result.success && result.certificateJobId) {
+                toast.success('站点保存成功', {
+                    description: '正在申请证书，签发前站点以 HTTP 提供服务，签发后自动启用 HTTPS',
+                });
+                navigate('/');
+            } else if (result.success) {
                 toast.success('站点保存成功');
                 navigate('/');
             } else {
@@ -313,6 +357,84 @@ export default function ProxyEditPage() {
                                 />
                             </div>
                         </div>
+
+                        {/* 上游 TLS */}
+                        {site.upstreamScheme === 'https' && (
+                            <div className="border-t pt-3 space-y-3">
+                                <div className="grid grid-cols-2 gap-3">
+                                    <div className="space-y-1.5">
+                                        <Label htmlFor="upstreamSslName" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                            SNI 名称
+                                        </Label>
+                                        <Input
+                                            id="upstreamSslName"
+                                            value={site.upstreamSslName || ''}
+                                            onChange={(e) => updateSite({ upstreamSslName: e.target.value })}
+                                            placeholder={site.upstreamHost || 'backend.internal'}
+                                        />
+                                    </div>
+                                    <div className="space-y-1.5">
+                                        <Label className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                            客户端证书
+                                        </Label>
+                                        <Select
+                                            value={site.upstreamClientCertId || 'off'}
+                                            onValueChange={(value) => updateSite({ upstreamClientCertId: value === 'off' ? '' : value })}
+                                        >
+                                            <SelectTrigger>
+                                                <SelectValue />
+                                            </SelectTrigger>
+                                            <SelectContent>
+                                                <SelectItem value="off">不出示</SelectItem>
+                                                {clientCertificates.map((cert) => (
+                                                    <SelectItem key={cert.id} value={cert.id}>
+                                                        {cert.domain}
+                                                    </SelectItem>
+                                                ))}
+                                            </SelectContent>
+                                        </Select>
+                                    </div>
+                                </div>
+                                <div className="flex items-center justify-between">
+                                    <div>
+                                        <Label className="text-sm">校验上游证书</Label>
+                                        <p className="text-xs text-muted-foreground font-mono">
+                                            证书不受信任或名称不匹配时拒绝转发
+                                        </p>
+                                    </div>
+                                    <Switch
+                                        checked={!!site.upstreamSslVerify}
+                                        onCheckedChange={(checked: boolean) => updateSite({ upstreamSslVerify: checked })}
+                                    />
+                                </div>
+                                {site.upstreamSslVerify && (
+                                    <div className="space-y-1.5">
+                                        <Label className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                            受信任的 CA
+                                        </Label>
+                                        <Select
+                                            value={site.upstreamCa || 'system'}
+                                            onValueChange={(value) => updateSite({ upstreamCa: value === 'system' ? '' : value })}
+                                        >
+                                            <SelectTrigger>
+                                                <SelectValue />
+                                            </SelectTrigger>
+                                            <SelectContent>
+                                                <SelectItem value="system">系统 CA</SelectItem>
+                                                {internalCAConfigured && (
+                                                    <SelectItem value="internal">内部 CA</SelectItem>
+                                                )}
+                                                {clientCAs.map((ca) => (
+                                                    <SelectItem key={ca.id} value={ca.id}>
+                                                        {ca.name}
+                                                    </SelectItem>
+                                                ))}
+                                            </SelectContent>
+                                        </Select>
+                                    </div>
+                                )}
+                            </div>
+                        )}
                     </div>
 
                     {/* 功能选项 */}
@@ -338,7 +460,7 @@ export default function ProxyEditPage() {
                             </div>
                             <Switch
                                 checked={site.ssl}
-                                onCheckedChange={(checked: boolean) => updateSite({ ssl: checked, certificateId: '' })}
+                                onCheckedChange={(checked: boolean) => updateSite({ ssl: checked, certificateId: '', clientAuth: '' })}
                             />
                         </div>
 
@@ -355,13 +477,14 @@ export default function ProxyEditPage() {
                                             选择证书
                                         </Label>
                                         <Select
-                                            value={site.certificateId || ''}
-                                            onValueChange={(value) => updateSite({ certificateId: value })}
+                                            value={site.certificateId || 'auto'}
+                                            onValueChange={(value) => updateSite({ certificateId: value === 'auto' ? '' : value })}
                                         >
                                             <SelectTrigger>
-                                                <SelectValue placeholder="请选择一个证书" />
+                                                <SelectValue />
                                             </SelectTrigger>
                                             <SelectContent>
+                                                <SelectItem value="auto">自动选择覆盖该域名的证书</SelectItem>
                                                 {certificates.map((cert) => (
                                                     <SelectItem key={cert.id} value={cert.id}>
                                                         <div className="flex items-center gap-2">
@@ -379,7 +502,7 @@ export default function ProxyEditPage() {
                                     <div className="bg-amber-500/10 border border-amber-500/20 p-3 rounded flex items-center justify-between gap-3">
                                         <div className="flex items-center gap-2">
                                             <AlertCircle className="h-4 w-4 text-amber-500 shrink-0" />
-                                            <span className="text-sm text-amber-500">尚未申请证书</span>
+                                            <span className="text-sm text-amber-500">尚未申请证书，开启自动申请时保存后自动申请</span>
                                         </div>
                                         <Link to="/ssl">
                                             <Button variant="outline" size="sm" className="gap-1.5 text-xs h-7">
@@ -389,6 +512,156 @@ export default function ProxyEditPage() {
                                         </Link>
                                     </div>
                                 )}
+
+                                {site.certificateJobId && !site.certificateId && (
+                                    <div className="bg-primary/10 border border-primary/20 p-3 rounded flex items-center justify-between gap-3">
+                                        <div className="flex items-center gap-2">
+                                            <Loader2 className="h-4 w-4 text-primary shrink-0" />
+                                            <span className="text-sm">等待自动申请的证书，签发前站点以 HTTP 提供服务；申请失败时重新保存即可再次申请</span>
+                                        </div>
+                                        <Link to="/ssl">
+                                            <Button variant="outline" size="sm" className="gap-1.5 text-xs h-7">
+                                                <ExternalLink className="h-3 w-3" />
+                                                查看进度
+                                            </Button>
+                                        </Link>
+                                    </div>
+                                )}
+
+                                {/* TLS 设置 */}
+                                <div className="border-t pt-3 space-y-3">
+                                    <div className="flex items-center gap-2">
+                                        <Lock className="h-4 w-4 text-primary" />
+                                        <Label className="text-sm font-semibold">TLS 设置</Label>
+                                    </div>
+                                    <div className="grid grid-cols-2 gap-3">
+                                        <div className="space-y-1.5">
+                                            <Label className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                                TLS 配置档
+                                            </Label>
+                                            <Select
+                                                value={site.tlsProfile || 'global'}
+                                                onValueChange={(value) =>
+                                                    updateSite({ tlsProfile: value === 'global' ? '' : (value as TLSProfile) })
+                                                }
+                                                disabled={!!site.tls13Only}
+                                            >
+                                                <SelectTrigger>
+                                                    <SelectValue />
+                                                </SelectTrigger>
+                                                <SelectContent>
+                                                    <SelectItem value="global">使用全局设置</SelectItem>
+                                                    {TLS_PROFILES.map((profile) => (
+                                                        <SelectItem key={profile.value} value={profile.value}>
+                                                            {profile.label}
+                                                        </SelectItem>
+                                                    ))}
+                                                </SelectContent>
+                                            </Select>
+                                        </div>
+                                        <div className="flex items-center justify-between">
+                                            <div>
+                                                <Label className="text-sm">仅允许 TLS 1.3</Label>
+                                                <p className="text-xs text-muted-foreground">
+                                                    拒绝 TLS 1.2 及以下的客户端
+                                                </p>
+                                            </div>
+                                            <Switch
+                                                checked={!!site.tls13Only}
+                                                onCheckedChange={(checked: boolean) => updateSite({ tls13Only: checked })}
+                                            />
+                                        </div>
+                                    </div>
+                                </div>
+
+                                {/* 客户端证书认证 */}
+                                <div className="border-t pt-3 space-y-3">
+                                    <div className="flex items-center gap-2">
+                                        <BadgeCheck className="h-4 w-4 text-primary" />
+                                        <Label className="text-sm font-semibold">客户端证书认证（mTLS）</Label>
+                                    </div>
+                                    <div className="grid grid-cols-2 gap-3">
+                                        <div className="space-y-1.5">
+                                            <Label className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                                校验模式
+                                            </Label>
+                                            <Select
+                                                value={site.clientAuth || 'off'}
+                                                onValueChange={(value) =>
+                                                    updateSite({ clientAuth: value === 'off' ? '' : (value as 'optional' | 'required') })
+                                                }
+                                            >
+                                                <SelectTrigger>
+                                                    <SelectValue />
+                                                </SelectTrigger>
+                                                <SelectContent>
+                                                    <SelectItem value="off">不校验</SelectItem>
+                                                    <SelectItem value="optional">可选（由上游判断）</SelectItem>
+                                                    <SelectItem value="required">必须提供有效证书</SelectItem>
+                                                </SelectContent>
+                                            </Select>
+                                        </div>
+                                        {site.clientAuth && (
+                                            <div className="space-y-1.5">
+                                                <Label className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                                    客户端 CA
+                                                </Label>
+                                                <Select
+                                                    value={site.clientCa || ''}
+                                                    onValueChange={(value) => updateSite({ clientCa: value })}
+                                                >
+                                                    <SelectTrigger>
+                                                        <SelectValue placeholder="请选择 CA" />
+                                                    </SelectTrigger>
+                                                    <SelectContent>
+                                                        {internalCAConfigured && (
+                                                            <SelectItem value="internal">内部 CA</SelectItem>
+                                                        )}
+                                                        {clientCAs.map((ca) => (
+                                                            <SelectItem key={ca.id} value={ca.id}>
+                                                                {ca.name}
+                                                            </SelectItem>
+                                                        ))}
+                                                    </SelectContent>
+                                                </Select>
+                                            </div>
+                                        )}
+                                    </div>
+                                    {site.clientAuth && (
+                                        <>
+                                            <div className="space-y-1.5">
+                                                <Label htmlFor="clientVerifyDepth" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                                    证书链校验深度
+                                                </Label>
+                                                <Input
+                                                    id="clientVerifyDepth"
+                                                    type="number"
+                                                    min={1}
+                                                    max={10}
+                                                    value={site.clientVerifyDepth || 2}
+                                                    onChange={(e) => updateSite({ clientVerifyDepth: parseInt(e.target.value) || 2 })}
+                                                />
+                                            </div>
+                                            <div className="flex items-center justify-between">
+                                                <div>
+                                                    <Label className="text-sm">转发客户端证书信息</Label>
+                                                    <p className="text-xs text-muted-foreground font-mono">
+                                                        X-SSL-Client-Verify / S-DN / Serial / Fingerprint
+                                                    </p>
+                                                </div>
+                                                <Switch
+                                                    checked={!!site.clientCertHeaders}
+                                                    onCheckedChange={(checked: boolean) => updateSite({ clientCertHeaders: checked })}
+                                                />
+                                            </div>
+                                            {!internalCAConfigured && clientCAs.length === 0 && (
+                                                <p className="text-xs text-amber-500">
+                                                    尚未配置 CA，请先在 <Link to="/ssl/client-cas" className="underline">客户端 CA</Link> 中添加
+                                                </p>
+                                            )}
+                                        </>
+                                    )}
+                                </div>
                             </div>
                         )}
 
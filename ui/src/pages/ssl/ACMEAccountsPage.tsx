@@ -0,0 +1,477 @@
+import { useState, useEffect } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { toast } from 'sonner';
+import {
+    Plus,
+    Trash2,
+    ChevronLeft,
+    Loader2,
+    UserRound,
+    Pencil,
+    Mail
+} from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
+import {
+    Dialog,
+    DialogContent,
+    DialogDescription,
+    DialogFooter,
+    DialogHeader,
+    DialogTitle,
+} from '@/components/ui/dialog';
+import {
+    AlertDialog,
+    AlertDialogAction,
+    AlertDialogCancel,
+    AlertDialogContent,
+    AlertDialogDescription,
+    AlertDialogFooter,
+    AlertDialogHeader,
+    AlertDialogTitle,
+} from '@/components/ui/alert-dialog';
+import {
+    listACMEAccounts,
+    createACMEAccount,
+    updateACMEAccount,
+    deleteACMEAccount,
+    getSSLStatus,
+    type ACMEAccount,
+    type ACMEPreset,
+} from '@/api/ssl';
+
+export default function ACMEAccountsPage() {
+    const navigate = useNavigate();
+    const [accounts, setAccounts] = useState<ACMEAccount[]>([]);
+    const [presets, setPresets] = useState<ACMEPreset[]>([]);
+    const [defaultDirectory, setDefaultDirectory] = useState('');
+    const [loading, setLoading] = useState(true);
+
+    // 注册账户弹窗
+    const [createDialogOpen, setCreateDialogOpen] = useState(false);
+    const [creating, setCreating] = useState(false);
+    const [email, setEmail] = useState('');
+    const [acmeDirectory, setACMEDirectory] = useState(''); // '' 使用默认目录，custom 为自定义地址
+    const [customDirectory, setCustomDirectory] = useState('');
+    const [eabKid, setEabKid] = useState('');
+    const [eabHmacKey, setEabHmacKey] = useState('');
+
+    // 修改邮箱弹窗
+    const [editingAccount, setEditingAccount] = useState<ACMEAccount | null>(null);
+    const [editEmail, setEditEmail] = useState('');
+    const [saving, setSaving] = useState(false);
+
+    // 删除账户弹窗
+    const [deleteDialogOpen, setDeleteDialogOpen] = useState(false);
+    const [deletingAccount, setDeletingAccount] = useState<ACMEAccount | null>(null);
+    const [deleting, setDeleting] = useState(false);
+
+    useEffect(() => {
+        loadAccounts();
+    }, []);
+
+    const loadAccounts = async () => {
+        try {
+            const [result, status] = await Promise.all([
+                listACMEAccounts(),
+                getSSLStatus(),
+            ]);
+            setAccounts(result.accounts);
+            setPresets(status.directories);
+            setDefaultDirectory(status.acmeDirectory);
+        } catch (err) {
+            console.error('Failed to load accounts:', err);
+            toast.error('加载 ACME 账户失败');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const resetForm = () => {
+        setEmail('');
+        setACMEDirectory('');
+        setCustomDirectory('');
+        setEabKid('');
+        setEabHmacKey('');
+    };
+
+    const handleCreate = async () => {
+        if (!email.trim()) {
+            toast.error('请输入邮箱');
+            return;
+        }
+        if (acmeDirectory === 'custom' && !customDirectory.trim()) {
+            toast.error('请输入 ACME 目录地址');
+            return;
+        }
+        if (!eabKid.trim() !== !eabHmacKey.trim()) {
+            toast.error('EAB Key ID 和 HMAC 密钥需要同时填写');
+            return;
+        }
+
+        setCreating(true);
+        try {
+            const result = await createACMEAccount(email.trim(), {
+                acmeDirectory: acmeDirectory === 'custom' ? customDirectory.trim() : acmeDirectory,
+                eabKid: eabKid.trim(),
+                eabHmacKey: eabHmacKey.trim(),
+            });
+            if (result.success) {
+                toast.success('ACME 账户注册成功');
+                setCreateDialogOpen(false);
+                resetForm();
+                loadAccounts();
+            } else {
+                toast.error(result.error || '注册失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setCreating(false);
+        }
+    };
+
+    const handleSave = async () => {
+        if (!editingAccount) return;
+        if (!editEmail.trim()) {
+            toast.error('请输入邮箱');
+            return;
+        }
+
+        setSaving(true);
+        try {
+            const result = await updateACMEAccount(editingAccount.id, editEmail.trim());
+            if (result.success) {
+                toast.success('联系邮箱已更新');
+                setEditingAccount(null);
+                loadAccounts();
+            } else {
+                toast.error(result.error || '更新失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleDelete = async () => {
+        if (!deletingAccount) return;
+
+        setDeleting(true);
+        try {
+            const result = await deleteACMEAccount(deletingAccount.id);
+            if (result.success) {
+                toast.success('ACME 账户已删除');
+                setDeleteDialogOpen(false);
+                setDeletingAccount(null);
+                loadAccounts();
+            } else {
+                toast.error(result.error || '删除失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setDeleting(false);
+        }
+    };
+
+    const selectedPreset = presets.find(p => p.name === acmeDirectory);
+
+    if (loading) {
+        return (
+            <div className="min-h-screen flex items-center justify-center">
+                <Loader2 className="h-8 w-8 animate-spin text-primary" />
+            </div>
+        );
+    }
+
+    return (
+        <div className="min-h-screen flex flex-col">
+            {/* Header */}
+            <header className="border-b bg-card/80 backdrop-blur-sm sticky top-0 z-50">
+                <div className="flex h-14 items-center justify-between px-4 lg:px-6">
+                    <div className="flex items-center gap-4">
+                        <Button
+                            variant="ghost"
+                            size="sm"
+                            onClick={() => navigate('/ssl')}
+                            className="gap-2"
+                        >
+                            <ChevronLeft className="h-4 w-4" />
+                            返回
+                        </Button>
+                        <div className="w-px h-6 bg-border" />
+                        <div className="flex items-center gap-3">
+                            <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                <UserRound className="h-4 w-4 text-primary" />
+                            </div>
+                            <div>
+                                <h1 className="font-semibold">ACME 账户</h1>
+                                <p className="text-xs text-muted-foreground font-mono">
+                                    证书申请和续期使用的 CA 账户
+                                </p>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+            </header>
+
+            {/* Main Content */}
+            <main className="flex-1 p-4 lg:p-6">
+                <div className="max-w-4xl mx-auto space-y-6">
+                    <div className="bg-card border">
+                        <div className="flex items-center justify-between p-4 border-b">
+                            <div className="flex items-center gap-3">
+                                <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                    <UserRound className="h-4 w-4 text-primary" />
+                                </div>
+                                <div>
+                                    <h2 className="font-semibold">账户列表</h2>
+                                    <p className="text-xs text-muted-foreground font-mono">
+                                        {accounts.length} 个账户
+                                    </p>
+                                </div>
+                            </div>
+                            <Button
+                                size="sm"
+                                onClick={() => setCreateDialogOpen(true)}
+                                className="gap-2 font-mono text-xs"
+                            >
+                                <Plus className="h-3.5 w-3.5" />
+                                注册账户
+                            </Button>
+                        </div>
+
+                        {accounts.length === 0 ? (
+                            <div className="text-center py-16">
+                                <UserRound className="h-12 w-12 mx-auto mb-4 text-muted-foreground/30" />
+                                <p className="text-muted-foreground font-mono text-sm">暂无 ACME 账户</p>
+                                <p className="text-xs text-muted-foreground/60 mt-1">
+                                    申请证书时会按邮箱自动注册账户
+                                </p>
+                            </div>
+                        ) : (
+                            <div className="divide-y">
+                                {accounts.map((account) => (
+                                    <div
+                                        key={account.id}
+                                        className="grid grid-cols-[1fr_auto_auto] gap-4 px-4 py-3 items-center hover:bg-muted/50 transition-colors"
+                                    >
+                                        <div className="flex items-center gap-3 min-w-0">
+                                            <Mail className="h-4 w-4 text-muted-foreground" />
+                                            <div className="min-w-0">
+                                                <p className="font-medium truncate">
+                                                    {account.email}
+                                                    {account.status !== 'valid' && (
+                                                        <span className="ml-2 text-xs text-red-500">已停用</span>
+                                                    )}
+                                                </p>
+                                                <p className="text-xs text-muted-foreground truncate" title={account.directory}>
+                                                    {account.caName || account.directory}
+                                                </p>
+                                            </div>
+                                        </div>
+
+                                        <span className="text-xs text-muted-foreground font-mono">
+                                            {account.certificateCount} 个证书
+                                        </span>
+
+                                        <div className="flex items-center gap-1">
+                                            <Button
+                                                variant="ghost"
+                                                size="icon-sm"
+                                                onClick={() => {
+                                                    setEditingAccount(account);
+                                                    setEditEmail(account.email);
+                                                }}
+                                                disabled={account.status !== 'valid'}
+                                                title="修改联系邮箱"
+                                            >
+                                                <Pencil className="h-4 w-4" />
+                                            </Button>
+                                            <Button
+                                                variant="ghost"
+                                                size="icon-sm"
+                                                onClick={() => {
+                                                    setDeletingAccount(account);
+                                                    setDeleteDialogOpen(true);
+                                                }}
+                                                disabled={account.certificateCount > 0}
+                                                title={account.certificateCount > 0 ? '仍有证书使用该账户' : '删除账户'}
+                                                className="text-muted-foreground hover:text-destructive"
+                                            >
+                                                <Trash2 className="h-4 w-4" />
+                                            </Button>
+                                        </div>
+                                    </div>
+                                ))}
+                            </div>
+                        )}
+                    </div>
+                </div>
+            </main>
+
+            {/* Create Account Dialog */}
+            <Dialog open={createDialogOpen} onOpenChange={setCreateDialogOpen}>
+                <DialogContent>
+                    <DialogHeader>
+                        <DialogTitle className="flex items-center gap-2">
+                            <UserRound className="h-5 w-5 text-primary" />
+                            注册 ACME 账户
+                        </DialogTitle>
+                        <DialogDescription className="font-mono">
+                            CA 会通过联系邮箱发送证书过期等通知
+                        </DialogDescription>
+                    </DialogHeader>
+                    <div className="space-y-4 py-4">
+                        <div className="space-y-2">
+                            <Label htmlFor="account-email" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                联系邮箱 *
+                            </Label>
+                            <Input
+                                id="account-email"
+                                type="email"
+                                value={email}
+                                onChange={(e) => setEmail(e.target.value)}
+                                placeholder="your@email.com"
+                            />
+                        </div>
+                        <div className="space-y-2">
+                            <Label htmlFor="account-directory" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                证书颁发机构
+                            </Label>
+                            <select
+                                id="account-directory"
+                                value={acmeDirectory}
+                                onChange={(e) => setACMEDirectory(e.target.value)}
+                                className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                            >
+                                <option value="">默认（{presets.find(p => p.url === defaultDirectory)?.label || defaultDirectory}）</option>
+                                {presets.map((p) => (
+                                    <option key={p.name} value={p.name}>
+                                        {p.label}
+                                    </option>
+                                ))}
+                                <option value="custom">自定义 ACME 目录</option>
+                            </select>
+                            {acmeDirectory === 'custom' && (
+                                <Input
+                                    value={customDirectory}
+                                    onChange={(e) => setCustomDirectory(e.target.value)}
+                                    placeholder="https://ca.example.com/acme/directory"
+                                    className="font-mono"
+                                />
+                            )}
+                        </div>
+                        {(acmeDirectory === 'custom' || selectedPreset?.eabRequired) && (
+                            <div className="space-y-2">
+                                <Label htmlFor="account-eab-kid" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                    External Account Binding
+                                </Label>
+                                <Input
+                                    id="account-eab-kid"
+                                    value={eabKid}
+                                    onChange={(e) => setEabKid(e.target.value)}
+                                    placeholder="EAB Key ID"
+                                    className="font-mono"
+                                />
+                                <Input
+                                    value={eabHmacKey}
+                                    onChange={(e) => setEabHmacKey(e.target.value)}
+                                    placeholder="EAB HMAC 密钥"
+                                    className="font-mono"
+                                />
+                                {acmeDirectory === 'zerossl' && (
+                                    <p className="text-xs text-muted-foreground">
+                                        留空时使用邮箱地址自动获取 ZeroSSL 的 EAB 凭据
+                                    </p>
+                                )}
+                            </div>
+                        )}
+                    </div>
+                    <DialogFooter>
+                        <Button
+                            variant="outline"
+                            onClick={() => {
+                                setCreateDialogOpen(false);
+                                resetForm();
+                            }}
+                            disabled={creating}
+                        >
+                            取消
+                        </Button>
+                        <Button onClick={handleCreate} disabled={creating} className="gap-2">
+                            {creating ? <Loader2 className="h-4 w-4 animate-spin" /> : <Plus className="h-4 w-4" />}
+                            注册
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+
+            {/* Edit Email Dialog */}
+            <Dialog open={editingAccount !== null} onOpenChange={(open) => !open && setEditingAccount(null)}>
+                <DialogContent>
+                    <DialogHeader>
+                        <DialogTitle className="flex items-center gap-2">
+                            <Pencil className="h-5 w-5 text-primary" />
+                            修改联系邮箱
+                        </DialogTitle>
+                        <DialogDescription className="font-mono">
+                            同时更新 CA 端的账户联系方式
+                        </DialogDescription>
+                    </DialogHeader>
+                    <div className="space-y-2 py-4">
+                        <Label htmlFor="edit-email" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                            联系邮箱
+                        </Label>
+                        <Input
+                            id="edit-email"
+                            type="email"
+                            value={editEmail}
+                            onChange={(e) => setEditEmail(e.target.value)}
+                        />
+                    </div>
+                    <DialogFooter>
+                        <Button variant="outline" onClick={() => setEditingAccount(null)} disabled={saving}>
+                            取消
+                        </Button>
+                        <Button onClick={handleSave} disabled={saving} className="gap-2">
+                            {saving && <Loader2 className="h-4 w-4 animate-spin" />}
+                            保存
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+
+            {/* Delete Account Dialog */}
+            <AlertDialog open={deleteDialogOpen} onOpenChange={setDeleteDialogOpen}>
+                <AlertDialogContent>
+                    <AlertDialogHeader>
+                        <AlertDialogTitle>确认删除 ACME 账户</AlertDialogTitle>
+                        <AlertDialogDescription className="space-y-2">
+                            <span>账户会先在 CA 停用，然后删除本地私钥，此操作无法撤销。</span>
+                            {deletingAccount && (
+                                <code className="block mt-2 p-2 bg-muted text-sm font-mono rounded">
+                                    {deletingAccount.email} ({deletingAccount.caName || deletingAccount.directory})
+                                </code>
+                            )}
+                        </AlertDialogDescription>
+                    </AlertDialogHeader>
+                    <AlertDialogFooter>
+                        <AlertDialogCancel disabled={deleting}>取消</AlertDialogCancel>
+                        <AlertDialogAction
+                            onClick={handleDelete}
+                            disabled={deleting}
+                            className="bg-destructive text-destructive-foreground hover:bg-destructive/90"
+                        >
+                            {deleting ? <Loader2 className="h-4 w-4 animate-spin mr-2" /> : <Trash2 className="h-4 w-4 mr-2" />}
+                            删除
+                        </AlertDialogAction>
+                    </AlertDialogFooter>
+                </AlertDialogContent>
+            </AlertDialog>
+        </div>
+    );
+}
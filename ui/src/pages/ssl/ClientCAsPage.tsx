@@ -0,0 +1,340 @@
+import { useState, useEffect } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { toast } from 'sonner';
+import {
+    Plus,
+    Trash2,
+    ChevronLeft,
+    Loader2,
+    BadgeCheck,
+    Pencil
+} from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
+import {
+    Dialog,
+    DialogContent,
+    DialogDescription,
+    DialogFooter,
+    DialogHeader,
+    DialogTitle,
+} from '@/components/ui/dialog';
+import {
+    AlertDialog,
+    AlertDialogAction,
+    AlertDialogCancel,
+    AlertDialogContent,
+    AlertDialogDescription,
+    AlertDialogFooter,
+    AlertDialogHeader,
+    AlertDialogTitle,
+} from '@/components/ui/alert-dialog';
+import {
+    listClientCAs,
+    createClientCA,
+    updateClientCA,
+    deleteClientCA,
+    type ClientCA,
+} from '@/api/ssl';
+
+export default function ClientCAsPage() {
+    const navigate = useNavigate();
+    const [clientCAs, setClientCAs] = useState<ClientCA[]>([]);
+    const [loading, setLoading] = useState(true);
+
+    // 添加/编辑弹窗（editingCA 不为空时编辑）
+    const [dialogOpen, setDialogOpen] = useState(false);
+    const [editingCA, setEditingCA] = useState<ClientCA | null>(null);
+    const [name, setName] = useState('');
+    const [certificate, setCertificate] = useState('');
+    const [saving, setSaving] = useState(false);
+
+    // 删除弹窗
+    const [deleteDialogOpen, setDeleteDialogOpen] = useState(false);
+    const [deletingCA, setDeletingCA] = useState<ClientCA | null>(null);
+    const [deleting, setDeleting] = useState(false);
+
+    useEffect(() => {
+        loadClientCAs();
+    }, []);
+
+    const loadClientCAs = async () => {
+        try {
+            const result = await listClientCAs();
+            setClientCAs(result.clientCas || []);
+        } catch (err) {
+            console.error('Failed to load client CAs:', err);
+            toast.error('加载客户端 CA 失败');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const openDialog = (ca: ClientCA | null) => {
+        setEditingCA(ca);
+        setName(ca?.name || '');
+        setCertificate('');
+        setDialogOpen(true);
+    };
+
+    const handleSave = async () => {
+        if (!name.trim()) {
+            toast.error('请输入名称');
+            return;
+        }
+        if (!editingCA && !certificate.trim()) {
+            toast.error('请填写 CA 证书');
+            return;
+        }
+
+        setSaving(true);
+        try {
+            const result = editingCA
+                ? await updateClientCA(editingCA.id, name.trim(), certificate)
+                : await createClientCA(name.trim(), certificate);
+            if (result.success) {
+                toast.success(editingCA ? '客户端 CA 已更新，重新加载 nginx 后生效' : '客户端 CA 已添加');
+                setDialogOpen(false);
+                loadClientCAs();
+            } else {
+                toast.error(result.error || '保存失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleDelete = async () => {
+        if (!deletingCA) return;
+
+        setDeleting(true);
+        try {
+            const result = await deleteClientCA(deletingCA.id);
+            if (result.success) {
+                toast.success('客户端 CA 已删除');
+                setDeleteDialogOpen(false);
+                setDeletingCA(null);
+                loadClientCAs();
+            } else {
+                toast.error(result.error || '删除失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setDeleting(false);
+        }
+    };
+
+    if (loading) {
+        return (
+            <div className="min-h-screen flex items-center justify-center">
+                <Loader2 className="h-8 w-8 animate-spin text-primary" />
+            </div>
+        );
+    }
+
+    return (
+        <div className="min-h-screen flex flex-col">
+            {/* Header */}
+            <header className="border-b bg-card/80 backdrop-blur-sm sticky top-0 z-50">
+                <div className="flex h-14 items-center justify-between px-4 lg:px-6">
+                    <div className="flex items-center gap-4">
+                        <Button
+                            variant="ghost"
+                            size="sm"
+                            onClick={() => navigate('/ssl')}
+                            className="gap-2"
+                        >
+                            <ChevronLeft className="h-4 w-4" />
+                            返回
+                        </Button>
+                        <div className="w-px h-6 bg-border" />
+                        <div className="flex items-center gap-3">
+                            <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                <BadgeCheck className="h-4 w-4 text-primary" />
+                            </div>
+                            <div>
+                                <h1 className="font-semibold">客户端 CA</h1>
+                                <p className="text-xs text-muted-foreground font-mono">
+                                    校验客户端证书（mTLS）或 HTTPS 上游的服务端证书
+                                </p>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+            </header>
+
+            {/* Main Content */}
+            <main className="flex-1 p-4 lg:p-6">
+                <div className="max-w-4xl mx-auto space-y-6">
+                    <div className="bg-card border">
+                        <div className="flex items-center justify-between p-4 border-b">
+                            <div className="flex items-center gap-3">
+                                <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                    <BadgeCheck className="h-4 w-4 text-primary" />
+                                </div>
+                                <div>
+                                    <h2 className="font-semibold">CA 证书包</h2>
+                                    <p className="text-xs text-muted-foreground font-mono">
+                                        {clientCAs.length} 个证书包，内部 CA 无需添加即可选择
+                                    </p>
+                                </div>
+                            </div>
+                            <Button
+                                size="sm"
+                                onClick={() => openDialog(null)}
+                                className="gap-2 font-mono text-xs"
+                            >
+                                <Plus className="h-3.5 w-3.5" />
+                                添加 CA
+                            </Button>
+                        </div>
+
+                        {clientCAs.length === 0 ? (
+                            <div className="text-center py-16">
+                                <BadgeCheck className="h-12 w-12 mx-auto mb-4 text-muted-foreground/30" />
+                                <p className="text-muted-foreground font-mono text-sm">暂无客户端 CA</p>
+                                <p className="text-xs text-muted-foreground/60 mt-1">
+                                    添加签发客户端证书的 CA，例如合作方或设备厂商的根证书
+                                </p>
+                            </div>
+                        ) : (
+                            <div className="divide-y">
+                                {clientCAs.map((ca) => (
+                                    <div
+                                        key={ca.id}
+                                        className="grid grid-cols-[1fr_auto_auto] gap-4 px-4 py-3 items-center hover:bg-muted/50 transition-colors"
+                                    >
+                                        <div className="min-w-0">
+                                            <p className="font-medium truncate">{ca.name}</p>
+                                            <p className="text-xs text-muted-foreground truncate" title={ca.subjects.join(', ')}>
+                                                {ca.subjects.join(', ')} · 有效期至 {new Date(ca.notAfter).toLocaleDateString('zh-CN')}
+                                            </p>
+                                        </div>
+
+                                        <span className="text-xs text-muted-foreground font-mono" title={ca.sites.join(', ')}>
+                                            {ca.sites.length} 个站点
+                                        </span>
+
+                                        <div className="flex items-center gap-1">
+                                            <Button
+                                                variant="ghost"
+                                                size="icon-sm"
+                                                onClick={() => openDialog(ca)}
+                                                title="修改"
+                                            >
+                                                <Pencil className="h-4 w-4" />
+                                            </Button>
+                                            <Button
+                                                variant="ghost"
+                                                size="icon-sm"
+                                                onClick={() => {
+                                                    setDeletingCA(ca);
+                                                    setDeleteDialogOpen(true);
+                                                }}
+                                                disabled={ca.sites.length > 0}
+                                                title={ca.sites.length > 0 ? '仍有站点使用该 CA' : '删除'}
+                                                className="text-muted-foreground hover:text-destructive"
+                                            >
+                                                <Trash2 className="h-4 w-4" />
+                                            </Button>
+                                        </div>
+                                    </div>
+                                ))}
+                            </div>
+                        )}
+                    </div>
+                </div>
+            </main>
+
+            {/* Create/Edit Dialog */}
+            <Dialog open={dialogOpen} onOpenChange={setDialogOpen}>
+                <DialogContent>
+                    <DialogHeader>
+                        <DialogTitle className="flex items-center gap-2">
+                            <BadgeCheck className="h-5 w-5 text-primary" />
+                            {editingCA ? '修改客户端 CA' : '添加客户端 CA'}
+                        </DialogTitle>
+                        <DialogDescription className="font-mono">
+                            {editingCA ? '证书留空则保持不变' : '可包含多个 CA 证书，客户端证书由其中任一签发即可通过'}
+                        </DialogDescription>
+                    </DialogHeader>
+                    <div className="space-y-4 py-4">
+                        <div className="space-y-2">
+                            <Label htmlFor="client-ca-name" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                名称 *
+                            </Label>
+                            <Input
+                                id="client-ca-name"
+                                value={name}
+                                onChange={(e) => setName(e.target.value)}
+                                placeholder="partners"
+                            />
+                        </div>
+                        <div className="space-y-2">
+                            <Label htmlFor="client-ca-cert" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                CA 证书{!editingCA && ' *'}
+                            </Label>
+                            <textarea
+                                id="client-ca-cert"
+                                value={certificate}
+                                onChange={(e) => setCertificate(e.target.value)}
+                                placeholder="-----BEGIN CERTIFICATE-----"
+                                rows={6}
+                                className="w-full px-3 py-2 bg-background border rounded-md text-xs font-mono"
+                            />
+                            <Input
+                                type="file"
+                                accept=".pem,.crt,.cer"
+                                onChange={async (e) => {
+                                    const file = e.target.files?.[0];
+                                    if (file) setCertificate(await file.text());
+                                }}
+                            />
+                        </div>
+                    </div>
+                    <DialogFooter>
+                        <Button variant="outline" onClick={() => setDialogOpen(false)} disabled={saving}>
+                            取消
+                        </Button>
+                        <Button onClick={handleSave} disabled={saving} className="gap-2">
+                            {saving && <Loader2 className="h-4 w-4 animate-spin" />}
+                            保存
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+
+            {/* Delete Dialog */}
+            <AlertDialog open={deleteDialogOpen} onOpenChange={setDeleteDialogOpen}>
+                <AlertDialogContent>
+                    <AlertDialogHeader>
+                        <AlertDialogTitle>确认删除客户端 CA</AlertDialogTitle>
+                        <AlertDialogDescription className="space-y-2">
+                            <span>此操作无法撤销。</span>
+                            {deletingCA && (
+                                <code className="block mt-2 p-2 bg-muted text-sm font-mono rounded">
+                                    {deletingCA.name}
+                                </code>
+                            )}
+                        </AlertDialogDescription>
+                    </AlertDialogHeader>
+                    <AlertDialogFooter>
+                        <AlertDialogCancel disabled={deleting}>取消</AlertDialogCancel>
+                        <AlertDialogAction
+                            onClick={handleDelete}
+                            disabled={deleting}
+                            className="bg-destructive text-destructive-foreground hover:bg-destructive/90"
+                        >
+                            {deleting ? <Loader2 className="h-4 w-4 animate-spin mr-2" /> : <Trash2 className="h-4 w-4 mr-2" />}
+                            删除
+                        </AlertDialogAction>
+                    </AlertDialogFooter>
+                </AlertDialogContent>
+            </AlertDialog>
+        </div>
+    );
+}
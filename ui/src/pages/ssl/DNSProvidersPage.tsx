@@ -33,13 +33,13 @@ import {
 } from '@/components/ui/alert-dialog';
 import {
     listDNSProviders,
+    listDNSProviderTypes,
     createDNSProvider,
     deleteDNSProvider,
     type DNSProvider,
     type DNSProviderType,
-    type AliDNSConfig,
-    type TencentCloudConfig,
-    type CloudflareConfig,
+    type DNSProviderDefinition,
+    type DNSProviderConfig,
     getDNSProviderLabel,
 } from '@/api/ssl';
 
@@ -52,21 +52,11 @@ export default function DNSProvidersPage() {
     const [createDialogOpen, setCreateDialogOpen] = useState(false);
     const [creating, setCreating] = useState(false);
     const [providerName, setProviderName] = useState('');
-    const [providerType, setProviderType] = useState<DNSProviderType>('alidns');
+    const [providerType, setProviderType] = useState<DNSProviderType>('');
 
-    // 阿里云配置
-    const [aliAccessKeyId, setAliAccessKeyId] = useState('');
-    const [aliAccessKeySecret, setAliAccessKeySecret] = useState('');
-    const [aliRegionId, setAliRegionId] = useState('');
-
-    // 腾讯云配置
-    const [tencentSecretId, setTencentSecretId] = useState('');
-    const [tencentSecretKey, setTencentSecretKey] = useState('');
-
-    // Cloudflare 配置
-    const [cfApiToken, setCfApiToken] = useState('');
-    const [cfEmail, setCfEmail] = useState('');
-    const [cfApiKey, setCfApiKey] = useState('');
+    // 支持的提供商及其配置字段，表单按字段定义生成
+    const [definitions, setDefinitions] = useState<DNSProviderDefinition[]>([]);
+    const [configValues, setConfigValues] = useState<Record<string, string>>({});
 
     // 删除提供商弹窗
     const [deleteDialogOpen, setDeleteDialogOpen] = useState(false);
@@ -75,8 +65,35 @@ export default function DNSProvidersPage() {
 
     useEffect(() => {
         loadProviders();
+        loadDefinitions();
     }, []);
 
+    const loadDefinitions = async () => {
+        try {
+            const result = await listDNSProviderTypes();
+            setDefinitions(result.types);
+            if (result.types.length > 0) {
+                selectType(result.types[0].type, result.types);
+            }
+        } catch (err) {
+            console.error('Failed to load provider types:', err);
+            toast.error('加载 DNS 提供商类型失败');
+        }
+    };
+
+    // 切换类型时按字段默认值重置配置
+    const selectType = (type: DNSProviderType, defs: DNSProviderDefinition[] = definitions) => {
+        const def = defs.find((d) => d.type === type);
+        const values: Record<string, string> = {};
+        def?.fields.forEach((field) => {
+            values[field.name] = field.default ?? '';
+        });
+        setProviderType(type);
+        setConfigValues(values);
+    };
+
+    const currentDefinition = definitions.find((d) => d.type === providerType);
+
     const loadProviders = async () => {
         try {
             const result = await listDNSProviders();
@@ -91,15 +108,9 @@ export default function DNSProvidersPage() {
 
     const resetForm = () => {
         setProviderName('');
-        setProviderType('alidns');
-        setAliAccessKeyId('');
-        setAliAccessKeySecret('');
-        setAliRegionId('');
-        setTencentSecretId('');
-        setTencentSecretKey('');
-        setCfApiToken('');
-        setCfEmail('');
-        setCfApiKey('');
+        if (definitions.length > 0) {
+            selectType(definitions[0].type);
+        }
     };
 
     const handleCreate = async () => {
@@ -108,41 +119,22 @@ export default function DNSProvidersPage() {
             return;
         }
 
-        let config: AliDNSConfig | TencentCloudConfig | CloudflareConfig;
+        if (!currentDefinition) {
+            toast.error('请选择 DNS 提供商');
+            return;
+        }
 
-        switch (providerType) {
-            case 'alidns':
-                if (!aliAccessKeyId || !aliAccessKeySecret) {
-                    toast.error('请填写阿里云 AccessKey 信息');
-                    return;
-                }
-                config = {
-                    accessKeyId: aliAccessKeyId,
-                    accessKeySecret: aliAccessKeySecret,
-                    regionId: aliRegionId || undefined,
-                };
-                break;
-            case 'tencentcloud':
-                if (!tencentSecretId || !tencentSecretKey) {
-                    toast.error('请填写腾讯云密钥信息');
+        const config: DNSProviderConfig = {};
+        for (const field of currentDefinition.fields) {
+            const value = (configValues[field.name] ?? '').trim();
+            if (!value) {
+                if (field.required) {
+                    toast.error(`请填写 ${field.label}`);
                     return;
                 }
-                config = {
-                    secretId: tencentSecretId,
-                    secretKey: tencentSecretKey,
-                };
-                break;
-            case 'cloudflare':
-                if (!cfApiToken && (!cfEmail || !cfApiKey)) {
-                    toast.error('请填写 Cloudflare API Token 或 Global API Key');
-                    return;
-                }
-                config = {
-                    apiToken: cfApiToken || undefined,
-                    email: cfEmail || undefined,
-                    apiKey: cfApiKey || undefined,
-                };
-                break;
+                continue;
+            }
+            config[field.name] = field.type === 'number' ? Number(value) : value;
         }
 
         setCreating(true);
@@ -185,126 +177,53 @@ export default function DNSProvidersPage() {
     };
 
     const renderConfigForm = () => {
-        switch (providerType) {
-            case 'alidns':
-                return (
-                    <>
-                        <div className="space-y-2">
-                            <Label htmlFor="ali-access-key-id" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                AccessKey ID *
-                            </Label>
-                            <Input
-                                id="ali-access-key-id"
-                                value={aliAccessKeyId}
-                                onChange={(e) => setAliAccessKeyId(e.target.value)}
-                                placeholder="LTAI..."
-                                className="font-mono"
-                            />
-                        </div>
-                        <div className="space-y-2">
-                            <Label htmlFor="ali-access-key-secret" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                AccessKey Secret *
-                            </Label>
-                            <Input
-                                id="ali-access-key-secret"
-                                type="password"
-                                value={aliAccessKeySecret}
-                                onChange={(e) => setAliAccessKeySecret(e.target.value)}
-                                placeholder="Your secret key"
-                                className="font-mono"
-                            />
-                        </div>
-                        <div className="space-y-2">
-                            <Label htmlFor="ali-region" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                Region ID (可选)
-                            </Label>
-                            <Input
-                                id="ali-region"
-                                value={aliRegionId}
-                                onChange={(e) => setAliRegionId(e.target.value)}
-                                placeholder="cn-hangzhou"
-                                className="font-mono"
-                            />
-                        </div>
-                    </>
-                );
-            case 'tencentcloud':
-                return (
-                    <>
-                        <div className="space-y-2">
-                            <Label htmlFor="tencent-secret-id" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                SecretId *
-                            </Label>
-                            <Input
-                                id="tencent-secret-id"
-                                value={tencentSecretId}
-                                onChange={(e) => setTencentSecretId(e.target.value)}
-                                placeholder="AKID..."
-                                className="font-mono"
-                            />
-                        </div>
-                        <div className="space-y-2">
-                            <Label htmlFor="tencent-secret-key" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                SecretKey *
-                            </Label>
-                            <Input
-                                id="tencent-secret-key"
-                                type="password"
-                                value={tencentSecretKey}
-                                onChange={(e) => setTencentSecretKey(e.target.value)}
-                                placeholder="Your secret key"
-                                className="font-mono"
-                            />
-                        </div>
-                    </>
-                );
-            case 'cloudflare':
-                return (
-                    <>
-                        <div className="space-y-2">
-                            <Label htmlFor="cf-api-token" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                API Token (推荐) *
-                            </Label>
-                            <Input
-                                id="cf-api-token"
-                                type="password"
-                                value={cfApiToken}
-                                onChange={(e) => setCfApiToken(e.target.value)}
-                                placeholder="Your API token"
-                                className="font-mono"
-                            />
-                            <p className="text-xs text-muted-foreground">
-                                推荐使用 API Token。如使用 Global API Key，请填写下面两项。
-                            </p>
-                        </div>
-                        <div className="space-y-2">
-                            <Label htmlFor="cf-email" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                Email (使用 Global API Key 时)
-                            </Label>
-                            <Input
-                                id="cf-email"
-                                type="email"
-                                value={cfEmail}
-                                onChange={(e) => setCfEmail(e.target.value)}
-                                placeholder="your@email.com"
-                            />
-                        </div>
-                        <div className="space-y-2">
-                            <Label htmlFor="cf-api-key" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                Global API Key (使用 Global API Key 时)
+        if (!currentDefinition) return null;
+
+        return (
+            <>
+                {currentDefinition.description && (
+                    <p className="text-xs text-muted-foreground">{currentDefinition.description}</p>
+                )}
+                {currentDefinition.fields.map((field) => {
+                    const id = `dns-field-${field.name}`;
+                    const value = configValues[field.name] ?? '';
+                    const onChange = (v: string) => setConfigValues((prev) => ({ ...prev, [field.name]: v }));
+
+                    return (
+                        <div key={field.name} className="space-y-2">
+                            <Label htmlFor={id} className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                {field.label} {field.required ? '*' : '(可选)'}
                             </Label>
-                            <Input
-                                id="cf-api-key"
-                                type="password"
-                                value={cfApiKey}
-                                onChange={(e) => setCfApiKey(e.target.value)}
-                                placeholder="Your global API key"
-                                className="font-mono"
-                            />
+                            {field.type === 'select' ? (
+                                <select
+                                    id={id}
+                                    value={value}
+                                    onChange={(e) => onChange(e.target.value)}
+                                    className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                                >
+                                    {!field.required && !field.default && <option value="">默认</option>}
+                                    {field.options?.map((option) => (
+                                        <option key={option.value} value={option.value}>{option.label}</option>
+                                    ))}
+                                </select>
+                            ) : (
+                                <Input
+                                    id={id}
+                                    type={field.type}
+                                    value={value}
+                                    onChange={(e) => onChange(e.target.value)}
+                                    placeholder={field.placeholder}
+                                    className="font-mono"
+                                />
+                            )}
+                            {field.help && (
+                                <p className="text-xs text-muted-foreground">{field.help}</p>
+                            )}
                         </div>
-                    </>
-                );
-        }
+                    );
+                })}
+            </>
+        );
     };
 
     if (loading) {
@@ -460,12 +379,12 @@ export default function DNSProvidersPage() {
                             <select
                                 id="provider-type"
                                 value={providerType}
-                                onChange={(e) => setProviderType(e.target.value as DNSProviderType)}
+                                onChange={(e) => selectType(e.target.value)}
                                 className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
                             >
-                                <option value="alidns">阿里云 DNS</option>
-                                <option value="tencentcloud">腾讯云 DNSPod</option>
-                                <option value="cloudflare">Cloudflare</option>
+                                {definitions.map((def) => (
+                                    <option key={def.type} value={def.type}>{def.name}</option>
+                                ))}
                             </select>
                         </div>
 
@@ -0,0 +1,710 @@
+import { useState, useEffect } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { toast } from 'sonner';
+import {
+    Plus,
+    Trash2,
+    ChevronLeft,
+    Loader2,
+    Bell,
+    Pencil,
+    Send,
+    CheckCircle2,
+    XCircle
+} from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
+import { Switch } from '@/components/ui/switch';
+import {
+    Dialog,
+    DialogContent,
+    DialogDescription,
+    DialogFooter,
+    DialogHeader,
+    DialogTitle,
+} from '@/components/ui/dialog';
+import {
+    AlertDialog,
+    AlertDialogAction,
+    AlertDialogCancel,
+    AlertDialogContent,
+    AlertDialogDescription,
+    AlertDialogFooter,
+    AlertDialogHeader,
+    AlertDialogTitle,
+} from '@/components/ui/alert-dialog';
+import {
+    listNotifyEvents,
+    listNotificationChannels,
+    createNotificationChannel,
+    updateNotificationChannel,
+    deleteNotificationChannel,
+    testNotificationChannel,
+    getChannelTypeLabel,
+    type ChannelType,
+    type ChannelConfig,
+    type NotificationChannel,
+    type NotifyEvent,
+    type WebhookConfig,
+    type EmailConfig,
+    type BotConfig,
+} from '@/api/notify';
+
+// 表单字段，各渠道类型共用
+interface ChannelForm {
+    name: string;
+    type: ChannelType;
+    events: NotifyEvent[];
+    url: string;
+    method: 'POST' | 'PUT';
+    headers: string; // 每行一个 "Name: Value"
+    body: string;
+    secret: string;
+    host: string;
+    port: string;
+    security: 'starttls' | 'tls' | 'none';
+    username: string;
+    password: string;
+    from: string;
+    to: string; // 逗号或换行分隔
+}
+
+const emptyForm: ChannelForm = {
+    name: '',
+    type: 'webhook',
+    events: ['renew_failed', 'expiring'],
+    url: '',
+    method: 'POST',
+    headers: '',
+    body: '',
+    secret: '',
+    host: '',
+    port: '',
+    security: 'starttls',
+    username: '',
+    password: '',
+    from: '',
+    to: '',
+};
+
+const urlPlaceholders: Record<ChannelType, string> = {
+    webhook: 'https://example.com/hooks/hop',
+    email: '',
+    slack: 'https://hooks.slack.com/services/...',
+    feishu: 'https://open.feishu.cn/open-apis/bot/v2/hook/...',
+    dingtalk: 'https://oapi.dingtalk.com/robot/send?access_token=...',
+};
+
+const bodyPlaceholder = '{"text": {{json .Title}}, "domain": {{json .Domain}}, "days": {{.DaysRemaining}}}';
+
+function channelToForm(ch: NotificationChannel): ChannelForm {
+    const form: ChannelForm = { ...emptyForm, name: ch.name, type: ch.type, events: ch.events };
+    if (ch.type === 'email') {
+        const c = ch.config as EmailConfig;
+        return {
+            ...form,
+            host: c.host,
+            port: c.port ? String(c.port) : '',
+            security: c.security || 'starttls',
+            username: c.username || '',
+            from: c.from,
+            to: (c.to || []).join(', '),
+        };
+    }
+    if (ch.type === 'webhook') {
+        const c = ch.config as WebhookConfig;
+        return {
+            ...form,
+            url: c.url,
+            method: c.method || 'POST',
+            headers: Object.entries(c.headers || {}).map(([k, v]) => `${k}: ${v}`).join('\n'),
+            body: c.body || '',
+        };
+    }
+    return { ...form, url: (ch.config as BotConfig).url };
+}
+
+function formToConfig(form: ChannelForm): ChannelConfig {
+    switch (form.type) {
+        case 'email':
+            return {
+                host: form.host.trim(),
+                port: form.port ? Number(form.port) : undefined,
+                security: form.security,
+                username: form.username.trim(),
+                password: form.password,
+                from: form.from.trim(),
+                to: form.to.split(/[,\n]/).map((s) => s.trim()).filter(Boolean),
+            };
+        case 'webhook': {
+            const headers: Record<string, string> = {};
+            for (const line of form.headers.split('\n')) {
+                const i = line.indexOf(':');
+                if (i > 0) headers[line.slice(0, i).trim()] = line.slice(i + 1).trim();
+            }
+            return { url: form.url.trim(), method: form.method, headers, body: form.body };
+        }
+        case 'feishu':
+        case 'dingtalk':
+            return { url: form.url.trim(), secret: form.secret };
+        default:
+            return { url: form.url.trim() };
+    }
+}
+
+// 渠道的目标地址，用于列表展示
+function channelTarget(ch: NotificationChannel): string {
+    if (ch.type === 'email') return (ch.config as EmailConfig).to?.join(', ') || '';
+    return (ch.config as BotConfig).url || '';
+}
+
+export default function NotificationsPage() {
+    const navigate = useNavigate();
+    const [channels, setChannels] = useState<NotificationChannel[]>([]);
+    const [events, setEvents] = useState<{ type: NotifyEvent; label: string }[]>([]);
+    const [loading, setLoading] = useState(true);
+    const [testingId, setTestingId] = useState('');
+
+    // 添加/编辑弹窗（editing 不为空时编辑）
+    const [dialogOpen, setDialogOpen] = useState(false);
+    const [editing, setEditing] = useState<NotificationChannel | null>(null);
+    const [form, setForm] = useState<ChannelForm>(emptyForm);
+    const [saving, setSaving] = useState(false);
+
+    // 删除弹窗
+    const [deleteDialogOpen, setDeleteDialogOpen] = useState(false);
+    const [deleting, setDeleting] = useState<NotificationChannel | null>(null);
+    const [deletingLoading, setDeletingLoading] = useState(false);
+
+    const labelClass = 'text-xs font-mono uppercase tracking-wider text-muted-foreground';
+
+    useEffect(() => {
+        loadData();
+    }, []);
+
+    const loadData = async () => {
+        try {
+            const [channelsRes, eventsRes] = await Promise.all([listNotificationChannels(), listNotifyEvents()]);
+            setChannels(channelsRes.channels || []);
+            setEvents(eventsRes.events || []);
+        } catch (err) {
+            console.error('Failed to load notification channels:', err);
+            toast.error('加载通知渠道失败');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const eventLabel = (type: NotifyEvent) => events.find((e) => e.type === type)?.label || type;
+
+    const openDialog = (ch: NotificationChannel | null) => {
+        setEditing(ch);
+        setForm(ch ? channelToForm(ch) : emptyForm);
+        setDialogOpen(true);
+    };
+
+    const updateForm = (patch: Partial<ChannelForm>) => setForm((prev) => ({ ...prev, ...patch }));
+
+    const toggleEvent = (type: NotifyEvent, checked: boolean) =>
+        setForm((prev) => ({
+            ...prev,
+            events: checked ? [...prev.events, type] : prev.events.filter((e) => e !== type),
+        }));
+
+    const handleSave = async () => {
+        if (!form.name.trim()) {
+            toast.error('请输入名称');
+            return;
+        }
+        if (form.events.length === 0) {
+            toast.error('请选择要通知的事件');
+            return;
+        }
+
+        setSaving(true);
+        try {
+            const data = { name: form.name.trim(), type: form.type, config: formToConfig(form), events: form.events };
+            const result = editing
+                ? await updateNotificationChannel(editing.id, data)
+                : await createNotificationChannel(data);
+            if (result.success) {
+                toast.success(editing ? '通知渠道已更新' : '通知渠道已添加');
+                setDialogOpen(false);
+                loadData();
+            } else {
+                toast.error(result.error || '保存失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleToggle = async (ch: NotificationChannel, enabled: boolean) => {
+        const result = await updateNotificationChannel(ch.id, { enabled });
+        if (result.success) {
+            setChannels((prev) => prev.map((c) => (c.id === ch.id ? { ...c, enabled } : c)));
+        } else {
+            toast.error(result.error || '更新失败');
+        }
+    };
+
+    const handleTest = async (ch: NotificationChannel) => {
+        setTestingId(ch.id);
+        try {
+            const result = await testNotificationChannel(ch.id);
+            if (result.success) {
+                toast.success('测试通知已发送');
+            } else {
+                toast.error(result.error || '发送失败');
+            }
+            loadData();
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setTestingId('');
+        }
+    };
+
+    const handleDelete = async () => {
+        if (!deleting) return;
+
+        setDeletingLoading(true);
+        try {
+            const result = await deleteNotificationChannel(deleting.id);
+            if (result.success) {
+                toast.success('通知渠道已删除');
+                setDeleteDialogOpen(false);
+                setDeleting(null);
+                loadData();
+            } else {
+                toast.error(result.error || '删除失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setDeletingLoading(false);
+        }
+    };
+
+    if (loading) {
+        return (
+            <div className="min-h-screen flex items-center justify-center">
+                <Loader2 className="h-8 w-8 animate-spin text-primary" />
+            </div>
+        );
+    }
+
+    return (
+        <div className="min-h-screen flex flex-col">
+            {/* Header */}
+            <header className="border-b bg-card/80 backdrop-blur-sm sticky top-0 z-50">
+                <div className="flex h-14 items-center justify-between px-4 lg:px-6">
+                    <div className="flex items-center gap-4">
+                        <Button
+                            variant="ghost"
+                            size="sm"
+                            onClick={() => navigate('/ssl')}
+                            className="gap-2"
+                        >
+                            <ChevronLeft className="h-4 w-4" />
+                            返回
+                        </Button>
+                        <div className="w-px h-6 bg-border" />
+                        <div className="flex items-center gap-3">
+                            <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                <Bell className="h-4 w-4 text-primary" />
+                            </div>
+                            <div>
+                                <h1 className="font-semibold">通知</h1>
+                                <p className="text-xs text-muted-foreground font-mono">
+                                    证书续期失败、即将过期或续期成功时发送通知
+                                </p>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+            </header>
+
+            {/* Main Content */}
+            <main className="flex-1 p-4 lg:p-6">
+                <div className="max-w-4xl mx-auto space-y-6">
+                    <div className="bg-card border">
+                        <div className="flex items-center justify-between p-4 border-b">
+                            <div className="flex items-center gap-3">
+                                <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                    <Bell className="h-4 w-4 text-primary" />
+                                </div>
+                                <div>
+                                    <h2 className="font-semibold">通知渠道</h2>
+                                    <p className="text-xs text-muted-foreground font-mono">
+                                        {channels.length} 个渠道
+                                    </p>
+                                </div>
+                            </div>
+                            <Button
+                                size="sm"
+                                onClick={() => openDialog(null)}
+                                className="gap-2 font-mono text-xs"
+                            >
+                                <Plus className="h-3.5 w-3.5" />
+                                添加渠道
+                            </Button>
+                        </div>
+
+                        {channels.length === 0 ? (
+                            <div className="text-center py-16">
+                                <Bell className="h-12 w-12 mx-auto mb-4 text-muted-foreground/30" />
+                                <p className="text-muted-foreground font-mono text-sm">暂无通知渠道</p>
+                                <p className="text-xs text-muted-foreground/60 mt-1">
+                                    支持 Webhook、邮件、Slack、飞书和钉钉
+                                </p>
+                            </div>
+                        ) : (
+                            <div className="divide-y">
+                                {channels.map((ch) => (
+                                    <div
+                                        key={ch.id}
+                                        className="grid grid-cols-[1fr_auto_auto] gap-4 px-4 py-3 items-center hover:bg-muted/50 transition-colors"
+                                    >
+                                        <div className="min-w-0">
+                                            <div className="flex items-center gap-2">
+                                                <span className="text-xs font-mono px-1.5 py-0.5 bg-muted">
+                                                    {getChannelTypeLabel(ch.type)}
+                                                </span>
+                                                <p className="font-medium truncate">{ch.name}</p>
+                                                {ch.lastSentAt && (ch.lastError ? (
+                                                    <span title={ch.lastError}>
+                                                        <XCircle className="h-4 w-4 text-destructive" />
+                                                    </span>
+                                                ) : (
+                                                    <span title={`最近发送于 ${new Date(ch.lastSentAt).toLocaleString('zh-CN')}`}>
+                                                        <CheckCircle2 className="h-4 w-4 text-green-500" />
+                                                    </span>
+                                                ))}
+                                            </div>
+                                            <p className="text-xs text-muted-foreground truncate font-mono" title={channelTarget(ch)}>
+                                                {channelTarget(ch)}
+                                            </p>
+                                            <p className="text-xs text-muted-foreground truncate">
+                                                {ch.events.map(eventLabel).join('、')}
+                                            </p>
+                                        </div>
+
+                                        <Switch checked={ch.enabled} onCheckedChange={(checked) => handleToggle(ch, checked)} />
+
+                                        <div className="flex items-center gap-1">
+                                            <Button
+                                                variant="ghost"
+                                                size="icon-sm"
+                                                onClick={() => handleTest(ch)}
+                                                disabled={testingId === ch.id}
+                                                title="发送测试通知"
+                                            >
+                                                {testingId === ch.id ? (
+                                                    <Loader2 className="h-4 w-4 animate-spin" />
+                                                ) : (
+                                                    <Send className="h-4 w-4" />
+                                                )}
+                                            </Button>
+                                            <Button
+                                                variant="ghost"
+                                                size="icon-sm"
+                                                onClick={() => openDialog(ch)}
+                                                title="修改"
+                                            >
+                                                <Pencil className="h-4 w-4" />
+                                            </Button>
+                                            <Button
+                                                variant="ghost"
+                                                size="icon-sm"
+                                                onClick={() => {
+                                                    setDeleting(ch);
+                                                    setDeleteDialogOpen(true);
+                                                }}
+                                                title="删除"
+                                                className="text-muted-foreground hover:text-destructive"
+                                            >
+                                                <Trash2 className="h-4 w-4" />
+                                            </Button>
+                                        </div>
+                                    </div>
+                                ))}
+                            </div>
+                        )}
+                    </div>
+                </div>
+            </main>
+
+            {/* Create/Edit Dialog */}
+            <Dialog open={dialogOpen} onOpenChange={setDialogOpen}>
+                <DialogContent className="max-w-2xl max-h-[90vh] overflow-y-auto">
+                    <DialogHeader>
+                        <DialogTitle className="flex items-center gap-2">
+                            <Bell className="h-5 w-5 text-primary" />
+                            {editing ? '修改通知渠道' : '添加通知渠道'}
+                        </DialogTitle>
+                        <DialogDescription className="font-mono">
+                            {editing && editing.hasSecret ? '密码和签名密钥留空则保持不变' : '保存后可发送测试通知检查配置'}
+                        </DialogDescription>
+                    </DialogHeader>
+                    <div className="space-y-4 py-4">
+                        <div className="grid grid-cols-2 gap-4">
+                            <div className="space-y-2">
+                                <Label htmlFor="channel-name" className={labelClass}>
+                                    名称 *
+                                </Label>
+                                <Input
+                                    id="channel-name"
+                                    value={form.name}
+                                    onChange={(e) => updateForm({ name: e.target.value })}
+                                    placeholder="ops"
+                                />
+                            </div>
+                            <div className="space-y-2">
+                                <Label htmlFor="channel-type" className={labelClass}>
+                                    类型
+                                </Label>
+                                <select
+                                    id="channel-type"
+                                    value={form.type}
+                                    onChange={(e) => updateForm({ type: e.target.value as ChannelType })}
+                                    className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                                >
+                                    <option value="webhook">Webhook</option>
+                                    <option value="email">邮件（SMTP）</option>
+                                    <option value="slack">Slack（兼容 Mattermost 等）</option>
+                                    <option value="feishu">飞书机器人</option>
+                                    <option value="dingtalk">钉钉机器人</option>
+                                </select>
+                            </div>
+                        </div>
+
+                        {form.type === 'email' ? (
+                            <>
+                                <div className="grid grid-cols-[1fr_100px_140px] gap-4">
+                                    <div className="space-y-2">
+                                        <Label htmlFor="channel-host" className={labelClass}>
+                                            SMTP 服务器 *
+                                        </Label>
+                                        <Input
+                                            id="channel-host"
+                                            value={form.host}
+                                            onChange={(e) => updateForm({ host: e.target.value })}
+                                            placeholder="smtp.example.com"
+                                            className="font-mono"
+                                        />
+                                    </div>
+                                    <div className="space-y-2">
+                                        <Label htmlFor="channel-port" className={labelClass}>
+                                            端口
+                                        </Label>
+                                        <Input
+                                            id="channel-port"
+                                            type="number"
+                                            value={form.port}
+                                            onChange={(e) => updateForm({ port: e.target.value })}
+                                            placeholder={{ tls: '465', starttls: '587', none: '25' }[form.security]}
+                                            className="font-mono"
+                                        />
+                                    </div>
+                                    <div className="space-y-2">
+                                        <Label htmlFor="channel-security" className={labelClass}>
+                                            加密
+                                        </Label>
+                                        <select
+                                            id="channel-security"
+                                            value={form.security}
+                                            onChange={(e) => updateForm({ security: e.target.value as ChannelForm['security'] })}
+                                            className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                                        >
+                                            <option value="starttls">STARTTLS</option>
+                                            <option value="tls">SSL/TLS</option>
+                                            <option value="none">不加密</option>
+                                        </select>
+                                    </div>
+                                </div>
+                                <div className="grid grid-cols-2 gap-4">
+                                    <div className="space-y-2">
+                                        <Label htmlFor="channel-username" className={labelClass}>
+                                            用户名
+                                        </Label>
+                                        <Input
+                                            id="channel-username"
+                                            value={form.username}
+                                            onChange={(e) => updateForm({ username: e.target.value })}
+                                            placeholder="留空则不认证"
+                                        />
+                                    </div>
+                                    <div className="space-y-2">
+                                        <Label htmlFor="channel-password" className={labelClass}>
+                                            密码
+                                        </Label>
+                                        <Input
+                                            id="channel-password"
+                                            type="password"
+                                            value={form.password}
+                                            onChange={(e) => updateForm({ password: e.target.value })}
+                                            placeholder={editing?.hasSecret ? '保持不变' : ''}
+                                        />
+                                    </div>
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="channel-from" className={labelClass}>
+                                        发件人 *
+                                    </Label>
+                                    <Input
+                                        id="channel-from"
+                                        value={form.from}
+                                        onChange={(e) => updateForm({ from: e.target.value })}
+                                        placeholder="Hop <hop@example.com>"
+                                    />
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="channel-to" className={labelClass}>
+                                        收件人 *
+                                    </Label>
+                                    <Input
+                                        id="channel-to"
+                                        value={form.to}
+                                        onChange={(e) => updateForm({ to: e.target.value })}
+                                        placeholder="ops@example.com, admin@example.com"
+                                    />
+                                </div>
+                            </>
+                        ) : (
+                            <div className="space-y-2">
+                                <Label htmlFor="channel-url" className={labelClass}>
+                                    URL *
+                                </Label>
+                                <div className="flex gap-2">
+                                    {form.type === 'webhook' && (
+                                        <select
+                                            value={form.method}
+                                            onChange={(e) => updateForm({ method: e.target.value as ChannelForm['method'] })}
+                                            className="h-10 px-3 py-2 bg-background border rounded-md text-sm font-mono"
+                                        >
+                                            <option value="POST">POST</option>
+                                            <option value="PUT">PUT</option>
+                                        </select>
+                                    )}
+                                    <Input
+                                        id="channel-url"
+                                        value={form.url}
+                                        onChange={(e) => updateForm({ url: e.target.value })}
+                                        placeholder={urlPlaceholders[form.type]}
+                                        className="font-mono"
+                                    />
+                                </div>
+                            </div>
+                        )}
+
+                        {(form.type === 'feishu' || form.type === 'dingtalk') && (
+                            <div className="space-y-2">
+                                <Label htmlFor="channel-secret" className={labelClass}>
+                                    签名密钥
+                                </Label>
+                                <Input
+                                    id="channel-secret"
+                                    type="password"
+                                    value={form.secret}
+                                    onChange={(e) => updateForm({ secret: e.target.value })}
+                                    placeholder={editing?.hasSecret ? '保持不变' : form.type === 'dingtalk' ? 'SEC...' : ''}
+                                    className="font-mono"
+                                />
+                                <p className="text-xs text-muted-foreground">机器人开启了签名校验（加签）时填写</p>
+                            </div>
+                        )}
+
+                        {form.type === 'webhook' && (
+                            <>
+                                <div className="space-y-2">
+                                    <Label htmlFor="channel-headers" className={labelClass}>
+                                        请求头
+                                    </Label>
+                                    <textarea
+                                        id="channel-headers"
+                                        value={form.headers}
+                                        onChange={(e) => updateForm({ headers: e.target.value })}
+                                        placeholder="Authorization: Bearer ..."
+                                        rows={2}
+                                        className="w-full px-3 py-2 bg-background border rounded-md text-xs font-mono"
+                                    />
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="channel-body" className={labelClass}>
+                                        请求体模板
+                                    </Label>
+                                    <textarea
+                                        id="channel-body"
+                                        value={form.body}
+                                        onChange={(e) => updateForm({ body: e.target.value })}
+                                        placeholder={bodyPlaceholder}
+                                        rows={4}
+                                        className="w-full px-3 py-2 bg-background border rounded-md text-xs font-mono"
+                                    />
+                                    <p className="text-xs text-muted-foreground">
+                                        Go 模板，可用 .Type .Title .Message .Domain .Domains .NotAfter .DaysRemaining .Error；
+                                        {'{{json .X}}'} 输出 JSON 值。留空则发送事件的 JSON
+                                    </p>
+                                </div>
+                            </>
+                        )}
+
+                        <div className="space-y-2">
+                            <Label className={labelClass}>通知事件 *</Label>
+                            {events.map((e) => (
+                                <div key={e.type} className="flex items-center justify-between">
+                                    <Label className="text-sm">{e.label}</Label>
+                                    <Switch
+                                        checked={form.events.includes(e.type)}
+                                        onCheckedChange={(checked: boolean) => toggleEvent(e.type, checked)}
+                                    />
+                                </div>
+                            ))}
+                        </div>
+                    </div>
+                    <DialogFooter>
+                        <Button variant="outline" onClick={() => setDialogOpen(false)} disabled={saving}>
+                            取消
+                        </Button>
+                        <Button onClick={handleSave} disabled={saving} className="gap-2">
+                            {saving && <Loader2 className="h-4 w-4 animate-spin" />}
+                            保存
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+
+            {/* Delete Dialog */}
+            <AlertDialog open={deleteDialogOpen} onOpenChange={setDeleteDialogOpen}>
+                <AlertDialogContent>
+                    <AlertDialogHeader>
+                        <AlertDialogTitle>确认删除通知渠道</AlertDialogTitle>
+                        <AlertDialogDescription className="space-y-2">
+                            <span>此操作无法撤销。</span>
+                            {deleting && (
+                                <code className="block mt-2 p-2 bg-muted text-sm font-mono rounded">
+                                    {deleting.name}
+                                </code>
+                            )}
+                        </AlertDialogDescription>
+                    </AlertDialogHeader>
+                    <AlertDialogFooter>
+                        <AlertDialogCancel disabled={deletingLoading}>取消</AlertDialogCancel>
+                        <AlertDialogAction
+                            onClick={handleDelete}
+                            disabled={deletingLoading}
+                            className="bg-destructive text-destructive-foreground hover:bg-destructive/90"
+                        >
+                            {deletingLoading ? <Loader2 className="h-4 w-4 animate-spin mr-2" /> : <Trash2 className="h-4 w-4 mr-2" />}
+                            删除
+                        </AlertDialogAction>
+                    </AlertDialogFooter>
+                </AlertDialogContent>
+            </AlertDialog>
+        </div>
+    );
+}
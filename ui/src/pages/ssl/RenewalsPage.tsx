@@ -0,0 +1,326 @@
+import { useState, useEffect } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { toast } from 'sonner';
+import {
+    ChevronLeft,
+    Loader2,
+    CalendarClock,
+    Play,
+    Pencil,
+    AlertCircle,
+    ExternalLink
+} from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
+import {
+    Dialog,
+    DialogContent,
+    DialogDescription,
+    DialogFooter,
+    DialogHeader,
+    DialogTitle,
+} from '@/components/ui/dialog';
+import {
+    getSchedulerStatus,
+    runScheduler,
+    listCertificates,
+    updateCertificateRenewBefore,
+    getRenewBasisLabel,
+    type Certificate,
+    type RenewalStatus,
+    type SchedulerStatus,
+} from '@/api/ssl';
+
+export default function RenewalsPage() {
+    const navigate = useNavigate();
+    const [status, setStatus] = useState<SchedulerStatus | null>(null);
+    const [certificates, setCertificates] = useState<Certificate[]>([]);
+    const [loading, setLoading] = useState(true);
+    const [running, setRunning] = useState(false);
+
+    // 修改提前续期天数弹窗
+    const [editingCert, setEditingCert] = useState<Certificate | null>(null);
+    const [dialogOpen, setDialogOpen] = useState(false);
+    const [renewBefore, setRenewBefore] = useState('');
+    const [saving, setSaving] = useState(false);
+
+    useEffect(() => {
+        loadStatus();
+    }, []);
+
+    const loadStatus = async () => {
+        try {
+            const [statusRes, certsRes] = await Promise.all([getSchedulerStatus(), listCertificates()]);
+            setStatus(statusRes);
+            setCertificates(certsRes.certificates);
+        } catch (err) {
+            console.error('Failed to load scheduler status:', err);
+            toast.error('加载续期计划失败');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const handleRun = async () => {
+        setRunning(true);
+        try {
+            const result = await runScheduler();
+            if (result.success) {
+                toast.success('已开始检查，到期的证书将加入续期队列');
+                // 等待检查完成后刷新
+                setTimeout(loadStatus, 3000);
+            } else {
+                toast.error(result.error || '执行检查失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setRunning(false);
+        }
+    };
+
+    const openDialog = (item: RenewalStatus) => {
+        const cert = certificates.find(c => c.id === item.id);
+        if (!cert) return;
+        setEditingCert(cert);
+        setRenewBefore(cert.renewBefore > 0 ? String(cert.renewBefore) : '');
+        setDialogOpen(true);
+    };
+
+    const handleSave = async () => {
+        if (!editingCert) return;
+        const days = renewBefore.trim() ? parseInt(renewBefore, 10) : 0;
+        if (isNaN(days) || days < 0) {
+            toast.error('请输入有效的天数');
+            return;
+        }
+
+        setSaving(true);
+        try {
+            const result = await updateCertificateRenewBefore(editingCert, days);
+            if (result.success) {
+                toast.success('续期时间已更新');
+                setDialogOpen(false);
+                // 重新计算续期时间可能需要查询 CA
+                setTimeout(loadStatus, 1000);
+            } else {
+                toast.error(result.error || '保存失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const formatTime = (value: string | null) => {
+        if (!value) return '-';
+        return new Date(value).toLocaleString('zh-CN', {
+            year: 'numeric',
+            month: '2-digit',
+            day: '2-digit',
+            hour: '2-digit',
+            minute: '2-digit',
+        });
+    };
+
+    if (loading) {
+        return (
+            <div className="min-h-screen flex items-center justify-center">
+                <Loader2 className="h-8 w-8 animate-spin text-primary" />
+            </div>
+        );
+    }
+
+    return (
+        <div className="min-h-screen flex flex-col">
+            {/* Header */}
+            <header className="border-b bg-card/80 backdrop-blur-sm sticky top-0 z-50">
+                <div className="flex h-14 items-center justify-between px-4 lg:px-6">
+                    <div className="flex items-center gap-4">
+                        <Button
+                            variant="ghost"
+                            size="sm"
+                            onClick={() => navigate('/ssl')}
+                            className="gap-2"
+                        >
+                            <ChevronLeft className="h-4 w-4" />
+                            返回
+                        </Button>
+                        <div className="w-px h-6 bg-border" />
+                        <div className="flex items-center gap-3">
+                            <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                <CalendarClock className="h-4 w-4 text-primary" />
+                            </div>
+                            <div>
+                                <h1 className="font-semibold">续期计划</h1>
+                                <p className="text-xs text-muted-foreground font-mono">
+                                    CA 支持 ARI 时按建议窗口续期，失败后指数退避重试
+                                </p>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+            </header>
+
+            {/* Main Content */}
+            <main className="flex-1 p-4 lg:p-6">
+                <div className="max-w-6xl mx-auto space-y-6">
+                    {status && (
+                        <div className="bg-card border">
+                            <div className="flex items-center justify-between p-4 border-b">
+                                <div className="flex items-center gap-3">
+                                    <div className="w-8 h-8 bg-primary/10 flex items-center justify-center">
+                                        <CalendarClock className="h-4 w-4 text-primary" />
+                                    </div>
+                                    <div>
+                                        <h2 className="font-semibold">调度器</h2>
+                                        <p className="text-xs text-muted-foreground font-mono">
+                                            每 {status.interval} 检查一次 · 默认提前 {status.renewBeforeDays} 天续期
+                                        </p>
+                                    </div>
+                                </div>
+                                <Button
+                                    size="sm"
+                                    onClick={handleRun}
+                                    disabled={running || status.running}
+                                    className="gap-2 font-mono text-xs"
+                                >
+                                    {running || status.running ? (
+                                        <Loader2 className="h-3.5 w-3.5 animate-spin" />
+                                    ) : (
+                                        <Play className="h-3.5 w-3.5" />
+                                    )}
+                                    立即检查
+                                </Button>
+                            </div>
+                            <div className="grid grid-cols-3 gap-4 p-4 text-sm">
+                                <div>
+                                    <p className="text-xs text-muted-foreground font-mono">上次检查</p>
+                                    <p>{formatTime(status.lastRunAt)}</p>
+                                </div>
+                                <div>
+                                    <p className="text-xs text-muted-foreground font-mono">下次检查</p>
+                                    <p>{formatTime(status.nextRunAt)}</p>
+                                </div>
+                                <div>
+                                    <p className="text-xs text-muted-foreground font-mono">上次加入队列</p>
+                                    <p>{status.lastQueued} 个证书</p>
+                                </div>
+                            </div>
+                        </div>
+                    )}
+
+                    <div className="bg-card border">
+                        <div className="grid grid-cols-[1fr_140px_140px_160px_auto] gap-4 px-4 py-2 border-b text-xs text-muted-foreground font-mono">
+                            <span>证书</span>
+                            <span>到期时间</span>
+                            <span>计划续期</span>
+                            <span>下次尝试</span>
+                            <span />
+                        </div>
+                        {!status || status.certificates.length === 0 ? (
+                            <div className="text-center py-16">
+                                <CalendarClock className="h-12 w-12 mx-auto mb-4 text-muted-foreground/30" />
+                                <p className="text-muted-foreground font-mono text-sm">暂无可自动续期的证书</p>
+                                <p className="text-xs text-muted-foreground/60 mt-1">
+                                    上传的证书不会自动续期
+                                </p>
+                            </div>
+                        ) : (
+                            <div className="divide-y">
+                                {status.certificates.map((item) => (
+                                    <div
+                                        key={item.id}
+                                        className="grid grid-cols-[1fr_140px_140px_160px_auto] gap-4 px-4 py-3 items-center hover:bg-muted/50 transition-colors"
+                                    >
+                                        <div className="min-w-0">
+                                            <p className="font-medium truncate">{item.domain}</p>
+                                            <p className="text-xs text-muted-foreground truncate">
+                                                {getRenewBasisLabel(item.basis)}
+                                                {item.renewBefore > 0 && ` · 提前 ${item.renewBefore} 天`}
+                                                {item.explanationUrl && (
+                                                    <a
+                                                        href={item.explanationUrl}
+                                                        target="_blank"
+                                                        rel="noreferrer"
+                                                        className="inline-flex items-center gap-0.5 ml-1 text-primary hover:underline"
+                                                    >
+                                                        CA 说明
+                                                        <ExternalLink className="h-3 w-3" />
+                                                    </a>
+                                                )}
+                                            </p>
+                                            {item.lastError && (
+                                                <p className="flex items-center gap-1 text-xs text-red-500 truncate" title={item.lastError}>
+                                                    <AlertCircle className="h-3 w-3 shrink-0" />
+                                                    连续失败 {item.failures} 次：{item.lastError}
+                                                </p>
+                                            )}
+                                        </div>
+                                        <span className="text-xs font-mono">{formatTime(item.notAfter)}</span>
+                                        <span className="text-xs font-mono">{formatTime(item.renewAt)}</span>
+                                        <span className="text-xs font-mono">
+                                            {item.autoRenew ? formatTime(item.nextAttemptAt) : '未开启自动续期'}
+                                        </span>
+                                        <Button
+                                            variant="ghost"
+                                            size="icon-sm"
+                                            onClick={() => openDialog(item)}
+                                            title="修改续期时间"
+                                        >
+                                            <Pencil className="h-4 w-4" />
+                                        </Button>
+                                    </div>
+                                ))}
+                            </div>
+                        )}
+                    </div>
+                </div>
+            </main>
+
+            {/* Edit Dialog */}
+            <Dialog open={dialogOpen} onOpenChange={setDialogOpen}>
+                <DialogContent>
+                    <DialogHeader>
+                        <DialogTitle className="flex items-center gap-2">
+                            <CalendarClock className="h-5 w-5 text-primary" />
+                            修改续期时间
+                        </DialogTitle>
+                        <DialogDescription className="font-mono">
+                            {editingCert?.domain}
+                        </DialogDescription>
+                    </DialogHeader>
+                    <div className="space-y-4 py-4">
+                        <div className="space-y-2">
+                            <Label htmlFor="renew-before" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                提前续期天数
+                            </Label>
+                            <Input
+                                id="renew-before"
+                                type="number"
+                                min={0}
+                                value={renewBefore}
+                                onChange={(e) => setRenewBefore(e.target.value)}
+                                placeholder="自动"
+                            />
+                            <p className="text-xs text-muted-foreground">
+                                留空或 0 表示自动：CA 支持 ARI 时按建议窗口续期，否则提前 {status?.renewBeforeDays} 天，有效期较短的证书在剩余三分之一时续期
+                            </p>
+                        </div>
+                    </div>
+                    <DialogFooter>
+                        <Button variant="outline" onClick={() => setDialogOpen(false)} disabled={saving}>
+                            取消
+                        </Button>
+                        <Button onClick={handleSave} disabled={saving} className="gap-2">
+                            {saving && <Loader2 className="h-4 w-4 animate-spin" />}
+                            保存
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+        </div>
+    );
+}
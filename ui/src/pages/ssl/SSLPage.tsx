@@ -13,11 +13,22 @@ import {
     ChevronLeft,
     Loader2,
     Settings,
-    Calendar
+    Calendar,
+    UserRound,
+    Upload,
+    ShieldCheck,
+    BadgeCheck,
+    ScrollText,
+    Webhook,
+    Bell,
+    CalendarClock,
+    Pencil,
+    Ban
 } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
+import { Switch } from '@/components/ui/switch';
 import {
     Dialog,
     DialogContent,
@@ -41,39 +52,94 @@ import {
     listDNSProviders,
     issueCertificate,
     renewCertificate,
+    updateCertificateKey,
+    updateCertificateDomains,
     cleanupCertificate,
+    revokeCertificate,
+    REVOKE_REASONS,
     deleteCertificate,
     getSSLStatus,
+    listACMEAccounts,
+    listCertificateJobs,
+    type ACMEAccount,
+    type CertificateJob,
+    type ACMEPreset,
     type Certificate,
+    type CertificateKeyOptions,
+    type ChallengeType,
     type DNSProvider,
     getCertificateStatusLabel,
+    getKeyTypeLabel,
     getCertificateStatusColor,
     getDNSProviderLabel,
+    getJobTypeLabel,
 } from '@/api/ssl';
+import { KeyOptionsFields } from '@/components/ssl/KeyOptionsFields';
+import { UploadCertificateDialog } from '@/components/ssl/UploadCertificateDialog';
+import { JobLogDialog } from '@/components/ssl/JobLogDialog';
+import { HooksDialog } from '@/components/ssl/HooksDialog';
 
 export default function SSLPage() {
     const navigate = useNavigate();
     const [certificates, setCertificates] = useState<Certificate[]>([]);
     const [providers, setProviders] = useState<DNSProvider[]>([]);
     const [loading, setLoading] = useState(true);
-    const [legoInstalled, setLegoInstalled] = useState(false);
 
     // 申请证书弹窗
     const [issueDialogOpen, setIssueDialogOpen] = useState(false);
     const [issuing, setIssuing] = useState(false);
     const [domains, setDomains] = useState('');
+    const [challengeType, setChallengeType] = useState<ChallengeType>('dns-01');
     const [selectedProvider, setSelectedProvider] = useState('');
     const [email, setEmail] = useState('');
+    const [accounts, setAccounts] = useState<ACMEAccount[]>([]);
+    const [selectedAccount, setSelectedAccount] = useState(''); // '' 按邮箱查找或注册账户
+    const [presets, setPresets] = useState<ACMEPreset[]>([]);
+    const [defaultDirectory, setDefaultDirectory] = useState('');
+    const [acmeDirectory, setACMEDirectory] = useState(''); // '' 使用默认目录，custom 为自定义地址
+    const [customDirectory, setCustomDirectory] = useState('');
+    const [eabKid, setEabKid] = useState('');
+    const [eabHmacKey, setEabHmacKey] = useState('');
+    const [keyOptions, setKeyOptions] = useState<CertificateKeyOptions>({ keyType: 'ec256' });
+    const [replaceId, setReplaceId] = useState(''); // 签发成功后替换的证书，'' 不替换
 
     // 续期证书弹窗
     const [renewDialogOpen, setRenewDialogOpen] = useState(false);
     const [renewingCert, setRenewingCert] = useState<Certificate | null>(null);
     const [renewing, setRenewing] = useState(false);
     const [renewEmail, setRenewEmail] = useState('');
+    const [renewKeyOptions, setRenewKeyOptions] = useState<CertificateKeyOptions>({});
+
+    // 修改域名弹窗
+    const [domainsDialogOpen, setDomainsDialogOpen] = useState(false);
+    const [editingCert, setEditingCert] = useState<Certificate | null>(null);
+    const [editDomains, setEditDomains] = useState('');
+    const [savingDomains, setSavingDomains] = useState(false);
+
+    // 上传证书弹窗（replacingCert 不为空时替换该证书）
+    const [uploadDialogOpen, setUploadDialogOpen] = useState(false);
+    const [replacingCert, setReplacingCert] = useState<Certificate | null>(null);
+
+    // 申请和续期任务：排队和执行中的任务，以及正在查看日志的任务
+    const [activeJobs, setActiveJobs] = useState<CertificateJob[]>([]);
+    const [watchingJob, setWatchingJob] = useState<CertificateJob | null>(null);
+    const [jobDialogOpen, setJobDialogOpen] = useState(false);
+
+    // 部署钩子弹窗
+    const [hooksCert, setHooksCert] = useState<Certificate | null>(null);
+    const [hooksDialogOpen, setHooksDialogOpen] = useState(false);
+
+    // 撤销证书弹窗
+    const [revokeDialogOpen, setRevokeDialogOpen] = useState(false);
+    const [revokingCert, setRevokingCert] = useState<Certificate | null>(null);
+    const [revokeReason, setRevokeReason] = useState(0);
+    const [revokeDetach, setRevokeDetach] = useState(false);
+    const [revoking, setRevoking] = useState(false);
 
     // 删除证书弹窗
     const [deleteDialogOpen, setDeleteDialogOpen] = useState(false);
     const [deletingCert, setDeletingCert] = useState<Certificate | null>(null);
+    const [deleteCascade, setDeleteCascade] = useState(false);
     const [deleting, setDeleting] = useState(false);
 
     useEffect(() => {
@@ -82,14 +148,19 @@ export default function SSLPage() {
 
     const loadData = async () => {
         try {
-            const [certsRes, providersRes, statusRes] = await Promise.all([
+            const [certsRes, providersRes, status, accountsRes, jobsRes] = await Promise.all([
                 listCertificates(),
                 listDNSProviders(),
                 getSSLStatus(),
+                listACMEAccounts(),
+                listCertificateJobs(),
             ]);
             setCertificates(certsRes.certificates);
+            setActiveJobs(jobsRes.jobs.filter(j => j.status === 'queued' || j.status === 'running'));
             setProviders(providersRes.providers);
-            setLegoInstalled(statusRes.legoInstalled);
+            setAccounts(accountsRes.accounts.filter(a => a.status === 'valid'));
+            setPresets(status.directories);
+            setDefaultDirectory(status.acmeDirectory);
         } catch (err) {
             console.error('Failed to load SSL data:', err);
             toast.error('加载数据失败');
@@ -103,48 +174,56 @@ export default function SSLPage() {
             toast.error('请输入域名');
             return;
         }
-        if (!selectedProvider) {
+        if (challengeType === 'dns-01' && !selectedProvider) {
             toast.error('请选择 DNS 提供商');
             return;
         }
-        if (!email.trim()) {
+        if (!selectedAccount && !email.trim()) {
             toast.error('请输入邮箱');
             return;
         }
+        if (acmeDirectory === 'custom' && !customDirectory.trim()) {
+            toast.error('请输入 ACME 目录地址');
+            return;
+        }
+        if (!eabKid.trim() !== !eabHmacKey.trim()) {
+            toast.error('EAB Key ID 和 HMAC 密钥需要同时填写');
+            return;
+        }
 
         setIssuing(true);
         try {
             const domainList = domains.split(',').map(d => d.trim()).filter(d => d);
-            const result = await issueCertificate(domainList, selectedProvider, email);
-            if (result.success) {
-                toast.success('证书申请成功');
+            const result = await issueCertificate(
+                domainList,
+                challengeType === 'dns-01' ? selectedProvider : '',
+                email,
+                challengeType,
+                {
+                    accountId: selectedAccount,
+                    acmeDirectory: acmeDirectory === 'custom' ? customDirectory.trim() : acmeDirectory,
+                    eabKid: eabKid.trim(),
+                    eabHmacKey: eabHmacKey.trim(),
+                    replace: replaceId,
+                },
+                keyOptions
+            );
+            if (result.success && result.job) {
                 setIssueDialogOpen(false);
                 setDomains('');
+                setChallengeType('dns-01');
                 setSelectedProvider('');
                 setEmail('');
-                loadData();
+                setSelectedAccount('');
+                setACMEDirectory('');
+                setCustomDirectory('');
+                setEabKid('');
+                setEabHmacKey('');
+                setKeyOptions({ keyType: 'ec256' });
+                setReplaceId('');
+                watchJob(result.job);
             } else {
-                const errorMsg = result.error || '证书申请失败';
-                
-                // 检查是否是 DNS 记录冲突错误
-                if (errorMsg.includes('已存在') || errorMsg.includes('already exists')) {
-                    toast.error('DNS 验证记录冲突', {
-                        description: '请在证书列表中点击"清理"按钮清除旧记录后重试',
-                        duration: 5000,
-                    });
-                } else if (errorMsg.includes('认证失败') || errorMsg.includes('authentication')) {
-                    toast.error('DNS API 认证失败', {
-                        description: '请检查 DNS 提供商配置是否正确',
-                        duration: 5000,
-                    });
-                } else if (errorMsg.includes('rate limit') || errorMsg.includes('速率限制')) {
-                    toast.error('触发速率限制', {
-                        description: 'Let\'s Encrypt 限制了申请频率，请稍后再试',
-                        duration: 5000,
-                    });
-                } else {
-                    toast.error(errorMsg);
-                }
+                toast.error(result.error || '证书申请失败');
             }
         } catch (err) {
             toast.error((err as Error).message);
@@ -153,22 +232,70 @@ export default function SSLPage() {
         }
     };
 
+    // 打开任务日志弹窗，任务结束后刷新列表
+    const watchJob = (job: CertificateJob) => {
+        setWatchingJob(job);
+        setJobDialogOpen(true);
+        loadData();
+    };
+
+    const handleJobFinished = (job: CertificateJob) => {
+        loadData();
+        if (job.status === 'succeeded') {
+            toast.success(`${job.domain} ${getJobTypeLabel(job.type)}成功`);
+            return;
+        }
+
+        const errorMsg = job.error || `证书${getJobTypeLabel(job.type)}失败`;
+
+        // 检查是否是 DNS 记录冲突错误
+        if (errorMsg.includes('已存在') || errorMsg.includes('already exists')) {
+            toast.error('DNS 验证记录冲突', {
+                description: '请在证书列表中点击"清理"按钮清除旧记录后重试',
+                duration: 5000,
+            });
+        } else if (errorMsg.includes('认证失败') || errorMsg.includes('authentication')) {
+            toast.error('DNS API 认证失败', {
+                description: '请检查 DNS 提供商配置是否正确',
+                duration: 5000,
+            });
+        } else if (errorMsg.includes('rate limit') || errorMsg.includes('速率限制')) {
+            toast.error('触发速率限制', {
+                description: 'Let\'s Encrypt 限制了申请频率，请稍后再试',
+                duration: 5000,
+            });
+        } else {
+            toast.error(errorMsg);
+        }
+    };
+
     const handleRenew = async () => {
         if (!renewingCert) return;
-        if (!renewEmail.trim()) {
-            toast.error('请输入邮箱');
+        if (renewingCert.source === 'acme' && !renewingCert.accountId && !renewEmail.trim()) {
+            toast.error('证书未关联 ACME 账户，请输入邮箱');
             return;
         }
 
         setRenewing(true);
         try {
+            // 私钥设置有变化时先保存，本次续期即按新设置签发
+            if (
+                (renewKeyOptions.keyType || 'ec256') !== (renewingCert.keyType || 'ec256') ||
+                !!renewKeyOptions.reuseKey !== renewingCert.reuseKey ||
+                !!renewKeyOptions.dual !== renewingCert.dual
+            ) {
+                const updated = await updateCertificateKey(renewingCert.id, renewKeyOptions);
+                if (!updated.success) {
+                    toast.error(updated.error || '保存私钥设置失败');
+                    return;
+                }
+            }
             const result = await renewCertificate(renewingCert.id, renewEmail);
-            if (result.success) {
-                toast.success('证书续期成功');
+            if (result.success && result.job) {
                 setRenewDialogOpen(false);
                 setRenewingCert(null);
                 setRenewEmail('');
-                loadData();
+                watchJob(result.job);
             } else {
                 toast.error(result.error || '证书续期失败');
             }
@@ -179,17 +306,85 @@ export default function SSLPage() {
         }
     };
 
+    const openDomainsDialog = (cert: Certificate) => {
+        setEditingCert(cert);
+        setEditDomains(cert.domains.join(', '));
+        setDomainsDialogOpen(true);
+    };
+
+    const handleUpdateDomains = async () => {
+        if (!editingCert) return;
+        const domainList = editDomains.split(',').map(d => d.trim()).filter(d => d);
+        if (domainList.length === 0) {
+            toast.error('请输入域名');
+            return;
+        }
+
+        setSavingDomains(true);
+        try {
+            const result = await updateCertificateDomains(editingCert.id, domainList);
+            if (result.success && result.job) {
+                setDomainsDialogOpen(false);
+                setEditingCert(null);
+                watchJob(result.job);
+            } else {
+                toast.error(result.error || '修改域名失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setSavingDomains(false);
+        }
+    };
+
+    const handleRevoke = async () => {
+        if (!revokingCert) return;
+
+        setRevoking(true);
+        try {
+            const result = await revokeCertificate(revokingCert.id, revokeReason, revokeDetach);
+            if (result.success) {
+                const sites = result.sites || [];
+                if (sites.length === 0) {
+                    toast.success('证书已撤销');
+                } else if (revokeDetach) {
+                    toast.success('证书已撤销', {
+                        description: `已停用 ${sites.join(', ')} 的 HTTPS`,
+                    });
+                } else {
+                    toast.warning('证书已撤销', {
+                        description: `${sites.join(', ')} 仍在使用该证书，请尽快更换`,
+                        duration: 5000,
+                    });
+                }
+                setRevokeDialogOpen(false);
+                setRevokingCert(null);
+                loadData();
+            } else {
+                toast.error(result.error || '撤销失败');
+            }
+        } catch (err) {
+            toast.error((err as Error).message);
+        } finally {
+            setRevoking(false);
+        }
+    };
+
     const handleDelete = async () => {
         if (!deletingCert) return;
 
         setDeleting(true);
         try {
-            const result = await deleteCertificate(deletingCert.id);
+            const result = await deleteCertificate(deletingCert.id, deleteCascade);
             if (result.success) {
-                toast.success('证书已删除');
+                toast.success(deleteCascade ? '证书已删除，相关站点已停用 HTTPS' : '证书已删除');
                 setDeleteDialogOpen(false);
                 setDeletingCert(null);
                 loadData();
+            } else if (result.sites) {
+                // 打开弹窗后有站点开始引用该证书，刷新列表中的站点
+                toast.error(result.error || '仍有站点在使用该证书');
+                loadData();
             } else {
                 toast.error(result.error || '删除失败');
             }
@@ -204,7 +399,7 @@ export default function SSLPage() {
         try {
             const result = await cleanupCertificate(cert.id);
             if (result.success) {
-                toast.success('已清理 lego 数据', {
+                toast.success('已清理 DNS 验证记录', {
                     description: '现在可以重新申请证书了',
                 });
                 loadData();
@@ -269,12 +464,39 @@ export default function SSLPage() {
                             <div>
                                 <h1 className="font-semibold">SSL 证书管理</h1>
                                 <p className="text-xs text-muted-foreground font-mono">
-                                    Let's Encrypt 自动化证书
+                                    ACME 自动化证书
                                 </p>
                             </div>
                         </div>
                     </div>
                     <div className="flex items-center gap-2">
+                        <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => navigate('/ssl/ca')}
+                            className="gap-2 font-mono text-xs"
+                        >
+                            <ShieldCheck className="h-3.5 w-3.5" />
+                            内部 CA
+                        </Button>
+                        <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => navigate('/ssl/client-cas')}
+                            className="gap-2 font-mono text-xs"
+                        >
+                            <BadgeCheck className="h-3.5 w-3.5" />
+                            客户端 CA
+                        </Button>
+                        <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => navigate('/ssl/accounts')}
+                            className="gap-2 font-mono text-xs"
+                        >
+                            <UserRound className="h-3.5 w-3.5" />
+                            ACME 账户
+                        </Button>
                         <Button
                             variant="outline"
                             size="sm"
@@ -284,6 +506,24 @@ export default function SSLPage() {
                             <Settings className="h-3.5 w-3.5" />
                             DNS 配置
                         </Button>
+                        <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => navigate('/ssl/renewals')}
+                            className="gap-2 font-mono text-xs"
+                        >
+                            <CalendarClock className="h-3.5 w-3.5" />
+                            续期计划
+                        </Button>
+                        <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => navigate('/ssl/notifications')}
+                            className="gap-2 font-mono text-xs"
+                        >
+                            <Bell className="h-3.5 w-3.5" />
+                            通知
+                        </Button>
                     </div>
                 </div>
             </header>
@@ -291,21 +531,35 @@ export default function SSLPage() {
             {/* Main Content */}
             <main className="flex-1 p-4 lg:p-6">
                 <div className="max-w-6xl mx-auto space-y-6">
-                    {/* Lego Status Warning */}
-                    {!legoInstalled && (
-                        <div className="bg-yellow-500/10 border border-yellow-500/20 p-4 rounded">
-                            <div className="flex items-start gap-3">
-                                <AlertCircle className="h-5 w-5 text-yellow-500 shrink-0 mt-0.5" />
-                                <div className="flex-1">
-                                    <h3 className="font-semibold text-sm">Lego 未安装</h3>
-                                    <p className="text-xs text-muted-foreground mt-1">
-                                        请先安装 lego 工具：
-                                        <code className="ml-2 px-2 py-1 bg-muted rounded text-xs">
-                                            go install github.com/go-acme/lego/v4/cmd/lego@latest
-                                        </code>
-                                    </p>
+                    {/* 排队和执行中的任务 */}
+                    {activeJobs.length > 0 && (
+                        <div className="bg-card border divide-y">
+                            {activeJobs.map((job) => (
+                                <div key={job.id} className="flex items-center justify-between gap-4 px-4 py-2.5">
+                                    <div className="flex items-center gap-2 min-w-0">
+                                        {job.status === 'running' ? (
+                                            <Loader2 className="h-4 w-4 animate-spin text-primary shrink-0" />
+                                        ) : (
+                                            <Clock className="h-4 w-4 text-yellow-500 shrink-0" />
+                                        )}
+                                        <span className="text-sm truncate">
+                                            {getJobTypeLabel(job.type)} {job.domain}
+                                        </span>
+                                        <span className="text-xs text-muted-foreground font-mono">
+                                            {job.status === 'running' ? '执行中' : '排队中'}
+                                        </span>
+                                    </div>
+                                    <Button
+                                        variant="ghost"
+                                        size="sm"
+                                        onClick={() => watchJob(job)}
+                                        className="gap-1.5 text-xs h-7"
+                                    >
+                                        <ScrollText className="h-3.5 w-3.5" />
+                                        查看进度
+                                    </Button>
                                 </div>
-                            </div>
+                            ))}
                         </div>
                     )}
 
@@ -323,15 +577,28 @@ export default function SSLPage() {
                                     </p>
                                 </div>
                             </div>
-                            <Button
-                                size="sm"
-                                onClick={() => setIssueDialogOpen(true)}
-                                disabled={!legoInstalled || providers.length === 0}
-                                className="gap-2 font-mono text-xs"
-                            >
-                                <Plus className="h-3.5 w-3.5" />
-                                申请证书
-                            </Button>
+                            <div className="flex items-center gap-2">
+                                <Button
+                                    variant="outline"
+                                    size="sm"
+                                    onClick={() => {
+                                        setReplacingCert(null);
+                                        setUploadDialogOpen(true);
+                                    }}
+                                    className="gap-2 font-mono text-xs"
+                                >
+                                    <Upload className="h-3.5 w-3.5" />
+                                    上传证书
+                                </Button>
+                                <Button
+                                    size="sm"
+                                    onClick={() => setIssueDialogOpen(true)}
+                                    className="gap-2 font-mono text-xs"
+                                >
+                                    <Plus className="h-3.5 w-3.5" />
+                                    申请证书
+                                </Button>
+                            </div>
                         </div>
 
                         {/* Table */}
@@ -372,6 +639,34 @@ export default function SSLPage() {
                                                         +{cert.domains.length - 1} 个域名
                                                     </p>
                                                 )}
+                                                {cert.source === 'upload' && (
+                                                    <p className="text-xs text-muted-foreground truncate" title={cert.issuer}>
+                                                        上传 · {cert.issuer}
+                                                    </p>
+                                                )}
+                                                {cert.source === 'internal' && (
+                                                    <p className="text-xs text-muted-foreground truncate" title={cert.issuer}>
+                                                        内部 CA · {cert.issuer}
+                                                        {cert.usage === 'client' && ' · 客户端证书'}
+                                                    </p>
+                                                )}
+                                                {cert.acmeDirectory && (
+                                                    <p className="text-xs text-muted-foreground truncate" title={cert.acmeDirectory}>
+                                                        {cert.caName || cert.acmeDirectory}
+                                                        {cert.accountEmail && ` · ${cert.accountEmail}`}
+                                                    </p>
+                                                )}
+                                                <p className="text-xs text-muted-foreground font-mono">
+                                                    {getKeyTypeLabel(cert.keyType)}
+                                                    {cert.dual && ' + RSA 2048'}
+                                                    {cert.reuseKey && ' · 复用私钥'}
+                                                    {cert.source === 'upload' && ' · 不自动续期'}
+                                                </p>
+                                                {cert.sites.length > 0 && (
+                                                    <p className="text-xs text-muted-foreground truncate" title={cert.sites.join(', ')}>
+                                                        {cert.sites.length} 个站点使用中
+                                                    </p>
+                                                )}
                                                 {cert.error && (
                                                     <p className="text-xs text-red-500 mt-1 truncate" title={cert.error}>
                                                         {cert.error}
@@ -393,27 +688,83 @@ export default function SSLPage() {
                                         </div>
 
                                         <div className="flex items-center gap-1">
+                                            {cert.source === 'upload' ? (
+                                                <Button
+                                                    variant="ghost"
+                                                    size="icon-sm"
+                                                    onClick={() => {
+                                                        setReplacingCert(cert);
+                                                        setUploadDialogOpen(true);
+                                                    }}
+                                                    title="上传新证书替换"
+                                                >
+                                                    <Upload className="h-4 w-4" />
+                                                </Button>
+                                            ) : (
+                                                <Button
+                                                    variant="ghost"
+                                                    size="icon-sm"
+                                                    onClick={() => {
+                                                        setRenewingCert(cert);
+                                                        setRenewKeyOptions({
+                                                            keyType: cert.keyType || 'ec256',
+                                                            reuseKey: cert.reuseKey,
+                                                            dual: cert.dual,
+                                                        });
+                                                        setRenewDialogOpen(true);
+                                                    }}
+                                                    disabled={cert.status !== 'active' && cert.status !== 'revoked'}
+                                                    title={cert.status === 'revoked' ? '重新签发证书' : '续期证书'}
+                                                >
+                                                    <RefreshCw className="h-4 w-4" />
+                                                </Button>
+                                            )}
+                                            {cert.source !== 'upload' && (
+                                                <Button
+                                                    variant="ghost"
+                                                    size="icon-sm"
+                                                    onClick={() => openDomainsDialog(cert)}
+                                                    title="修改域名"
+                                                >
+                                                    <Pencil className="h-4 w-4" />
+                                                </Button>
+                                            )}
+                                            {cert.status === 'error' && cert.challengeType !== 'http-01' && (
+                                                <Button
+                                                    variant="ghost"
+                                                    size="icon-sm"
+                                                    onClick={() => handleCleanup(cert)}
+                                                    title="清理 DNS 验证记录（解决 DNS 冲突）"
+                                                    className="text-yellow-500 hover:text-yellow-600"
+                                                >
+                                                    <AlertCircle className="h-4 w-4" />
+                                                </Button>
+                                            )}
                                             <Button
                                                 variant="ghost"
                                                 size="icon-sm"
                                                 onClick={() => {
-                                                    setRenewingCert(cert);
-                                                    setRenewDialogOpen(true);
+                                                    setHooksCert(cert);
+                                                    setHooksDialogOpen(true);
                                                 }}
-                                                disabled={cert.status !== 'active'}
-                                                title="续期证书"
+                                                title="部署钩子"
                                             >
-                                                <RefreshCw className="h-4 w-4" />
+                                                <Webhook className="h-4 w-4" />
                                             </Button>
-                                            {cert.status === 'error' && (
+                                            {cert.source === 'acme' && cert.status !== 'revoked' && (
                                                 <Button
                                                     variant="ghost"
                                                     size="icon-sm"
-                                                    onClick={() => handleCleanup(cert)}
-                                                    title="清理 lego 数据（解决 DNS 冲突）"
-                                                    className="text-yellow-500 hover:text-yellow-600"
+                                                    onClick={() => {
+                                                        setRevokingCert(cert);
+                                                        setRevokeReason(0);
+                                                        setRevokeDetach(false);
+                                                        setRevokeDialogOpen(true);
+                                                    }}
+                                                    className="text-muted-foreground hover:text-destructive"
+                                                    title="撤销证书"
                                                 >
-                                                    <AlertCircle className="h-4 w-4" />
+                                                    <Ban className="h-4 w-4" />
                                                 </Button>
                                             )}
                                             <Button
@@ -421,6 +772,7 @@ export default function SSLPage() {
                                                 size="icon-sm"
                                                 onClick={() => {
                                                     setDeletingCert(cert);
+                                                    setDeleteCascade(false);
                                                     setDeleteDialogOpen(true);
                                                 }}
                                                 className="text-muted-foreground hover:text-destructive"
@@ -450,7 +802,7 @@ export default function SSLPage() {
                             申请 SSL 证书
                         </DialogTitle>
                         <DialogDescription className="font-mono">
-                            使用 Let's Encrypt 免费申请 SSL 证书
+                            通过 ACME 协议免费申请 SSL 证书
                         </DialogDescription>
                     </DialogHeader>
                     <div className="space-y-4 py-4">
@@ -467,35 +819,161 @@ export default function SSLPage() {
                             />
                         </div>
                         <div className="space-y-2">
-                            <Label htmlFor="provider" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                DNS 提供商
+                            <Label htmlFor="replace" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                替换证书
                             </Label>
                             <select
-                                id="provider"
-                                value={selectedProvider}
-                                onChange={(e) => setSelectedProvider(e.target.value)}
+                                id="replace"
+                                value={replaceId}
+                                onChange={(e) => setReplaceId(e.target.value)}
                                 className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
                             >
-                                <option value="">选择 DNS 提供商</option>
-                                {providers.map((p) => (
-                                    <option key={p.id} value={p.id}>
-                                        {p.name} ({getDNSProviderLabel(p.type)})
+                                <option value="">不替换，作为新证书添加</option>
+                                {certificates.filter(c => c.usage !== 'client').map((c) => (
+                                    <option key={c.id} value={c.id}>
+                                        {c.domain} ({getKeyTypeLabel(c.keyType)}，{formatDate(c.notAfter)} 到期)
                                     </option>
                                 ))}
                             </select>
+                            {replaceId && (
+                                <p className="text-xs text-muted-foreground">
+                                    签发成功后引用该证书的站点改用新证书，部署钩子一并转移，然后删除原证书
+                                </p>
+                            )}
                         </div>
                         <div className="space-y-2">
-                            <Label htmlFor="email" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                邮箱地址
+                            <Label htmlFor="challengeType" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                验证方式
                             </Label>
-                            <Input
-                                id="email"
-                                type="email"
-                                value={email}
-                                onChange={(e) => setEmail(e.target.value)}
-                                placeholder="your@email.com"
-                            />
+                            <select
+                                id="challengeType"
+                                value={challengeType}
+                                onChange={(e) => setChallengeType(e.target.value as ChallengeType)}
+                                className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                            >
+                                <option value="dns-01">DNS-01（支持通配符）</option>
+                                <option value="http-01">HTTP-01（需开放 80 端口）</option>
+                            </select>
+                            {challengeType === 'http-01' && (
+                                <p className="text-xs text-muted-foreground">
+                                    nginx 将在 80 端口转发验证请求到 Hop，不支持通配符域名
+                                </p>
+                            )}
                         </div>
+                        {challengeType === 'dns-01' && (
+                            <div className="space-y-2">
+                                <Label htmlFor="provider" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                    DNS 提供商
+                                </Label>
+                                <select
+                                    id="provider"
+                                    value={selectedProvider}
+                                    onChange={(e) => setSelectedProvider(e.target.value)}
+                                    className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                                >
+                                    <option value="">选择 DNS 提供商</option>
+                                    {providers.map((p) => (
+                                        <option key={p.id} value={p.id}>
+                                            {p.name} ({getDNSProviderLabel(p.type)})
+                                        </option>
+                                    ))}
+                                </select>
+                            </div>
+                        )}
+                        {accounts.length > 0 && (
+                            <div className="space-y-2">
+                                <Label htmlFor="account" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                    ACME 账户
+                                </Label>
+                                <select
+                                    id="account"
+                                    value={selectedAccount}
+                                    onChange={(e) => setSelectedAccount(e.target.value)}
+                                    className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                                >
+                                    <option value="">按邮箱使用或注册账户</option>
+                                    {accounts.map((a) => (
+                                        <option key={a.id} value={a.id}>
+                                            {a.email} ({a.caName || a.directory})
+                                        </option>
+                                    ))}
+                                </select>
+                            </div>
+                        )}
+                        {!selectedAccount && (
+                            <>
+                                <div className="space-y-2">
+                                    <Label htmlFor="email" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                        邮箱地址
+                                    </Label>
+                                    <Input
+                                        id="email"
+                                        type="email"
+                                        value={email}
+                                        onChange={(e) => setEmail(e.target.value)}
+                                        placeholder="your@email.com"
+                                    />
+                                </div>
+                                <div className="space-y-2">
+                                    <Label htmlFor="acmeDirectory" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                        证书颁发机构
+                                    </Label>
+                                    <select
+                                        id="acmeDirectory"
+                                        value={acmeDirectory}
+                                        onChange={(e) => setACMEDirectory(e.target.value)}
+                                        className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                                    >
+                                        <option value="">默认（{presets.find(p => p.url === defaultDirectory)?.label || defaultDirectory}）</option>
+                                        {presets.map((p) => (
+                                            <option key={p.name} value={p.name}>
+                                                {p.label}
+                                            </option>
+                                        ))}
+                                        <option value="custom">自定义 ACME 目录</option>
+                                    </select>
+                                    {acmeDirectory === 'custom' && (
+                                        <Input
+                                            value={customDirectory}
+                                            onChange={(e) => setCustomDirectory(e.target.value)}
+                                            placeholder="https://ca.example.com/acme/directory"
+                                            className="font-mono"
+                                        />
+                                    )}
+                                    {presets.find(p => p.name === acmeDirectory)?.staging && (
+                                        <p className="text-xs text-yellow-600">
+                                            测试环境签发的证书不受浏览器信任，仅用于调试
+                                        </p>
+                                    )}
+                                </div>
+                                {(acmeDirectory === 'custom' || presets.find(p => p.name === acmeDirectory)?.eabRequired) && (
+                                    <div className="space-y-2">
+                                        <Label htmlFor="eabKid" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                            External Account Binding
+                                        </Label>
+                                        <Input
+                                            id="eabKid"
+                                            value={eabKid}
+                                            onChange={(e) => setEabKid(e.target.value)}
+                                            placeholder="EAB Key ID"
+                                            className="font-mono"
+                                        />
+                                        <Input
+                                            value={eabHmacKey}
+                                            onChange={(e) => setEabHmacKey(e.target.value)}
+                                            placeholder="EAB HMAC 密钥"
+                                            className="font-mono"
+                                        />
+                                        <p className="text-xs text-muted-foreground">
+                                            {acmeDirectory === 'zerossl'
+                                                ? '留空时使用邮箱地址自动获取 ZeroSSL 的 EAB 凭据'
+                                                : '仅首次注册账户时使用，可在 CA 控制台获取'}
+                                        </p>
+                                    </div>
+                                )}
+                            </>
+                        )}
+                        <KeyOptionsFields value={keyOptions} onChange={setKeyOptions} />
                     </div>
                     <DialogFooter>
                         <Button variant="outline" onClick={() => setIssueDialogOpen(false)} disabled={issuing}>
@@ -518,30 +996,164 @@ export default function SSLPage() {
                             续期证书
                         </DialogTitle>
                         <DialogDescription className="font-mono">
-                            为 {renewingCert?.domain} 续期证书
+                            {renewingCert?.source === 'internal'
+                                ? `由内部 CA 重新签发 ${renewingCert.domain} 的证书`
+                                : `为 ${renewingCert?.domain} 续期证书`}
+                        </DialogDescription>
+                    </DialogHeader>
+                    <div className="space-y-4 py-4">
+                        {renewingCert?.source !== 'internal' && (
+                            <div className="space-y-2">
+                                <Label htmlFor="renew-email" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                    邮箱地址{renewingCert?.accountId && '（可选）'}
+                                </Label>
+                                <Input
+                                    id="renew-email"
+                                    type="email"
+                                    value={renewEmail}
+                                    onChange={(e) => setRenewEmail(e.target.value)}
+                                    placeholder={renewingCert?.accountEmail || 'your@email.com'}
+                                />
+                                <p className="text-xs text-muted-foreground">
+                                    {renewingCert?.accountId
+                                        ? '留空使用证书关联的账户，填写其他邮箱将切换到该邮箱的账户'
+                                        : '证书未关联 ACME 账户，续期后将关联到该邮箱的账户'}
+                                </p>
+                            </div>
+                        )}
+                        <KeyOptionsFields
+                            value={renewKeyOptions}
+                            onChange={setRenewKeyOptions}
+                            hideDual={renewingCert?.source === 'internal'}
+                        />
+                    </div>
+                    <DialogFooter>
+                        <Button variant="outline" onClick={() => setRenewDialogOpen(false)} disabled={renewing}>
+                            取消
+                        </Button>
+                        <Button onClick={handleRenew} disabled={renewing} className="gap-2">
+                            {renewing ? <Loader2 className="h-4 w-4 animate-spin" /> : <RefreshCw className="h-4 w-4" />}
+                            续期
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+
+            {/* Edit Domains Dialog */}
+            <Dialog open={domainsDialogOpen} onOpenChange={setDomainsDialogOpen}>
+                <DialogContent>
+                    <DialogHeader>
+                        <DialogTitle className="flex items-center gap-2">
+                            <Pencil className="h-5 w-5 text-primary" />
+                            修改域名
+                        </DialogTitle>
+                        <DialogDescription className="font-mono">
+                            按新的域名列表重新签发 {editingCert?.domain} 的证书
                         </DialogDescription>
                     </DialogHeader>
                     <div className="space-y-4 py-4">
                         <div className="space-y-2">
-                            <Label htmlFor="renew-email" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
-                                邮箱地址
+                            <Label htmlFor="edit-domains" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                域名 (逗号分隔)
                             </Label>
                             <Input
-                                id="renew-email"
-                                type="email"
-                                value={renewEmail}
-                                onChange={(e) => setRenewEmail(e.target.value)}
-                                placeholder="your@email.com"
+                                id="edit-domains"
+                                value={editDomains}
+                                onChange={(e) => setEditDomains(e.target.value)}
+                                placeholder="example.com, www.example.com"
+                                className="font-mono"
                             />
+                            <p className="text-xs text-muted-foreground">
+                                第一个域名为主域名。签发成功后证书 ID 不变，使用该证书的站点无需修改；签发失败时保留原证书
+                            </p>
                         </div>
                     </div>
                     <DialogFooter>
-                        <Button variant="outline" onClick={() => setRenewDialogOpen(false)} disabled={renewing}>
+                        <Button variant="outline" onClick={() => setDomainsDialogOpen(false)} disabled={savingDomains}>
                             取消
                         </Button>
-                        <Button onClick={handleRenew} disabled={renewing} className="gap-2">
-                            {renewing ? <Loader2 className="h-4 w-4 animate-spin" /> : <RefreshCw className="h-4 w-4" />}
-                            续期
+                        <Button onClick={handleUpdateDomains} disabled={savingDomains} className="gap-2">
+                            {savingDomains ? <Loader2 className="h-4 w-4 animate-spin" /> : <RefreshCw className="h-4 w-4" />}
+                            重新签发
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+
+            {/* Upload Certificate Dialog */}
+            <UploadCertificateDialog
+                open={uploadDialogOpen}
+                onOpenChange={setUploadDialogOpen}
+                onUploaded={loadData}
+                replaceDomain={replacingCert?.domain}
+            />
+
+            {/* 任务日志 */}
+            <JobLogDialog
+                job={watchingJob}
+                open={jobDialogOpen}
+                onOpenChange={setJobDialogOpen}
+                onFinished={handleJobFinished}
+            />
+
+            {/* 部署钩子 */}
+            <HooksDialog cert={hooksCert} open={hooksDialogOpen} onOpenChange={setHooksDialogOpen} />
+
+            {/* Revoke Certificate Dialog */}
+            <Dialog open={revokeDialogOpen} onOpenChange={setRevokeDialogOpen}>
+                <DialogContent>
+                    <DialogHeader>
+                        <DialogTitle className="flex items-center gap-2">
+                            <Ban className="h-5 w-5 text-destructive" />
+                            撤销证书
+                        </DialogTitle>
+                        <DialogDescription className="font-mono">
+                            向 CA 撤销 {revokingCert?.domain} 的证书，撤销后无法恢复
+                        </DialogDescription>
+                    </DialogHeader>
+                    <div className="space-y-4 py-4">
+                        <div className="space-y-2">
+                            <Label htmlFor="revoke-reason" className="text-xs font-mono uppercase tracking-wider text-muted-foreground">
+                                撤销原因
+                            </Label>
+                            <select
+                                id="revoke-reason"
+                                value={revokeReason}
+                                onChange={(e) => setRevokeReason(parseInt(e.target.value, 10))}
+                                className="w-full h-10 px-3 py-2 bg-background border rounded-md text-sm"
+                            >
+                                {REVOKE_REASONS.map((r) => (
+                                    <option key={r.value} value={r.value}>
+                                        {r.label}
+                                    </option>
+                                ))}
+                            </select>
+                            {revokeReason === 1 && (
+                                <p className="text-xs text-muted-foreground">
+                                    使用证书私钥签名撤销请求，之后续期会生成新私钥
+                                </p>
+                            )}
+                        </div>
+                        <div className="flex items-center justify-between">
+                            <div>
+                                <Label className="text-sm">停用站点 HTTPS</Label>
+                                <p className="text-xs text-muted-foreground">
+                                    关闭引用该证书的站点的 SSL；不开启时站点保持不变，需手动更换证书
+                                </p>
+                            </div>
+                            <Switch
+                                checked={revokeDetach}
+                                onCheckedChange={(checked: boolean) => setRevokeDetach(checked)}
+                            />
+                        </div>
+                    </div>
+                    <DialogFooter>
+                        <Button variant="outline" onClick={() => setRevokeDialogOpen(false)} disabled={revoking}>
+                            取消
+                        </Button>
+                        <Button variant="destructive" onClick={handleRevoke} disabled={revoking} className="gap-2">
+                            {revoking ? <Loader2 className="h-4 w-4 animate-spin" /> : <Ban className="h-4 w-4" />}
+                            撤销
                         </Button>
                     </DialogFooter>
                 </DialogContent>
@@ -561,11 +1173,35 @@ export default function SSLPage() {
                             )}
                         </AlertDialogDescription>
                     </AlertDialogHeader>
+                    {deletingCert && deletingCert.sites.length > 0 && (
+                        <div className="space-y-3">
+                            <div className="space-y-1">
+                                <p className="text-sm">以下站点仍在使用该证书：</p>
+                                <ul className="text-xs font-mono text-muted-foreground">
+                                    {deletingCert.sites.map((site) => (
+                                        <li key={site}>{site}</li>
+                                    ))}
+                                </ul>
+                            </div>
+                            <div className="flex items-center justify-between">
+                                <div>
+                                    <Label className="text-sm">同时停用站点 HTTPS</Label>
+                                    <p className="text-xs text-muted-foreground">
+                                        关闭这些站点的 SSL（上游客户端证书则不再发送）后删除；不开启时无法删除
+                                    </p>
+                                </div>
+                                <Switch
+                                    checked={deleteCascade}
+                                    onCheckedChange={(checked: boolean) => setDeleteCascade(checked)}
+                                />
+                            </div>
+                        </div>
+                    )}
                     <AlertDialogFooter>
                         <AlertDialogCancel disabled={deleting}>取消</AlertDialogCancel>
                         <AlertDialogAction
                             onClick={handleDelete}
-                            disabled={deleting}
+                            disabled={deleting || (!!deletingCert && deletingCert.sites.length > 0 && !deleteCascade)}
                             className="bg-destructive text-destructive-foreground hover:bg-destructive/90"
                         >
                             {deleting ? <Loader2 className="h-4 w-4 animate-spin mr-2" /> : <Trash2 className="h-4 w-4 mr-2" />}